@@ -0,0 +1,69 @@
+package uonum
+
+import (
+	"strings"
+
+	"github.com/ikawaha/kagome/v2/tokenizer"
+)
+
+// Morpheme is a morpheme of a text analyzed by kagome.
+type Morpheme struct {
+	Surface string
+	// Class is the part of speech with its sub-classes separated by "/"
+	// (e.g. 名詞/固有名詞/人名), as in ParseClasses.
+	Class    string
+	POS      []string
+	BaseForm string `json:",omitempty"`
+	Reading  string `json:",omitempty"`
+	// Features are all the features in the dictionary. The first one is the
+	// class of the word in the keys of the model.
+	Features []string
+	// Unknown is true if the word is not in the dictionaries.
+	Unknown bool `json:",omitempty"`
+}
+
+// Analyze tokenizes text with the dictionaries of opts (see WithDict and
+// WithUserDict), and returns its morphemes as kagome analyzes them, without
+// the normalizers, the filters or the placeholders of a Generator. The
+// white spaces are left out as in the model.
+func Analyze(text string, opts ...Option) ([]Morpheme, error) {
+	g := New(opts...).(*generator)
+	t, err := g.sharedTokenizer()
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []Morpheme
+	for _, tk := range t.get().Tokenize(text) {
+		if tk.Class == tokenizer.DUMMY || strings.TrimSpace(tk.Surface) == "" {
+			continue
+		}
+
+		pos := tk.POS()
+		m := Morpheme{
+			Surface:  tk.Surface,
+			Class:    posClass(pos),
+			POS:      pos,
+			Features: tk.Features(),
+			Unknown:  tk.Class == tokenizer.UNKNOWN,
+		}
+		m.BaseForm, _ = tk.BaseForm()
+		m.Reading, _ = tk.Reading()
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// posClass returns the class of pos, without the empty sub-classes "*".
+func posClass(pos []string) string {
+	var c []string
+	for _, p := range pos {
+		if p == "" || p == "*" {
+			break
+		}
+		c = append(c, p)
+	}
+
+	return strings.Join(c, "/")
+}
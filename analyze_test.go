@@ -0,0 +1,96 @@
+package uonum
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	udict := filepath.Join(t.TempDir(), "user.csv")
+	err := os.WriteFile(udict, []byte("うおぬむ,うおぬむ,ウオヌム,カスタム名詞\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		opts []Option
+		// want are the surface, the class, the base form and the reading
+		// of each morpheme, and "unknown" if it is unknown
+		want [][]string
+	}{
+		{
+			name: "inflected",
+			text: "猫が鳴いた。",
+			want: [][]string{
+				{"猫", "名詞/一般", "猫", "ネコ"},
+				{"が", "助詞/格助詞/一般", "が", "ガ"},
+				{"鳴い", "動詞/自立", "鳴く", "ナイ"},
+				{"た", "助動詞", "た", "タ"},
+				{"。", "記号/句点", "。", "。"},
+			},
+		},
+		{
+			name: "spaces",
+			text: " 猫 が ",
+			want: [][]string{{"猫", "名詞/一般", "猫", "ネコ"}, {"が", "接続詞", "が", "ガ"}},
+		},
+		{name: "unknown", text: "ヌムヌムヌ", want: [][]string{{"ヌムヌムヌ", "名詞/固有名詞/組織", "", "", "unknown"}}},
+		{
+			name: "user dict",
+			text: "うおぬむ",
+			opts: []Option{WithUserDict(udict)},
+			want: [][]string{{"うおぬむ", "カスタム名詞", "", ""}},
+		},
+		{name: "empty", text: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms, err := Analyze(tt.text, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got [][]string
+			for _, m := range ms {
+				v := []string{m.Surface, m.Class, m.BaseForm, m.Reading}
+				if m.Unknown {
+					v = append(v, "unknown")
+				}
+				got = append(got, v)
+				if len(m.Features) == 0 || m.Features[0] != m.POS[0] {
+					t.Errorf("morpheme = %+v", m)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Analyze(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Analyze("猫", WithUserDict(filepath.Join(t.TempDir(), "missing.csv"))); err == nil {
+		t.Error("Analyze() with a missing user dictionary = nil error")
+	}
+}
+
+func TestPOSClass(t *testing.T) {
+	tests := []struct {
+		pos  []string
+		want string
+	}{
+		{pos: []string{"名詞", "固有名詞", "人名", "名"}, want: "名詞/固有名詞/人名/名"},
+		{pos: []string{"動詞", "自立", "*", "*"}, want: "動詞/自立"},
+		{pos: []string{"助動詞", "", "*"}, want: "助動詞"},
+		{pos: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := posClass(tt.pos); got != tt.want {
+			t.Errorf("posClass(%q) = %q, want %q", tt.pos, got, tt.want)
+		}
+	}
+}
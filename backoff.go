@@ -0,0 +1,109 @@
+package uonum
+
+import (
+	"math"
+)
+
+// DefaultBackoff is the weight of a backed-off estimate recommended for
+// WithBackoff.
+const DefaultBackoff = 0.4
+
+// WithBackoff enables the stupid backoff from the links of a word to the
+// frequencies of the words alone.
+//
+// When the links left by the sources, the age or the banned words are
+// exhausted mid-sentence, the generation falls back to all the links of the
+// word, then to a word chosen by its frequency, instead of stopping.
+// A transition which is not in the chain is scored as alpha times the
+// probability of the next word alone.
+func WithBackoff(alpha float64) Option {
+	return func(g *generator) {
+		g.backoff = alpha
+	}
+}
+
+// backOff returns the next key of w chosen by the backoff, or "" if the
+// sentence ends at w.
+func (g *generator) backOff(b bucket, w *wordLink) (string, error) {
+	if g.backoff <= 0 {
+		return "", nil
+	}
+	// the texts ended here
+	if len(w.Links) == 0 && g.ending == nil {
+		return "", nil
+	}
+	if g.ending != nil && g.ending(w.Features) {
+		return "", nil
+	}
+
+	if n := g.choose(w); n != "" {
+		return n, nil
+	}
+
+	return g.unigramKey(b)
+}
+
+// unigramKey returns a key in b chosen at random with probability
+// proportional to the frequency of the word in the class index, or "" if
+// there is none.
+func (g *generator) unigramKey(b bucket) (string, error) {
+	var key string
+	var total float64
+	err := eachIndexedKey(b, func(k []byte, count int64) error {
+		if count <= 0 || string(k) == bosKey || !g.allowed(string(k)) {
+			return nil
+		}
+		n := float64(count)
+		total += n
+		if random.Float64()*total < n {
+			key = string(k)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// frequency returns the number of times the word followed another one.
+func (w *wordLink) frequency() int64 {
+	var n int64
+	for _, c := range w.Prev {
+		n += c
+	}
+
+	return n
+}
+
+// unigramTotal returns the sum of the frequencies of the words in the class
+// index of b, which are read without decoding the words.
+func unigramTotal(b bucket) (int64, error) {
+	var total int64
+	err := eachIndexedKey(b, func(k []byte, count int64) error {
+		if string(k) != bosKey {
+			total += count
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// backoffLogProb returns the log-probability of the transition to next which
+// is not in the chain, where total is the sum of the frequencies.
+func (g *generator) backoffLogProb(next *wordLink, total int64) float64 {
+	if g.backoff <= 0 || next == nil || total <= 0 {
+		return unseenLogProb
+	}
+	n := next.occurrences()
+	if n <= 0 {
+		return unseenLogProb
+	}
+
+	return math.Log(g.backoff) + math.Log(float64(n)/float64(total))
+}
@@ -0,0 +1,68 @@
+package uonum
+
+import "testing"
+
+func TestUnigram(t *testing.T) {
+	tests := []struct {
+		name   string
+		texts  []string
+		banned []string
+		// wantTotal is the sum of the occurrences of the words
+		wantTotal int64
+		// wantKeys are the keys which can be chosen
+		wantKeys []string
+	}{
+		{name: "empty"},
+		{
+			name:      "words",
+			texts:     []string{"猫が鳴く。", "猫が鳴く。"},
+			wantTotal: 8,
+			wantKeys:  []string{encodeKey("猫", "名詞"), encodeKey("が", "助詞"), encodeKey("鳴く", "動詞"), encodeKey("。", "記号")},
+		},
+		{
+			name:      "banned",
+			texts:     []string{"猫が鳴く。"},
+			banned:    []string{"猫", "鳴く"},
+			wantTotal: 4,
+			wantKeys:  []string{encodeKey("が", "助詞"), encodeKey("。", "記号")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, tt.texts, WithBannedWords(tt.banned))
+			err := g.viewWords(func(b bucket) error {
+				total, err := unigramTotal(b)
+				if err != nil {
+					return err
+				}
+				if total != tt.wantTotal {
+					t.Errorf("unigramTotal() = %d, want %d", total, tt.wantTotal)
+				}
+
+				want := make(map[string]bool)
+				for _, k := range tt.wantKeys {
+					want[k] = true
+				}
+				seen := make(map[string]bool)
+				for i := 0; i < 200; i++ {
+					k, err := g.unigramKey(b)
+					if err != nil {
+						return err
+					}
+					if (k == "") != (len(want) == 0) || (k != "" && !want[k]) {
+						t.Fatalf("unigramKey() = %q, want one of %q", displayKey(k), tt.wantKeys)
+					}
+					seen[k] = true
+				}
+				if len(want) > 0 && len(seen) != len(want) {
+					t.Errorf("unigramKey() chose %d keys, want %d", len(seen), len(want))
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package uonum
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/boltdb/bolt"
+)
+
+// snapshotter is a store which can write a consistent snapshot of itself.
+type snapshotter interface {
+	WriteTo(w io.Writer) (int64, error)
+}
+
+func (s *boltStore) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+
+	return n, err
+}
+
+// Backup writes a consistent snapshot of the whole database to w, while the
+// other goroutines keep using the Generator. It returns the number of bytes
+// written. It is not supported by the Redis store.
+func (g *generator) Backup(w io.Writer) (int64, error) {
+	if g.s == nil {
+		return 0, ErrNotOpen
+	}
+
+	s, ok := g.s.(snapshotter)
+	if !ok {
+		return 0, errors.New("The database does not support backup.")
+	}
+
+	n, err := s.WriteTo(w)
+	if err != nil {
+		return n, fmt.Errorf("could not write the backup: %w", err)
+	}
+
+	return n, nil
+}
+
+// Restore replaces the Bolt database file name with the backup read from r.
+// The database must not be opened: it fails if the lock of the database is
+// not taken in lockTimeout, and holds the lock until the file is replaced.
+// The file is replaced only after the backup has been written and verified.
+func Restore(name string, r io.Reader) error {
+	if isRedisDSN(name) {
+		return errors.New("The database does not support restore.")
+	}
+
+	db, err := bolt.Open(name, 0600, &bolt.Options{Timeout: lockTimeout})
+	switch {
+	case errors.Is(err, bolt.ErrTimeout):
+		return fmt.Errorf("The database [%s] is in use.", name)
+	case err == nil:
+		defer db.Close()
+	}
+	// the other errors are of a file which is not a database, and not in use
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".restore*")
+	if err != nil {
+		return fmt.Errorf("could not create a temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write the backup: %w", err)
+	}
+
+	err = verifyBolt(tmp.Name())
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), name)
+	if err != nil {
+		return fmt.Errorf("could not replace the database: %w", err)
+	}
+
+	return nil
+}
+
+// verifyBolt checks that name is a Bolt database of this package.
+func verifyBolt(name string) error {
+	db, err := bolt.Open(name, 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("the backup is not a valid database: %w", err)
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketWords) == nil {
+			return errors.New("The backup has no words.")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,160 @@
+package uonum
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+	g := New().(*generator)
+	if err := g.Open(filepath.Join(dir, "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	for _, text := range []string{"猫が魚を食べる。", "犬が肉を食べる。"} {
+		if err := g.Register(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var backup bytes.Buffer
+	n, err := g.Backup(&backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(backup.Len()) {
+		t.Errorf("Backup() = %d, want %d bytes written", n, backup.Len())
+	}
+
+	tests := []struct {
+		name   string
+		backup []byte
+		// existing is the content of the file before the restore
+		existing []byte
+		wantErr  bool
+	}{
+		{name: "new file", backup: backup.Bytes()},
+		{name: "replaced", backup: backup.Bytes(), existing: []byte("old")},
+		{name: "invalid", backup: []byte("not a database"), existing: []byte("old"), wantErr: true},
+		{name: "empty", existing: []byte("old"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "restored.db")
+			if tt.existing != nil {
+				if err := os.WriteFile(name, tt.existing, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := Restore(name, bytes.NewReader(tt.backup))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Restore() succeeded, want an error")
+				}
+				// the database is left as it is
+				if got, _ := os.ReadFile(name); !bytes.Equal(got, tt.existing) {
+					t.Errorf("the database is changed to %q", got)
+				}
+				if files, _ := filepath.Glob(name + ".restore*"); len(files) > 0 {
+					t.Errorf("the temporary files %q are left", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := New().(*generator)
+			if err := r.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if got := textsIn(t, r); got != 2 {
+				t.Errorf("texts restored = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestRestoreLocked(t *testing.T) {
+	old := lockTimeout
+	lockTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = old })
+
+	name := filepath.Join(t.TempDir(), "test.db")
+	g := New().(*generator)
+	if err := g.Open(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Register("猫が鳴く。"); err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	if _, err := g.Backup(&backup); err != nil {
+		t.Fatal(err)
+	}
+	g.Close()
+	if _, err := CreateSnapshot(name, "one"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		restore func() error
+	}{
+		{name: "restore", restore: func() error { return Restore(name, bytes.NewReader(backup.Bytes())) }},
+		{name: "rollback", restore: func() error { return RollbackSnapshot(name, "one") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New().(*generator)
+			if err := g.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+			if err := g.Register("犬が鳴く。"); err != nil {
+				t.Fatal(err)
+			}
+
+			want := textsIn(t, g)
+
+			if err := tt.restore(); err == nil {
+				t.Fatal("the restore of the database in use succeeded, want an error")
+			}
+			// the database in use is left as it is
+			if got := textsIn(t, g); got != want {
+				t.Errorf("texts = %d, want %d", got, want)
+			}
+			if files, _ := filepath.Glob(name + ".restore*"); len(files) > 0 {
+				t.Errorf("the temporary files %q are left", files)
+			}
+		})
+	}
+}
+
+func TestBackupRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	dsn := "redis://" + mr.Addr()
+
+	g := New()
+	if err := g.Open(dsn); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	if _, err := g.Backup(new(bytes.Buffer)); err == nil {
+		t.Error("Backup() of the Redis store succeeded, want an error")
+	}
+	if err := Restore(dsn, new(bytes.Buffer)); err == nil {
+		t.Error("Restore() to the Redis store succeeded, want an error")
+	}
+}
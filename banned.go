@@ -0,0 +1,49 @@
+package uonum
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// WithBannedWords prevents the generated sentences from containing words.
+// The transitions to the banned words are never chosen.
+func WithBannedWords(words []string) Option {
+	return func(g *generator) {
+		if g.banned == nil {
+			g.banned = make(map[string]bool)
+		}
+		for _, w := range words {
+			g.banned[w] = true
+		}
+	}
+}
+
+// ReadWordList reads a list of words, one per line. Blank lines and lines
+// starting with "#" are ignored.
+func ReadWordList(r io.Reader) ([]string, error) {
+	var words []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		w := strings.TrimSpace(s.Text())
+		if w == "" || strings.HasPrefix(w, "#") {
+			continue
+		}
+		words = append(words, w)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return words, nil
+}
+
+// allowed reports whether the transition to key may be chosen.
+func (g *generator) allowed(key string) bool {
+	if len(g.banned) == 0 {
+		return true
+	}
+
+	w, _ := splitKey(key)
+	return !g.banned[w]
+}
@@ -0,0 +1,54 @@
+package uonum
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadWordList(t *testing.T) {
+	const list = "猫\n\n  犬  \n# comment\n鳴く\n"
+
+	got, err := ReadWordList(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"猫", "犬", "鳴く"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadWordList() = %q, want %q", got, want)
+	}
+}
+
+func TestBannedWords(t *testing.T) {
+	texts := []string{"猫が鳴く。", "猫が走る。"}
+
+	tests := []struct {
+		name   string
+		banned []string
+		want   []string
+	}{
+		{name: "none", want: []string{"猫が鳴く。", "猫が走る。"}},
+		{name: "banned", banned: []string{"鳴く"}, want: []string{"猫が走る。"}},
+		{name: "unknown", banned: []string{"犬"}, want: []string{"猫が鳴く。", "猫が走る。"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, texts, WithBannedWords(tt.banned))
+			got := make(map[string]bool)
+			for range 50 {
+				text, err := g.Generate("猫")
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[text] = true
+			}
+			want := make(map[string]bool)
+			for _, text := range tt.want {
+				want[text] = true
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("generated %v, want %v", got, want)
+			}
+		})
+	}
+}
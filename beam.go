@@ -0,0 +1,156 @@
+package uonum
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxBeamWords is the length of the sentences of GenerateBeam if MaxWords of
+// the limits is not set.
+const maxBeamWords = 100
+
+type beam struct {
+	keys []string
+	lp   float64
+}
+
+// GenerateBeam returns the sentence starting from trigger which has the
+// highest joint probability, searched by keeping the width most probable
+// partial sentences at each word. It returns the most typical sentence
+// rather than a random one.
+func (g *generator) GenerateBeam(trigger string, width int) (string, error) {
+	trigger = g.triggerWord(trigger)
+	if trigger == "" {
+		return "", nil
+	}
+	if width <= 0 {
+		width = 1
+	}
+
+	deadline := g.deadline()
+	var text string
+	err := g.viewChain(func(b, tb bucket) error {
+		key, err := g.startKey(b, trigger)
+		if err != nil {
+			return err
+		}
+		if key == nil || b.Get(key) == nil {
+			return missing(b, key)
+		}
+
+		best, err := g.beamSearch(b, string(key), width)
+		if err != nil {
+			return err
+		}
+
+		words, err := g.realize(b, best.keys, eosKey)
+		if err != nil {
+			return err
+		}
+		text = g.postProcess(g.concat(words...))
+		if !g.safe(text, deadline) {
+			return errRejected
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return text, nil
+}
+
+func (g *generator) beamSearch(b bucket, key string, width int) (*beam, error) {
+	var deadline time.Time
+	if g.limits.Timeout > 0 {
+		deadline = time.Now().Add(g.limits.Timeout)
+	}
+	maxWords := g.limits.MaxWords
+	if maxWords <= 0 {
+		maxWords = maxBeamWords
+	}
+
+	var best *beam
+	complete := func(bm beam) {
+		if best == nil || bm.lp > best.lp {
+			best = &bm
+		}
+	}
+
+	beams := []beam{{keys: []string{key}}}
+	for len(beams) > 0 {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out: %w", ErrGenerationFailed)
+		}
+
+		var next []beam
+		for _, bm := range beams {
+			last := bm.keys[len(bm.keys)-1]
+			w, err := g.wordLink(b, []byte(last))
+			if err != nil {
+				return nil, err
+			}
+			if w == nil {
+				if g.endsNaturally(nil) {
+					complete(bm)
+				}
+				continue
+			}
+			if _, ok := g.twMap[w.Word]; ok {
+				complete(bm)
+				continue
+			}
+
+			links := g.restrict(w).Links
+			var total int64
+			for k, c := range links {
+				if c > 0 && g.allowed(k) {
+					total += c
+				}
+			}
+			if total == 0 {
+				if g.endsNaturally(w) {
+					complete(bm)
+				}
+				continue
+			}
+
+			for k, c := range links {
+				if c <= 0 || !g.allowed(k) {
+					continue
+				}
+				lp := bm.lp + math.Log(float64(c)/float64(total))
+				if k == eosKey {
+					complete(beam{keys: bm.keys, lp: lp})
+					continue
+				}
+				if len(bm.keys) >= maxWords {
+					continue
+				}
+				keys := make([]string, len(bm.keys), len(bm.keys)+1)
+				copy(keys, bm.keys)
+				next = append(next, beam{keys: append(keys, k), lp: lp})
+			}
+		}
+
+		sort.SliceStable(next, func(i, j int) bool {
+			return next[i].lp > next[j].lp
+		})
+		if len(next) > width {
+			next = next[:width]
+		}
+		// the probabilities only decrease as the sentences grow
+		for len(next) > 0 && best != nil && next[len(next)-1].lp <= best.lp {
+			next = next[:len(next)-1]
+		}
+		beams = next
+	}
+
+	if best == nil {
+		return nil, ErrGenerationFailed
+	}
+
+	return best, nil
+}
@@ -0,0 +1,48 @@
+package uonum
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGenerateBeam(t *testing.T) {
+	// 猫が is more probable than 猫は, but 猫は寝る。 is the most probable
+	texts := []string{"猫が鳴く。", "猫が鳴く。", "猫が走る。", "猫が走る。", "猫は寝る。", "猫は寝る。", "猫は寝る。"}
+
+	tests := []struct {
+		name    string
+		trigger string
+		width   int
+		opts    []Option
+		want    []string
+		wantErr error
+	}{
+		{name: "greedy", trigger: "猫", width: 1, want: []string{"猫が鳴く。", "猫が走る。"}},
+		{name: "zero width", trigger: "猫", width: 0, want: []string{"猫が鳴く。", "猫が走る。"}},
+		{name: "beam", trigger: "猫", width: 2, want: []string{"猫は寝る。"}},
+		{name: "wide", trigger: "猫", width: 10, want: []string{"猫は寝る。"}},
+		{name: "from the middle", trigger: "寝る", width: 2, opts: []Option{WithTriggerMatch(Class("動詞"))}, want: []string{"寝る。"}},
+		{name: "too long", trigger: "猫", width: 2, opts: []Option{WithLimits(Limits{MaxWords: 2})}, wantErr: ErrGenerationFailed},
+		{name: "unknown", trigger: "犬", width: 2, wantErr: ErrUnknownTrigger},
+		{name: "empty trigger", trigger: "", width: 2, want: []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, texts, tt.opts...)
+			got, err := g.GenerateBeam(tt.trigger, tt.width)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateBeam() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			for _, want := range tt.want {
+				if got == want {
+					return
+				}
+			}
+			t.Errorf("GenerateBeam() = %q, want one of %q", got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package uonum
+
+import "strings"
+
+// defaultCloseBias is the probability of closing an open bracket used by
+// WithBracketBalance by default.
+const defaultCloseBias = 0.5
+
+// WithBracketBalance makes the generation track the brackets and the quotes
+// opened in a sentence: a next word closing the innermost open one is
+// chosen with probability p if there is one, and the words closing the
+// others or nothing are avoided. If p <= 0, 0.5 is used. The brackets still
+// unbalanced at the end are fixed as by BalanceBrackets.
+func WithBracketBalance(p float64) Option {
+	return func(g *generator) {
+		if p <= 0 {
+			p = defaultCloseBias
+		}
+		g.closeBias = p
+	}
+}
+
+// trackBrackets returns the stack of the open brackets open updated by the
+// brackets in word.
+func trackBrackets(open []rune, word string) []rune {
+	for _, r := range word {
+		if _, ok := brackets[r]; ok {
+			open = append(open, r)
+		} else if o, ok := closing[r]; ok && len(open) > 0 && open[len(open)-1] == o {
+			open = open[:len(open)-1]
+		}
+	}
+
+	return open
+}
+
+// balanceLinks returns the links of w to choose the next word from with the
+// brackets open: the ones closing the innermost bracket with probability
+// g.closeBias, or else those not closing any other bracket.
+func (g *generator) balanceLinks(w *wordLink, open []rune) *wordLink {
+	var want rune
+	if len(open) > 0 {
+		want = brackets[open[len(open)-1]]
+	}
+
+	closers := make(map[string]int64)
+	others := make(map[string]int64, len(w.Links))
+	for k, c := range w.Links {
+		word, _ := splitKey(k)
+		switch {
+		case want != 0 && strings.ContainsRune(word, want):
+			closers[k] = c
+		case !strayCloser(word, want):
+			others[k] = c
+		}
+	}
+
+	if len(closers) > 0 && (len(others) == 0 || random.Float64() < g.closeBias) {
+		return &wordLink{Links: closers}
+	}
+	if len(others) == 0 {
+		// nothing better, left to BalanceBrackets
+		return w
+	}
+
+	return &wordLink{Links: others}
+}
+
+// strayCloser reports whether word has a closing bracket other than want.
+func strayCloser(word string, want rune) bool {
+	for _, r := range word {
+		if _, ok := closing[r]; ok && r != want {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package uonum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrackBrackets(t *testing.T) {
+	tests := []struct {
+		open []rune
+		word string
+		want []rune
+	}{
+		{word: "「", want: []rune{'「'}},
+		{open: []rune{'「'}, word: "」", want: []rune{}},
+		{open: []rune{'「'}, word: "（『", want: []rune{'「', '（', '『'}},
+		// not the innermost one
+		{open: []rune{'「', '（'}, word: "」", want: []rune{'「', '（'}},
+		{word: "」"},
+		{open: []rune{'('}, word: "猫", want: []rune{'('}},
+	}
+
+	for _, tt := range tests {
+		got := trackBrackets(append([]rune(nil), tt.open...), tt.word)
+		if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("trackBrackets(%q, %q) = %q, want %q", tt.open, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestStrayCloser(t *testing.T) {
+	tests := []struct {
+		word string
+		want rune
+		ok   bool
+	}{
+		{word: "」", want: '」'},
+		{word: "」", ok: true},
+		{word: "）」", want: '」', ok: true},
+		{word: "猫"},
+	}
+
+	for _, tt := range tests {
+		if got := strayCloser(tt.word, tt.want); got != tt.ok {
+			t.Errorf("strayCloser(%q, %q) = %v, want %v", tt.word, tt.want, got, tt.ok)
+		}
+	}
+}
+
+func TestBalanceLinks(t *testing.T) {
+	closer, stray, word := encodeKey("」", "記号"), encodeKey("）", "記号"), encodeKey("猫", "名詞")
+	w := &wordLink{Links: map[string]int64{closer: 1, stray: 1, word: 1}}
+
+	tests := []struct {
+		name string
+		open []rune
+		bias float64
+		want []string
+	}{
+		{name: "close", open: []rune{'「'}, bias: 1, want: []string{closer}},
+		{name: "keep open", open: []rune{'「'}, bias: 0, want: []string{word}},
+		{name: "nothing open", bias: 1, want: []string{word}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &generator{closeBias: tt.bias}
+			got := g.balanceLinks(w, tt.open).sortedLinks()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("balanceLinks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// only stray closers
+	only := &wordLink{Links: map[string]int64{stray: 1}}
+	if got := (&generator{closeBias: 1}).balanceLinks(only, nil); got != only {
+		t.Errorf("balanceLinks() = %v, want the links as they are", got.Links)
+	}
+}
+
+func TestWithBracketBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		texts   []string
+		trigger string
+		want    string
+	}{
+		{name: "close", texts: []string{"猫は「魚が好き」と鳴く。", "猫は「魚が好き。"}, trigger: "猫", want: "猫は「魚が好き」と鳴く。"},
+		{name: "stray", texts: []string{"犬が吠える」。", "犬が吠える。"}, trigger: "犬", want: "犬が吠える。"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, tt.texts, WithBracketBalance(1))
+			for i := 0; i < 10; i++ {
+				got, err := g.Generate(tt.trigger)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("Generate() = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
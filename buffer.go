@@ -0,0 +1,226 @@
+package uonum
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// WithBuffer makes Register accumulate the texts and the counts of the links
+// in memory, and write them to the database when n texts are accumulated,
+// and every d in the background until Close. Zero disables each condition.
+// The buffered texts are not used by the generation until they are written.
+func WithBuffer(n int, d time.Duration) Option {
+	return func(g *generator) {
+		g.buf = &writeBuffer{
+			size:     n,
+			interval: d,
+			models:   make(map[string]*pending),
+		}
+	}
+}
+
+// writeBuffer is shared by the generators of the namespaces.
+type writeBuffer struct {
+	mu       sync.Mutex
+	size     int
+	interval time.Duration
+	n        int
+	models   map[string]*pending
+	// stop stops the goroutine writing the buffer every interval, which
+	// closes done when it returns
+	stop chan struct{}
+	done chan struct{}
+}
+
+// pending is the texts and the words of a model not written yet.
+type pending struct {
+	texts  []textRecord
+	words  map[string]*wordLink
+	hashes map[string]uint64
+}
+
+// add adds text and its words wlmap to the model ns, and tells whether the
+// buffer should be flushed.
+func (b *writeBuffer) add(ns string, text textRecord, wlmap map[string]*wordLink) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p := b.model(ns)
+	p.texts = append(p.texts, text)
+	for k, w := range wlmap {
+		if old, ok := p.words[k]; ok {
+			old.merge(w)
+		} else {
+			p.words[k] = w
+		}
+	}
+	b.n++
+
+	return b.size > 0 && b.n >= b.size
+}
+
+// model returns the pending model ns. b.mu must be held.
+func (b *writeBuffer) model(ns string) *pending {
+	p, ok := b.models[ns]
+	if !ok {
+		p = &pending{
+			words:  make(map[string]*wordLink),
+			hashes: make(map[string]uint64),
+		}
+		b.models[ns] = p
+	}
+
+	return p
+}
+
+// addHash counts the hash h of a text of the model ns, and returns the
+// count of the pending texts with h before it.
+func (b *writeBuffer) addHash(ns string, h []byte) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p := b.model(ns)
+	n := p.hashes[string(h)]
+	p.hashes[string(h)]++
+
+	return n
+}
+
+// take removes the pending models from b and returns them.
+func (b *writeBuffer) take() map[string]*pending {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m := b.models
+	b.models = make(map[string]*pending)
+	b.n = 0
+
+	return m
+}
+
+// putBack returns the pending models taken from b but not written, ahead of
+// the ones added since.
+func (b *writeBuffer) putBack(models map[string]*pending) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ns, p := range models {
+		q := b.model(ns)
+		q.texts = append(p.texts, q.texts...)
+		for k, w := range q.words {
+			if old, ok := p.words[k]; ok {
+				old.merge(w)
+			} else {
+				p.words[k] = w
+			}
+		}
+		q.words = p.words
+		for h, n := range p.hashes {
+			q.hashes[h] += n
+		}
+		b.n += len(p.texts)
+	}
+}
+
+// startFlusher starts writing the buffer of g every interval until
+// stopFlusher is called.
+func (g *generator) startFlusher() {
+	b := g.buf
+	if b == nil || b.interval <= 0 || g.readOnly {
+		return
+	}
+
+	b.stop, b.done = make(chan struct{}), make(chan struct{})
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+		t := time.NewTicker(b.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				// the texts failed to be written are put back, and
+				// written with the next Flush
+				err := g.Flush()
+				if err != nil {
+					g.debug("Could not flush the buffer.", "err", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(b.stop, b.done)
+}
+
+// stopFlusher stops the goroutine started by startFlusher, and waits for it.
+func (g *generator) stopFlusher() {
+	b := g.buf
+	if b == nil || b.stop == nil {
+		return
+	}
+
+	close(b.stop)
+	<-b.done
+	b.stop, b.done = nil, nil
+}
+
+// Flush writes the texts buffered by WithBuffer to the database. The texts
+// failed to be written are kept in the buffer. It does nothing without
+// WithBuffer.
+func (g *generator) Flush() error {
+	s := g.s
+	if s == nil {
+		return ErrNotOpen
+	}
+	if g.buf == nil {
+		return nil
+	}
+
+	models := g.buf.take()
+	if len(models) == 0 {
+		return nil
+	}
+
+	_, span := g.span("uonum.update")
+	var w written
+	err := s.Update(func(tx tx) error {
+		w = written{}
+		for ns, p := range models {
+			c, err := namespace(tx, ns, true)
+			if err != nil {
+				return fmt.Errorf("[%s] could not create the namespace: %w", ns, err)
+			}
+			err = g.putText(c, p.words, p.texts...)
+			if err != nil {
+				return err
+			}
+			err = addHashes(c, p.hashes)
+			if err != nil {
+				return err
+			}
+			w.add(c, ns, p.words)
+		}
+		return nil
+	})
+	if g.spans != nil {
+		texts := 0
+		for _, p := range models {
+			texts += len(p.texts)
+		}
+		span.SetAttributes(slog.Int("models", len(models)), slog.Int("texts", texts))
+	}
+	endSpan(span, err)
+	if err != nil {
+		g.buf.putBack(models)
+		return fmt.Errorf("failed to update the database: %w", err)
+	}
+	g.invalidate(&w)
+	if g.debugging() {
+		for ns, p := range models {
+			g.debug("Flushed the buffer.", "ns", ns, "texts", len(p.texts), "words", len(p.words))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package uonum
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// textsIn returns the number of the texts in the database of g.
+func textsIn(t *testing.T, g *generator) int {
+	t.Helper()
+
+	n := 0
+	err := g.s.View(func(tx tx) error {
+		c := tx.Bucket(bucketTexts).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func TestWriteBuffer(t *testing.T) {
+	const text = "猫が魚を食べる。"
+
+	tests := []struct {
+		name  string
+		size  int
+		texts int
+		// wantBefore is the number of the texts written before Flush
+		wantBefore int
+	}{
+		{name: "no size", size: 0, texts: 3, wantBefore: 0},
+		{name: "size 1", size: 1, texts: 3, wantBefore: 3},
+		{name: "size 2", size: 2, texts: 3, wantBefore: 2},
+		{name: "size 3", size: 3, texts: 3, wantBefore: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.db")
+			g := New(WithBuffer(tt.size, 0)).(*generator)
+			if err := g.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+
+			for i := 0; i < tt.texts; i++ {
+				if err := g.Register(text); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := textsIn(t, g); got != tt.wantBefore {
+				t.Errorf("texts before Flush = %d, want %d", got, tt.wantBefore)
+			}
+
+			if err := g.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := textsIn(t, g); got != tt.texts {
+				t.Errorf("texts after Flush = %d, want %d", got, tt.texts)
+			}
+
+			var cat *wordLink
+			err := g.s.View(func(tx tx) error {
+				var err error
+				cat, err = g.wordLink(tx.Bucket(bucketWords), []byte(encodeKey("猫", "名詞")))
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cat.Links[encodeKey("が", "助詞")]; got != int64(tt.texts) {
+				t.Errorf("links of 猫 to が = %d, want %d", got, tt.texts)
+			}
+		})
+	}
+}
+
+func TestWriteBufferClose(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.db")
+	g := New(WithBuffer(0, 0))
+	if err := g.Open(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Register("猫が魚を食べる。"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	g2 := New().(*generator)
+	if err := g2.Open(name); err != nil {
+		t.Fatal(err)
+	}
+	defer g2.Close()
+	if got := textsIn(t, g2); got != 1 {
+		t.Errorf("texts after Close = %d, want 1", got)
+	}
+}
+
+func TestWriteBufferInterval(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.db")
+	g := New(WithBuffer(0, 10*time.Millisecond)).(*generator)
+	if err := g.Open(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Register("猫が魚を食べる。"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the texts are written in the background without Flush
+	deadline := time.Now().Add(5 * time.Second)
+	for textsIn(t, g) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("the buffered text was not written in the interval")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if g.buf.stop != nil {
+		t.Error("the goroutine writing the buffer is left after Close")
+	}
+}
+
+func TestWriteBufferFlushError(t *testing.T) {
+	mr := miniredis.RunT(t)
+	g := New(WithBuffer(0, 0)).(*generator)
+	if err := g.Open("redis://" + mr.Addr()); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	if err := g.Register("猫が魚を食べる。"); err != nil {
+		t.Fatal(err)
+	}
+	mr.SetError("failed")
+	if err := g.Flush(); err == nil {
+		t.Fatal("Flush() succeeded, want an error")
+	}
+	mr.SetError("")
+
+	// the texts registered meanwhile are written after the ones put back
+	if err := g.Register("犬が肉を食べる。"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	err := g.s.View(func(tx tx) error {
+		c := tx.Bucket(bucketTexts).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			texts = append(texts, string(v))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"猫が魚を食べる。", "犬が肉を食べる。"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("texts = %q, want %q", texts, want)
+	}
+
+	wlmap, err := g.wordLinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := wlmap[encodeKey("が", "助詞")].Links[encodeKey("魚", "名詞")]; got != 1 {
+		t.Errorf("links of が to 魚 = %d, want 1", got)
+	}
+	if got := wlmap[encodeKey("を", "助詞")].Links[encodeKey("食べる", "動詞")]; got != 2 {
+		t.Errorf("links of を to 食べる = %d, want 2", got)
+	}
+}
@@ -0,0 +1,225 @@
+package uonum
+
+import (
+	"container/list"
+	"sync"
+)
+
+// WithCache makes the generation keep up to size decoded words in memory.
+// The cached words are invalidated when they are registered by the
+// Generator, but not when the database is updated by another process.
+func WithCache(size int) Option {
+	return func(g *generator) {
+		if size <= 0 {
+			g.cache = nil
+			return
+		}
+		g.cache = newLRU(size)
+	}
+}
+
+// lru is a size-bounded cache of the words, shared by the generators of the
+// namespaces.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+	// latest is the version of the last write which invalidated words. The
+	// words read from an older version are not added, since a reader may
+	// still see the words from before the write after they are removed.
+	latest uint64
+}
+
+type lruEntry struct {
+	key string
+	wl  *wordLink
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *lru) get(key string) (*wordLink, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+
+	return e.Value.(*lruEntry).wl, true
+}
+
+// add adds the word wl read from the version of the store.
+func (c *lru) add(key string, wl *wordLink, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version < c.latest {
+		return
+	}
+
+	if e, ok := c.items[key]; ok {
+		e.Value.(*lruEntry).wl = wl
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, wl: wl})
+	if c.order.Len() > c.size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.items, e.Value.(*lruEntry).key)
+	}
+}
+
+// remove removes keys written by the version of the store, or by the next
+// version if the store does not tell it. It is called after the write is
+// committed.
+func (c *lru) remove(keys []string, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(version)
+	for _, key := range keys {
+		if e, ok := c.items[key]; ok {
+			c.order.Remove(e)
+			delete(c.items, key)
+		}
+	}
+}
+
+// purge removes all the words, after a write of the version of the store
+// has changed the words at large.
+func (c *lru) purge(version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(version)
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+func (c *lru) advance(version uint64) {
+	if version == 0 {
+		version = c.latest + 1
+	}
+	if version > c.latest {
+		c.latest = version
+	}
+}
+
+// version returns the version of the store read by from.
+func (c *lru) version(from any) uint64 {
+	if v, ok := from.(versioned); ok {
+		return v.version()
+	}
+
+	// the reads of the other stores are not isolated, so a word read
+	// from now is newer than the writes invalidated before
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.latest
+}
+
+// versioned is a transaction or a bucket of a store which reads from
+// snapshots, such as Bolt.
+type versioned interface {
+	// version returns the ID of the last transaction committed in the
+	// snapshot read, or the ID of the transaction if it is writable.
+	version() uint64
+}
+
+// writeVersion returns the version written by the transaction of c, or 0
+// if the store does not tell it.
+func writeVersion(c container) uint64 {
+	if v, ok := c.(versioned); ok {
+		return v.version()
+	}
+
+	return 0
+}
+
+// cacheKey is the key of the word key of the model ns in the cache.
+func cacheKey(ns string, key []byte) string {
+	return ns + "\x00" + string(key)
+}
+
+// wordLink is like getWordLink but uses the cache. The returned word must
+// not be modified.
+func (g *generator) wordLink(b bucket, key []byte) (*wordLink, error) {
+	if g.cache == nil {
+		return getWordLink(b, key)
+	}
+
+	ck := cacheKey(g.ns, key)
+	if wl, ok := g.cache.get(ck); ok {
+		g.counters.cacheHits.Add(1)
+		return wl, nil
+	}
+	g.counters.cacheMisses.Add(1)
+
+	version := g.cache.version(b)
+	wl, err := getWordLink(b, key)
+	if err != nil {
+		return nil, err
+	}
+	g.cache.add(ck, wl, version)
+
+	return wl, nil
+}
+
+// wordHead is like wordLink but reads only the first part of the links of a
+// word split into parts, if there is no cache.
+func (g *generator) wordHead(b bucket, key []byte) (*wordLink, error) {
+	if g.cache != nil {
+		return g.wordLink(b, key)
+	}
+
+	return getWordHead(b, key)
+}
+
+// written is the words written by a transaction, to be removed from the
+// cache after it is committed.
+type written struct {
+	version uint64
+	keys    []string
+	all     bool
+}
+
+// add records the words wlmap of the model ns written in the transaction of
+// c.
+func (w *written) add(c container, ns string, wlmap map[string]*wordLink) {
+	w.version = writeVersion(c)
+	for k := range wlmap {
+		w.keys = append(w.keys, cacheKey(ns, []byte(k)))
+	}
+}
+
+// addAll records that the transaction of c changed the words at large.
+func (w *written) addAll(c container) {
+	w.version = writeVersion(c)
+	w.all = true
+}
+
+// invalidate removes the words written from the cache. It is called after
+// the transaction writing them is committed, since the readers which began
+// before may put the words from before it back until then.
+func (g *generator) invalidate(w *written) {
+	if g.cache == nil {
+		return
+	}
+
+	if w.all {
+		g.cache.purge(w.version)
+		return
+	}
+	g.cache.remove(w.keys, w.version)
+}
@@ -0,0 +1,201 @@
+package uonum
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+func TestCacheInvalidation(t *testing.T) {
+	const text = "猫が魚を食べる。"
+	cat := encodeKey("猫", "名詞")
+	dangling := encodeKey("犬", "名詞")
+
+	tests := []struct {
+		name string
+		// change changes the word 猫 after it is cached
+		change func(g *generator) error
+		// want returns whether the word read after the change is new
+		want func(wl *wordLink) bool
+	}{
+		{
+			name:   "register",
+			change: func(g *generator) error { return g.Register(text) },
+			want:   func(wl *wordLink) bool { return wl.Links[encodeKey("が", "助詞")] == 2 },
+		},
+		{
+			name: "check repair",
+			change: func(g *generator) error {
+				_, err := g.Check(true)
+				return err
+			},
+			want: func(wl *wordLink) bool { return wl.Links[dangling] == 0 },
+		},
+		{
+			name: "rebuild",
+			change: func(g *generator) error {
+				_, err := g.Rebuild()
+				return err
+			},
+			want: func(wl *wordLink) bool { return wl.Links[dangling] == 0 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(WithCache(10)).(*generator)
+			if err := g.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+			if err := g.Register(text); err != nil {
+				t.Fatal(err)
+			}
+
+			// a dangling link written behind the cache
+			err := g.s.Update(func(tx tx) error {
+				b := tx.Bucket(bucketWords)
+				wl, err := getWordLink(b, []byte(cat))
+				if err != nil {
+					return err
+				}
+				wl.Links[dangling] = 1
+				_, err = putWordLink(b, []byte(cat), wl, 0)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			read := func() *wordLink {
+				var wl *wordLink
+				err := g.viewWords(func(b bucket) error {
+					var err error
+					wl, err = g.wordLink(b, []byte(cat))
+					return err
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return wl
+			}
+			before := read()
+			if before == nil || before.Links[dangling] != 1 {
+				t.Fatalf("cached word = %+v, want the dangling link", before)
+			}
+
+			if err := tt.change(g); err != nil {
+				t.Fatal(err)
+			}
+			if wl := read(); !tt.want(wl) {
+				t.Errorf("word after the change = %+v", wl)
+			}
+		})
+	}
+}
+
+func TestCacheConcurrentRegister(t *testing.T) {
+	const text = "猫が魚を食べる。"
+	cat := encodeKey("猫", "名詞")
+	ga := encodeKey("が", "助詞")
+
+	name := filepath.Join(t.TempDir(), "test.db")
+	g := New(WithCache(10)).(*generator)
+	if err := g.Open(name); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if err := g.Register(text); err != nil {
+		t.Fatal(err)
+	}
+	// a writer waits for the readers to grow the map of the file, so it is
+	// large enough for the registrations while a reader is open
+	g.s.Close()
+	db, err := bolt.Open(name, 0600, &bolt.Options{InitialMmapSize: 16 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.s = &boltStore{db: db}
+
+	read := func() *wordLink {
+		var wl *wordLink
+		err := g.viewWords(func(b bucket) error {
+			var err error
+			wl, err = g.wordLink(b, []byte(cat))
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wl
+	}
+
+	// a generation which began before a registration reads the word after
+	// the registration is committed
+	began, registered := make(chan struct{}), make(chan struct{})
+	done := make(chan *wordLink)
+	go func() {
+		var wl *wordLink
+		err := g.viewWords(func(b bucket) error {
+			close(began)
+			<-registered
+			var err error
+			wl, err = g.wordLink(b, []byte(cat))
+			return err
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- wl
+	}()
+	<-began
+	if err := g.Register(text); err != nil {
+		t.Fatal(err)
+	}
+	close(registered)
+	if wl := <-done; wl == nil || wl.Links[ga] != 1 {
+		t.Errorf("word in the older transaction = %+v, want 1 link to が", wl)
+	}
+	if wl := read(); wl.Links[ga] != 2 {
+		t.Errorf("word after the registration = %+v, want 2 links to が", wl)
+	}
+
+	// registrations and generations at once
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if err := g.Register(text); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if _, err := g.Generate("猫"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	var want *wordLink
+	err = g.s.View(func(tx tx) error {
+		var err error
+		want, err = getWordLink(tx.Bucket(bucketWords), []byte(cat))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); !reflect.DeepEqual(got.Links, want.Links) {
+		t.Errorf("cached links = %v, want %v", got.Links, want.Links)
+	}
+}
@@ -0,0 +1,62 @@
+package uonum
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// Scorer returns the score of a generated text. Higher is better.
+type Scorer func(text string) float64
+
+// ScoreLength scores a text by its length in characters.
+func ScoreLength(text string) float64 {
+	return float64(utf8.RuneCountInString(text))
+}
+
+// Best returns the candidate which has the highest score.
+// The first one wins a tie. It returns "" if candidates is empty.
+func Best(candidates []string, score Scorer) string {
+	var best string
+	var bestScore float64
+	for i, c := range candidates {
+		s := score(c)
+		if i == 0 || s > bestScore {
+			best = c
+			bestScore = s
+		}
+	}
+
+	return best
+}
+
+func (g *generator) GenerateN(trigger string, n int) ([]string, error) {
+	candidates := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		text, err := g.Generate(trigger)
+		if errors.Is(err, ErrGenerationFailed) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if text == "" {
+			continue
+		}
+		candidates = append(candidates, text)
+	}
+
+	return candidates, nil
+}
+
+func (g *generator) GenerateBest(trigger string, n int, score Scorer) (string, error) {
+	if score == nil {
+		score = ScoreLength
+	}
+
+	candidates, err := g.GenerateN(trigger, n)
+	if err != nil {
+		return "", err
+	}
+
+	return Best(candidates, score), nil
+}
@@ -0,0 +1,92 @@
+package uonum
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBest(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []string
+		want       string
+	}{
+		{name: "none", want: ""},
+		{name: "one", candidates: []string{"猫。"}, want: "猫。"},
+		{name: "longest", candidates: []string{"猫。", "猫が鳴く。", "犬。"}, want: "猫が鳴く。"},
+		{name: "tie", candidates: []string{"猫。", "犬。"}, want: "猫。"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Best(tt.candidates, ScoreLength); got != tt.want {
+				t.Errorf("Best() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateN(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。"})
+
+	tests := []struct {
+		name    string
+		trigger string
+		n       int
+		want    []string
+		wantErr error
+	}{
+		{name: "none", trigger: "猫", want: []string{}},
+		{name: "three", trigger: "猫", n: 3, want: []string{"猫が鳴く。", "猫が鳴く。", "猫が鳴く。"}},
+		{name: "unknown", trigger: "犬", n: 3, wantErr: ErrUnknownTrigger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.GenerateN(tt.trigger, tt.n)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateN() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenerateN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateBest(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "猫が魚を食べる。"})
+
+	tests := []struct {
+		name  string
+		score Scorer
+		want  string
+	}{
+		{name: "length", want: "猫が魚を食べる。"},
+		{
+			name: "scorer",
+			score: func(text string) float64 {
+				if strings.Contains(text, "鳴く") {
+					return 1
+				}
+				return 0
+			},
+			want: "猫が鳴く。",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// either sentence is missed in 50 candidates by 2^-50
+			got, err := g.GenerateBest("猫", 50, tt.score)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("GenerateBest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package uonum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+// FeatureMatcher reports whether a word which has the features matches.
+type FeatureMatcher func(features []string) bool
+
+// Class returns a FeatureMatcher which matches the words of class.
+// If sub is given, the following sub-classes must match as well,
+// e.g. Class("名詞", "固有名詞").
+func Class(class string, sub ...string) FeatureMatcher {
+	want := append([]string{class}, sub...)
+	return func(features []string) bool {
+		if len(features) < len(want) {
+			return false
+		}
+		for i, w := range want {
+			if features[i] != w {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf returns a FeatureMatcher which matches if any of ms matches.
+func AnyOf(ms ...FeatureMatcher) FeatureMatcher {
+	return func(features []string) bool {
+		for _, m := range ms {
+			if m(features) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ParseClasses parses a comma separated list of classes into a
+// FeatureMatcher. Sub-classes follow the class separated by "/",
+// e.g. "名詞/固有名詞,動詞".
+func ParseClasses(s string) FeatureMatcher {
+	var ms []FeatureMatcher
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		f := strings.Split(c, "/")
+		ms = append(ms, Class(f[0], f[1:]...))
+	}
+
+	return AnyOf(ms...)
+}
+
+func (g *generator) GenerateWithClasses(trigger string, classes ...string) (string, error) {
+	ms := make([]FeatureMatcher, len(classes))
+	for i, c := range classes {
+		ms[i] = Class(c)
+	}
+
+	return g.GenerateMatch(trigger, AnyOf(ms...))
+}
+
+// GenerateMatch generates a sentence starting from trigger, whose class is
+// chosen at random from those matched by match.
+func (g *generator) GenerateMatch(trigger string, match FeatureMatcher) (string, error) {
+	trigger = g.triggerWord(trigger)
+	if trigger == "" {
+		return "", nil
+	}
+
+	var text string
+	err := g.viewChain(func(b, tb bucket) error {
+		key, err := matchKey(b, trigger, match)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return missing(b, nil)
+		}
+
+		text, err = g.generate(b, tb, key)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return text, nil
+}
+
+// matchKey returns the key of trigger in b whose class is chosen at random
+// from those matched by match, or nil if there is none.
+func matchKey(b bucket, trigger string, match FeatureMatcher) ([]byte, error) {
+	var key []byte
+	n := 0
+	prefix := wordPrefix(trigger)
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		wl, err := getWordLink(b, k)
+		if err != nil {
+			return nil, err
+		}
+		if wl == nil || !match(wl.Features) {
+			continue
+		}
+		n++
+		if random.Intn(n) == 0 {
+			key = append(key[:0], k...)
+		}
+	}
+
+	return key, nil
+}
+
+// startKey returns the key in b which Generate starts from for trigger.
+func (g *generator) startKey(b bucket, trigger string) ([]byte, error) {
+	if g.trigger != nil {
+		return matchKey(b, trigger, g.trigger)
+	}
+
+	return []byte(encodeKey(trigger, g.defaultClass())), nil
+}
+
+// The classes bucket, nested in the words bucket like the parts, indexes the
+// words by class, so that the words of a class are found without scanning
+// all of them. Its keys are the class and the key of the word separated by
+// classSep, and its values are the frequencies of the words (see
+// wordLink.occurrences) as 8-byte big endian integers.
+var bucketClasses = []byte("\xffclasses")
+
+const classSep = "\x00"
+
+func classKey(class, key string) []byte {
+	return []byte(class + classSep + key)
+}
+
+// classCount returns the frequency in the value v of the classes bucket.
+func classCount(v []byte) int64 {
+	if len(v) != 8 {
+		return 0
+	}
+
+	return int64(binary.BigEndian.Uint64(v))
+}
+
+// putClasses adds the words of counts to the index in the words bucket b,
+// adding the counts to their frequencies, or replacing them if replace is
+// true.
+func putClasses(b bucket, counts map[string]int64, replace bool) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	cb, err := b.CreateBucketIfNotExists(bucketClasses)
+	if err != nil {
+		return err
+	}
+
+	for k, n := range counts {
+		_, class := splitKey(k)
+		ck := classKey(class, k)
+		if !replace {
+			n += classCount(cb.Get(ck))
+		}
+		err := cb.Put(ck, itob(uint64(max(n, 0))))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eachClassKey calls fn with the key and the frequency of each word of class
+// in the words bucket b, in key order.
+func eachClassKey(b bucket, class string, fn func(key []byte, count int64) error) error {
+	cb := b.Bucket(bucketClasses)
+	if cb == nil {
+		return nil
+	}
+
+	prefix := []byte(class + classSep)
+	c := cb.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		err := fn(k[len(prefix):], classCount(v))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eachIndexedKey calls fn with the key and the frequency of each word in the
+// index of the words bucket b, in class order.
+func eachIndexedKey(b bucket, fn func(key []byte, count int64) error) error {
+	cb := b.Bucket(bucketClasses)
+	if cb == nil {
+		return nil
+	}
+
+	c := cb.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		i := bytes.Index(k, []byte(classSep))
+		if i < 0 {
+			continue
+		}
+		err := fn(k[i+len(classSep):], classCount(v))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateClasses indexes the words registered before the classes bucket.
+func migrateClasses(tx tx) error {
+	for _, m := range models(tx) {
+		b := m.Bucket(bucketWords)
+		if b == nil {
+			continue
+		}
+		err := rebuildClasses(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildClasses indexes all the words in b by class with their frequencies.
+func rebuildClasses(b bucket) error {
+	counts := make(map[string]int64)
+	err := eachWord(b, func(wl *wordLink) error {
+		counts[wl.key()] = wl.occurrences()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return putClasses(b, counts, true)
+}
+
+// occurrences returns about the number of times the word appeared in the
+// texts: the number of the links from or to it, whichever is larger, since a
+// word at the beginning of a text has no link to it and one at the end has
+// no link from it.
+func (w *wordLink) occurrences() int64 {
+	return max(w.total(), w.frequency())
+}
+
+// eachWordOfClass is like eachWord but calls fn only with the words of class
+// found in the index, if class is not empty.
+func eachWordOfClass(b bucket, class string, fn func(wl *wordLink) error) error {
+	if class == "" {
+		return eachWord(b, fn)
+	}
+
+	return eachClassKey(b, class, func(key []byte, _ int64) error {
+		wl, err := getWordLink(b, key)
+		if err != nil || wl == nil {
+			return err
+		}
+		return fn(wl)
+	})
+}
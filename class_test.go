@@ -0,0 +1,211 @@
+package uonum
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseClasses(t *testing.T) {
+	tests := []struct {
+		classes  string
+		features []string
+		want     bool
+	}{
+		{classes: "名詞", features: []string{"名詞", "一般"}, want: true},
+		{classes: "名詞/固有名詞", features: []string{"名詞", "一般"}, want: false},
+		{classes: "名詞/固有名詞", features: []string{"名詞", "固有名詞", "人名"}, want: true},
+		{classes: "動詞, 名詞", features: []string{"名詞"}, want: true},
+		{classes: "動詞", features: []string{"名詞"}, want: false},
+		{classes: "名詞/固有名詞", features: []string{"名詞"}, want: false},
+		{classes: "", features: []string{"名詞"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := ParseClasses(tt.classes)(tt.features); got != tt.want {
+			t.Errorf("ParseClasses(%q)(%q) = %v, want %v", tt.classes, tt.features, got, tt.want)
+		}
+	}
+}
+
+func TestClassIndex(t *testing.T) {
+	cat, dog := encodeKey("猫", "名詞"), encodeKey("犬", "名詞")
+	ga := encodeKey("が", "助詞")
+
+	tests := []struct {
+		name string
+		// puts are put in turn, each replacing the counts if replace
+		puts    []map[string]int64
+		replace bool
+		class   string
+		want    map[string]int64
+	}{
+		{
+			name:  "class",
+			puts:  []map[string]int64{{cat: 2, dog: 1, ga: 3}},
+			class: "名詞",
+			want:  map[string]int64{cat: 2, dog: 1},
+		},
+		{
+			name:  "added",
+			puts:  []map[string]int64{{cat: 2}, {cat: 3, ga: 1}},
+			class: "名詞",
+			want:  map[string]int64{cat: 5},
+		},
+		{
+			name:    "replaced",
+			puts:    []map[string]int64{{cat: 2}, {cat: 3}},
+			replace: true,
+			class:   "名詞",
+			want:    map[string]int64{cat: 3},
+		},
+		{
+			name:  "negative",
+			puts:  []map[string]int64{{cat: 2}, {cat: -5}},
+			class: "名詞",
+			want:  map[string]int64{cat: 0},
+		},
+		{
+			name:  "no words",
+			puts:  []map[string]int64{{cat: 2}},
+			class: "動詞",
+			want:  map[string]int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := openBoltStore(filepath.Join(t.TempDir(), "test.db"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			got := make(map[string]int64)
+			err = s.Update(func(tx tx) error {
+				b, err := tx.CreateBucketIfNotExists(bucketWords)
+				if err != nil {
+					return err
+				}
+				for _, counts := range tt.puts {
+					if err := putClasses(b, counts, tt.replace); err != nil {
+						return err
+					}
+				}
+				return eachClassKey(b, tt.class, func(key []byte, count int64) error {
+					got[string(key)] = count
+					return nil
+				})
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("words of %s = %v, want %v", tt.class, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRebuildClasses(t *testing.T) {
+	g := New().(*generator)
+	if err := g.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if err := g.Register("猫が魚を食べる。"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Register("猫が鳴く。"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the index kept by the registrations is the same as the one rebuilt
+	index := func() map[string]int64 {
+		m := make(map[string]int64)
+		err := g.s.View(func(tx tx) error {
+			return eachClassKey(tx.Bucket(bucketWords), "名詞", func(key []byte, count int64) error {
+				m[displayKey(string(key))] = count
+				return nil
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	want := map[string]int64{"猫_名詞": 2, "魚_名詞": 1}
+	if got := index(); !reflect.DeepEqual(got, want) {
+		t.Errorf("index = %v, want %v", got, want)
+	}
+
+	err := g.s.Update(func(tx tx) error {
+		return rebuildClasses(tx.Bucket(bucketWords))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := index(); !reflect.DeepEqual(got, want) {
+		t.Errorf("rebuilt index = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateWithClasses(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		generate func(g *generator) (string, error)
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "class",
+			generate: func(g *generator) (string, error) { return g.GenerateWithClasses("鳴く", "動詞") },
+			want:     "鳴く。",
+		},
+		{
+			name:     "any class",
+			generate: func(g *generator) (string, error) { return g.GenerateWithClasses("鳴く", "名詞", "動詞") },
+			want:     "鳴く。",
+		},
+		{
+			name:     "other class",
+			generate: func(g *generator) (string, error) { return g.GenerateWithClasses("鳴く", "名詞") },
+			wantErr:  ErrUnknownTrigger,
+		},
+		{
+			name:     "sub-class",
+			generate: func(g *generator) (string, error) { return g.GenerateMatch("鳴く", ParseClasses("動詞/自立")) },
+			want:     "鳴く。",
+		},
+		{
+			name:     "trigger match",
+			opts:     []Option{WithTriggerMatch(Class("動詞"))},
+			generate: func(g *generator) (string, error) { return g.Generate("鳴く") },
+			want:     "鳴く。",
+		},
+		{
+			name:     "nouns by default",
+			generate: func(g *generator) (string, error) { return g.Generate("鳴く") },
+			wantErr:  ErrUnknownTrigger,
+		},
+		{
+			name:     "empty trigger",
+			generate: func(g *generator) (string, error) { return g.GenerateWithClasses("", "動詞") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, []string{"猫が鳴く。"}, tt.opts...)
+			got, err := tt.generate(g)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("generated %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
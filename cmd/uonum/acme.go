@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// letsEncrypt is the default directory of the ACME server.
+const letsEncrypt = autocert.DefaultACMEDirectory
+
+// acmeRenewBefore is how long before the expiry the certificate is renewed.
+const acmeRenewBefore = 30 * 24 * time.Hour
+
+// newACMEManager returns the manager obtaining and renewing the certificates
+// of the domains from an ACME server such as Let's Encrypt. The account key
+// and the certificates are kept in the cache directory.
+func newACMEManager(directory, email string, domains []string, cache string) *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:      autocert.AcceptTOS,
+		Cache:       autocert.DirCache(cache),
+		HostPolicy:  autocert.HostWhitelist(domains...),
+		RenewBefore: acmeRenewBefore,
+		Client:      &acme.Client{DirectoryURL: directory},
+		Email:       email,
+	}
+}
+
+// httpsRedirect redirects the requests to HTTPS served on addr.
+func httpsRedirect(addr string) http.Handler {
+	_, port, _ := net.SplitHostPort(addr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Use HTTPS.", http.StatusBadRequest)
+			return
+		}
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		u := *r.URL
+		u.Scheme = "https"
+		u.Host = host
+		if port != "" && port != "443" {
+			u.Host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, u.String(), http.StatusFound)
+	})
+}
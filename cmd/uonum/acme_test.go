@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		name   string
+		addr   string
+		method string
+		target string
+		want   string
+	}{
+		{name: "default port", addr: ":443", method: http.MethodGet, target: "http://example.com/generate?trigger=cat", want: "https://example.com/generate?trigger=cat"},
+		{name: "port", addr: ":8443", method: http.MethodGet, target: "http://example.com:8080/stats", want: "https://example.com:8443/stats"},
+		{name: "no port", addr: "", method: http.MethodHead, target: "http://example.com:8080/stats", want: "https://example.com/stats"},
+		{name: "post", addr: ":443", method: http.MethodPost, target: "http://example.com/register"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			httpsRedirect(tt.addr).ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if tt.want == "" {
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+				}
+				return
+			}
+			if w.Code != http.StatusFound {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusFound)
+			}
+			if got := w.Header().Get("Location"); got != tt.want {
+				t.Errorf("Location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestACMEManager(t *testing.T) {
+	m := newACMEManager(letsEncrypt, "admin@example.com", []string{"example.com", "www.example.com"}, t.TempDir())
+
+	if m.RenewBefore != acmeRenewBefore || m.Email != "admin@example.com" || m.Client.DirectoryURL != letsEncrypt {
+		t.Errorf("manager = %+v", m)
+	}
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{host: "example.com"},
+		{host: "www.example.com"},
+		{host: "evil.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := m.HostPolicy(context.Background(), tt.host); (err != nil) != tt.wantErr {
+			t.Errorf("HostPolicy(%q) error = %v, want error %v", tt.host, err, tt.wantErr)
+		}
+	}
+}
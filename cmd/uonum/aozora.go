@@ -0,0 +1,182 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/kechako/uonum"
+)
+
+var (
+	// aozoraNote matches the editorial notes ［＃…］, with the ※ of the
+	// characters out of JIS described by them.
+	aozoraNote = regexp.MustCompile(`※?［＃[^］]*］`)
+	// aozoraRuby matches the ruby 《…》 and the ｜ marking its base.
+	aozoraRuby = regexp.MustCompile(`《[^》]*》|｜`)
+)
+
+// aozoraText is a text of Aozora Bunko.
+type aozoraText struct {
+	title  string
+	author string
+	// lines are the paragraphs of the body without the formatting
+	lines []string
+}
+
+func importAozora(fs *flag.FlagSet) runner {
+	learnFlags(fs)
+	source := fs.String("source", "aozora", "Source of the texts.")
+	split := fs.Bool("split", true, "Split the paragraphs into sentences at the term words.")
+	encoding := fs.String("encoding", uonum.EncodingAuto, "Character encoding of the text (shift_jis usually), or auto to detect it.")
+
+	return func(args []string) (int, error) {
+		if len(args) == 0 {
+			return 2, errors.New("Text file or its zip is required.")
+		}
+
+		var opts []uonum.Option
+		if *split {
+			opts = append(opts, uonum.WithSentenceSplit())
+		}
+		g, err := openGenerator(opts...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		for _, name := range args {
+			t, err := readAozoraFile(name, *encoding)
+			if err != nil {
+				return 1, err
+			}
+
+			meta := uonum.Meta{Source: *source, Author: t.author, Fields: map[string]string{"title": t.title}}
+			for _, line := range t.lines {
+				err := g.In(ns).RegisterWithMeta(line, meta)
+				if err != nil {
+					return 1, err
+				}
+			}
+			fmt.Fprintf(os.Stderr, "Registered %d paragraphs of %s (%s).\n", len(t.lines), t.title, t.author)
+		}
+
+		return 0, nil
+	}
+}
+
+// readAozoraFile reads the text file, or the first text file in the zip
+// file as distributed by Aozora Bunko.
+func readAozoraFile(name, encoding string) (*aozoraText, error) {
+	if strings.EqualFold(path.Ext(name), ".zip") {
+		z, err := zip.OpenReader(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not open the archive [%s]: %w", name, err)
+		}
+		defer z.Close()
+
+		for _, f := range z.File {
+			if !strings.EqualFold(path.Ext(f.Name), ".txt") {
+				continue
+			}
+			r, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("could not open [%s] in the archive: %w", f.Name, err)
+			}
+			defer r.Close()
+			return readAozora(r, encoding)
+		}
+		return nil, fmt.Errorf("No text file in the archive [%s].", name)
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the input file [%s]: %w", name, err)
+	}
+	defer file.Close()
+
+	return readAozora(file, encoding)
+}
+
+// readAozora reads a text of Aozora Bunko: the title and the author in the
+// first lines, the explanation of the symbols between the lines of
+// hyphens, the body, and the bibliography from "底本：".
+func readAozora(r io.Reader, encoding string) (*aozoraText, error) {
+	r, err := uonum.DecodeReader(r, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		lines = append(lines, strings.TrimRight(s.Text(), "\r"))
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("could not read the text: %w", err)
+	}
+
+	t := new(aozoraText)
+	var header []string
+	i := 0
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		if isAozoraRule(lines[i]) {
+			break
+		}
+		header = append(header, strings.TrimSpace(lines[i]))
+	}
+	if len(header) > 0 {
+		t.title = header[0]
+	}
+	if len(header) > 1 {
+		t.author = header[len(header)-1]
+	}
+
+	// the explanation of the symbols
+	rules := 0
+	for j := i; j < len(lines) && j < i+100; j++ {
+		if isAozoraRule(lines[j]) {
+			rules++
+			if rules == 2 {
+				i = j + 1
+				break
+			}
+		}
+	}
+
+	for ; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "底本：") || strings.HasPrefix(lines[i], "底本:") {
+			break
+		}
+		// the headings are not sentences
+		if strings.Contains(lines[i], "見出し］") {
+			continue
+		}
+		line := cleanAozora(lines[i])
+		if line != "" {
+			t.lines = append(t.lines, line)
+		}
+	}
+
+	return t, nil
+}
+
+func isAozoraRule(line string) bool {
+	return strings.HasPrefix(line, "----------")
+}
+
+// cleanAozora removes the notes and the ruby of Aozora Bunko from line, and
+// the indent.
+func cleanAozora(line string) string {
+	line = aozoraNote.ReplaceAllString(line, "")
+	line = aozoraRuby.ReplaceAllString(line, "")
+	return strings.TrimSpace(strings.Trim(line, "　"))
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+const testAozora = "吾輩は猫である\r\n" +
+	"夏目漱石\r\n" +
+	"\r\n" +
+	"-------------------------------------------------------\r\n" +
+	"【テキスト中に現れる記号について】\r\n" +
+	"\r\n" +
+	"《》：ルビ\r\n" +
+	"（例）吾輩《わがはい》\r\n" +
+	"-------------------------------------------------------\r\n" +
+	"\r\n" +
+	"［＃８字下げ］一［＃「一」は中見出し］\r\n" +
+	"\r\n" +
+	"　吾輩《わがはい》は猫である。名前はまだ無い。\r\n" +
+	"　どこで生れたかとんと見当《けんとう》がつかぬ。［＃「つかぬ」に傍点］\r\n" +
+	"　｜薄暗《うすぐら》い所で※［＃「口＋愛」、第3水準1-15-23］と泣いていた。\r\n" +
+	"\r\n" +
+	"底本：「夏目漱石全集1」ちくま文庫、筑摩書房\r\n" +
+	"入力：柴田卓治\r\n"
+
+var testAozoraLines = []string{
+	"吾輩は猫である。名前はまだ無い。",
+	"どこで生れたかとんと見当がつかぬ。",
+	"薄暗い所でと泣いていた。",
+}
+
+func TestCleanAozora(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "　吾輩《わがはい》は猫である。", want: "吾輩は猫である。"},
+		{line: "｜薄暗《うすぐら》い所", want: "薄暗い所"},
+		{line: "［＃ここから２字下げ］", want: ""},
+		{line: "※［＃「口＋愛」、第3水準1-15-23］と泣く", want: "と泣く"},
+		{line: "見当がつかぬ。［＃「つかぬ」に傍点］", want: "見当がつかぬ。"},
+		{line: "　", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := cleanAozora(tt.line); got != tt.want {
+			t.Errorf("cleanAozora(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestReadAozora(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantTitle  string
+		wantAuthor string
+		wantLines  []string
+	}{
+		{
+			name:       "aozora",
+			text:       testAozora,
+			wantTitle:  "吾輩は猫である",
+			wantAuthor: "夏目漱石",
+			wantLines:  testAozoraLines,
+		},
+		{
+			name:       "no symbols",
+			text:       "坊っちゃん\n夏目漱石\n\n親譲りの無鉄砲で小供の時から損ばかりしている。\n",
+			wantTitle:  "坊っちゃん",
+			wantAuthor: "夏目漱石",
+			wantLines:  []string{"親譲りの無鉄砲で小供の時から損ばかりしている。"},
+		},
+		{
+			name:      "title only",
+			text:      "無題\n-----------\n記号\n-----------\n本文。\n",
+			wantTitle: "無題",
+			wantLines: []string{"本文。"},
+		},
+		{name: "empty", text: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readAozora(strings.NewReader(tt.text), "utf-8")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.title != tt.wantTitle || got.author != tt.wantAuthor {
+				t.Errorf("readAozora() = %q, %q, want %q, %q", got.title, got.author, tt.wantTitle, tt.wantAuthor)
+			}
+			if !reflect.DeepEqual(got.lines, tt.wantLines) {
+				t.Errorf("readAozora() lines = %q, want %q", got.lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestReadAozoraFile(t *testing.T) {
+	sjis, err := japanese.ShiftJIS.NewEncoder().String(testAozora)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeZip := func(name string, files map[string]string) string {
+		name = filepath.Join(dir, name)
+		f, err := os.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		w := zip.NewWriter(f)
+		for name, content := range files {
+			fw, err := w.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := fw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	txt := filepath.Join(dir, "wagahaiwa_nekodearu.txt")
+	if err := os.WriteFile(txt, []byte(sjis), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		encoding string
+		wantErr  bool
+	}{
+		{name: "text", file: txt, encoding: "auto"},
+		{name: "shift_jis", file: txt, encoding: "shift_jis"},
+		{
+			name:     "zip",
+			file:     writeZip("789_ruby_5639.zip", map[string]string{"wagahaiwa_nekodearu.txt": sjis}),
+			encoding: "auto",
+		},
+		{
+			name:     "zip of images",
+			file:     writeZip("images.ZIP", map[string]string{"fig1.png": "png"}),
+			encoding: "auto",
+			wantErr:  true,
+		},
+		{name: "missing", file: filepath.Join(dir, "missing.txt"), encoding: "auto", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readAozoraFile(tt.file, tt.encoding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readAozoraFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.title != "吾輩は猫である" || got.author != "夏目漱石" {
+				t.Errorf("readAozoraFile() = %q, %q", got.title, got.author)
+			}
+			if !reflect.DeepEqual(got.lines, testAozoraLines) {
+				t.Errorf("readAozoraFile() lines = %q, want %q", got.lines, testAozoraLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// guard authenticates the requests by the API keys and limits their rate.
+type guard struct {
+	// keys are the API keys, or empty for no authentication
+	keys    []string
+	limiter *rateLimiter
+}
+
+// apiKey returns the key of r in the Authorization header as a bearer
+// token, or in the X-API-Key header.
+func apiKey(r *http.Request) string {
+	if k, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(k)
+	}
+
+	return r.Header.Get("X-API-Key")
+}
+
+// valid reports whether key is one of the API keys.
+func (gd *guard) valid(key string) bool {
+	ok := false
+	for _, k := range gd.keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			ok = true
+		}
+	}
+
+	return ok
+}
+
+var (
+	errInvalidKey      = errors.New("Invalid API key.")
+	errTooManyRequests = errors.New("Too many requests.")
+)
+
+// check returns errInvalidKey for a request of client without a valid API
+// key, or errTooManyRequests with the time to wait for one exceeding the
+// rate of its key, or of the client without the keys.
+func (gd *guard) check(key, client string) (time.Duration, error) {
+	if len(gd.keys) > 0 {
+		if !gd.valid(key) {
+			return 0, errInvalidKey
+		}
+		client = "key:" + key
+	}
+
+	if gd.limiter != nil {
+		if wait, ok := gd.limiter.allow(client); !ok {
+			return wait, errTooManyRequests
+		}
+	}
+
+	return 0, nil
+}
+
+// protect rejects the requests to h without a valid API key, and the ones
+// exceeding the rate of their key, or of their address without the keys.
+func (gd *guard) protect(h http.Handler) http.Handler {
+	if len(gd.keys) == 0 && gd.limiter == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wait, err := gd.check(apiKey(r), clientAddr(r))
+		switch {
+		case errors.Is(err, errInvalidKey):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="uonum"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+			return
+		case errors.Is(err, errTooManyRequests):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// clientAddr returns the IP address of the client of r.
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// rateLimiter is a token bucket of each client, which is filled at rate
+// tokens per second up to burst.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+	pruned  time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		pruned:  time.Now(),
+	}
+}
+
+// allow takes a token of the client, or returns the time to wait for it.
+func (l *rateLimiter) allow(client string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.pruned) > time.Minute {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+	}
+	b.tokens--
+
+	return 0, true
+}
+
+// prune removes the buckets which have been filled up, which are the same
+// as new ones.
+func (l *rateLimiter) prune(now time.Time) {
+	for k, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, k)
+		}
+	}
+	l.pruned = now
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   string
+	}{
+		{name: "bearer", header: map[string]string{"Authorization": "Bearer secret "}, want: "secret"},
+		{name: "x-api-key", header: map[string]string{"X-API-Key": "secret"}, want: "secret"},
+		{
+			name:   "both",
+			header: map[string]string{"Authorization": "Bearer first", "X-API-Key": "second"},
+			want:   "first",
+		},
+		{name: "basic", header: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, want: ""},
+		{name: "none", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/generate", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			if got := apiKey(r); got != tt.want {
+				t.Errorf("apiKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	type request struct {
+		key    string
+		remote string
+	}
+
+	tests := []struct {
+		name     string
+		guard    guard
+		requests []request
+		want     []int
+	}{
+		{
+			name:     "open",
+			requests: []request{{}, {key: "wrong"}},
+			want:     []int{http.StatusOK, http.StatusOK},
+		},
+		{
+			name:     "keys",
+			guard:    guard{keys: []string{"one", "two"}},
+			requests: []request{{key: "one"}, {key: "two"}, {key: "three"}, {}},
+			want:     []int{http.StatusOK, http.StatusOK, http.StatusUnauthorized, http.StatusUnauthorized},
+		},
+		{
+			name:  "rate of address",
+			guard: guard{limiter: newRateLimiter(0.001, 2)},
+			requests: []request{
+				{remote: "192.0.2.1:1000"},
+				{remote: "192.0.2.1:1001"},
+				{remote: "192.0.2.1:1002"},
+				{remote: "192.0.2.2:1000"},
+			},
+			want: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name:  "rate of key",
+			guard: guard{keys: []string{"one", "two"}, limiter: newRateLimiter(0.001, 1)},
+			requests: []request{
+				{key: "one", remote: "192.0.2.1:1000"},
+				{key: "one", remote: "192.0.2.2:1000"},
+				{key: "two", remote: "192.0.2.1:1000"},
+			},
+			want: []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.guard.protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			for i, req := range tt.requests {
+				r := httptest.NewRequest(http.MethodGet, "/generate", nil)
+				if req.key != "" {
+					r.Header.Set("X-API-Key", req.key)
+				}
+				if req.remote != "" {
+					r.RemoteAddr = req.remote
+				}
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				if w.Code != tt.want[i] {
+					t.Errorf("request %d status = %d, want %d", i, w.Code, tt.want[i])
+				}
+				switch w.Code {
+				case http.StatusUnauthorized:
+					if got := w.Header().Get("WWW-Authenticate"); got != `Bearer realm="uonum"` {
+						t.Errorf("WWW-Authenticate = %q", got)
+					}
+				case http.StatusTooManyRequests:
+					if got := w.Header().Get("Retry-After"); got == "" || got == "0" {
+						t.Errorf("Retry-After = %q", got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(10, 0)
+	if _, ok := l.allow("a"); !ok {
+		t.Fatal("allow() = false at first")
+	}
+	wait, ok := l.allow("a")
+	if ok {
+		t.Fatal("allow() = true over the burst of 1")
+	}
+	if wait <= 0 || wait > 100*time.Millisecond {
+		t.Errorf("allow() wait = %v, want up to 100ms", wait)
+	}
+
+	// the bucket is filled at the rate
+	time.Sleep(wait + 10*time.Millisecond)
+	if _, ok := l.allow("a"); !ok {
+		t.Error("allow() = false after waiting")
+	}
+
+	// the full buckets are pruned
+	l.buckets["b"] = &tokenBucket{tokens: 1, last: time.Now().Add(-time.Hour)}
+	l.prune(time.Now())
+	if _, ok := l.buckets["b"]; ok {
+		t.Error("prune() kept a full bucket")
+	}
+	if _, ok := l.buckets["a"]; !ok {
+		t.Error("prune() removed a bucket being filled")
+	}
+}
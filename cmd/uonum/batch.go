@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kechako/uonum"
+)
+
+// batchSentence is a line of the JSONL output of generate -count.
+type batchSentence struct {
+	Trigger string  `json:"trigger"`
+	Text    string  `json:"text"`
+	Tokens  int     `json:"tokens"`
+	Score   float64 `json:"score"` // log-probability
+}
+
+// readTriggers reads the trigger words in the file name, one per line.
+func readTriggers(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the triggers file [%s]: %w", name, err)
+	}
+	defer file.Close()
+
+	var words []string
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		if w := strings.TrimSpace(s.Text()); w != "" {
+			words = append(words, w)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("could not read the triggers file [%s]: %w", name, err)
+	}
+
+	return words, nil
+}
+
+// generateBatch writes count sentences as JSONL to the sinks, from the
+// trigger words in turn, or from random trigger words weighted by their
+// frequencies if there is none. If count is 0, it writes a sentence for each
+// trigger word.
+//
+// The trigger words without a sentence are skipped, so that fewer than count
+// may be written.
+func generateBatch(g uonum.Producer, triggers []string, count int, sinks []sink) error {
+	random := len(triggers) == 0
+	if random {
+		words, err := g.Triggers("")
+		if err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			return uonum.ErrEmptyModel
+		}
+		triggers = words
+	}
+	if count == 0 {
+		count = len(triggers)
+	}
+
+	for i := 0; i < count; i++ {
+		trigger := triggers[i%len(triggers)]
+		if random {
+			var err error
+			trigger, err = g.RandomTrigger("")
+			if err != nil {
+				return err
+			}
+		}
+
+		t, err := g.GenerateTraced(trigger)
+		if errors.Is(err, uonum.ErrUnknownTrigger) || errors.Is(err, uonum.ErrGenerationFailed) {
+			logger.Warn("Skipped the trigger word.", "trigger", trigger, "err", err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if t.Text == "" {
+			continue
+		}
+
+		s := batchSentence{Trigger: trigger, Text: t.Text, Tokens: len(t.Steps) + 1}
+		s.Score, err = g.Score(t.Text)
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(&s)
+		if err != nil {
+			return err
+		}
+		if err := writeSinks(sinks, string(line)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kechako/uonum"
+	"github.com/kechako/uonum/uonumtest"
+)
+
+// memSink keeps the sentences written.
+type memSink struct {
+	lines []string
+}
+
+func (s *memSink) Write(ctx context.Context, text string) error {
+	s.lines = append(s.lines, text)
+	return nil
+}
+
+func (s *memSink) Close() error {
+	return nil
+}
+
+func TestReadTriggers(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "triggers.txt")
+	if err := os.WriteFile(name, []byte("猫\n\n  犬 \r\n鳥"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readTriggers(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"猫", "犬", "鳥"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("readTriggers() = %q, want %q", got, want)
+	}
+
+	if _, err := readTriggers(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("readTriggers() of a missing file = nil error")
+	}
+}
+
+func TestGenerateBatch(t *testing.T) {
+	defer func(l *slog.Logger) { logger = l }(logger)
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		texts        []string
+		triggers     []string
+		count        int
+		wantTriggers []string
+		// wantCount is the number of the sentences from random triggers
+		wantCount int
+		wantErr   error
+	}{
+		{
+			name:         "each trigger",
+			texts:        []string{"猫 は 鳴く 。", "犬 が 吠える 。"},
+			triggers:     []string{"猫", "犬"},
+			wantTriggers: []string{"猫", "犬"},
+		},
+		{
+			name:         "in turn",
+			texts:        []string{"猫 は 鳴く 。", "犬 が 吠える 。"},
+			triggers:     []string{"猫", "犬"},
+			count:        3,
+			wantTriggers: []string{"猫", "犬", "猫"},
+		},
+		{
+			name:         "unknown skipped",
+			texts:        []string{"猫 は 鳴く 。"},
+			triggers:     []string{"鳥", "猫"},
+			wantTriggers: []string{"猫"},
+		},
+		{
+			name:      "random",
+			texts:     []string{"猫 は 鳴く 。"},
+			count:     2,
+			wantCount: 2,
+		},
+		{name: "empty", wantErr: uonum.ErrEmptyModel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := uonumtest.New(1)
+			for _, text := range tt.texts {
+				if err := g.Register(text); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s := new(memSink)
+			err := generateBatch(g, tt.triggers, tt.count, []sink{s})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("generateBatch() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var triggers []string
+			for _, line := range s.lines {
+				var bs batchSentence
+				if err := json.Unmarshal([]byte(line), &bs); err != nil {
+					t.Fatalf("invalid line %q: %v", line, err)
+				}
+				triggers = append(triggers, bs.Trigger)
+				if !strings.HasPrefix(bs.Text, bs.Trigger+" ") || bs.Tokens != len(strings.Fields(bs.Text)) || bs.Score > 0 {
+					t.Errorf("sentence = %+v", bs)
+				}
+			}
+			if tt.wantCount > 0 {
+				if len(triggers) != tt.wantCount {
+					t.Errorf("sentences = %d, want %d", len(triggers), tt.wantCount)
+				}
+			} else if !reflect.DeepEqual(triggers, tt.wantTriggers) {
+				t.Errorf("triggers = %q, want %q", triggers, tt.wantTriggers)
+			}
+		})
+	}
+}
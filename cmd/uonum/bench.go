@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// benchReport is the result of bench.
+type benchReport struct {
+	Open       time.Duration `json:"open_ns"`
+	Dict       time.Duration `json:"dict_ns"`
+	DictHeap   uint64        `json:"dict_heap_bytes"`
+	Texts      int           `json:"texts"`
+	Register   time.Duration `json:"register_ns"`
+	RegAllocs  uint64        `json:"register_allocs_per_text"`
+	WordWrites int64         `json:"word_writes"`
+	WordBytes  int64         `json:"word_bytes"`
+	DBSize     int64         `json:"db_size_bytes"`
+	Generated  int           `json:"generated"`
+	GenMean    time.Duration `json:"generate_mean_ns"`
+	GenP50     time.Duration `json:"generate_p50_ns"`
+	GenP99     time.Duration `json:"generate_p99_ns"`
+	GenAllocs  uint64        `json:"generate_allocs_per_call"`
+	Heap       uint64        `json:"heap_bytes"`
+	Sys        uint64        `json:"sys_bytes"`
+	Violations []string      `json:"violations,omitempty"`
+}
+
+func (r *benchReport) registerRate() float64 {
+	if r.Register <= 0 {
+		return 0
+	}
+
+	return float64(r.Texts) / r.Register.Seconds()
+}
+
+func (r *benchReport) print() {
+	mb := func(n uint64) float64 {
+		return float64(n) / (1 << 20)
+	}
+	fmt.Printf("open\t%v\n", r.Open)
+	fmt.Printf("dictionary\t%v\t+%.1f MB heap\n", r.Dict, mb(r.DictHeap))
+	fmt.Printf("register\t%d texts in %v\t%.0f texts/s\t%d allocs/text\n", r.Texts, r.Register, r.registerRate(), r.RegAllocs)
+	fmt.Printf("writes\t%d words\t%.1f MB\t%.1f MB on disk\n", r.WordWrites, mb(uint64(r.WordBytes)), mb(uint64(r.DBSize)))
+	fmt.Printf("generate\t%d sentences\tmean %v\tp50 %v\tp99 %v\t%d allocs/call\n", r.Generated, r.GenMean, r.GenP50, r.GenP99, r.GenAllocs)
+	fmt.Printf("memory\t%.1f MB heap\t%.1f MB from the OS\n", mb(r.Heap), mb(r.Sys))
+	for _, v := range r.Violations {
+		fmt.Printf("regression\t%s\n", v)
+	}
+}
+
+func allocs() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Mallocs
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+func bench(fs *flag.FlagSet) runner {
+	conversationFlags(fs)
+	n := fs.Int("n", 1000, "Number of the sentences generated.")
+	cpuProfile := fs.String("cpuprofile", "", "Write a CPU profile of the registration and the generation to this file.")
+	memProfile := fs.String("memprofile", "", "Write a heap profile at the end to this file.")
+	maxOpen := fs.Duration("max-open", 0, "Fail if opening the database takes longer (e.g. 50ms).")
+	minRate := fs.Float64("min-register-rate", 0, "Fail if fewer texts than this are registered per second.")
+	maxGenerate := fs.Duration("max-generate", 0, "Fail if the p99 latency of the generation is longer (e.g. 5ms).")
+	maxHeap := fs.Int("max-heap", 0, "Fail if more MB than this are in the heap at the end.")
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		if len(args) == 0 {
+			return 2, errors.New("Input file of the texts is required.")
+		}
+		texts, err := readLines(args[0])
+		if err != nil {
+			return 1, err
+		}
+
+		dir, err := os.MkdirTemp("", "uonum-bench")
+		if err != nil {
+			return 1, classify(errIO, fmt.Errorf("could not create a temporary directory: %w", err))
+		}
+		defer os.RemoveAll(dir)
+
+		opts, err := generatorOptions(termWordsOption(*tw)...)
+		if err != nil {
+			return 1, err
+		}
+
+		var r benchReport
+		start := time.Now()
+		g, err := openGeneratorAt(filepath.Join(dir, "bench.db"), opts...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+		g = g.In(ns)
+		r.Open = time.Since(start)
+
+		// the first tokenization loads the dictionary
+		heap := heapInUse()
+		start = time.Now()
+		if _, err := g.Score("辞書"); err != nil {
+			return 1, err
+		}
+		r.Dict = time.Since(start)
+		if h := heapInUse(); h > heap {
+			r.DictHeap = h - heap
+		}
+
+		if *cpuProfile != "" {
+			file, err := os.Create(*cpuProfile)
+			if err != nil {
+				return 1, fmt.Errorf("could not create the CPU profile [%s]: %w", *cpuProfile, err)
+			}
+			defer file.Close()
+			if err := pprof.StartCPUProfile(file); err != nil {
+				return 1, err
+			}
+			defer pprof.StopCPUProfile()
+		}
+
+		r.Texts = len(texts)
+		a := allocs()
+		start = time.Now()
+		if err := g.RegisterReader(strings.NewReader(strings.Join(texts, "\n"))); err != nil {
+			return 1, err
+		}
+		r.Register = time.Since(start)
+		if r.Texts > 0 {
+			r.RegAllocs = (allocs() - a) / uint64(r.Texts)
+		}
+		gm := g.Metrics()
+		r.WordWrites, r.WordBytes = gm.WordWrites, gm.WordBytes
+		if fi, err := os.Stat(filepath.Join(dir, "bench.db")); err == nil {
+			r.DBSize = fi.Size()
+		}
+
+		triggers, err := g.Triggers("")
+		if err != nil {
+			return 1, err
+		}
+		if len(triggers) == 0 {
+			return 1, uonum.ErrEmptyModel
+		}
+		latencies := make([]time.Duration, 0, *n)
+		a = allocs()
+		for i := 0; i < *n; i++ {
+			trigger := triggers[rand.Intn(len(triggers))]
+			start := time.Now()
+			_, err := g.Generate(trigger)
+			if err != nil && !errors.Is(err, uonum.ErrGenerationFailed) {
+				return 1, err
+			}
+			latencies = append(latencies, time.Since(start))
+		}
+		if len(latencies) > 0 {
+			r.Generated = len(latencies)
+			r.GenAllocs = (allocs() - a) / uint64(len(latencies))
+			var total time.Duration
+			for _, l := range latencies {
+				total += l
+			}
+			r.GenMean = total / time.Duration(len(latencies))
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			r.GenP50 = latencies[len(latencies)/2]
+			r.GenP99 = latencies[len(latencies)*99/100]
+		}
+
+		r.Heap = heapInUse()
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		r.Sys = m.Sys
+		if *memProfile != "" {
+			file, err := os.Create(*memProfile)
+			if err != nil {
+				return 1, fmt.Errorf("could not create the heap profile [%s]: %w", *memProfile, err)
+			}
+			defer file.Close()
+			if err := pprof.WriteHeapProfile(file); err != nil {
+				return 1, err
+			}
+		}
+
+		if *maxOpen > 0 && r.Open > *maxOpen {
+			r.Violations = append(r.Violations, fmt.Sprintf("open took %v, over %v", r.Open, *maxOpen))
+		}
+		if *minRate > 0 && r.registerRate() < *minRate {
+			r.Violations = append(r.Violations, fmt.Sprintf("registered %.0f texts/s, under %.0f", r.registerRate(), *minRate))
+		}
+		if *maxGenerate > 0 && r.GenP99 > *maxGenerate {
+			r.Violations = append(r.Violations, fmt.Sprintf("p99 of generate is %v, over %v", r.GenP99, *maxGenerate))
+		}
+		if *maxHeap > 0 && r.Heap > uint64(*maxHeap)<<20 {
+			r.Violations = append(r.Violations, fmt.Sprintf("heap is %.1f MB, over %d MB", float64(r.Heap)/(1<<20), *maxHeap))
+		}
+
+		if jsonOutput {
+			if err := printJSON(&r); err != nil {
+				return 1, err
+			}
+		} else {
+			r.print()
+		}
+		if len(r.Violations) > 0 {
+			return 1, fmt.Errorf("Benchmark regression: %s.", strings.Join(r.Violations, ", "))
+		}
+
+		return 0, nil
+	}
+}
+
+// readLines returns the non-empty lines of the file name.
+func readLines(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the input file [%s]: %w", name, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	s := bufio.NewScanner(file)
+	s.Buffer(make([]byte, 64*1024), 1<<20)
+	for s.Scan() {
+		if l := strings.TrimSpace(s.Text()); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("could not read the input file [%s]: %w", name, err)
+	}
+
+	return lines, nil
+}
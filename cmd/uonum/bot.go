@@ -0,0 +1,48 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/kechako/uonum"
+)
+
+// generateRandom generates a sentence from trigger, or from a random trigger
+// word weighted by its frequency if trigger is empty.
+func generateRandom(g uonum.Producer, trigger string) (string, error) {
+	if trigger == "" {
+		var err error
+		trigger, err = g.RandomTrigger("")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return g.Generate(trigger)
+}
+
+// splitList returns the comma separated items of s.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+var (
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</p>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// htmlText returns the plain text of an HTML fragment, with the line breaks
+// and the paragraphs as newlines.
+func htmlText(content string) string {
+	s := htmlBreak.ReplaceAllString(content, "\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
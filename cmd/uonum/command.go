@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// command is a subcommand of uonum.
+type command struct {
+	name    string
+	args    string
+	summary string
+	// flags defines the flags of the command in fs and returns the runner,
+	// which is called with the arguments after the flags.
+	flags func(fs *flag.FlagSet) runner
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"register", "[input file]", "Register the texts in the input file or the standard input, one per line.", register},
+		{"generate", "[trigger word]", "Generate sentences starting from the trigger word.", generate},
+		{"reply", "[message]", "Generate a reply to the message.", withFlags(speakFlags, reply)},
+		{"repl", "", "Generate and register interactively.", repl},
+		{"serve", "", "Serve generate, reply, register, stats and webhook over HTTP, and gRPC.", serve},
+		{"daemon", "", "Hold the database open and run the commands of ctl over a Unix socket.", daemon},
+		{"ctl", "generate|reply|register|stats|flush|ping|stop [args...]", "Run a command on the daemon.", ctl},
+		{"schedule", "", "Post generated sentences at the times of a cron expression.", schedule},
+		{"feed", "add|remove|list|poll [url...]", "Manage the RSS/Atom feeds, and register their new entries.", feed},
+		{"import-twitter", "<archive.zip>", "Register the tweets in a Twitter/X account archive.", importTwitter},
+		{"import-aozora", "<file.txt or file.zip>...", "Register the body of the Aozora Bunko texts without their formatting.", importAozora},
+		{"mastodon", "", "Learn from a Mastodon timeline, and post and reply with generated statuses.", mastodon},
+		{"discord", "", "Learn from Discord channels, and reply with generated messages.", discord},
+		{"slack", "", "Learn from Slack channels over Socket Mode, and reply to the mentions.", slack},
+		{"irc", "", "Join IRC channels, register the messages, and speak by chance or when mentioned.", irc},
+		{"score", "[input file]", "Print the log-probability and the perplexity of each line.", score},
+		{"word", "<surface>", "Print the classes, the features and the transitions of a word.", word},
+		{"tokenize", "[text]", "Print the morphemes of the text or the lines of the standard input, with their classes, base forms and readings.", tokenize},
+		{"stats", "", "Print the numbers of the words, the links and the texts of the model.", noFlags(stats)},
+		{"triggers", "[prefix or word]", "List the trigger words.", triggers},
+		{"dump", "", "Dump the words and their links.", dump},
+		{"graph", "[word]", "Write the word chain as a Graphviz DOT graph.", graph},
+		{"migrate", "", "Upgrade the database to the current schema version.", noFlags(migrate)},
+		{"fsck", "", "Check the consistency of the database.", fsck},
+		{"backup", "<dest file or ->", "Write a consistent snapshot of the database.", noFlags(backup)},
+		{"bench", "<input file>", "Measure the open time, the registration, the generation and the memory in a temporary database.", bench},
+		{"restore", "<backup file or ->", "Replace the database with a backup.", noFlags(restore)},
+		{"snapshot", "create|rollback|list|remove [name]", "Manage the named snapshots of the database, and roll it back to them.", noFlags(snapshot)},
+		{"compact", "", "Rewrite the database file to reclaim the free space.", noFlags(compact)},
+		{"merge", "<other database>", "Add the texts and the links of another database.", withFlags(learnFlags, merge)},
+		{"diff", "<database A> <database B>", "Print the differences of the links of two databases.", noFlags(diff)},
+		{"decay", "", "Decay the counts of the links by their age.", decay},
+		{"rebuild", "", "Build the links again from the registered texts, under -stop-words and -stop-classes.", withFlags(learnFlags, rebuild)},
+		{"help", "[command]", "Print the help of a command.", noFlags(help)},
+		{"completion", "bash|zsh|fish", "Print the shell completion script.", noFlags(completion)},
+	}
+}
+
+// noFlags returns the flags of a command which has no flags of its own.
+func noFlags(r runner) func(fs *flag.FlagSet) runner {
+	return func(*flag.FlagSet) runner {
+		return r
+	}
+}
+
+// withFlags returns the flags of a command which has only the flags defined
+// by define.
+func withFlags(define func(fs *flag.FlagSet), r runner) func(fs *flag.FlagSet) runner {
+	return func(fs *flag.FlagSet) runner {
+		define(fs)
+		return r
+	}
+}
+
+// findCommand returns the command named name, or nil if there is none.
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// run parses the flags in args and runs the command.
+// The global options are accepted after the command as well.
+func (c *command) run(args []string) (int, error) {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	r := c.flags(fs)
+	// the flags are overridden in this order
+	err := conf.apply(fs, c.name)
+	if err != nil {
+		return 1, err
+	}
+	err = applyEnv(fs, c.name)
+	if err != nil {
+		return 1, err
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		if fs.Lookup(f.Name) == nil {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fs.Usage = func() {
+		c.printUsage(os.Stderr)
+	}
+	fs.Parse(args)
+
+	return r(fs.Args())
+}
+
+// flagSet returns the flags of the command itself.
+func (c *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	c.flags(fs)
+	return fs
+}
+
+func (c *command) printUsage(w io.Writer) {
+	fs := c.flagSet()
+
+	var n int
+	fs.VisitAll(func(*flag.Flag) { n++ })
+
+	synopsis := []string{"uonum [options]", c.name}
+	if n > 0 {
+		synopsis = append(synopsis, "[flags]")
+	}
+	if c.args != "" {
+		synopsis = append(synopsis, c.args)
+	}
+	fmt.Fprintf(w, "\nUsage:\n    %s\n", strings.Join(synopsis, " "))
+	fmt.Fprintf(w, "\n%s\n", c.summary)
+
+	if n > 0 {
+		fmt.Fprintln(w, "\nFlags:")
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+	fmt.Fprintln(w, "\nRun \"uonum help\" for the global options.")
+	fmt.Fprintln(w)
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `
+Usage:
+    uonum [options] <command> [flags] [arguments]
+
+Commands:
+`)
+	for _, c := range commands {
+		fmt.Fprintf(w, "    %-16s%s\n", c.name, c.summary)
+	}
+	fmt.Fprint(w, `
+Run "uonum help <command>" for the flags of a command.
+
+Config file:
+    The options and the flags default to the values in $UONUM_CONFIG or
+    ~/.config/uonum/config.toml, then to the environment variables UONUM_<NAME>
+    for the options (e.g. UONUM_DB) and UONUM_<COMMAND>_<NAME> for the flags
+    of a command (e.g. UONUM_GENERATE_TERM_WORDS). The flags of a command are
+    in the section of its name, and the flags of the model shared by several
+    commands, such as normalize, may be in the global section as well:
+
+        db = "/path/to/uonum.db"
+        normalize = "all"
+        [generate]
+        term_words = ["。", "！"]
+
+Exit status:
+    0  success
+    1  error
+    2  invalid arguments
+    3  unknown trigger word
+    4  empty model
+    5  broken data in the database
+    6  could not generate a sentence
+    7  database is not opened
+    8  could not open the database
+    9  I/O error reading or writing a file
+
+Options:
+`)
+	out := flag.CommandLine.Output()
+	flag.CommandLine.SetOutput(w)
+	flag.PrintDefaults()
+	flag.CommandLine.SetOutput(out)
+	fmt.Fprintln(w)
+}
+
+func help(args []string) (int, error) {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return 0, nil
+	}
+
+	c := findCommand(args[0])
+	if c == nil {
+		return 2, fmt.Errorf("Unknown command [%s].", args[0])
+	}
+	c.printUsage(os.Stdout)
+
+	return 0, nil
+}
+
+func completion(args []string) (int, error) {
+	if len(args) == 0 {
+		return 2, errors.New("Shell is required (bash, zsh or fish).")
+	}
+
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout)
+	case "zsh":
+		fmt.Fprintln(os.Stdout, "autoload -U +X bashcompinit && bashcompinit")
+		writeBashCompletion(os.Stdout)
+	case "fish":
+		writeFishCompletion(os.Stdout)
+	default:
+		return 2, fmt.Errorf("Unknown shell [%s].", args[0])
+	}
+
+	return 0, nil
+}
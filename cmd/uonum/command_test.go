@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, c := range commands {
+		if seen[c.name] {
+			t.Errorf("command [%s] is defined twice", c.name)
+		}
+		seen[c.name] = true
+
+		if got := findCommand(c.name); got != c {
+			t.Errorf("findCommand(%q) = %v, want the command", c.name, got)
+		}
+		// the flags of each command are defined without a conflict
+		c.flagSet()
+	}
+
+	if c := findCommand("unknown"); c != nil {
+		t.Errorf("findCommand(%q) = %v, want nil", "unknown", c.name)
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "register",
+			want: []string{"uonum [options] register [flags] [input file]", "Flags:", "-term-words"},
+		},
+		{
+			name:    "stats",
+			want:    []string{"uonum [options] stats\n"},
+			notWant: []string{"[flags]", "Flags:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			findCommand(tt.name).printUsage(&buf)
+			got := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("usage does not contain %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("usage contains %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHelpErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		run  runner
+		args []string
+	}{
+		{name: "unknown command", run: help, args: []string{"unknown"}},
+		{name: "no shell", run: completion},
+		{name: "unknown shell", run: completion, args: []string{"ksh"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := tt.run(tt.args)
+			if code != 2 || err == nil {
+				t.Errorf("run(%q) = %d, %v, want 2 and an error", tt.args, code, err)
+			}
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w *bytes.Buffer)
+		want  []string
+	}{
+		{
+			name:  "bash",
+			write: func(w *bytes.Buffer) { writeBashCompletion(w) },
+			want: []string{
+				"        -db|-dict|-ns|",
+				"    register)\n",
+				" -term-words ",
+				"complete -F _uonum uonum\n",
+			},
+		},
+		{
+			name:  "fish",
+			write: func(w *bytes.Buffer) { writeFishCompletion(w) },
+			want: []string{
+				"complete -c uonum -n __fish_use_subcommand -o db -d ",
+				"complete -c uonum -f -n __fish_use_subcommand -a register -d ",
+				"complete -c uonum -n '__fish_seen_subcommand_from register' -o term-words -d ",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.write(&buf)
+			got := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("completion does not contain %q", s)
+				}
+			}
+		})
+	}
+}
+
+func TestFishQuote(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{s: "word", want: "'word'"},
+		{s: "it's", want: `'it\'s'`},
+		{s: `a\b`, want: `'a\\b'`},
+	}
+
+	for _, tt := range tests {
+		if got := fishQuote(tt.s); got != tt.want {
+			t.Errorf("fishQuote(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// flagNames returns the names of the flags in fs with a leading "-".
+// If values is true, only the flags which take a value are returned.
+func flagNames(fs *flag.FlagSet, values bool) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); values && ok && b.IsBoolFlag() {
+			return
+		}
+		names = append(names, "-"+f.Name)
+	})
+
+	return names
+}
+
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+
+	return names
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintf(w, `_uonum() {
+    local cur=${COMP_WORDS[COMP_CWORD]} cmd= i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case ${COMP_WORDS[i]} in
+        %s) ((i++)) ;;
+        -*) ;;
+        *) cmd=${COMP_WORDS[i]}; break ;;
+        esac
+    done
+
+    case $cmd in
+    "")
+        if [[ $cur == -* ]]; then
+            COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        else
+            COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        fi
+        ;;
+    help)
+        COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        ;;
+    completion)
+        COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+        ;;
+`, strings.Join(flagNames(flag.CommandLine, true), "|"),
+		strings.Join(flagNames(flag.CommandLine, false), " "),
+		strings.Join(commandNames(), " "),
+		strings.Join(commandNames(), " "))
+
+	for _, c := range commands {
+		names := flagNames(c.flagSet(), false)
+		if len(names) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, `    %s)
+        if [[ $cur == -* ]]; then
+            COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        else
+            COMPREPLY=($(compgen -f -- "$cur"))
+        fi
+        ;;
+`, c.name, strings.Join(names, " "))
+	}
+
+	fmt.Fprint(w, `    *)
+        COMPREPLY=($(compgen -f -- "$cur"))
+        ;;
+    esac
+}
+complete -F _uonum uonum
+`)
+}
+
+func writeFishCompletion(w io.Writer) {
+	flag.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(w, "complete -c uonum -n __fish_use_subcommand -o %s -d %s\n", f.Name, fishQuote(f.Usage))
+	})
+	for _, c := range commands {
+		fmt.Fprintf(w, "complete -c uonum -f -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.summary))
+		c.flagSet().VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, "complete -c uonum -n '__fish_seen_subcommand_from %s' -o %s -d %s\n", c.name, f.Name, fishQuote(f.Usage))
+		})
+	}
+	fmt.Fprintf(w, "complete -c uonum -f -n '__fish_seen_subcommand_from help' -a '%s'\n", strings.Join(commandNames(), " "))
+	fmt.Fprintln(w, "complete -c uonum -f -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'")
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
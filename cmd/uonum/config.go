@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// config is the values of the flags in the config file by section.
+// The global options are in the section "", and the flags of a command are
+// in the section named after it.
+type config map[string]map[string]string
+
+// conf is the loaded config file.
+var conf config
+
+// configPath returns the path of the config file: $UONUM_CONFIG, or
+// config.toml in the uonum directory of the user config directory
+// (e.g. ~/.config/uonum/config.toml).
+func configPath() string {
+	if p := os.Getenv("UONUM_CONFIG"); p != "" {
+		return p
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "uonum", "config.toml")
+}
+
+// loadConfig reads the config file at path. It returns an empty config if
+// the file does not exist.
+//
+// The file is a subset of TOML: sections, and keys of strings, numbers,
+// booleans or arrays of strings, which are joined with commas. The keys are
+// the names of the flags, with "_" as well as "-".
+func loadConfig(path string) (config, error) {
+	c := make(config)
+	if path == "" {
+		return c, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open the config file [%s]: %w", path, err)
+	}
+	defer file.Close()
+
+	section := ""
+	s := bufio.NewScanner(file)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(s.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid line %d of the config file [%s].", n, path)
+		}
+		key := strings.ReplaceAll(strings.TrimSpace(line[:i]), "_", "-")
+		value, err := parseConfigValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of [%s] at line %d of the config file [%s]: %w", key, n, path, err)
+		}
+
+		if c[section] == nil {
+			c[section] = make(map[string]string)
+		}
+		c[section][key] = value
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("could not read the config file [%s]: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// stripComment removes the comment starting with "#" outside of the quotes.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+func parseConfigValue(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		return strconv.Unquote(v)
+	case strings.HasPrefix(v, "'"):
+		if len(v) < 2 || !strings.HasSuffix(v, "'") {
+			return "", errors.New("Unterminated string.")
+		}
+		return v[1 : len(v)-1], nil
+	case strings.HasPrefix(v, "["):
+		if !strings.HasSuffix(v, "]") {
+			return "", errors.New("Unterminated array.")
+		}
+		var items []string
+		for _, item := range strings.Split(v[1:len(v)-1], ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			s, err := parseConfigValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	}
+
+	return v, nil
+}
+
+// apply sets the flags in fs to the values in section. The flags of the
+// model shared by several commands (see sharedFlags) are accepted in the
+// global section, and set in the flags of the commands defining them.
+func (c config) apply(fs *flag.FlagSet, section string) error {
+	if section != "" {
+		for key, value := range c[""] {
+			if sharedFlags[key] && fs.Lookup(key) != nil {
+				if err := fs.Set(key, value); err != nil {
+					return fmt.Errorf("invalid value of [%s] in the config file: %w", key, err)
+				}
+			}
+		}
+	}
+
+	for key, value := range c[section] {
+		if fs.Lookup(key) == nil {
+			if section == "" {
+				if sharedFlags[key] {
+					continue
+				}
+				return fmt.Errorf("Unknown option [%s] in the config file.", key)
+			}
+			return fmt.Errorf("Unknown flag [%s] of %s in the config file.", key, section)
+		}
+		if err := fs.Set(key, value); err != nil {
+			return fmt.Errorf("invalid value of [%s] in the config file: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// applyEnv sets the flags in fs to the environment variables named UONUM_
+// followed by the names of the flags in upper case, with "_" for "-"
+// (e.g. UONUM_DB, UONUM_USER_DICT). The flags of a command are named after
+// the command as well (e.g. UONUM_DISCORD_TOKEN, UONUM_GENERATE_TERM_WORDS),
+// and the shared flags of the model fall back to UONUM_<NAME>.
+func applyEnv(fs *flag.FlagSet, command string) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+
+		names := []string{envName("", f.Name)}
+		if command != "" {
+			names = []string{envName(command, f.Name)}
+			if sharedFlags[f.Name] {
+				names = append(names, envName("", f.Name))
+			}
+		}
+		for _, name := range names {
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if serr := fs.Set(f.Name, v); serr != nil {
+				err = fmt.Errorf("invalid value of %s: %w", name, serr)
+			}
+			return
+		}
+	})
+
+	return err
+}
+
+// envName returns the name of the environment variable of the flag name of
+// command, or of the global option name if command is "".
+func envName(command, name string) string {
+	if command != "" {
+		name = command + "_" + name
+	}
+
+	return "UONUM_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		env     map[string]string
+		want    map[string]string
+	}{
+		{
+			name:    "command",
+			command: "discord",
+			env:     map[string]string{"UONUM_DISCORD_TOKEN": "d", "UONUM_SLACK_TOKEN": "s"},
+			want:    map[string]string{"token": "d"},
+		},
+		{
+			name:    "no name of the command",
+			command: "discord",
+			env:     map[string]string{"UONUM_TOKEN": "t"},
+			want:    map[string]string{"token": ""},
+		},
+		{
+			name:    "shared",
+			command: "generate",
+			env:     map[string]string{"UONUM_NORMALIZE": "all"},
+			want:    map[string]string{"normalize": "all"},
+		},
+		{
+			name:    "shared of the command",
+			command: "generate",
+			env:     map[string]string{"UONUM_NORMALIZE": "all", "UONUM_GENERATE_NORMALIZE": "nfkc"},
+			want:    map[string]string{"normalize": "nfkc"},
+		},
+		{
+			name: "global",
+			env:  map[string]string{"UONUM_TOKEN": "t"},
+			want: map[string]string{"token": "t"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			fs := flag.NewFlagSet(tt.command, flag.ContinueOnError)
+			fs.String("token", "", "")
+			fs.String("normalize", "", "")
+			if err := applyEnv(fs, tt.command); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.want {
+				if got := fs.Lookup(name).Value.String(); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config
+		section string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "shared in the global section",
+			conf:    config{"": {"normalize": "all"}},
+			section: "generate",
+			want:    map[string]string{"normalize": "all"},
+		},
+		{
+			name:    "shared of the command",
+			conf:    config{"": {"normalize": "all"}, "generate": {"normalize": "nfkc"}},
+			section: "generate",
+			want:    map[string]string{"normalize": "nfkc"},
+		},
+		{
+			name:    "shared skipped by the global options",
+			conf:    config{"": {"normalize": "all"}},
+			section: "",
+		},
+		{
+			name:    "unknown option",
+			conf:    config{"": {"token": "t"}},
+			section: "",
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag",
+			conf:    config{"generate": {"bogus": "1"}},
+			section: "generate",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet(tt.section, flag.ContinueOnError)
+			if tt.section != "" {
+				fs.String("normalize", "", "")
+			}
+
+			err := tt.conf.apply(fs, tt.section)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, want error %v", err, tt.wantErr)
+			}
+			for name, want := range tt.want {
+				if got := fs.Lookup(name).Value.String(); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    config
+		wantErr bool
+	}{
+		{
+			name: "sections",
+			text: `# uonum
+db = "/tmp/uonum.db" # the database
+normalize = all
+
+[generate]
+term_words = ["。", '！']
+count = 3
+balance = true
+`,
+			want: config{
+				"":         {"db": "/tmp/uonum.db", "normalize": "all"},
+				"generate": {"term-words": "。,！", "count": "3", "balance": "true"},
+			},
+		},
+		{name: "hash in a string", text: `user-dict = "a#b.csv"`, want: config{"": {"user-dict": "a#b.csv"}}},
+		{name: "no value", text: "db\n", wantErr: true},
+		{name: "unterminated string", text: `db = 'uonum.db`, wantErr: true},
+		{name: "unterminated array", text: `term_words = ["。"`, wantErr: true},
+		{name: "invalid quote", text: `db = "uonum.db`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.text), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfig() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		got, err := loadConfig(filepath.Join(t.TempDir(), "config.toml"))
+		if err != nil || len(got) != 0 {
+			t.Errorf("loadConfig() = %v, %v, want an empty config", got, err)
+		}
+	})
+}
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("UONUM_CONFIG", "/etc/uonum.toml")
+	if got := configPath(); got != "/etc/uonum.toml" {
+		t.Errorf("configPath() = %q, want %q", got, "/etc/uonum.toml")
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a cron expression of the minute, the hour, the day of the
+// month, the month and the day of the week, each a bit set of the values.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// the day matches either field if both of them are restricted
+	domStar, dowStar bool
+	loc              *time.Location
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	cronMonths = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronDays   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// parseCron parses the cron expression s in the time zone loc. Each field is
+// *, or a comma separated list of values, ranges (e.g. 1-5) and steps (e.g.
+// */15 or 9-17/2). The months and the days of the week can be named (e.g. jan
+// or mon), and 7 is Sunday as well as 0.
+func parseCron(s string, loc *time.Location) (*cronSchedule, error) {
+	expr := strings.TrimSpace(s)
+	if m, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Invalid cron expression [%s]: 5 fields are required.", s)
+	}
+
+	c := &cronSchedule{
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+		loc:     loc,
+	}
+	var err error
+	for i, f := range []struct {
+		bits     *uint64
+		min, max int
+		names    []string
+		name     string
+	}{
+		{&c.minute, 0, 59, nil, "minute"},
+		{&c.hour, 0, 23, nil, "hour"},
+		{&c.dom, 1, 31, nil, "day of month"},
+		{&c.month, 1, 12, cronMonths, "month"},
+		{&c.dow, 0, 7, cronDays, "day of week"},
+	} {
+		*f.bits, err = parseCronField(fields[i], f.min, f.max, f.names)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression [%s] (%s): %w", s, f.name, err)
+		}
+	}
+	// Sunday is 0 and 7
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+
+	return c, nil
+}
+
+func parseCronField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("Invalid step [%s].", item)
+			}
+			rng, step = item[:i], n
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			lo, err = cronValue(a, min, max, names)
+			if err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				hi, err = cronValue(b, min, max, names)
+				if err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// 5/15 means from 5 to the maximum
+				hi = max
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("Invalid range [%s].", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+func cronValue(s string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			// the months start from 1
+			return i + min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("Invalid value [%s].", s)
+	}
+
+	return v, nil
+}
+
+// next returns the first time after t matching the schedule, or the zero time
+// if there is none within 5 years (e.g. February 30).
+func (c *cronSchedule) next(t time.Time) time.Time {
+	t = t.In(c.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+
+	return dom || dow
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "* * * * *"},
+		{expr: "0 9-21/3 * * mon-fri"},
+		{expr: "*/15 0,12 1 jan,Jul 7"},
+		{expr: "5/20 * * * *"},
+		{expr: " @Hourly "},
+		{expr: "@weekly"},
+		{expr: "* * * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "* 24 * * *", wantErr: true},
+		{expr: "* * 0 * *", wantErr: true},
+		{expr: "* * * 13 *", wantErr: true},
+		{expr: "* * * * 8", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "5-1 * * * *", wantErr: true},
+		{expr: "* * * foo *", wantErr: true},
+		{expr: "@reboot", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := parseCron(tt.expr, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseCron(%q) error = %v, want error %v", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	// 2024-01-01 is Monday
+	from := time.Date(2024, 1, 1, 10, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{expr: "* * * * *", want: time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{expr: "5/20 * * * *", want: time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{expr: "@hourly", want: time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{expr: "0 9-21/3 * * *", want: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * *", want: time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * *", loc: jst, want: time.Date(2024, 1, 2, 9, 0, 0, 0, jst)},
+		{expr: "0 0 * * sat", want: time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", want: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		// either the day of the month or the day of the week
+		{expr: "0 0 15 * sun", want: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 15 * *", want: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", want: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 feb *", want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "@yearly", want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			loc := tt.loc
+			if loc == nil {
+				loc = time.UTC
+			}
+			c, err := parseCron(tt.expr, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.next(from); !got.Equal(tt.want) {
+				t.Errorf("next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
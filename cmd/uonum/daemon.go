@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// daemonBatch is the number of the texts sent in a register request of ctl.
+const daemonBatch = 1000
+
+func defaultSocketPath() string {
+	return filepath.Join(dataDir(), "uonum.sock")
+}
+
+// daemonRequest is a command sent to the daemon, a JSON object per line.
+type daemonRequest struct {
+	Cmd     string   `json:"cmd"`
+	NS      string   `json:"ns,omitempty"`
+	Trigger string   `json:"trigger,omitempty"`
+	Message string   `json:"message,omitempty"`
+	Texts   []string `json:"texts,omitempty"`
+	Source  string   `json:"source,omitempty"`
+	Author  string   `json:"author,omitempty"`
+}
+
+// daemonResponse is the response to a daemonRequest.
+type daemonResponse struct {
+	Text  string       `json:"text,omitempty"`
+	Count int          `json:"count,omitempty"`
+	Stats *uonum.Stats `json:"stats,omitempty"`
+	Error string       `json:"error,omitempty"`
+	// Code is the kind of Error, by which the client exits with the same
+	// status as the command run without the daemon
+	Code string `json:"code,omitempty"`
+}
+
+var daemonErrors = map[string]error{
+	"unknown_trigger":   uonum.ErrUnknownTrigger,
+	"empty_model":       uonum.ErrEmptyModel,
+	"decode":            uonum.ErrDecode,
+	"generation_failed": uonum.ErrGenerationFailed,
+	"not_open":          uonum.ErrNotOpen,
+	"open_db":           errOpenDB,
+	"io":                errIO,
+}
+
+// daemonError is an error returned by the daemon.
+type daemonError struct {
+	code string
+	msg  string
+}
+
+func (e *daemonError) Error() string {
+	return e.msg
+}
+
+func (e *daemonError) Is(target error) bool {
+	return daemonErrors[e.code] == target
+}
+
+func errorCode(err error) string {
+	for code, e := range daemonErrors {
+		if errors.Is(err, e) {
+			return code
+		}
+	}
+	if isIO(err) {
+		return "io"
+	}
+
+	return ""
+}
+
+func daemon(fs *flag.FlagSet) runner {
+	conversationFlags(fs)
+	socket := fs.String("socket", defaultSocketPath(), "Unix socket to accept the commands on.")
+	cache := fs.Int("cache", 0, "Number of the words kept in memory for the generation.")
+	buffer := fs.Int("buffer", 0, "Accumulate this number of registered texts in memory before writing them.")
+	interval := fs.Duration("flush-interval", 0, "Write the accumulated texts at this interval (e.g. 10s).")
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		opts := append(termWordsOption(*tw), uonum.WithCache(*cache))
+		if *buffer > 0 || *interval > 0 {
+			opts = append(opts, uonum.WithBuffer(*buffer, *interval))
+		}
+		// listen first, which fails fast if a daemon is running, rather than
+		// waiting for its lock of the database
+		l, err := listenSocket(*socket)
+		if err != nil {
+			return 1, err
+		}
+		defer l.Close()
+
+		g, err := openGenerator(opts...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			l.Close()
+		}()
+
+		d := &daemonServer{g: g.In(ns), stop: stop}
+		logger.Info("Listening.", "socket", *socket)
+		var wg sync.WaitGroup
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				return 1, fmt.Errorf("could not accept a connection: %w", err)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.serve(ctx, conn)
+			}()
+		}
+		wg.Wait()
+
+		return 0, nil
+	}
+}
+
+// listenSocket listens on the Unix socket at path, removing the socket left
+// by a daemon which is not running.
+func listenSocket(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("The daemon is already running on [%s].", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("could not remove the socket [%s]: %w", path, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("could not create the directory of the socket: %w", err)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on [%s]: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("could not change the mode of the socket: %w", err)
+	}
+
+	return l, nil
+}
+
+// daemonServer runs the commands on the generator.
+type daemonServer struct {
+	// mu serializes the calls of g
+	mu   sync.Mutex
+	g    uonum.Generator
+	stop func()
+}
+
+// serve answers the requests of conn until it is closed or ctx is done.
+func (d *daemonServer) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		conn.SetReadDeadline(time.Now())
+	}()
+
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	enc := json.NewEncoder(conn)
+	for {
+		var req daemonRequest
+		if err := dec.Decode(&req); err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				enc.Encode(&daemonResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+			}
+			return
+		}
+
+		res, err := d.run(&req)
+		if err != nil {
+			res = &daemonResponse{Error: err.Error(), Code: errorCode(err)}
+		}
+		if err := enc.Encode(res); err != nil {
+			return
+		}
+		if req.Cmd == "stop" {
+			d.stop()
+			return
+		}
+	}
+}
+
+func (d *daemonServer) run(req *daemonRequest) (*daemonResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	g := d.g
+	if req.NS != "" {
+		g = g.In(req.NS)
+	}
+
+	res := new(daemonResponse)
+	var err error
+	switch req.Cmd {
+	case "ping", "stop":
+	case "generate":
+		res.Text, err = generateRandom(g, req.Trigger)
+	case "reply":
+		res.Text, err = g.Reply(req.Message)
+	case "register":
+		meta := uonum.Meta{Source: req.Source, Author: req.Author}
+		for _, t := range req.Texts {
+			if err = g.RegisterWithMeta(t, meta); err != nil {
+				break
+			}
+			res.Count++
+		}
+	case "stats":
+		res.Stats, err = g.Stats()
+	case "flush":
+		err = g.Flush()
+	default:
+		err = fmt.Errorf("Unknown command [%s].", req.Cmd)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// daemonClient sends the commands to the daemon.
+type daemonClient struct {
+	conn net.Conn
+	dec  *json.Decoder
+	enc  *json.Encoder
+}
+
+func dialDaemon(path string) (*daemonClient, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the daemon [%s]: %w", path, err)
+	}
+
+	return &daemonClient{conn: conn, dec: json.NewDecoder(conn), enc: json.NewEncoder(conn)}, nil
+}
+
+func (c *daemonClient) call(req *daemonRequest) (*daemonResponse, error) {
+	req.NS = ns
+	if err := c.enc.Encode(req); err != nil {
+		return nil, fmt.Errorf("could not send the command: %w", err)
+	}
+
+	var res daemonResponse
+	if err := c.dec.Decode(&res); err != nil {
+		return nil, fmt.Errorf("could not read the response of the daemon: %w", err)
+	}
+	if res.Error != "" {
+		return nil, &daemonError{code: res.Code, msg: res.Error}
+	}
+
+	return &res, nil
+}
+
+func (c *daemonClient) Close() error {
+	return c.conn.Close()
+}
+
+func ctl(fs *flag.FlagSet) runner {
+	socket := fs.String("socket", defaultSocketPath(), "Unix socket of the daemon.")
+	source := fs.String("source", "", "Source of the texts of register.")
+	author := fs.String("author", "", "Author of the texts of register.")
+
+	return func(args []string) (int, error) {
+		if len(args) == 0 {
+			return 2, errors.New("Subcommand is required (generate, reply, register, stats, flush, ping or stop).")
+		}
+
+		c, err := dialDaemon(*socket)
+		if err != nil {
+			return 1, err
+		}
+		defer c.Close()
+
+		rest := strings.Join(args[1:], " ")
+		switch args[0] {
+		case "generate":
+			res, err := c.call(&daemonRequest{Cmd: "generate", Trigger: rest})
+			if err != nil {
+				return 1, err
+			}
+			fmt.Println(sentenceLine(rest, res.Text))
+		case "reply":
+			res, err := c.call(&daemonRequest{Cmd: "reply", Message: rest})
+			if err != nil {
+				return 1, err
+			}
+			fmt.Println(sentenceLine("", res.Text))
+		case "register":
+			return ctlRegister(c, args[1:], *source, *author)
+		case "stats":
+			res, err := c.call(&daemonRequest{Cmd: "stats"})
+			if err != nil {
+				return 1, err
+			}
+			if err := printStats(res.Stats); err != nil {
+				return 1, err
+			}
+		case "flush", "ping", "stop":
+			if _, err := c.call(&daemonRequest{Cmd: args[0]}); err != nil {
+				return 1, err
+			}
+		default:
+			return 2, fmt.Errorf("Unknown subcommand [%s].", args[0])
+		}
+
+		return 0, nil
+	}
+}
+
+// ctlRegister sends the lines of the input file or the standard input in
+// batches.
+func ctlRegister(c *daemonClient, args []string, source, author string) (int, error) {
+	var r io.Reader = os.Stdin
+	name := "-"
+	if len(args) > 0 && args[0] != "-" {
+		name = args[0]
+		file, err := os.Open(name)
+		if err != nil {
+			return 1, fmt.Errorf("could not open the input file [%s]: %w", name, err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	send := func(texts []string) error {
+		_, err := c.call(&daemonRequest{Cmd: "register", Texts: texts, Source: source, Author: author})
+		return err
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1<<20)
+	var texts []string
+	for s.Scan() {
+		if t := strings.TrimSpace(s.Text()); t != "" {
+			texts = append(texts, t)
+		}
+		if len(texts) == daemonBatch {
+			if err := send(texts); err != nil {
+				return 1, err
+			}
+			texts = texts[:0]
+		}
+	}
+	if err := s.Err(); err != nil {
+		return 1, fmt.Errorf("could not read the input file [%s]: %w", name, err)
+	}
+	if len(texts) > 0 {
+		if err := send(texts); err != nil {
+			return 1, err
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kechako/uonum"
+	"github.com/kechako/uonum/uonumtest"
+)
+
+// startDaemon serves a daemon of g on a socket, and returns its path and
+// the channel closed when it is stopped.
+func startDaemon(t *testing.T, g uonum.Generator) (string, <-chan struct{}) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "uonum.sock")
+	l, err := listenSocket(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	var once sync.Once
+	d := &daemonServer{g: g, stop: func() { once.Do(func() { close(stopped) }) }}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(ctx, conn)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		l.Close()
+	})
+
+	return path, stopped
+}
+
+func TestDaemon(t *testing.T) {
+	path, stopped := startDaemon(t, uonumtest.New(1))
+
+	c, err := dialDaemon(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		name    string
+		req     daemonRequest
+		want    daemonResponse
+		wantErr error
+	}{
+		{name: "ping", req: daemonRequest{Cmd: "ping"}},
+		{name: "empty", req: daemonRequest{Cmd: "generate"}, wantErr: uonum.ErrEmptyModel},
+		{
+			name: "register",
+			req:  daemonRequest{Cmd: "register", Texts: []string{"猫 は 鳴く 。", "犬 が 吠える 。"}},
+			want: daemonResponse{Count: 2},
+		},
+		{name: "generate", req: daemonRequest{Cmd: "generate", Trigger: "猫"}, want: daemonResponse{Text: "猫 は 鳴く 。"}},
+		{name: "unknown trigger", req: daemonRequest{Cmd: "generate", Trigger: "鳥"}, wantErr: uonum.ErrUnknownTrigger},
+		{name: "reply", req: daemonRequest{Cmd: "reply", Message: "犬"}, want: daemonResponse{Text: "犬 が 吠える 。"}},
+		{name: "flush", req: daemonRequest{Cmd: "flush"}},
+		{name: "unknown command", req: daemonRequest{Cmd: "dance"}, wantErr: errors.New("Unknown command [dance].")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.call(&tt.req)
+			if tt.wantErr != nil {
+				if err == nil || (!errors.Is(err, tt.wantErr) && err.Error() != tt.wantErr.Error()) {
+					t.Errorf("call() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Text != tt.want.Text || res.Count != tt.want.Count {
+				t.Errorf("call() = %+v, want %+v", res, tt.want)
+			}
+		})
+	}
+
+	res, err := c.call(&daemonRequest{Cmd: "stats"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stats == nil || res.Stats.Texts != 2 {
+		t.Errorf("stats = %+v, want 2 texts", res.Stats)
+	}
+
+	// the namespace of the client
+	defer func(s string) { ns = s }(ns)
+	ns = "cats"
+	if _, err := c.call(&daemonRequest{Cmd: "generate", Trigger: "猫"}); !errors.Is(err, uonum.ErrEmptyModel) {
+		t.Errorf("generate in cats error = %v, want %v", err, uonum.ErrEmptyModel)
+	}
+	ns = ""
+
+	// an invalid request ends the connection
+	if _, err := fmt.Fprintln(c.conn, "[]"); err != nil {
+		t.Fatal(err)
+	}
+	var bad daemonResponse
+	if err := c.dec.Decode(&bad); err != nil || !strings.HasPrefix(bad.Error, "invalid request") {
+		t.Errorf("response of an invalid request = %+v, %v", bad, err)
+	}
+
+	c, err = dialDaemon(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.call(&daemonRequest{Cmd: "stop"}); err != nil {
+		t.Fatal(err)
+	}
+	<-stopped
+}
+
+func TestDaemonErrorCode(t *testing.T) {
+	for code, err := range daemonErrors {
+		t.Run(code, func(t *testing.T) {
+			if got := errorCode(fmt.Errorf("wrapped: %w", err)); got != code {
+				t.Errorf("errorCode() = %q, want %q", got, code)
+			}
+			// the client gets back the same error
+			if de := (&daemonError{code: code, msg: err.Error()}); !errors.Is(de, err) {
+				t.Errorf("daemonError of %q is not %v", code, err)
+			}
+		})
+	}
+
+	if got := errorCode(errors.New("other")); got != "" {
+		t.Errorf("errorCode() = %q, want none", got)
+	}
+}
+
+func TestListenSocket(t *testing.T) {
+	path, _ := startDaemon(t, uonumtest.New(1))
+	if l, err := listenSocket(path); err == nil {
+		l.Close()
+		t.Error("listenSocket() of a running daemon = nil error")
+	}
+
+	// a socket left by a daemon which is not running
+	stale := filepath.Join(t.TempDir(), "sub", "uonum.sock")
+	l, err := listenSocket(stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatal(err)
+	}
+	l, err = listenSocket(stale)
+	if err != nil {
+		t.Fatalf("listenSocket() of a stale socket error = %v", err)
+	}
+	defer l.Close()
+	if fi, err := os.Stat(stale); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %v, %v, want 0600", fi.Mode().Perm(), err)
+	}
+}
+
+func TestCtlRegister(t *testing.T) {
+	g := uonumtest.New(1)
+	path, _ := startDaemon(t, g)
+	c, err := dialDaemon(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var b strings.Builder
+	for i := range daemonBatch + 2 {
+		fmt.Fprintf(&b, "猫 %d 匹 。\n\n", i)
+	}
+	name := filepath.Join(t.TempDir(), "texts.txt")
+	if err := os.WriteFile(name, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, err := ctlRegister(c, []string{name}, "test", "alice"); code != 0 || err != nil {
+		t.Fatalf("ctlRegister() = %d, %v", code, err)
+	}
+	st, err := g.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Texts != daemonBatch+2 {
+		t.Errorf("texts = %d, want %d", st.Texts, daemonBatch+2)
+	}
+
+	if code, err := ctlRegister(c, []string{filepath.Join(t.TempDir(), "missing.txt")}, "", ""); code != 1 || err == nil {
+		t.Errorf("ctlRegister() of a missing file = %d, %v", code, err)
+	}
+}
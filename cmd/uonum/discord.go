@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kechako/uonum"
+)
+
+// the gateway intents
+const discordIntents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages |
+	discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent
+
+func discord(fs *flag.FlagSet) runner {
+	conversationFlags(fs)
+	token := fs.String("token", "", "Token of the bot.")
+	channels := fs.String("channels", "", "Comma separated IDs of the channels learned from.")
+	channelNS := fs.String("channel-ns", "", "Comma separated namespaces of the channels (e.g. \"<channel ID>=<namespace>\"), learned from as well.")
+	learn := fs.Bool("learn", true, "Register the messages in the channels.")
+	command := fs.String("command", "uonum", "Name of the slash command generating a sentence (none if empty).")
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		if *token == "" {
+			return 2, errors.New("Token is required.")
+		}
+		nss, err := parseChannelNS(*channelNS)
+		if err != nil {
+			return 2, err
+		}
+		learned := make(map[string]bool)
+		for _, id := range splitList(*channels) {
+			learned[id] = true
+		}
+		for id := range nss {
+			learned[id] = true
+		}
+
+		g, err := openGenerator(termWordsOption(*tw)...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		s, err := discordgo.New("Bot " + *token)
+		if err != nil {
+			return 1, err
+		}
+		s.Identify.Intents = discordIntents
+
+		b := &discordBot{
+			g:       g,
+			nss:     nss,
+			learned: learned,
+			learn:   *learn,
+			command: *command,
+			log:     logger.With("bot", "discord"),
+		}
+		// the handlers are called in their own goroutines, which take b.mu
+		// to use g, and the session reconnects by itself
+		s.AddHandler(b.ready)
+		s.AddHandler(b.message)
+		s.AddHandler(b.interaction)
+
+		if err := s.Open(); err != nil {
+			return 1, fmt.Errorf("could not connect to Discord: %w", err)
+		}
+		defer s.Close()
+
+		<-ctx.Done()
+		return 0, nil
+	}
+}
+
+// parseChannelNS parses the namespaces of the channels in the form of
+// "<channel ID>=<namespace>,...".
+func parseChannelNS(s string) (map[string]string, error) {
+	nss := make(map[string]string)
+	for _, item := range splitList(s) {
+		i := strings.Index(item, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("Invalid namespace of a channel [%s].", item)
+		}
+		nss[strings.TrimSpace(item[:i])] = strings.TrimSpace(item[i+1:])
+	}
+
+	return nss, nil
+}
+
+// discordBot learns from and replies in the Discord channels.
+type discordBot struct {
+	// mu serializes the calls of g
+	mu      sync.Mutex
+	g       uonum.Generator
+	nss     map[string]string
+	learned map[string]bool
+	learn   bool
+	command string
+
+	log *slog.Logger
+
+	registered atomic.Bool
+}
+
+// in returns the generator of the namespace of the channel.
+func (b *discordBot) in(channel string) uonum.Generator {
+	if n, ok := b.nss[channel]; ok {
+		return b.g.In(n)
+	}
+
+	return b.g.In(ns)
+}
+
+// ready registers the slash command when the session is ready for the first
+// time.
+func (b *discordBot) ready(s *discordgo.Session, r *discordgo.Ready) {
+	if b.command == "" || r.Application == nil || !b.registered.CompareAndSwap(false, true) {
+		return
+	}
+
+	_, err := s.ApplicationCommandCreate(r.Application.ID, "", &discordgo.ApplicationCommand{
+		Name:        b.command,
+		Description: "Generate a sentence.",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "trigger",
+				Description: "Trigger word.",
+			},
+		},
+	})
+	if err != nil {
+		b.registered.Store(false)
+		b.log.Error("Could not register the command.", "err", err)
+	}
+}
+
+// discordMarkup matches the mentions of the users, the roles and the
+// channels, and the custom emojis.
+var discordMarkup = regexp.MustCompile(`<(@[!&]?|#|a?:\w+:)\d+>`)
+
+// message replies to the message if it mentions the bot, and registers it if
+// it is in a channel learned from.
+func (b *discordBot) message(s *discordgo.Session, m *discordgo.MessageCreate) {
+	me := s.State.User
+	if m.Author == nil || m.Author.Bot || (me != nil && m.Author.ID == me.ID) {
+		return
+	}
+	text := strings.TrimSpace(discordMarkup.ReplaceAllString(m.Content, ""))
+
+	for _, u := range m.Mentions {
+		if me != nil && u.ID == me.ID {
+			b.reply(s, m, text)
+			break
+		}
+	}
+
+	if !b.learn || !b.learned[m.ChannelID] || text == "" {
+		return
+	}
+	b.mu.Lock()
+	err := b.in(m.ChannelID).RegisterWithMeta(text, uonum.Meta{Source: "discord", Author: m.Author.Username})
+	b.mu.Unlock()
+	if err != nil {
+		b.log.Error("Could not register the message.", "err", err)
+		return
+	}
+	b.log.Info("Registered.", "text", text)
+}
+
+// reply replies to the message with a generated reply to text.
+func (b *discordBot) reply(s *discordgo.Session, m *discordgo.MessageCreate, text string) {
+	b.mu.Lock()
+	reply, err := b.in(m.ChannelID).Reply(text)
+	b.mu.Unlock()
+	if err != nil {
+		b.log.Error("Could not generate a reply.", "err", err)
+		return
+	}
+	if reply == "" {
+		return
+	}
+
+	_, err = s.ChannelMessageSendReply(m.ChannelID, reply, m.Reference())
+	if err != nil {
+		b.log.Error("Could not send the reply.", "err", err)
+	}
+}
+
+// interaction responds to the slash command with a generated sentence.
+func (b *discordBot) interaction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	cmd := i.ApplicationCommandData()
+	if cmd.Name != b.command {
+		return
+	}
+
+	var trigger string
+	for _, o := range cmd.Options {
+		if o.Name == "trigger" {
+			trigger = o.StringValue()
+		}
+	}
+
+	data := new(discordgo.InteractionResponseData)
+	b.mu.Lock()
+	text, err := generateRandom(b.in(i.ChannelID), trigger)
+	b.mu.Unlock()
+	if err != nil {
+		data.Content = err.Error()
+		data.Flags = discordgo.MessageFlagsEphemeral
+	} else {
+		data.Content = text
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		b.log.Error("Could not respond to the interaction.", "err", err)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kechako/uonum/uonumtest"
+)
+
+func TestParseChannelNS(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    map[string]string
+		wantErr bool
+	}{
+		{s: "", want: map[string]string{}},
+		{s: "1=cats, 2 = dogs", want: map[string]string{"1": "cats", "2": "dogs"}},
+		{s: "1=", want: map[string]string{"1": ""}},
+		{s: "1", wantErr: true},
+		{s: "=cats", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := parseChannelNS(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseChannelNS() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseChannelNS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscordMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *discordgo.Message
+		// want are the numbers of the texts registered in the namespaces
+		want map[string]int
+	}{
+		{
+			name: "learned",
+			m:    &discordgo.Message{ChannelID: "1", Content: "猫 が 鳴く 。", Author: &discordgo.User{ID: "2"}},
+			want: map[string]int{"": 1},
+		},
+		{
+			name: "namespace of the channel",
+			m:    &discordgo.Message{ChannelID: "3", Content: "猫 が <:cat:123> 鳴く 。 <@!456>", Author: &discordgo.User{ID: "2"}},
+			want: map[string]int{"cats": 1},
+		},
+		{
+			name: "not learned",
+			m:    &discordgo.Message{ChannelID: "4", Content: "猫 が 鳴く 。", Author: &discordgo.User{ID: "2"}},
+		},
+		{
+			name: "bot",
+			m:    &discordgo.Message{ChannelID: "1", Content: "猫 が 鳴く 。", Author: &discordgo.User{ID: "2", Bot: true}},
+		},
+		{
+			name: "itself",
+			m:    &discordgo.Message{ChannelID: "1", Content: "猫 が 鳴く 。", Author: &discordgo.User{ID: "9"}},
+		},
+		{
+			name: "markup only",
+			m:    &discordgo.Message{ChannelID: "1", Content: "<@123> <#456>", Author: &discordgo.User{ID: "2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := uonumtest.New(1)
+			b := &discordBot{
+				g:       g,
+				nss:     map[string]string{"3": "cats"},
+				learned: map[string]bool{"1": true, "3": true},
+				learn:   true,
+				log:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			s := &discordgo.Session{State: discordgo.NewState()}
+			s.State.User = &discordgo.User{ID: "9"}
+			b.message(s, &discordgo.MessageCreate{Message: tt.m})
+
+			texts := make(map[string]int)
+			for _, n := range []string{"", "cats"} {
+				st, err := g.In(n).Stats()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if st.Texts > 0 {
+					texts[n] = st.Texts
+				}
+			}
+			if len(texts) != len(tt.want) || (len(texts) > 0 && !reflect.DeepEqual(texts, tt.want)) {
+				t.Errorf("texts = %v, want %v", texts, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// maxFeedSeen is the number of the latest entries of a feed remembered to
+// skip them.
+const maxFeedSeen = 1000
+
+// feedState is a feed added, and the entries registered from it.
+type feedState struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	// Seen is the IDs of the registered entries, oldest first
+	Seen         []string  `json:"seen,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Checked      time.Time `json:"checked,omitempty"`
+}
+
+func (f *feedState) seen(id string) bool {
+	for _, s := range f.Seen {
+		if s == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *feedState) see(id string) {
+	f.Seen = append(f.Seen, id)
+	if len(f.Seen) > maxFeedSeen {
+		f.Seen = f.Seen[len(f.Seen)-maxFeedSeen:]
+	}
+}
+
+func defaultFeedsPath() string {
+	return filepath.Join(dataDir(), "feeds.json")
+}
+
+func loadFeeds(path string) ([]*feedState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the feeds [%s]: %w", path, err)
+	}
+
+	var feeds []*feedState
+	if err := json.Unmarshal(data, &feeds); err != nil {
+		return nil, fmt.Errorf("[%s] JSON unmarshal error: %w", path, err)
+	}
+
+	return feeds, nil
+}
+
+func saveFeeds(path string, feeds []*feedState) error {
+	data, err := json.MarshalIndent(feeds, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("could not create the directory of the feeds: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("could not write the feeds [%s]: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("could not write the feeds [%s]: %w", path, err)
+	}
+
+	return nil
+}
+
+func feed(fs *flag.FlagSet) runner {
+	learnFlags(fs)
+	path := fs.String("feeds", defaultFeedsPath(), "File of the added feeds and their registered entries.")
+	interval := fs.Duration("interval", 0, "Poll the feeds at this interval (once if 0).")
+	titles := fs.Bool("titles", true, "Register the titles of the entries.")
+	summaries := fs.Bool("summaries", true, "Register the summaries of the entries.")
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		if len(args) == 0 {
+			return 2, errors.New("Subcommand is required (add, remove, list or poll).")
+		}
+
+		feeds, err := loadFeeds(*path)
+		if err != nil {
+			return 1, err
+		}
+
+		switch args[0] {
+		case "add":
+			if len(args) < 2 {
+				return 2, errors.New("URL of the feed is required.")
+			}
+			for _, u := range args[1:] {
+				if findFeed(feeds, u) >= 0 {
+					fmt.Fprintf(os.Stderr, "[%s] already added.\n", u)
+					continue
+				}
+				feeds = append(feeds, &feedState{URL: u})
+			}
+			return saveFeedsCode(*path, feeds)
+		case "remove":
+			if len(args) < 2 {
+				return 2, errors.New("URL of the feed is required.")
+			}
+			for _, u := range args[1:] {
+				i := findFeed(feeds, u)
+				if i < 0 {
+					return 1, fmt.Errorf("Feed [%s] is not added.", u)
+				}
+				feeds = append(feeds[:i], feeds[i+1:]...)
+			}
+			return saveFeedsCode(*path, feeds)
+		case "list":
+			for _, f := range feeds {
+				checked := "never"
+				if !f.Checked.IsZero() {
+					checked = f.Checked.Local().Format(time.RFC3339)
+				}
+				fmt.Printf("%s\t%s\t%s\n", f.URL, f.Title, checked)
+			}
+			return 0, nil
+		case "poll":
+		default:
+			return 2, fmt.Errorf("Unknown subcommand [%s].", args[0])
+		}
+
+		if len(feeds) == 0 {
+			return 1, errors.New("No feeds are added.")
+		}
+
+		g, err := openGenerator(termWordsOption(*tw)...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		p := &feedPoller{g: g.In(ns), titles: *titles, summaries: *summaries}
+		for {
+			for _, f := range feeds {
+				n, err := p.poll(ctx, f)
+				if ctx.Err() != nil {
+					return 0, nil
+				}
+				if err != nil {
+					logger.Error("Could not poll the feed.", "feed", f.URL, "err", err)
+					continue
+				}
+				level := slog.LevelInfo
+				if n == 0 {
+					level = slog.LevelDebug
+				}
+				logger.Log(ctx, level, "Polled the feed.", "feed", f.URL, "entries", n)
+			}
+			if err := saveFeeds(*path, feeds); err != nil {
+				return 1, err
+			}
+
+			if *interval <= 0 {
+				return 0, nil
+			}
+			select {
+			case <-ctx.Done():
+				return 0, nil
+			case <-time.After(*interval):
+			}
+		}
+	}
+}
+
+func findFeed(feeds []*feedState, u string) int {
+	for i, f := range feeds {
+		if f.URL == u {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func saveFeedsCode(path string, feeds []*feedState) (int, error) {
+	if err := saveFeeds(path, feeds); err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+// feedPoller registers the new entries of the feeds.
+type feedPoller struct {
+	g         uonum.Generator
+	titles    bool
+	summaries bool
+}
+
+// poll fetches the feed and registers its new entries, and returns the
+// number of them.
+func (p *feedPoller) poll(ctx context.Context, f *feedState) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("invalid URL [%s]: %w", f.URL, err)
+	}
+	req.Header.Set("User-Agent", "uonum")
+	if f.ETag != "" {
+		req.Header.Set("If-None-Match", f.ETag)
+	}
+	if f.LastModified != "" {
+		req.Header.Set("If-Modified-Since", f.LastModified)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("could not fetch the feed [%s]: %w", f.URL, err)
+	}
+	defer res.Body.Close()
+
+	f.Checked = time.Now()
+	if res.StatusCode == http.StatusNotModified {
+		return 0, nil
+	}
+	if res.StatusCode/100 != 2 {
+		return 0, fmt.Errorf("Could not fetch the feed [%s]: %s.", f.URL, res.Status)
+	}
+
+	title, entries, err := parseFeed(res.Body)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse the feed [%s]: %w", f.URL, err)
+	}
+	f.ETag = res.Header.Get("ETag")
+	f.LastModified = res.Header.Get("Last-Modified")
+	if title != "" {
+		f.Title = title
+	}
+
+	var n int
+	// the entries are newest first
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if f.seen(e.id) {
+			continue
+		}
+
+		meta := uonum.Meta{Source: "feed", Author: e.author, Time: e.time}
+		if e.link != "" {
+			meta.Fields = map[string]string{"url": e.link}
+		}
+		if meta.Author == "" {
+			meta.Author = f.Title
+		}
+		if p.titles && e.title != "" {
+			if err := p.g.RegisterWithMeta(e.title, meta); err != nil {
+				return n, err
+			}
+		}
+		if p.summaries && e.summary != "" {
+			if err := p.register(e.summary, meta); err != nil {
+				return n, err
+			}
+		}
+		f.see(e.id)
+		n++
+	}
+
+	return n, nil
+}
+
+// register registers the lines of text.
+func (p *feedPoller) register(text string, meta uonum.Meta) error {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if err := p.g.RegisterWithMeta(line, meta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// feedEntry is an item of RSS or an entry of Atom.
+type feedEntry struct {
+	id      string
+	title   string
+	summary string
+	link    string
+	author  string
+	time    time.Time
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	GUID        string `xml:"guid"`
+	Link        string `xml:"link"`
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Author      string `xml:"author"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Links     []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Author struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+// feedDoc is a document of RSS 2.0, RSS 1.0 (RDF) or Atom.
+type feedDoc struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	// the items of RSS 1.0 are out of the channel
+	Items   []rssItem   `xml:"item"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// parseFeed returns the title and the entries of an RSS or Atom feed.
+func parseFeed(r io.Reader) (string, []feedEntry, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.CharsetReader = func(charset string, r io.Reader) (io.Reader, error) {
+		return uonum.DecodeReader(r, charset)
+	}
+
+	var doc feedDoc
+	if err := d.Decode(&doc); err != nil {
+		return "", nil, err
+	}
+
+	var entries []feedEntry
+	for _, it := range append(doc.Channel.Items, doc.Items...) {
+		e := feedEntry{
+			id:      firstNonEmpty(it.GUID, it.About, it.Link, it.Title),
+			title:   htmlText(it.Title),
+			summary: htmlText(it.Description),
+			link:    strings.TrimSpace(it.Link),
+			author:  strings.TrimSpace(firstNonEmpty(it.Creator, it.Author)),
+			time:    parseFeedTime(firstNonEmpty(it.PubDate, it.Date)),
+		}
+		entries = append(entries, e)
+	}
+	for _, it := range doc.Entries {
+		e := feedEntry{
+			title:   htmlText(it.Title),
+			summary: htmlText(firstNonEmpty(it.Summary, it.Content)),
+			author:  strings.TrimSpace(it.Author.Name),
+			time:    parseFeedTime(firstNonEmpty(it.Published, it.Updated)),
+		}
+		for _, l := range it.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				e.link = l.Href
+				break
+			}
+		}
+		e.id = firstNonEmpty(it.ID, e.link, it.Title)
+		entries = append(entries, e)
+	}
+
+	title := doc.Channel.Title
+	if title == "" {
+		title = doc.Title
+	}
+
+	return htmlText(title), entries, nil
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+
+	return ""
+}
+
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+func parseFeedTime(s string) time.Time {
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kechako/uonum"
+	"github.com/kechako/uonum/uonumtest"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+<title>猫の日記</title>
+<item>
+<title>猫が鳴く。</title>
+<description>&lt;p&gt;猫が鳴く。&lt;/p&gt;&lt;p&gt;犬が吠える。&lt;/p&gt;</description>
+<guid>2</guid>
+<link>https://example.com/2</link>
+<pubDate>Tue, 02 Jan 2024 15:04:05 +0900</pubDate>
+<dc:creator>たま</dc:creator>
+</item>
+<item>
+<title>魚を食べる。</title>
+<link>https://example.com/1</link>
+<pubDate>Mon, 1 Jan 2024 15:04:05 +0900</pubDate>
+</item>
+</channel>
+</rss>`
+
+const testRDF = `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel><title>犬の日記</title></channel>
+<item rdf:about="https://example.com/a">
+<title>犬が吠える。</title>
+<dc:date>2024-01-02T15:04:05+09:00</dc:date>
+</item>
+</rdf:RDF>`
+
+const testAtom = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<title>鳥の日記</title>
+<entry>
+<id>tag:example.com,2024:1</id>
+<title>鳥が飛ぶ。</title>
+<content>空を飛ぶ。</content>
+<link rel="edit" href="https://example.com/edit/1"/>
+<link href="https://example.com/1"/>
+<updated>2024-01-02T15:04:05Z</updated>
+<author><name>ぴよ</name></author>
+</entry>
+<entry>
+<title>鳥が鳴く。</title>
+<summary>朝に鳴く。</summary>
+<link rel="alternate" href="https://example.com/2"/>
+</entry>
+</feed>`
+
+func TestParseFeed(t *testing.T) {
+	jst := time.FixedZone("", 9*60*60)
+
+	tests := []struct {
+		name        string
+		feed        string
+		wantTitle   string
+		wantEntries []feedEntry
+		wantErr     bool
+	}{
+		{
+			name:      "rss",
+			feed:      testRSS,
+			wantTitle: "猫の日記",
+			wantEntries: []feedEntry{
+				{
+					id:      "2",
+					title:   "猫が鳴く。",
+					summary: "猫が鳴く。\n犬が吠える。",
+					link:    "https://example.com/2",
+					author:  "たま",
+					time:    time.Date(2024, 1, 2, 15, 4, 5, 0, jst),
+				},
+				{
+					id:    "https://example.com/1",
+					title: "魚を食べる。",
+					link:  "https://example.com/1",
+					time:  time.Date(2024, 1, 1, 15, 4, 5, 0, jst),
+				},
+			},
+		},
+		{
+			name:      "rdf",
+			feed:      testRDF,
+			wantTitle: "犬の日記",
+			wantEntries: []feedEntry{
+				{
+					id:    "https://example.com/a",
+					title: "犬が吠える。",
+					time:  time.Date(2024, 1, 2, 15, 4, 5, 0, jst),
+				},
+			},
+		},
+		{
+			name:      "atom",
+			feed:      testAtom,
+			wantTitle: "鳥の日記",
+			wantEntries: []feedEntry{
+				{
+					id:      "tag:example.com,2024:1",
+					title:   "鳥が飛ぶ。",
+					summary: "空を飛ぶ。",
+					link:    "https://example.com/1",
+					author:  "ぴよ",
+					time:    time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+				},
+				{
+					id:      "https://example.com/2",
+					title:   "鳥が鳴く。",
+					summary: "朝に鳴く。",
+					link:    "https://example.com/2",
+				},
+			},
+		},
+		{name: "empty", feed: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, entries, err := parseFeed(strings.NewReader(tt.feed))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFeed() error = %v, want error %v", err, tt.wantErr)
+			}
+			if title != tt.wantTitle {
+				t.Errorf("parseFeed() title = %q, want %q", title, tt.wantTitle)
+			}
+			if len(entries) != len(tt.wantEntries) {
+				t.Fatalf("parseFeed() entries = %+v, want %+v", entries, tt.wantEntries)
+			}
+			for i, e := range entries {
+				want := tt.wantEntries[i]
+				if !e.time.Equal(want.time) {
+					t.Errorf("entry %d time = %v, want %v", i, e.time, want.time)
+				}
+				e.time, want.time = time.Time{}, time.Time{}
+				if e != want {
+					t.Errorf("entry %d = %+v, want %+v", i, e, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFeedTime(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{s: "Tue, 02 Jan 2024 15:04:05 +0900", want: time.Date(2024, 1, 2, 6, 4, 5, 0, time.UTC)},
+		{s: "Tue, 2 Jan 2024 15:04:05 +0900", want: time.Date(2024, 1, 2, 6, 4, 5, 0, time.UTC)},
+		{s: "Tue, 02 Jan 2024 06:04:05 GMT", want: time.Date(2024, 1, 2, 6, 4, 5, 0, time.UTC)},
+		{s: "2 Jan 2024 15:04:05 +0900", want: time.Date(2024, 1, 2, 6, 4, 5, 0, time.UTC)},
+		{s: "2024-01-02T15:04:05+09:00", want: time.Date(2024, 1, 2, 6, 4, 5, 0, time.UTC)},
+		{s: "2024/01/02"},
+		{s: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := parseFeedTime(tt.s); !got.Equal(tt.want) {
+				t.Errorf("parseFeedTime(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedSeen(t *testing.T) {
+	var f feedState
+	for i := range maxFeedSeen + 2 {
+		f.see(strings.Repeat("x", i))
+	}
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "", want: false},
+		{id: "x", want: false},
+		{id: "xx", want: true},
+		{id: strings.Repeat("x", maxFeedSeen+1), want: true},
+		{id: "y", want: false},
+	}
+
+	if len(f.Seen) != maxFeedSeen {
+		t.Errorf("len(Seen) = %d, want %d", len(f.Seen), maxFeedSeen)
+	}
+	for _, tt := range tests {
+		if got := f.seen(tt.id); got != tt.want {
+			t.Errorf("seen(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestSaveFeeds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "feeds.json")
+
+	feeds, err := loadFeeds(path)
+	if err != nil || feeds != nil {
+		t.Fatalf("loadFeeds() = %v, %v, want nil", feeds, err)
+	}
+
+	want := []*feedState{
+		{URL: "https://example.com/a.xml", Title: "猫の日記", Seen: []string{"1", "2"}, ETag: `"abc"`},
+		{URL: "https://example.com/b.xml", Checked: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+	}
+	if err := saveFeeds(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadFeeds(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadFeeds() = %+v, want %+v", got, want)
+	}
+
+	if i := findFeed(got, "https://example.com/b.xml"); i != 1 {
+		t.Errorf("findFeed() = %d, want 1", i)
+	}
+	if i := findFeed(got, "https://example.com/c.xml"); i != -1 {
+		t.Errorf("findFeed() = %d, want -1", i)
+	}
+}
+
+// feedGenerator records the texts registered with their meta.
+type feedGenerator struct {
+	uonum.Generator
+	records []uonum.Record
+}
+
+func (g *feedGenerator) RegisterWithMeta(text string, meta uonum.Meta) error {
+	g.records = append(g.records, uonum.Record{Text: text, Meta: meta})
+	return nil
+}
+
+func TestFeedPoll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(testRSS))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name      string
+		titles    bool
+		summaries bool
+		want      []string
+		// wantAuthors is the authors of the texts, the title of the feed
+		// without the creator
+		wantAuthors []string
+	}{
+		{
+			name:        "titles",
+			titles:      true,
+			want:        []string{"魚を食べる。", "猫が鳴く。"},
+			wantAuthors: []string{"猫の日記", "たま"},
+		},
+		{
+			name:        "summaries",
+			summaries:   true,
+			want:        []string{"猫が鳴く。", "犬が吠える。"},
+			wantAuthors: []string{"たま", "たま"},
+		},
+		{
+			name:        "both",
+			titles:      true,
+			summaries:   true,
+			want:        []string{"魚を食べる。", "猫が鳴く。", "猫が鳴く。", "犬が吠える。"},
+			wantAuthors: []string{"猫の日記", "たま", "たま", "たま"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &feedGenerator{Generator: uonumtest.New(1)}
+			p := &feedPoller{g: g, titles: tt.titles, summaries: tt.summaries}
+			f := &feedState{URL: srv.URL}
+
+			n, err := p.poll(context.Background(), f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 2 {
+				t.Errorf("poll() = %d, want 2", n)
+			}
+			var texts, authors []string
+			for _, rec := range g.records {
+				texts = append(texts, rec.Text)
+				authors = append(authors, rec.Meta.Author)
+				if rec.Meta.Source != "feed" || rec.Meta.Fields["url"] == "" {
+					t.Errorf("meta = %+v", rec.Meta)
+				}
+			}
+			if !reflect.DeepEqual(texts, tt.want) {
+				t.Errorf("registered %q, want %q", texts, tt.want)
+			}
+			if !reflect.DeepEqual(authors, tt.wantAuthors) {
+				t.Errorf("authors = %q, want %q", authors, tt.wantAuthors)
+			}
+			if f.Title != "猫の日記" || f.ETag != `"v1"` || !reflect.DeepEqual(f.Seen, []string{"https://example.com/1", "2"}) {
+				t.Errorf("feed = %+v", f)
+			}
+
+			// not modified
+			n, err = p.poll(context.Background(), f)
+			if err != nil || n != 0 {
+				t.Errorf("poll() = %d, %v, want 0", n, err)
+			}
+
+			// all seen
+			f.ETag = ""
+			n, err = p.poll(context.Background(), f)
+			if err != nil || n != 0 {
+				t.Errorf("poll() = %d, %v, want 0", n, err)
+			}
+		})
+	}
+}
+
+func TestFeedPollError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			w.Write([]byte("<html"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, path := range []string{"/missing", "/invalid"} {
+		t.Run(path, func(t *testing.T) {
+			p := &feedPoller{g: uonumtest.New(1), titles: true}
+			if n, err := p.poll(context.Background(), &feedState{URL: srv.URL + path}); err == nil {
+				t.Errorf("poll() = %d, nil, want error", n)
+			}
+		})
+	}
+}
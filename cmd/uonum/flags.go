@@ -0,0 +1,65 @@
+package main
+
+import "flag"
+
+// The flags of the model are defined by the commands which read them: the
+// learning flags by the commands registering texts, and the speaking flags
+// by those generating sentences. They set the variables read by
+// generatorOptions, and are left to their defaults by the other commands.
+
+// learnFlags defines the flags of the registration of texts and of a new
+// database in fs.
+func learnFlags(fs *flag.FlagSet) {
+	textFlags(fs)
+	fs.StringVar(&filter, "filter", "", "Comma separated filters removing parts of registered texts (url, mention, hashtag, emoji or all).")
+	fs.StringVar(&stopWords, "stop-words", "", "File of words left out of the links of registered texts, one per line (run rebuild after changing it).")
+	fs.StringVar(&stopClass, "stop-classes", "", "Comma separated word classes left out of the links of registered texts (e.g. 記号,助詞/格助詞).")
+	fs.StringVar(&mode, "mode", "", "Mode of a new database: word, char for a chain of characters, or whitespace for languages written with spaces.")
+	fs.StringVar(&lang, "lang", "", "Language of a new database: ja (word mode) or en (whitespace mode).")
+	fs.BoolVar(&lemma, "lemma", false, "Key the words of a new database on their base forms, so that the inflections of a word make one word, and generate the surfaces seen with the next words.")
+	fs.IntVar(&compress, "compress", 0, "Compress the stored words of at least this many bytes of JSON (0 turns it off).")
+	fs.IntVar(&partSize, "part-size", 0, "Split the links of the stored words into parts of about this many links (0 turns it off).")
+}
+
+// speakFlags defines the flags of the generation of sentences in fs.
+func speakFlags(fs *flag.FlagSet) {
+	textFlags(fs)
+	fs.StringVar(&post, "post", "", "Comma separated post-processings of generated sentences (latin for the spaces around Latin words, particle, bracket or all).")
+	fs.BoolVar(&balance, "balance-brackets", false, "Prefer the next words closing the brackets and the quotes opened in generated sentences, and fix the ones left unbalanced.")
+	fs.StringVar(&safetyURL, "safety-url", "", "Moderation endpoint which generated sentences must pass: it is posted {\"text\": ...} and answers {\"flagged\": false} for the safe ones.")
+	fs.IntVar(&safetyTry, "safety-retries", 3, "Number of regenerations of a sentence rejected by -safety-url.")
+	fs.StringVar(&banned, "banned-words", "", "File of words never generated, one per line.")
+}
+
+// conversationFlags defines both the learning and the speaking flags, for
+// the commands which register texts and generate sentences.
+func conversationFlags(fs *flag.FlagSet) {
+	learnFlags(fs)
+	speakFlags(fs)
+}
+
+// textFlags defines the flags of the tokenization of the texts, which both
+// the learning and the speaking commands read.
+func textFlags(fs *flag.FlagSet) {
+	if fs.Lookup("normalize") != nil {
+		return
+	}
+
+	fs.StringVar(&normalize, "normalize", "", "Comma separated normalizations of texts and words (nfkc, width, lower, space or all).")
+	fs.BoolVar(&holders, "placeholders", false, "Register the numbers and the dates as <NUM> and <DATE>, and generate plausible values for them.")
+	fs.BoolVar(&emoji, "emoji", false, "Tokenize the emoji and the kaomoji as single words.")
+	fs.StringVar(&emojiFile, "emoji-patterns", "", "File of regular expressions of more emoji and kaomoji, one per line (implies -emoji).")
+}
+
+// sharedFlags are the names of the flags of the model, which are accepted in
+// the global section of the config file and as UONUM_<NAME> as well.
+var sharedFlags = func() map[string]bool {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	conversationFlags(fs)
+
+	names := make(map[string]bool)
+	fs.VisitAll(func(f *flag.Flag) {
+		names[f.Name] = true
+	})
+	return names
+}()
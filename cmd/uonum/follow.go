@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// follow registers the lines appended to the file name, like tail -f, until
+// ctx is done. The file is reopened when it is rotated or truncated, after
+// the rest of the rotated file is registered.
+func follow(ctx context.Context, g uonum.Learner, name string, meta uonum.Meta, poll time.Duration) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("could not open the input file [%s]: %w", name, err)
+	}
+	defer func() {
+		file.Close()
+	}()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("could not seek the input file [%s]: %w", name, err)
+	}
+	r := bufio.NewReader(file)
+	partial := ""
+
+	register := func(text string) error {
+		text = strings.TrimRight(text, "\r\n")
+		if text == "" {
+			return nil
+		}
+		err := g.RegisterWithMeta(text, meta)
+		if err != nil {
+			return err
+		}
+		logger.Info("Registered.", "text", text)
+		return nil
+	}
+
+	for {
+		var n int64
+		partial, n, err = readAppended(r, name, partial, register)
+		offset += n
+		if err != nil {
+			return err
+		}
+
+		err = g.Flush()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(poll):
+		}
+
+		fi, err := os.Stat(name)
+		if err != nil {
+			// rotated, and not created again yet
+			continue
+		}
+		cur, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("could not read the input file [%s]: %w", name, err)
+		}
+
+		switch {
+		case !os.SameFile(fi, cur):
+			nf, err := os.Open(name)
+			if err != nil {
+				continue
+			}
+			// the lines written to the old file before it was rotated, and
+			// the last one which will never be terminated
+			partial, _, err = readAppended(r, name, partial, register)
+			if err == nil {
+				err = register(partial)
+			}
+			file.Close()
+			file = nf
+			if err != nil {
+				return err
+			}
+		case fi.Size() < offset:
+			_, err = file.Seek(0, io.SeekStart)
+			if err != nil {
+				return fmt.Errorf("could not seek the input file [%s]: %w", name, err)
+			}
+		default:
+			continue
+		}
+		r.Reset(file)
+		offset = 0
+		partial = ""
+	}
+}
+
+// readAppended calls fn with each line read from r of the file name up to
+// EOF, the first one following partial, and returns the last line not
+// terminated yet and the number of bytes read.
+func readAppended(r *bufio.Reader, name, partial string, fn func(line string) error) (string, int64, error) {
+	var n int64
+	for {
+		line, err := r.ReadString('\n')
+		n += int64(len(line))
+		if errors.Is(err, io.EOF) {
+			return partial + line, n, nil
+		}
+		if err != nil {
+			return partial, n, fmt.Errorf("could not read the input file [%s]: %w", name, err)
+		}
+
+		err = fn(partial + line)
+		partial = ""
+		if err != nil {
+			return "", n, err
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// followLearner records the texts registered by follow, and signals flushed
+// on each Flush, which follow calls after reading up to the end of the file.
+type followLearner struct {
+	uonum.Learner
+	mu      sync.Mutex
+	texts   []string
+	flushed chan struct{}
+}
+
+func (l *followLearner) RegisterWithMeta(text string, meta uonum.Meta) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.texts = append(l.texts, text)
+	return nil
+}
+
+func (l *followLearner) Flush() error {
+	select {
+	case l.flushed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (l *followLearner) registered() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.texts...)
+}
+
+func TestFollow(t *testing.T) {
+	if logger == nil {
+		logger = newLogger()
+	}
+
+	tests := []struct {
+		name string
+		// write changes the followed file name after follow read it up to
+		// the end
+		write func(t *testing.T, name string)
+		want  []string
+	}{
+		{
+			name: "appended",
+			write: func(t *testing.T, name string) {
+				appendFile(t, name, "猫が鳴く。\n犬が鳴く。\n")
+			},
+			want: []string{"猫が鳴く。", "犬が鳴く。"},
+		},
+		{
+			name: "partial line",
+			write: func(t *testing.T, name string) {
+				appendFile(t, name, "猫が")
+				// follow reads up to the end of the line written so far
+				time.Sleep(250 * time.Millisecond)
+				appendFile(t, name, "鳴く。\r\n\n")
+			},
+			want: []string{"猫が鳴く。"},
+		},
+		{
+			name: "rotated",
+			write: func(t *testing.T, name string) {
+				// the lines written to the old file just before the rotation
+				appendFile(t, name, "猫が鳴く。\n犬が")
+				if err := os.Rename(name, name+".1"); err != nil {
+					t.Fatal(err)
+				}
+				appendFile(t, name, "鳥が鳴く。\n")
+			},
+			want: []string{"猫が鳴く。", "犬が", "鳥が鳴く。"},
+		},
+		{
+			name: "truncated",
+			write: func(t *testing.T, name string) {
+				if err := os.Truncate(name, 0); err != nil {
+					t.Fatal(err)
+				}
+				appendFile(t, name, "鳥が鳴く。\n")
+			},
+			want: []string{"鳥が鳴く。"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "input.txt")
+			if err := os.WriteFile(name, []byte("registered before\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			l := &followLearner{flushed: make(chan struct{}, 1)}
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- follow(ctx, l, name, uonum.Meta{}, 100*time.Millisecond)
+			}()
+			defer func() {
+				cancel()
+				if err := <-done; err != nil {
+					t.Error(err)
+				}
+			}()
+
+			<-l.flushed
+			tt.write(t, name)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for len(l.registered()) < len(tt.want) && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if got := l.registered(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("registered = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadAppended(t *testing.T) {
+	tests := []struct {
+		name        string
+		partial     string
+		input       string
+		want        []string
+		wantPartial string
+	}{
+		{name: "lines", input: "猫が鳴く。\n犬が鳴く。\n", want: []string{"猫が鳴く。\n", "犬が鳴く。\n"}},
+		{name: "partial", input: "猫が鳴く。\n犬が", want: []string{"猫が鳴く。\n"}, wantPartial: "犬が"},
+		{name: "following partial", partial: "猫が", input: "鳴く。\n犬が", want: []string{"猫が鳴く。\n"}, wantPartial: "犬が"},
+		{name: "still partial", partial: "猫が", input: "鳴く", wantPartial: "猫が鳴く"},
+		{name: "none", partial: "猫が", wantPartial: "猫が"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			partial, n, err := readAppended(bufio.NewReader(strings.NewReader(tt.input)), "input.txt", tt.partial, func(line string) error {
+				got = append(got, line)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || partial != tt.wantPartial {
+				t.Errorf("readAppended() = %q, %q, want %q, %q", got, partial, tt.want, tt.wantPartial)
+			}
+			if n != int64(len(tt.input)) {
+				t.Errorf("read %d bytes, want %d", n, len(tt.input))
+			}
+		})
+	}
+}
+
+func appendFile(t *testing.T, name, s string) {
+	t.Helper()
+
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
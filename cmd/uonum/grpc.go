@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/kechako/uonum/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer returns the gRPC server of the service of the tenants, over TLS
+// with tlsConfig if not nil.
+func (s *server) grpcServer(tlsConfig *tls.Config) *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryCall),
+		grpc.ChainStreamInterceptor(s.streamCall),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	gs := grpc.NewServer(opts...)
+	rpc.RegisterUonumServer(gs, &tenantService{s: s})
+	return gs
+}
+
+// unaryCall authenticates and counts the unary calls.
+func (s *server) unaryCall(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	res, err := handler(ctx, req)
+	s.metrics.grpc(info.FullMethod, start, err)
+	s.log.DebugContext(ctx, "Call.", "method", info.FullMethod, "code", status.Code(err), "duration", time.Since(start))
+
+	return res, err
+}
+
+// streamCall authenticates the streaming calls.
+func (s *server) streamCall(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context()); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := handler(srv, ss)
+	s.log.DebugContext(ss.Context(), "Call.", "method", info.FullMethod, "code", status.Code(err), "duration", time.Since(start))
+
+	return err
+}
+
+// authorize returns the status error of a call rejected by the guard of the
+// server.
+func (s *server) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var key string
+	if k, ok := strings.CutPrefix(mdValue(md, "authorization"), "Bearer "); ok {
+		key = strings.TrimSpace(k)
+	} else {
+		key = mdValue(md, "x-api-key")
+	}
+	var client string
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client); err == nil {
+			client = host
+		}
+	}
+	client = s.proxies.client(client, md.Get("x-forwarded-for"))
+
+	_, err := s.guard.check(key, client)
+	switch {
+	case errors.Is(err, errInvalidKey):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, errTooManyRequests):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return nil
+}
+
+// mdValue returns the first value of key in md, or "" if none.
+func mdValue(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+// tenantService serves the calls with the generator of the tenant in their
+// metadata.
+type tenantService struct {
+	rpc.UnimplementedUonumServer
+
+	s *server
+}
+
+// acquire returns the tenant of a call, which must be released.
+func (ts *tenantService) acquire(ctx context.Context) (*tenant, error) {
+	name := ""
+	if ts.s.tenantBy != "" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		name = mdValue(md, tenantHeader)
+	}
+	t, err := ts.s.tenants.acquire(name)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return t, nil
+}
+
+func (ts *tenantService) Register(ctx context.Context, req *rpc.RegisterRequest) (*rpc.RegisterResponse, error) {
+	t, err := ts.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer ts.s.tenants.release(t)
+
+	return rpc.NewServer(t.g, t.mu).Register(ctx, req)
+}
+
+func (ts *tenantService) Generate(ctx context.Context, req *rpc.GenerateRequest) (*rpc.GenerateResponse, error) {
+	t, err := ts.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer ts.s.tenants.release(t)
+
+	return rpc.NewServer(t.g, t.mu).Generate(ctx, req)
+}
+
+func (ts *tenantService) Stats(ctx context.Context, req *rpc.StatsRequest) (*rpc.StatsResponse, error) {
+	t, err := ts.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer ts.s.tenants.release(t)
+
+	return rpc.NewServer(t.g, t.mu).Stats(ctx, req)
+}
+
+func (ts *tenantService) Dump(req *rpc.DumpRequest, stream grpc.ServerStreamingServer[rpc.Word]) error {
+	t, err := ts.acquire(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer ts.s.tenants.release(t)
+
+	return rpc.NewServer(t.g, t.mu).Dump(req, stream)
+}
+
+// stopGRPC stops gs gracefully, or at once when ctx is done.
+func stopGRPC(ctx context.Context, gs *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		gs.Stop()
+	}
+}
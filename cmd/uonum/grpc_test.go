@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/kechako/uonum/rpc"
+	"github.com/kechako/uonum/uonumtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient serves the gRPC service of s, and returns its client.
+func newGRPCClient(t *testing.T, s *server) rpc.UonumClient {
+	t.Helper()
+
+	l := bufconn.Listen(1 << 20)
+	gs := s.grpcServer(nil)
+	go gs.Serve(l)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return rpc.NewUonumClient(conn)
+}
+
+func newGRPCServer(gd guard, tenantBy string) *server {
+	return &server{
+		tenants:  newTenantPool(uonumtest.New(1), "", 0, nil),
+		tenantBy: tenantBy,
+		metrics:  newMetrics(),
+		log:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		guard:    gd,
+	}
+}
+
+func TestGRPCAuthorize(t *testing.T) {
+	tests := []struct {
+		name  string
+		guard guard
+		// md are the pairs of the metadata of the calls
+		md []string
+		// want are the codes of the calls in order
+		want []codes.Code
+	}{
+		{name: "no keys", want: []codes.Code{codes.OK, codes.OK}},
+		{
+			name:  "bearer",
+			guard: guard{keys: []string{"secret"}},
+			md:    []string{"authorization", "Bearer secret"},
+			want:  []codes.Code{codes.OK},
+		},
+		{
+			name:  "x-api-key",
+			guard: guard{keys: []string{"other", "secret"}},
+			md:    []string{"x-api-key", "secret"},
+			want:  []codes.Code{codes.OK},
+		},
+		{
+			name:  "invalid key",
+			guard: guard{keys: []string{"secret"}},
+			md:    []string{"authorization", "Bearer wrong"},
+			want:  []codes.Code{codes.Unauthenticated},
+		},
+		{
+			name:  "no key",
+			guard: guard{keys: []string{"secret"}},
+			want:  []codes.Code{codes.Unauthenticated},
+		},
+		{
+			name:  "rate",
+			guard: guard{limiter: newRateLimiter(0.001, 2)},
+			want:  []codes.Code{codes.OK, codes.OK, codes.ResourceExhausted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newGRPCClient(t, newGRPCServer(tt.guard, ""))
+			ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(tt.md...))
+			for i, want := range tt.want {
+				_, err := c.Stats(ctx, &rpc.StatsRequest{})
+				if got := status.Code(err); got != want {
+					t.Errorf("call %d code = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGRPCTenant(t *testing.T) {
+	s := newGRPCServer(guard{}, "header")
+	c := newGRPCClient(t, s)
+
+	inTenant := func(name string) context.Context {
+		if name == "" {
+			return context.Background()
+		}
+		return metadata.AppendToOutgoingContext(context.Background(), "x-tenant", name)
+	}
+
+	if _, err := c.Register(inTenant("cats"), &rpc.RegisterRequest{Texts: []string{"猫 が 鳴く 。"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Register(inTenant(""), &rpc.RegisterRequest{Texts: []string{"犬 が 吠える 。", "鳥 が 飛ぶ 。"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tenant    string
+		wantTexts int64
+		wantCode  codes.Code
+	}{
+		{tenant: "cats", wantTexts: 1},
+		{tenant: "", wantTexts: 2},
+		{tenant: "dogs", wantTexts: 0},
+		{tenant: "../cats", wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tenant, func(t *testing.T) {
+			res, err := c.Stats(inTenant(tt.tenant), &rpc.StatsRequest{})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("Stats() code = %v, want %v", got, tt.wantCode)
+			}
+			if err == nil && res.Texts != tt.wantTexts {
+				t.Errorf("Stats() texts = %d, want %d", res.Texts, tt.wantTexts)
+			}
+		})
+	}
+
+	// the calls are counted in the metrics
+	if _, err := c.Generate(inTenant("cats"), &rpc.GenerateRequest{Trigger: "鳥"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Generate() error = %v, want %v", err, codes.NotFound)
+	}
+	if got := s.metrics.generations[[2]string{"grpc", "not_found"}]; got != 1 {
+		t.Errorf("generations = %d, want 1", got)
+	}
+	if got := s.metrics.registrations[[2]string{"grpc", "ok"}]; got != 2 {
+		t.Errorf("registrations = %d, want 2", got)
+	}
+}
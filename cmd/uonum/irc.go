@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kechako/uonum"
+)
+
+const (
+	// ircRetry is the wait before reconnecting to the server.
+	ircRetry = 30 * time.Second
+	// ircMaxText is the maximum size of a sent message in bytes, within the
+	// 512 bytes of a line.
+	ircMaxText = 400
+)
+
+// ircMessage is a line of the IRC protocol.
+type ircMessage struct {
+	// Nick is the nick of the prefix
+	Nick    string
+	Command string
+	Params  []string
+}
+
+// parseIRC parses a line of [":" prefix " "] command params [" :" trailing].
+func parseIRC(line string) ircMessage {
+	var m ircMessage
+	if strings.HasPrefix(line, ":") {
+		prefix, rest, _ := strings.Cut(line[1:], " ")
+		m.Nick, _, _ = strings.Cut(prefix, "!")
+		line = rest
+	}
+
+	var trailing string
+	hasTrailing := false
+	if i := strings.Index(line, " :"); i >= 0 {
+		trailing = line[i+2:]
+		hasTrailing = true
+		line = line[:i]
+	} else if strings.HasPrefix(line, ":") {
+		trailing = line[1:]
+		hasTrailing = true
+		line = ""
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) > 0 {
+		m.Command = strings.ToUpper(fields[0])
+		m.Params = fields[1:]
+	}
+	if hasTrailing {
+		m.Params = append(m.Params, trailing)
+	}
+
+	return m
+}
+
+// ircFormatting matches the color, bold, italic, underline and reset codes.
+var ircFormatting = regexp.MustCompile("\x03[0-9]{0,2}(,[0-9]{1,2})?|[\x02\x0f\x16\x1d\x1f]")
+
+func irc(fs *flag.FlagSet) runner {
+	conversationFlags(fs)
+	server := fs.String("server", "", "Address of the IRC server (host:port).")
+	useTLS := fs.Bool("tls", false, "Connect with TLS.")
+	password := fs.String("password", "", "Password of the server.")
+	channel := fs.String("channel", "", "Comma separated channels to join (e.g. \"#uonum\").")
+	nick := fs.String("nick", "uonum", "Nick of the bot.")
+	chance := fs.Float64("chance", 0.01, "Probability of speaking after a message which does not mention the nick.")
+	learn := fs.Bool("learn", true, "Register the messages in the channels.")
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		if *server == "" || *channel == "" {
+			return 2, errors.New("Server and channel are required.")
+		}
+
+		g, err := openGenerator(termWordsOption(*tw)...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		b := &ircBot{
+			g:        g.In(ns),
+			server:   *server,
+			tls:      *useTLS,
+			password: *password,
+			channels: splitList(*channel),
+			nick:     *nick,
+			chance:   *chance,
+			learn:    *learn,
+			log:      logger.With("bot", "irc"),
+		}
+		for {
+			err := b.connect(ctx)
+			if ctx.Err() != nil {
+				return 0, nil
+			}
+			if err != nil {
+				b.log.Warn("Disconnected.", "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return 0, nil
+			case <-time.After(ircRetry):
+			}
+		}
+	}
+}
+
+// ircBot learns from and speaks in the IRC channels.
+type ircBot struct {
+	g        uonum.Generator
+	server   string
+	tls      bool
+	password string
+	channels []string
+	nick     string
+	chance   float64
+	learn    bool
+	log      *slog.Logger
+
+	conn net.Conn
+}
+
+func (b *ircBot) send(format string, args ...interface{}) error {
+	_, err := fmt.Fprintf(b.conn, format+"\r\n", args...)
+	return err
+}
+
+// connect handles the messages of a connection until ctx is done or the
+// connection is lost.
+func (b *ircBot) connect(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", b.server)
+	if err != nil {
+		return fmt.Errorf("could not connect to [%s]: %w", b.server, err)
+	}
+	if b.tls {
+		host, _, _ := net.SplitHostPort(b.server)
+		conn = tls.Client(conn, &tls.Config{ServerName: host})
+	}
+	b.conn = conn
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		b.send("QUIT :bye")
+		conn.Close()
+	}()
+
+	if b.password != "" {
+		b.send("PASS %s", b.password)
+	}
+	nick := b.nick
+	b.send("NICK %s", nick)
+	b.send("USER %s 0 * :uonum Markov bot", b.nick)
+
+	s := bufio.NewScanner(conn)
+	for s.Scan() {
+		m := parseIRC(strings.TrimRight(s.Text(), "\r"))
+		switch m.Command {
+		case "PING":
+			err = b.send("PONG :%s", strings.Join(m.Params, " "))
+		case "001":
+			// welcome
+			if len(m.Params) > 0 {
+				nick = m.Params[0]
+			}
+			err = b.send("JOIN %s", strings.Join(b.channels, ","))
+		case "433":
+			// nick in use
+			nick += "_"
+			err = b.send("NICK %s", nick)
+		case "NICK":
+			if m.Nick == nick && len(m.Params) > 0 {
+				nick = m.Params[0]
+			}
+		case "PRIVMSG":
+			if len(m.Params) == 2 && m.Nick != nick {
+				err = b.privmsg(nick, &m)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("could not read from [%s]: %w", b.server, err)
+	}
+
+	return fmt.Errorf("Connection to [%s] closed.", b.server)
+}
+
+// privmsg registers the message to a channel, and replies if it mentions
+// nick or by chance.
+func (b *ircBot) privmsg(nick string, m *ircMessage) error {
+	target, text := m.Params[0], m.Params[1]
+	// CTCP such as ACTION
+	if strings.HasPrefix(text, "\x01") {
+		return nil
+	}
+	text = strings.TrimSpace(ircFormatting.ReplaceAllString(text, ""))
+	if text == "" {
+		return nil
+	}
+
+	private := !strings.ContainsAny(target[:1], "#&+!")
+	mentioned := private || strings.Contains(strings.ToLower(text), strings.ToLower(nick))
+	if mentioned {
+		text = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(strings.Replace(text, nick, "", 1)), ":,"))
+	} else if b.learn {
+		err := b.g.RegisterWithMeta(text, uonum.Meta{Source: "irc", Author: m.Nick, Fields: map[string]string{"channel": target}})
+		if err != nil {
+			b.log.Error("Could not register the message.", "err", err)
+		} else {
+			b.log.Info("Registered.", "text", text)
+		}
+	}
+
+	if !mentioned && rand.Float64() >= b.chance {
+		return nil
+	}
+
+	reply, err := b.g.Reply(text)
+	if err != nil {
+		b.log.Error("Could not generate a reply.", "err", err)
+		return nil
+	}
+	reply = ircLine(reply)
+	if reply == "" {
+		return nil
+	}
+
+	to := target
+	if private {
+		to = m.Nick
+	} else if mentioned {
+		reply = m.Nick + ": " + reply
+	}
+
+	return b.send("PRIVMSG %s :%s", to, reply)
+}
+
+// ircLine returns text in a line, truncated to ircMaxText bytes.
+func ircLine(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= ircMaxText {
+		return text
+	}
+
+	text = text[:ircMaxText]
+	for !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+
+	return text
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kechako/uonum/uonumtest"
+)
+
+func TestParseIRC(t *testing.T) {
+	tests := []struct {
+		line string
+		want ircMessage
+	}{
+		{line: "PING :server", want: ircMessage{Command: "PING", Params: []string{"server"}}},
+		{
+			line: ":alice!a@example.com PRIVMSG #cats :猫が鳴く。 :)",
+			want: ircMessage{Nick: "alice", Command: "PRIVMSG", Params: []string{"#cats", "猫が鳴く。 :)"}},
+		},
+		{line: ":server 001 uonum :Welcome", want: ircMessage{Nick: "server", Command: "001", Params: []string{"uonum", "Welcome"}}},
+		{line: ":alice NICK bob", want: ircMessage{Nick: "alice", Command: "NICK", Params: []string{"bob"}}},
+		{line: "join #cats", want: ircMessage{Command: "JOIN", Params: []string{"#cats"}}},
+		{line: "", want: ircMessage{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := parseIRC(tt.line)
+			if got.Nick != tt.want.Nick || got.Command != tt.want.Command || strings.Join(got.Params, "|") != strings.Join(tt.want.Params, "|") {
+				t.Errorf("parseIRC() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIRCLine(t *testing.T) {
+	long := strings.Repeat("猫", 200)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "spaces", text: " 猫が\n鳴く。  ", want: "猫が 鳴く。"},
+		// the text is cut at a rune boundary
+		{name: "long", text: long, want: strings.Repeat("猫", ircMaxText/3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ircLine(tt.text); got != tt.want {
+				t.Errorf("ircLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIRCBot(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// the lines sent by the server after each line received from the bot
+	script := []struct {
+		recv string
+		send []string
+	}{
+		{recv: "NICK uonum"},
+		{recv: "USER uonum 0 * :uonum Markov bot", send: []string{":server 433 * uonum :Nickname is already in use"}},
+		{recv: "NICK uonum_", send: []string{":server 001 uonum_ :Welcome"}},
+		{recv: "JOIN #cats", send: []string{"PING :server"}},
+		{
+			recv: "PONG :server",
+			send: []string{
+				":alice!a@example.com PRIVMSG #cats :猫 が 鳴く 。",
+				":alice!a@example.com PRIVMSG #cats :\x01ACTION 犬 が 鳴く 。\x01",
+				":alice!a@example.com PRIVMSG #cats :uonum_: 猫 は？",
+			},
+		},
+		{recv: "PRIVMSG #cats :alice: 猫 が 鳴く 。", send: []string{":alice!a@example.com PRIVMSG uonum_ :猫"}},
+		{recv: "PRIVMSG alice :猫 が 鳴く 。"},
+	}
+
+	var received []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := bufio.NewScanner(conn)
+		for _, step := range script {
+			if !s.Scan() {
+				return
+			}
+			received = append(received, strings.TrimRight(s.Text(), "\r"))
+			for _, line := range step.send {
+				io.WriteString(conn, line+"\r\n")
+			}
+		}
+	}()
+
+	g := uonumtest.New(1)
+	b := &ircBot{
+		g:        g,
+		server:   l.Addr().String(),
+		channels: []string{"#cats"},
+		nick:     "uonum",
+		learn:    true,
+		log:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	if err := b.connect(context.Background()); err == nil {
+		t.Error("connect() error = nil, want the closed connection")
+	}
+	<-done
+
+	var want []string
+	for _, step := range script {
+		want = append(want, step.recv)
+	}
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("received %q, want %q", received, want)
+	}
+	st, err := g.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Texts != 1 {
+		t.Errorf("registered %d messages, want 1", st.Texts)
+	}
+}
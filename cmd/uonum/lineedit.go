@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/text/width"
+)
+
+// errInterrupted is returned by readLine when the line is canceled by Ctrl-C.
+var errInterrupted = errors.New("Interrupted.")
+
+// lineEditor reads lines with the editing keys of readline (Ctrl-A, Ctrl-E,
+// the arrow keys, the history and so on) if the input is a terminal.
+type lineEditor struct {
+	in      *os.File
+	r       *bufio.Reader
+	out     io.Writer
+	history []string
+}
+
+func newLineEditor(in *os.File, out io.Writer, history []string) *lineEditor {
+	return &lineEditor{
+		in:      in,
+		r:       bufio.NewReader(in),
+		out:     out,
+		history: history,
+	}
+}
+
+// addHistory adds line to the history.
+func (e *lineEditor) addHistory(line string) {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+}
+
+// readLine reads a line after prompt. It returns io.EOF at the end of the
+// input or by Ctrl-D on an empty line.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(int(e.in.Fd()))
+	if err != nil {
+		// not a terminal
+		fmt.Fprint(e.out, prompt)
+		line, err := e.r.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+	defer restore()
+
+	var buf []rune
+	pos := 0
+	hist := len(e.history)
+	var saved []rune
+
+	refresh := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+		if w := textWidth(buf[pos:]); w > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", w)
+		}
+	}
+	recall := func(i int) {
+		if i < 0 || i > len(e.history) {
+			return
+		}
+		if hist == len(e.history) {
+			saved = buf
+		}
+		hist = i
+		if i == len(e.history) {
+			buf = saved
+		} else {
+			buf = []rune(e.history[i])
+		}
+		pos = len(buf)
+	}
+
+	refresh()
+	for {
+		r, _, err := e.r.ReadRune()
+		if err != nil {
+			fmt.Fprint(e.out, "\r\n")
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(buf), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case 127, 8: // Backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case 1: // Ctrl-A
+			pos = 0
+		case 5: // Ctrl-E
+			pos = len(buf)
+		case 2: // Ctrl-B
+			if pos > 0 {
+				pos--
+			}
+		case 6: // Ctrl-F
+			if pos < len(buf) {
+				pos++
+			}
+		case 11: // Ctrl-K
+			buf = buf[:pos]
+		case 21: // Ctrl-U
+			buf = append([]rune(nil), buf[pos:]...)
+			pos = 0
+		case 16: // Ctrl-P
+			recall(hist - 1)
+		case 14: // Ctrl-N
+			recall(hist + 1)
+		case 27: // escape sequences of the arrow keys and so on
+			b, _ := e.r.ReadByte()
+			if b != '[' && b != 'O' {
+				break
+			}
+			b, _ = e.r.ReadByte()
+			switch b {
+			case 'A':
+				recall(hist - 1)
+			case 'B':
+				recall(hist + 1)
+			case 'C':
+				if pos < len(buf) {
+					pos++
+				}
+			case 'D':
+				if pos > 0 {
+					pos--
+				}
+			case 'H':
+				pos = 0
+			case 'F':
+				pos = len(buf)
+			case '3': // Delete
+				if t, _ := e.r.ReadByte(); t == '~' && pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+		default:
+			if r < ' ' {
+				break
+			}
+			buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+			pos++
+		}
+		refresh()
+	}
+}
+
+// textWidth returns the width of rs in a terminal.
+func textWidth(rs []rune) int {
+	n := 0
+	for _, r := range rs {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+
+	return n
+}
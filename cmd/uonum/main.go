@@ -2,67 +2,96 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/kechako/uonum"
-	"github.com/pkg/errors"
 )
 
 var (
-	dbName  string
-	verbose bool
+	dbName     string
+	ns         string
+	normalize  string
+	filter     string
+	post       string
+	balance    bool
+	safetyURL  string
+	safetyTry  int
+	banned     string
+	stopWords  string
+	stopClass  string
+	dictName   string
+	userDict   string
+	mode       string
+	lang       string
+	lemma      bool
+	compress   int
+	partSize   int
+	holders    bool
+	emoji      bool
+	emojiFile  string
+	verbose    bool
+	debugLog   bool
+	jsonOutput bool
+
+	// logger writes the logs of the level by -v and -vv to stderr
+	logger *slog.Logger
 )
 
 func init() {
-	flag.StringVar(&dbName, "db", filepath.Join(getUserHome(), "nonum.db"), "Database path.")
-	flag.BoolVar(&verbose, "v", false, "Verbose messages.")
+	flag.StringVar(&dbName, "db", defaultDBName, "Database path, or Redis DSN (redis://host:port/db?prefix=name).")
+	flag.StringVar(&ns, "ns", "", "Namespace of the model in the database.")
+	flag.StringVar(&dictName, "dict", "ipa", "Dictionary of the tokenizer: ipa, uni, or the path of a kagome dictionary file (e.g. ipa-neologd).")
+	flag.StringVar(&userDict, "user-dict", "", "User dictionary file of custom words.")
+	flag.BoolVar(&verbose, "v", false, "Verbose messages of the info level.")
+	flag.BoolVar(&debugLog, "vv", false, "Verbose messages of the debug level, including the tokenization, the writes and the choices of the words.")
+	flag.BoolVar(&jsonOutput, "json", false, "Print the results of generate, stats, triggers, tokenize and ctl, and the errors, as JSON to stdout.")
 
 	flag.Usage = func() {
-		fmt.Fprint(os.Stderr, `
-Usage:
-    uonum [options] register [input file]
-    uonum [options] generate [trigger word]
-    uonum [options] dump
-
-Options:
-`)
-		flag.PrintDefaults()
-		fmt.Fprintln(os.Stderr)
+		printUsage(os.Stderr)
 	}
 }
 
 type runner func(args []string) (int, error)
 
 func main() {
+	err := loadGlobalConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	flag.Parse()
+	logger = newLogger()
 	if flag.NArg() == 0 {
 		printHelp()
 	}
 
-	cmd := flag.Args()[0]
-	args := flag.Args()[1:]
-
-	var r runner
-
-	switch cmd {
-	case "register":
-		r = register
-	case "generate":
-		r = generate
-	case "dump":
-		r = dump
-	default:
+	c := findCommand(flag.Arg(0))
+	if c == nil {
 		printHelp()
 	}
 
-	if code, err := r(args); err != nil {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "%+v\n", err)
-		} else {
+	if code, err := c.run(flag.Args()[1:]); err != nil {
+		code = exitCode(code, err)
+		switch {
+		case jsonOutput:
+			printJSON(&jsonError{Error: err.Error(), Code: errorCode(err), Status: code})
+		default:
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 		}
 
@@ -70,95 +99,656 @@ func main() {
 	}
 }
 
+// jsonError is an error printed with -json.
+type jsonError struct {
+	Error string `json:"error"`
+	// Code is the kind of the error, as in the responses of the daemon
+	Code   string `json:"code,omitempty"`
+	Status int    `json:"status"` // exit status
+}
+
+// jsonSentence is a generated sentence printed with -json.
+type jsonSentence struct {
+	Trigger string `json:"trigger,omitempty"`
+	Text    string `json:"text"`
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// sentenceLine returns the line of a sentence generated from trigger, which
+// is a JSON object with -json.
+func sentenceLine(trigger, text string) string {
+	if !jsonOutput {
+		return text
+	}
+
+	line, err := json.Marshal(&jsonSentence{Trigger: trigger, Text: text})
+	if err != nil {
+		return text
+	}
+	return string(line)
+}
+
+// printStats prints the statistics of a model, as a JSON object with -json.
+func printStats(st *uonum.Stats) error {
+	if jsonOutput {
+		return printJSON(st)
+	}
+
+	_, err := fmt.Printf("words\t%d\nlinks\t%d\ncount\t%d\ntexts\t%d\n", st.Words, st.Links, st.Count, st.Texts)
+	return err
+}
+
+// newLogger returns the logger of the level by -v and -vv. Only the
+// warnings and the errors are written without them.
+func newLogger() *slog.Logger {
+	level := slog.LevelWarn
+	switch {
+	case debugLog:
+		level = slog.LevelDebug
+	case verbose:
+		level = slog.LevelInfo
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// exitCode returns the exit status for err returned by a runner with code.
+func exitCode(code int, err error) int {
+	if code != 1 {
+		return code
+	}
+
+	switch {
+	case errors.Is(err, uonum.ErrUnknownTrigger):
+		return 3
+	case errors.Is(err, uonum.ErrEmptyModel):
+		return 4
+	case errors.Is(err, uonum.ErrDecode):
+		return 5
+	case errors.Is(err, uonum.ErrGenerationFailed):
+		return 6
+	case errors.Is(err, uonum.ErrNotOpen):
+		return 7
+	case errors.Is(err, errOpenDB):
+		return 8
+	case isIO(err):
+		return 9
+	}
+
+	return code
+}
+
+var (
+	// errOpenDB classifies the errors opening a database
+	errOpenDB = errors.New("Could not open the database.")
+	// errIO classifies the errors reading and writing files
+	errIO = errors.New("I/O error.")
+)
+
+// classError is err classified as class, with the message of err.
+type classError struct {
+	class error
+	err   error
+}
+
+func (e *classError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classError) Unwrap() []error {
+	return []error{e.err, e.class}
+}
+
+// classify returns err classified as class for exitCode.
+func classify(class, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &classError{class: class, err: err}
+}
+
+// isIO reports whether err is an I/O error, including the errors of the file
+// operations.
+func isIO(err error) bool {
+	var pe *os.PathError
+	return errors.Is(err, errIO) || errors.As(err, &pe)
+}
+
+// loadGlobalConfig loads the config file and sets the global options in it
+// and in the environment variables.
+func loadGlobalConfig() error {
+	var err error
+	conf, err = loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	err = conf.apply(flag.CommandLine, "")
+	if err != nil {
+		return err
+	}
+
+	return applyEnv(flag.CommandLine, "")
+}
+
 func printHelp() {
 	flag.Usage()
 	os.Exit(1)
 }
 
-func getUserHome() string {
-	home := os.Getenv("HOME")
-	if home == "" {
-		home = os.Getenv("USERPROFILE")
+// openGenerator opens the database with the options common to the commands.
+func openGenerator(opts ...uonum.Option) (uonum.Generator, error) {
+	opts, err := generatorOptions(opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	return home
+	return openGeneratorAt(dbName, opts...)
 }
 
-func register(args []string) (int, error) {
-	g := uonum.New()
-	err := g.Open(dbName)
+// generatorOptions returns opts with the options common to the commands.
+func generatorOptions(opts ...uonum.Option) ([]uonum.Option, error) {
+	n, err := uonum.ParseNormalizers(normalize)
 	if err != nil {
-		return 1, err
+		return nil, err
+	}
+	f, err := uonum.ParseFilters(filter)
+	if err != nil {
+		return nil, err
+	}
+	p, err := uonum.ParsePostProcessors(post)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, uonum.WithNormalizers(n...), uonum.WithFilters(f...), uonum.WithPostProcessors(p...), uonum.WithLogger(logger))
+	// the IPA dictionary is loaded by the generator only when it is needed
+	if dictName != "" && dictName != "ipa" {
+		d, err := uonum.LoadDict(dictName)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, uonum.WithDict(d))
+	}
+	if userDict != "" {
+		opts = append(opts, uonum.WithUserDict(userDict))
+	}
+	switch lang {
+	case "":
+	case "ja":
+		opts = append(opts, uonum.WithMode(uonum.ModeWord))
+	case "en":
+		opts = append(opts, uonum.WithWhitespaceTokenizer())
+	default:
+		return nil, fmt.Errorf("Unknown language [%s].", lang)
+	}
+	switch m := uonum.Mode(mode); m {
+	case "":
+	case uonum.ModeWord, uonum.ModeChar, uonum.ModeWhitespace:
+		opts = append(opts, uonum.WithMode(m))
+	default:
+		return nil, fmt.Errorf("Unknown mode [%s].", mode)
 	}
-	defer g.Close()
 
-	var r io.Reader
-	if len(args) > 0 {
-		file, err := os.Open(args[0])
+	if lemma {
+		opts = append(opts, uonum.WithLemma())
+	}
+
+	if compress > 0 {
+		opts = append(opts, uonum.WithCompression(compress))
+	}
+	if partSize > 0 {
+		opts = append(opts, uonum.WithPartSize(partSize))
+	}
+	if holders {
+		opts = append(opts, uonum.WithPlaceholders())
+	}
+	if balance {
+		opts = append(opts, uonum.WithBracketBalance(0))
+	}
+	if safetyURL != "" {
+		client := &http.Client{Timeout: safetyTimeout}
+		opts = append(opts, uonum.WithSafetyFilter(uonum.ModerationFilter(safetyURL, client), safetyTry))
+	}
+	if emoji || emojiFile != "" {
+		var patterns []*regexp.Regexp
+		if emojiFile != "" {
+			exprs, err := readWordFile(emojiFile, "emoji patterns")
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range exprs {
+				p, err := regexp.Compile(e)
+				if err != nil {
+					return nil, fmt.Errorf("invalid emoji pattern [%s]: %w", e, err)
+				}
+				patterns = append(patterns, p)
+			}
+		}
+		opts = append(opts, uonum.WithEmoji(patterns...))
+	}
+
+	if banned != "" {
+		words, err := readWordFile(banned, "banned words")
 		if err != nil {
-			return 1, errors.Wrapf(err, "Could not open the input file [%s].", args[0])
+			return nil, err
 		}
-		defer file.Close()
-		r = file
-	} else {
-		r = os.Stdin
+		opts = append(opts, uonum.WithBannedWords(words))
 	}
 
-	s := bufio.NewScanner(r)
-	for s.Scan() {
-		err = g.Register(s.Text())
+	if stopWords != "" || stopClass != "" {
+		var words []string
+		if stopWords != "" {
+			words, err = readWordFile(stopWords, "stop words")
+			if err != nil {
+				return nil, err
+			}
+		}
+		var classes uonum.FeatureMatcher
+		if stopClass != "" {
+			classes = uonum.ParseClasses(stopClass)
+		}
+		opts = append(opts, uonum.WithStopWords(words, classes))
+	}
+
+	return opts, nil
+}
+
+// readWordFile reads the list of words in the file name, which is what
+// words.
+func readWordFile(name, what string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the %s file [%s]: %w", what, name, err)
+	}
+	defer file.Close()
+
+	words, err := uonum.ReadWordList(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the %s file [%s]: %w", what, name, err)
+	}
+
+	return words, nil
+}
+
+// openGeneratorAt opens the database name with opts.
+func openGeneratorAt(name string, opts ...uonum.Option) (uonum.Generator, error) {
+	err := prepareDB(name)
+	if err != nil {
+		return nil, classify(errOpenDB, err)
+	}
+
+	g := uonum.New(opts...)
+	err = g.Open(name)
+	if err != nil {
+		return nil, classify(errOpenDB, err)
+	}
+
+	return g, nil
+}
+
+// recordsFormat returns the function making the reader of the records in the
+// input format, or nil for the text format.
+func recordsFormat(format, column string, header bool, field string, fields bool) (func(io.Reader) uonum.RecordReader, error) {
+	switch format {
+	case "text", "":
+		return nil, nil
+	case "csv", "tsv":
+		f := uonum.CSVFormat{Header: header, Fields: fields}
+		if format == "tsv" {
+			f.Comma = '\t'
+		}
+		if n, err := strconv.Atoi(column); err == nil {
+			f.Column = n
+		} else if header {
+			f.Name = column
+		} else {
+			return nil, fmt.Errorf("Invalid column [%s]. A name needs -header.", column)
+		}
+		return func(r io.Reader) uonum.RecordReader {
+			return uonum.NewCSVReader(r, f)
+		}, nil
+	case "jsonl":
+		f := uonum.JSONLFormat{Field: field, Fields: fields}
+		return func(r io.Reader) uonum.RecordReader {
+			return uonum.NewJSONLReader(r, f)
+		}, nil
+	case "html":
+		return newHTMLRecords, nil
+	case "markdown", "md":
+		return newMarkdownRecords, nil
+	}
+
+	return nil, fmt.Errorf("Unknown format [%s].", format)
+}
+
+// safetyTimeout is the time limit of a request to -safety-url.
+const safetyTimeout = 10 * time.Second
+
+const backoffUsage = "Back off to the word frequencies with this weight (e.g. 0.4) where the links run out."
+
+const termWordsUsage = "Comma separated words which end sentences (e.g. \"。,．,！,？\"). They are saved in the database."
+
+// termWordsOption returns the option to set the term words in s,
+// or nothing to use the ones saved in the database.
+func termWordsOption(s string) []uonum.Option {
+	if s == "" {
+		return nil
+	}
+
+	var tw []string
+	for _, w := range strings.Split(s, ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			tw = append(tw, w)
+		}
+	}
+
+	return []uonum.Option{uonum.WithTermWords(tw)}
+}
+
+// parseSince parses an age like "30d" or "12h", or a date like "2024-01-31",
+// and returns the time since then.
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	age, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Now().Add(-age), nil
+}
+
+// parseAge parses a duration which may be in days like "30d".
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
 		if err != nil {
-			return 1, err
+			return 0, fmt.Errorf("Invalid age [%s].", s)
 		}
+		return time.Duration(days) * 24 * time.Hour, nil
 	}
-	err = s.Err()
+
+	d, err := time.ParseDuration(s)
 	if err != nil {
-		return 1, err
+		return 0, fmt.Errorf("Invalid age [%s].", s)
 	}
 
-	return 0, nil
+	return d, nil
+}
+
+func register(fs *flag.FlagSet) runner {
+	learnFlags(fs)
+	tw := fs.String("term-words", "", termWordsUsage)
+	split := fs.Bool("split", false, "Split the lines into sentences at the term words.")
+	progress := fs.Bool("progress", false, "Show the progress on the standard error.")
+	workers := fs.Int("workers", 0, "Number of goroutines tokenizing the lines (default GOMAXPROCS).")
+	buffer := fs.Int("buffer", 0, "Accumulate this number of texts in memory before writing them.")
+	interval := fs.Duration("flush-interval", 0, "Write the accumulated texts at this interval (e.g. 10s).")
+	source := fs.String("source", "", "Source of the texts, such as twitter.")
+	author := fs.String("author", "", "Author of the texts.")
+	dedup := fs.String("dedup", "allow", "How to treat the texts registered before: allow, skip, or diminish their weight.")
+	provenance := fs.Bool("provenance", false, "Record which texts made each link, for generate -trace.")
+	followFile := fs.Bool("follow", false, "Keep registering the lines appended to the input file, like tail -f, until interrupted.")
+	poll := fs.Duration("poll", time.Second, "Interval of checking the input file for -follow.")
+	encoding := fs.String("encoding", uonum.EncodingAuto, "Character encoding of the input (e.g. utf-8, shift_jis, euc-jp), or auto to detect it.")
+	recursive := fs.Bool("recursive", false, "Register the files in the input directory and its subdirectories, skipping the files registered before.")
+	glob := fs.String("glob", "*", "Pattern of the names of the files registered with -recursive (e.g. *.txt).")
+	format := fs.String("format", "text", "Format of the input: text (a text per line), csv, tsv, jsonl, html or markdown (a text per paragraph).")
+	column := fs.String("column", "1", "Number (from 1) or header name of the column of the texts in -format csv or tsv.")
+	header := fs.Bool("header", false, "The first record of -format csv or tsv names the columns.")
+	field := fs.String("field", "text", "Field of the texts in -format jsonl (nested fields separated by \".\").")
+	fields := fs.Bool("fields", false, "Store the other columns or fields of -format csv, tsv or jsonl as the metadata of the texts.")
+	pageURL := fs.String("url", "", "Register the sentences of the article in the web page at the URL instead of the input.")
+	dryRun := fs.Bool("dry-run", false, "Print the tokens, their features and keys, and the links of each text, without registering them.")
+
+	return func(args []string) (int, error) {
+		newRecords, err := recordsFormat(*format, *column, *header, *field, *fields)
+		if err != nil {
+			return 2, err
+		}
+		if *dryRun && (*pageURL != "" || *recursive || *followFile) {
+			return 2, errors.New("-dry-run cannot be used with -url, -recursive or -follow.")
+		}
+
+		opts := termWordsOption(*tw)
+		opts = append(opts, uonum.WithWorkers(*workers), uonum.WithEncoding(*encoding))
+		d, err := uonum.ParseDedup(*dedup)
+		if err != nil {
+			return 2, err
+		}
+		opts = append(opts, uonum.WithDedup(d))
+		if *provenance {
+			opts = append(opts, uonum.WithProvenance())
+		}
+		if *buffer > 0 || *interval > 0 {
+			opts = append(opts, uonum.WithBuffer(*buffer, *interval))
+		}
+		if *split || *pageURL != "" {
+			opts = append(opts, uonum.WithSentenceSplit())
+		}
+		var bar *progressBar
+		if *progress {
+			bar = newProgressBar(os.Stderr)
+			opts = append(opts, uonum.WithProgress(bar.update))
+		}
+
+		if *dryRun {
+			return previewInput(args, *encoding, newRecords, opts...)
+		}
+
+		g, err := openGenerator(opts...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		meta := uonum.Meta{
+			Source: *source,
+			Author: *author,
+		}
+
+		if *pageURL != "" {
+			err = registerPage(g.In(ns), *pageURL, meta)
+			if err != nil {
+				return 1, err
+			}
+			return 0, nil
+		}
+
+		if *recursive {
+			if len(args) == 0 {
+				return 2, errors.New("Input directory is required with -recursive.")
+			}
+			err = registerTree(g.In(ns), args[0], *glob, meta)
+			if err != nil {
+				return 1, err
+			}
+			return 0, nil
+		}
+
+		if *followFile {
+			if len(args) == 0 {
+				return 2, errors.New("Input file is required with -follow.")
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			err = follow(ctx, g.In(ns), args[0], meta, *poll)
+			if err != nil {
+				return 1, err
+			}
+			return 0, nil
+		}
+
+		var r io.Reader
+		if len(args) > 0 {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return 1, fmt.Errorf("could not open the input file [%s]: %w", args[0], err)
+			}
+			defer file.Close()
+			r = file
+		} else {
+			r = os.Stdin
+		}
+
+		if newRecords != nil {
+			r, err = uonum.DecodeReader(r, *encoding)
+			if err != nil {
+				return 1, err
+			}
+			err = g.In(ns).RegisterRecords(newRecords(r), meta)
+		} else {
+			err = g.In(ns).RegisterReaderWithMeta(r, meta)
+		}
+		if bar != nil {
+			bar.finish()
+		}
+		if err != nil {
+			return 1, err
+		}
+
+		return 0, nil
+	}
 }
 
-func dump(args []string) (int, error) {
-	g := uonum.New()
-	err := g.Open(dbName)
+// previewInput prints the previews of the texts in the input file args[0] or
+// the standard input, for register -dry-run. The database is opened
+// read-only, and a new database is not created: the texts are previewed
+// against an empty model in a temporary database instead.
+func previewInput(args []string, encoding string, newRecords func(io.Reader) uonum.RecordReader, opts ...uonum.Option) (int, error) {
+	opts, err := generatorOptions(opts...)
 	if err != nil {
 		return 1, err
 	}
+	g := uonum.New(append(opts, uonum.WithReadOnly())...)
+	err = g.Open(dbName)
+	if errors.Is(err, os.ErrNotExist) {
+		dir, err := os.MkdirTemp("", "uonum-preview")
+		if err != nil {
+			return 1, err
+		}
+		defer os.RemoveAll(dir)
+		g, err = openGeneratorAt(filepath.Join(dir, "preview.db"), opts...)
+		if err != nil {
+			return 1, err
+		}
+	} else if err != nil {
+		return 1, classify(errOpenDB, err)
+	}
 	defer g.Close()
 
+	var r io.Reader = os.Stdin
+	if len(args) > 0 {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return 1, fmt.Errorf("could not open the input file [%s]: %w", args[0], err)
+		}
+		defer file.Close()
+		r = file
+	}
+	r, err = uonum.DecodeReader(r, encoding)
+	if err != nil {
+		return 1, err
+	}
+	err = previewTexts(g.In(ns), r, newRecords)
+	if err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+// previewTexts prints the previews of the texts read from r, a text per
+// line or the records of newRecords if it is not nil.
+func previewTexts(g uonum.Generator, r io.Reader, newRecords func(io.Reader) uonum.RecordReader) error {
 	buf := bufio.NewWriter(os.Stdout)
 	defer buf.Flush()
 
-	err = g.Dump(buf)
-	if err != nil {
-		return 1, nil
+	preview := func(text string) error {
+		p, err := g.Preview(text)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			buf.Flush()
+			return printJSON(p)
+		}
+
+		fmt.Fprintf(buf, "# %s\n", p.Text)
+		for _, tokens := range p.Sentences {
+			for _, t := range tokens {
+				fmt.Fprintf(buf, "  %s\t%s\t%s\n", t.Key, strings.Join(t.Features, ","), t.Reading)
+			}
+		}
+		for _, l := range p.Links {
+			mark := ""
+			if l.New {
+				mark = " (new)"
+			}
+			fmt.Fprintf(buf, "  %s -> %s : %d%s\n", l.From, l.To, l.Count, mark)
+		}
+		return nil
 	}
 
-	return 0, nil
+	if newRecords != nil {
+		rr := newRecords(r)
+		for {
+			rec, err := rr.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("could not read the input: %w", err)
+			}
+			if err := preview(rec.Text); err != nil {
+				return err
+			}
+		}
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		if err := preview(s.Text()); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("could not read the input: %w", err)
+	}
+
+	return nil
 }
 
-func generate(args []string) (int, error) {
-	g := uonum.New()
-	err := g.Open(dbName)
+func reply(args []string) (int, error) {
+	g, err := openGenerator()
 	if err != nil {
 		return 1, err
 	}
 	defer g.Close()
 
-	var trig string
+	var msg string
 	if len(args) > 0 {
-		trig = args[0]
+		msg = strings.Join(args, " ")
 	} else {
-		for trig == "" {
-			fmt.Print("Trigger word > ")
-			if _, err := fmt.Scanln(&trig); err != nil {
-				return 1, errors.Wrap(err, "Could not read trigger word.")
-			}
+		s := bufio.NewScanner(os.Stdin)
+		fmt.Print("Message > ")
+		if s.Scan() {
+			msg = s.Text()
+		}
+		if err := s.Err(); err != nil {
+			return 1, fmt.Errorf("could not read the message: %w", err)
 		}
 	}
 
-	text, err := g.Generate(trig)
+	text, err := g.In(ns).Reply(msg)
 	if err != nil {
 		return 1, err
 	}
@@ -167,3 +757,817 @@ func generate(args []string) (int, error) {
 
 	return 0, nil
 }
+
+func stats(args []string) (int, error) {
+	g, err := openGenerator()
+	if err != nil {
+		return 1, err
+	}
+	defer g.Close()
+
+	st, err := g.In(ns).Stats()
+	if err != nil {
+		return 1, err
+	}
+	if err := printStats(st); err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+func triggers(fs *flag.FlagSet) runner {
+	speakFlags(fs)
+	class := fs.String("class", "", "Word class of the triggers listed without a prefix (名詞, or 文字 in char mode, by default).")
+	fuzzy := fs.Int("fuzzy", 0, "Match words within N edits of the word instead of the prefix.")
+
+	return func(args []string) (int, error) {
+		g, err := openGenerator()
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+		g = g.In(ns)
+
+		buf := bufio.NewWriter(os.Stdout)
+		defer buf.Flush()
+
+		q := fs.Arg(0)
+		if q == "" {
+			words, err := g.Triggers(*class)
+			if err != nil {
+				return 1, err
+			}
+			if jsonOutput {
+				if words == nil {
+					words = []string{}
+				}
+				return 0, printJSON(words)
+			}
+			for _, w := range words {
+				fmt.Fprintln(buf, w)
+			}
+			return 0, nil
+		}
+
+		var ts []uonum.Trigger
+		if *fuzzy > 0 {
+			ts, err = g.MatchTriggers(q, *fuzzy)
+		} else {
+			ts, err = g.FindTrigger(q)
+		}
+		if err != nil {
+			return 1, err
+		}
+		if jsonOutput {
+			if ts == nil {
+				ts = []uonum.Trigger{}
+			}
+			return 0, printJSON(ts)
+		}
+		for _, t := range ts {
+			fmt.Fprintf(buf, "%s\t%s\n", t.Word, t.Class)
+		}
+
+		return 0, nil
+	}
+}
+
+func word(fs *flag.FlagSet) runner {
+	speakFlags(fs)
+	top := fs.Int("top", 10, "Number of the transitions printed for each class (0 means all).")
+
+	return func(args []string) (int, error) {
+		if fs.NArg() == 0 {
+			return 1, errors.New("Surface of the word is required.")
+		}
+
+		g, err := openGenerator()
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		infos, err := g.In(ns).Lookup(fs.Arg(0))
+		if err != nil {
+			return 1, err
+		}
+		if len(infos) == 0 {
+			return 1, fmt.Errorf("[%s] %w", fs.Arg(0), uonum.ErrUnknownTrigger)
+		}
+
+		buf := bufio.NewWriter(os.Stdout)
+		defer buf.Flush()
+
+		for i, info := range infos {
+			if i > 0 {
+				fmt.Fprintln(buf)
+			}
+			fmt.Fprintf(buf, "%s_%s\n", info.Word, info.Class)
+			fmt.Fprintf(buf, "  features: %s\n", strings.Join(info.Features, ","))
+			if info.Reading != "" {
+				fmt.Fprintf(buf, "  reading: %s\n", info.Reading)
+			}
+			fmt.Fprintf(buf, "  total: %d\n", info.Total)
+			for j, t := range info.Links {
+				if *top > 0 && j >= *top {
+					fmt.Fprintf(buf, "  ... %d more\n", len(info.Links)-j)
+					break
+				}
+				fmt.Fprintf(buf, "  -> %s_%s : %d\n", t.Word, t.Class, t.Count)
+			}
+		}
+
+		return 0, nil
+	}
+}
+
+func score(fs *flag.FlagSet) runner {
+	speakFlags(fs)
+	backoff := fs.Float64("backoff", 0, backoffUsage)
+
+	return func(args []string) (int, error) {
+		var opts []uonum.Option
+		if *backoff > 0 {
+			opts = append(opts, uonum.WithBackoff(*backoff))
+		}
+
+		g, err := openGenerator(opts...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+		g = g.In(ns)
+
+		var r io.Reader
+		if len(args) > 0 {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return 1, fmt.Errorf("could not open the input file [%s]: %w", args[0], err)
+			}
+			defer file.Close()
+			r = file
+		} else {
+			r = os.Stdin
+		}
+
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			p, err := g.Score(s.Text())
+			if err != nil {
+				return 1, err
+			}
+			pp, err := g.Perplexity(s.Text())
+			if err != nil {
+				return 1, err
+			}
+			fmt.Printf("%.4f\t%.4f\t%s\n", p, pp, s.Text())
+		}
+		err = s.Err()
+		if err != nil {
+			return 1, err
+		}
+
+		return 0, nil
+	}
+}
+
+func dump(fs *flag.FlagSet) runner {
+	format := fs.String("format", "text", "Output format (text, json, csv or dot).")
+	prefix := fs.String("prefix", "", "Dump only the words which start with the prefix.")
+	class := fs.String("class", "", "Dump only the words of the class.")
+	minCount := fs.Int64("min-count", 0, "Dump only the words whose total outgoing count is at least the value.")
+	limit := fs.Int("limit", 0, "Maximum number of the words (0 means no limit).")
+	offset := fs.Int("offset", 0, "Number of the words to skip.")
+	order := fs.String("sort", "key", "Order of the words (key or count).")
+
+	return func(args []string) (int, error) {
+		f, err := uonum.ParseFormat(*format)
+		if err != nil {
+			return 1, err
+		}
+		o, err := uonum.ParseDumpSort(*order)
+		if err != nil {
+			return 1, err
+		}
+
+		g, err := openGenerator()
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		buf := bufio.NewWriter(os.Stdout)
+		defer buf.Flush()
+
+		next, err := g.In(ns).DumpRange(buf, f, uonum.DumpRange{
+			Prefix:   *prefix,
+			Class:    *class,
+			MinCount: *minCount,
+			Sort:     o,
+			Offset:   *offset,
+			Limit:    *limit,
+		})
+		if err != nil {
+			return 1, err
+		}
+		if next > 0 {
+			fmt.Fprintf(os.Stderr, "More words from -offset %d.\n", next)
+		}
+
+		return 0, nil
+	}
+}
+
+func graph(fs *flag.FlagSet) runner {
+	hops := fs.Int("hops", 0, "Maximum number of links from the word (0 means no limit).")
+
+	return func(args []string) (int, error) {
+		g, err := openGenerator()
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		buf := bufio.NewWriter(os.Stdout)
+		defer buf.Flush()
+
+		err = g.In(ns).Graph(buf, fs.Arg(0), *hops)
+		if err != nil {
+			return 1, err
+		}
+
+		return 0, nil
+	}
+}
+
+func migrate(args []string) (int, error) {
+	err := prepareDB(dbName)
+	if err != nil {
+		return 1, err
+	}
+	from, to, err := uonum.Migrate(dbName)
+	if err != nil {
+		return 1, err
+	}
+
+	if from == to {
+		fmt.Printf("The database is up to date (version %d).\n", to)
+	} else {
+		fmt.Printf("Migrated the database from version %d to %d.\n", from, to)
+	}
+
+	return 0, nil
+}
+
+func fsck(fs *flag.FlagSet) runner {
+	repair := fs.Bool("repair", false, "Remove the invalid words and the dangling links.")
+
+	return func(args []string) (int, error) {
+		g, err := openGenerator()
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		r, err := g.In(ns).Check(*repair)
+		if err != nil {
+			return 1, err
+		}
+
+		for _, k := range r.Invalid {
+			fmt.Printf("invalid word: %s\n", k)
+		}
+		for _, l := range r.Dangling {
+			fmt.Printf("dangling link: %s -> %s\n", l.From, l.To)
+		}
+		fmt.Printf("%d words, %d links, %d invalid words, %d dangling links\n",
+			r.Words, r.Links, len(r.Invalid), len(r.Dangling))
+
+		if !r.OK() && !r.Repaired {
+			return 1, errors.New("The database has problems. Run with -repair to remove them.")
+		}
+
+		return 0, nil
+	}
+}
+
+func backup(args []string) (int, error) {
+	if len(args) == 0 {
+		printHelp()
+	}
+
+	g, err := openGenerator()
+	if err != nil {
+		return 1, err
+	}
+	defer g.Close()
+
+	if args[0] == "-" {
+		_, err = g.Backup(os.Stdout)
+		if err != nil {
+			return 1, err
+		}
+		return 0, nil
+	}
+
+	file, err := os.Create(args[0])
+	if err != nil {
+		return 1, fmt.Errorf("could not create the backup file [%s]: %w", args[0], err)
+	}
+	n, err := g.Backup(file)
+	if cerr := file.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("could not write the backup file [%s]: %w", args[0], cerr)
+	}
+	if err != nil {
+		return 1, err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d bytes to %s.\n", n, args[0])
+
+	return 0, nil
+}
+
+func restore(args []string) (int, error) {
+	if len(args) == 0 {
+		printHelp()
+	}
+
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return 1, fmt.Errorf("could not open the backup file [%s]: %w", args[0], err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	err := prepareDB(dbName)
+	if err != nil {
+		return 1, err
+	}
+	err = uonum.Restore(dbName, r)
+	if err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+func snapshot(args []string) (int, error) {
+	if len(args) == 0 {
+		return 2, errors.New("Subcommand is required (create, rollback, list or remove).")
+	}
+
+	err := prepareDB(dbName)
+	if err != nil {
+		return 1, err
+	}
+
+	if args[0] == "list" {
+		snaps, err := uonum.Snapshots(dbName)
+		if err != nil {
+			return 1, err
+		}
+		if jsonOutput {
+			if snaps == nil {
+				snaps = []uonum.Snapshot{}
+			}
+			return 0, printJSON(snaps)
+		}
+		for _, s := range snaps {
+			fmt.Printf("%s\t%s\t%d\n", s.Name, s.Time.Format(time.RFC3339), s.Size)
+		}
+		return 0, nil
+	}
+
+	if len(args) < 2 {
+		return 2, errors.New("Name of the snapshot is required.")
+	}
+
+	switch args[0] {
+	case "create":
+		s, err := uonum.CreateSnapshot(dbName, args[1])
+		if err != nil {
+			return 1, err
+		}
+		fmt.Fprintf(os.Stderr, "Created the snapshot %s (%d bytes).\n", s.Name, s.Size)
+	case "rollback":
+		err = uonum.RollbackSnapshot(dbName, args[1])
+		if err != nil {
+			return 1, err
+		}
+		fmt.Fprintf(os.Stderr, "Rolled the database back to the snapshot %s.\n", args[1])
+	case "remove":
+		err = uonum.RemoveSnapshot(dbName, args[1])
+		if err != nil {
+			return 1, err
+		}
+	default:
+		return 2, fmt.Errorf("Unknown subcommand [%s].", args[0])
+	}
+
+	return 0, nil
+}
+
+func compact(args []string) (int, error) {
+	err := prepareDB(dbName)
+	if err != nil {
+		return 1, err
+	}
+	before, after, err := uonum.Compact(dbName)
+	if err != nil {
+		return 1, err
+	}
+
+	saved := before - after
+	fmt.Printf("Compacted %d bytes to %d bytes (%d bytes saved).\n", before, after, saved)
+
+	return 0, nil
+}
+
+func merge(args []string) (int, error) {
+	if len(args) == 0 {
+		printHelp()
+	}
+
+	g, err := openGenerator()
+	if err != nil {
+		return 1, err
+	}
+	defer g.Close()
+
+	o := uonum.New()
+	err = o.Open(args[0])
+	if err != nil {
+		return 1, err
+	}
+	defer o.Close()
+
+	err = g.In(ns).Merge(o.In(ns))
+	if err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+func diff(args []string) (int, error) {
+	if len(args) < 2 {
+		printHelp()
+	}
+
+	var gs [2]uonum.Generator
+	for i, name := range args[:2] {
+		g := uonum.New()
+		err := g.Open(name)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+		gs[i] = g.In(ns)
+	}
+
+	r, err := uonum.Diff(gs[0], gs[1])
+	if err != nil {
+		return 1, err
+	}
+
+	for _, w := range r.OnlyA {
+		fmt.Printf("- %s\n", w)
+	}
+	for _, w := range r.OnlyB {
+		fmt.Printf("+ %s\n", w)
+	}
+	for _, l := range r.Links {
+		fmt.Printf("~ %s -> %s : %d -> %d (%+d)\n", l.From, l.To, l.A, l.B, l.B-l.A)
+	}
+
+	return 0, nil
+}
+
+func decay(fs *flag.FlagSet) runner {
+	learnFlags(fs)
+	halfLife := fs.String("half-life", "", "Age at which the counts of the links are halved (e.g. 30d).")
+
+	return func(args []string) (int, error) {
+		if *halfLife == "" {
+			printHelp()
+		}
+		hl, err := parseAge(*halfLife)
+		if err != nil {
+			return 1, err
+		}
+
+		g, err := openGenerator()
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		err = g.Decay(hl)
+		if err != nil {
+			return 1, err
+		}
+
+		return 0, nil
+	}
+}
+
+func rebuild(args []string) (int, error) {
+	g, err := openGenerator()
+	if err != nil {
+		return 1, err
+	}
+	defer g.Close()
+
+	n, err := g.Rebuild()
+	if err != nil {
+		return 1, err
+	}
+	fmt.Printf("Rebuilt the links of %d texts.\n", n)
+
+	return 0, nil
+}
+
+func generate(fs *flag.FlagSet) runner {
+	speakFlags(fs)
+	class := fs.String("class", "", "Comma separated word classes of the trigger word, sub-classes separated by \"/\" (e.g. 名詞/固有名詞,動詞).")
+	n := fs.Int("n", 1, "Number of sentences to generate.")
+	count := fs.Int("count", 0, "Write this number of sentences as JSONL with their trigger words, token counts and scores.")
+	triggersFile := fs.String("triggers-file", "", "Like -count, from the trigger words in this file in turn (one sentence for each if -count is 0).")
+	best := fs.Bool("best", false, "Print only the best one of the generated sentences.")
+	scoring := fs.String("score", "length", "Scoring of -best (length or logprob).")
+	overlap := fs.Float64("max-overlap", 0, "Reject sentences overlapping more than this ratio with a registered text (e.g. 0.8).")
+	retries := fs.Int("retries", 10, "Number of regenerations of a rejected sentence.")
+	timeout := fs.Duration("timeout", 0, "Time limit of a generation (e.g. 3s).")
+	maxWords := fs.Int("max-words", 0, "Reject sentences longer than this number of words.")
+	stream := fs.Bool("stream", false, "Print each word as soon as it is chosen.")
+	reading := fs.Bool("reading", false, "The trigger word is a reading in hiragana or katakana.")
+	beamWidth := fs.Int("beam", 0, "Print the most probable sentence found by a beam search of this width.")
+	paragraph := fs.Int("p", 0, "Generate a paragraph of this number of sentences linked by their nouns.")
+	around := fs.Bool("around", false, "Extend the trigger word in both directions, so that it can appear mid-sentence.")
+	result := fs.Bool("result", false, "Print the sentences as JSON with their tokens, score, dead end and retries.")
+	related := fs.Bool("related", false, "Start from a related word, of the same reading, a shared prefix or in the same texts, if the trigger word is unknown.")
+	require := fs.String("require", "", "Generate sentences containing this word, through a path from the trigger word.")
+	template := fs.Bool("template", false, "The argument is a template whose slots in braces are filled with the words of the classes in them (e.g. 今日は{名詞}が{動詞}).")
+	source := fs.String("source", "", "Comma separated sources of the links used (all if empty).")
+	trace := fs.Bool("trace", false, "Print the texts and the sources each transition came from.")
+	since := fs.String("since", "", "Use only the links registered within this age (e.g. 30d, 12h) or since this date (e.g. 2024-01-31).")
+	backoff := fs.Float64("backoff", 0, backoffUsage)
+	temperature := fs.Float64("temperature", 0, "Choose the next words by their counts sharpened (< 1) or flattened (> 1) by this temperature, instead of uniformly.")
+	topP := fs.Float64("top-p", 0, "Choose the next words only from the most probable ones whose cumulative probability reaches this value (e.g. 0.9).")
+	natural := fs.Bool("natural", false, "Regenerate sentences which do not end on a term word, an auxiliary verb or a sentence-final particle.")
+	ending := fs.String("ending", "", "Like -natural, but with comma separated word classes which can end sentences (e.g. 助動詞,助詞/終助詞).")
+	out := addSinkFlags(fs)
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		_, sinks, err := out.open()
+		if err != nil {
+			return 2, err
+		}
+		defer closeSinks(sinks)
+
+		opts := termWordsOption(*tw)
+		if *ending != "" {
+			opts = append(opts, uonum.WithEnding(uonum.ParseClasses(*ending)))
+		} else if *natural {
+			opts = append(opts, uonum.WithEnding(uonum.DefaultEnding))
+		}
+		if *class != "" {
+			opts = append(opts, uonum.WithTriggerMatch(uonum.ParseClasses(*class)))
+		}
+		if *source != "" {
+			opts = append(opts, uonum.WithSources(strings.Split(*source, ",")...))
+		}
+		if *since != "" {
+			t, err := parseSince(*since)
+			if err != nil {
+				return 1, err
+			}
+			opts = append(opts, uonum.WithSince(t))
+		}
+		if *backoff > 0 {
+			opts = append(opts, uonum.WithBackoff(*backoff))
+		}
+		if *temperature > 0 || *topP > 0 {
+			opts = append(opts, uonum.WithSampling(uonum.Sampling{
+				Temperature: *temperature,
+				TopP:        *topP,
+			}))
+		}
+		if *overlap > 0 {
+			opts = append(opts, uonum.WithMaxOverlap(*overlap, *retries))
+		}
+		if *require != "" {
+			opts = append(opts, uonum.WithRequiredWord(*require))
+		}
+		opts = append(opts, uonum.WithLimits(uonum.Limits{
+			Timeout:    *timeout,
+			MaxRetries: *retries,
+			MaxWords:   *maxWords,
+		}))
+
+		g, err := openGenerator(opts...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		if *count > 0 || *triggersFile != "" {
+			triggers := args
+			if *triggersFile != "" {
+				triggers, err = readTriggers(*triggersFile)
+				if err != nil {
+					return 1, err
+				}
+			}
+			if err := generateBatch(g.In(ns), triggers, *count, sinks); err != nil {
+				return 1, err
+			}
+			return 0, nil
+		}
+
+		var trig string
+		if len(args) > 0 {
+			trig = args[0]
+		} else {
+			for trig == "" {
+				fmt.Print("Trigger word > ")
+				if _, err := fmt.Scanln(&trig); err != nil {
+					return 1, fmt.Errorf("could not read trigger word: %w", err)
+				}
+			}
+		}
+
+		g = g.In(ns)
+
+		if *result {
+			for i := 0; i < *n; i++ {
+				r, err := g.GenerateResult(trig)
+				if err != nil {
+					return 1, err
+				}
+				if err := printJSON(r); err != nil {
+					return 1, err
+				}
+			}
+			return 0, nil
+		}
+
+		if *related {
+			for i := 0; i < *n; i++ {
+				text, used, err := g.GenerateRelated(trig)
+				if err != nil {
+					return 1, err
+				}
+				if used != trig && !jsonOutput {
+					fmt.Fprintf(os.Stderr, "Used [%s] for the unknown trigger word [%s].\n", used, trig)
+				}
+				if err := writeSinks(sinks, sentenceLine(used, text)); err != nil {
+					return 1, err
+				}
+			}
+			return 0, nil
+		}
+
+		if *template {
+			for i := 0; i < *n; i++ {
+				text, err := g.GenerateTemplate(trig)
+				if err != nil {
+					return 1, err
+				}
+				if err := writeSinks(sinks, sentenceLine("", text)); err != nil {
+					return 1, err
+				}
+			}
+			return 0, nil
+		}
+
+		if *reading {
+			text, err := g.GenerateByReading(trig)
+			if err != nil {
+				return 1, err
+			}
+			if err := writeSinks(sinks, sentenceLine(trig, text)); err != nil {
+				return 1, err
+			}
+			return 0, nil
+		}
+
+		if *beamWidth > 0 {
+			text, err := g.GenerateBeam(trig, *beamWidth)
+			if err != nil {
+				return 1, err
+			}
+			if err := writeSinks(sinks, sentenceLine(trig, text)); err != nil {
+				return 1, err
+			}
+			return 0, nil
+		}
+
+		if *paragraph > 0 {
+			for i := 0; i < *n; i++ {
+				text, err := g.GenerateParagraph(trig, *paragraph)
+				if err != nil {
+					return 1, err
+				}
+				if err := writeSinks(sinks, sentenceLine(trig, text)); err != nil {
+					return 1, err
+				}
+			}
+			return 0, nil
+		}
+
+		if *around {
+			for i := 0; i < *n; i++ {
+				text, err := g.GenerateAround(trig)
+				if errors.Is(err, uonum.ErrGenerationFailed) {
+					continue
+				}
+				if err != nil {
+					return 1, err
+				}
+				if err := writeSinks(sinks, sentenceLine(trig, text)); err != nil {
+					return 1, err
+				}
+			}
+			return 0, nil
+		}
+
+		if *trace {
+			t, err := g.GenerateTraced(trig)
+			if err != nil {
+				return 1, err
+			}
+			if jsonOutput {
+				return 0, printJSON(t)
+			}
+			fmt.Println(t.Text)
+			for _, s := range t.Steps {
+				fmt.Printf("  %s -> %s", s.From, s.To)
+				if len(s.Sources) > 0 {
+					fmt.Printf(" sources=%s", strings.Join(s.Sources, ","))
+				}
+				if len(s.TextIDs) > 0 {
+					ids := make([]string, len(s.TextIDs))
+					for i, id := range s.TextIDs {
+						ids[i] = strconv.FormatUint(id, 10)
+					}
+					fmt.Printf(" texts=%s", strings.Join(ids, ","))
+				}
+				fmt.Println()
+			}
+			return 0, nil
+		}
+
+		if *stream {
+			if jsonOutput {
+				return 2, errors.New("-stream cannot be used with -json.")
+			}
+			err := g.GenerateFunc(trig, func(word string) bool {
+				fmt.Print(word)
+				return true
+			})
+			fmt.Println()
+			if err != nil {
+				return 1, err
+			}
+			return 0, nil
+		}
+
+		if *best {
+			var scorer uonum.Scorer
+			switch *scoring {
+			case "length":
+				scorer = uonum.ScoreLength
+			case "logprob":
+				scorer = uonum.ScoreLogProb(g)
+			default:
+				return 1, fmt.Errorf("Unknown scoring [%s].", *scoring)
+			}
+
+			text, err := g.GenerateBest(trig, *n, scorer)
+			if err != nil {
+				return 1, err
+			}
+			if err := writeSinks(sinks, sentenceLine(trig, text)); err != nil {
+				return 1, err
+			}
+			return 0, nil
+		}
+
+		texts, err := g.GenerateN(trig, *n)
+		if err != nil {
+			return 1, err
+		}
+		for _, text := range texts {
+			if err := writeSinks(sinks, sentenceLine(trig, text)); err != nil {
+				return 1, err
+			}
+		}
+
+		return 0, nil
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/kechako/uonum"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		verbose  bool
+		debugLog bool
+		want     slog.Level
+	}{
+		{name: "default", want: slog.LevelWarn},
+		{name: "v", verbose: true, want: slog.LevelInfo},
+		{name: "vv", debugLog: true, want: slog.LevelDebug},
+		{name: "both", verbose: true, debugLog: true, want: slog.LevelDebug},
+	}
+
+	defer func(v, d bool) { verbose, debugLog = v, d }(verbose, debugLog)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verbose, debugLog = tt.verbose, tt.debugLog
+			l := newLogger()
+			for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+				if got, want := l.Enabled(context.Background(), level), level >= tt.want; got != want {
+					t.Errorf("Enabled(%v) = %v, want %v", level, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		err  error
+		want int
+	}{
+		{name: "ok", code: 0, want: 0},
+		{name: "usage", code: 2, err: uonum.ErrUnknownTrigger, want: 2},
+		{name: "unknown trigger", code: 1, err: fmt.Errorf("[猫] %w", uonum.ErrUnknownTrigger), want: 3},
+		{name: "empty model", code: 1, err: uonum.ErrEmptyModel, want: 4},
+		{name: "decode", code: 1, err: uonum.ErrDecode, want: 5},
+		{name: "generation failed", code: 1, err: uonum.ErrGenerationFailed, want: 6},
+		{name: "not open", code: 1, err: uonum.ErrNotOpen, want: 7},
+		{name: "open db", code: 1, err: classify(errOpenDB, errors.New("timeout")), want: 8},
+		{name: "io", code: 1, err: classify(errIO, errors.New("disk full")), want: 9},
+		{name: "path", code: 1, err: fmt.Errorf("could not open: %w", &fs.PathError{Op: "open", Path: "a.txt", Err: os.ErrNotExist}), want: 9},
+		{name: "daemon", code: 1, err: &daemonError{code: "empty_model", msg: "Empty model."}, want: 4},
+		{name: "other", code: 1, err: errors.New("other"), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.code, tt.err); got != tt.want {
+				t.Errorf("exitCode(%d, %v) = %d, want %d", tt.code, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	if err := classify(errIO, nil); err != nil {
+		t.Errorf("classify(nil) = %v, want nil", err)
+	}
+
+	cause := fmt.Errorf("could not lock: %w", os.ErrDeadlineExceeded)
+	err := classify(errOpenDB, cause)
+	if err.Error() != cause.Error() {
+		t.Errorf("Error() = %q, want %q", err, cause)
+	}
+	for _, target := range []error{errOpenDB, os.ErrDeadlineExceeded} {
+		if !errors.Is(err, target) {
+			t.Errorf("classified error is not %v", target)
+		}
+	}
+	if errors.Is(err, errIO) {
+		t.Errorf("classified error is %v", errIO)
+	}
+}
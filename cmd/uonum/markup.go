@@ -0,0 +1,153 @@
+package main
+
+import (
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/kechako/uonum"
+)
+
+// documentRecords is a RecordReader of the paragraphs of a whole document,
+// which is read and split by parse at the first Read.
+type documentRecords struct {
+	r          io.Reader
+	parse      func(doc string) []string
+	paragraphs []string
+	read       bool
+}
+
+func newHTMLRecords(r io.Reader) uonum.RecordReader {
+	return &documentRecords{r: r, parse: htmlParagraphs}
+}
+
+func newMarkdownRecords(r io.Reader) uonum.RecordReader {
+	return &documentRecords{r: r, parse: markdownParagraphs}
+}
+
+func (d *documentRecords) Read() (uonum.Record, error) {
+	if !d.read {
+		data, err := io.ReadAll(d.r)
+		if err != nil {
+			return uonum.Record{}, err
+		}
+		d.paragraphs = d.parse(string(data))
+		d.read = true
+	}
+	if len(d.paragraphs) == 0 {
+		return uonum.Record{}, io.EOF
+	}
+	p := d.paragraphs[0]
+	d.paragraphs = d.paragraphs[1:]
+
+	return uonum.Record{Text: p}, nil
+}
+
+// htmlParagraphs returns the texts of the block elements of an HTML
+// document, without the tags, the code blocks and the boilerplate.
+func htmlParagraphs(doc string) []string {
+	_, blocks := htmlBlocks(doc)
+
+	var paragraphs []string
+	for _, b := range blocks {
+		paragraphs = append(paragraphs, b.text)
+	}
+
+	return paragraphs
+}
+
+var (
+	mdImage     = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)|!\[[^\]]*\]\[[^\]]*\]`)
+	mdLink      = regexp.MustCompile(`\[([^\]]*)\](\([^)]*\)|\[[^\]]*\])`)
+	mdAutolink  = regexp.MustCompile(`<(https?|mailto):[^>]*>`)
+	mdCode      = regexp.MustCompile("`+[^`]*`+")
+	mdEmphasis  = regexp.MustCompile(`\*\*|__|~~|\*`)
+	mdListItem  = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+	mdReference = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s`)
+	mdRule      = regexp.MustCompile(`^\s{0,3}([-*_]\s*){3,}$`)
+	mdHeading   = regexp.MustCompile(`^\s{0,3}#{1,6}(\s|$)|^\s{0,3}(=+|-+)\s*$`)
+)
+
+// markdownParagraphs returns the paragraphs and the list items of a
+// Markdown document, without the front matter, the code blocks, the
+// headings, the tables, the HTML and the syntax of the links and the
+// emphases.
+func markdownParagraphs(doc string) []string {
+	lines := strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n")
+
+	// the front matter of YAML or TOML
+	if len(lines) > 0 && (lines[0] == "---" || lines[0] == "+++") {
+		for i := 1; i < len(lines); i++ {
+			if lines[i] == lines[0] {
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+
+	var paragraphs []string
+	var p []string
+	flush := func() {
+		if len(p) > 0 {
+			if s := collapseSpace(strings.Join(p, "\n")); s != "" {
+				paragraphs = append(paragraphs, s)
+			}
+		}
+		p = nil
+	}
+
+	var fence string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence = trimmed[:3]
+			continue
+		case trimmed == "":
+			flush()
+			continue
+		case len(p) == 0 && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")):
+			// an indented code block
+			continue
+		case mdRule.MatchString(line), mdHeading.MatchString(line):
+			// a setext heading underlines the previous line
+			p = nil
+			continue
+		case strings.HasPrefix(trimmed, "|"), mdReference.MatchString(line):
+			flush()
+			continue
+		}
+
+		if mdListItem.MatchString(line) {
+			flush()
+			for mdListItem.MatchString(line) {
+				line = mdListItem.ReplaceAllString(line, "")
+			}
+		}
+		line = strings.TrimLeft(strings.TrimSpace(line), "> ")
+		p = append(p, markdownInline(line))
+	}
+	flush()
+
+	return paragraphs
+}
+
+// markdownInline removes the syntax of the inline elements.
+func markdownInline(s string) string {
+	s = mdCode.ReplaceAllString(s, "")
+	s = mdImage.ReplaceAllString(s, "")
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdAutolink.ReplaceAllString(s, "")
+	s = htmlTag.ReplaceAllString(s, "")
+	s = mdEmphasis.ReplaceAllString(s, "")
+	return html.UnescapeString(s)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kechako/uonum"
+)
+
+func TestMarkdownParagraphs(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "paragraphs",
+			doc:  "吾輩は猫である。\n名前はまだ無い。\n\nどこで生れたか\r\nとんと見当がつかぬ。\n",
+			want: []string{"吾輩は猫である。名前はまだ無い。", "どこで生れたかとんと見当がつかぬ。"},
+		},
+		{
+			name: "front matter",
+			doc:  "---\ntitle: 猫\n---\n本文。\n",
+			want: []string{"本文。"},
+		},
+		{
+			name: "toml front matter",
+			doc:  "+++\ntitle = \"猫\"\n+++\n本文。\n",
+			want: []string{"本文。"},
+		},
+		{
+			name: "headings",
+			doc:  "# 見出し\n本文。\n\n見出し\n======\n\n見出し\n---\n",
+			want: []string{"本文。"},
+		},
+		{
+			name: "code",
+			doc:  "本文。\n\n```go\nfmt.Println(\"猫\")\n```\n\n    indented code\n\n~~~\ncode\n~~~\n終わり。\n",
+			want: []string{"本文。", "終わり。"},
+		},
+		{
+			name: "lists",
+			doc:  "- 猫が鳴く。\n- 犬が吠える。\n  続き。\n1. 鳥が飛ぶ。\n",
+			want: []string{"猫が鳴く。", "犬が吠える。続き。", "鳥が飛ぶ。"},
+		},
+		{
+			name: "quotes",
+			doc:  "> 猫が鳴く。\n> > 犬が吠える。\n",
+			want: []string{"猫が鳴く。犬が吠える。"},
+		},
+		{
+			name: "tables and rules",
+			doc:  "| 猫 | 犬 |\n|---|---|\n| 1 | 2 |\n\n***\n\n[猫]: https://example.com/cat\n本文。\n",
+			want: []string{"本文。"},
+		},
+		{
+			name: "inline",
+			doc:  "**猫**が[魚](https://example.com/fish)を`code`食べる。![画像](cat.png)<br>\n",
+			want: []string{"猫が魚を食べる。"},
+		},
+		{name: "empty", doc: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownParagraphs(tt.doc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("markdownParagraphs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownInline(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "**猫**と*犬*と__鳥__と~~魚~~", want: "猫と犬と鳥と魚"},
+		{s: "[猫](https://example.com/cat)と[犬][dog]", want: "猫と犬"},
+		{s: "![猫](cat.png)![犬][dog]", want: ""},
+		{s: "<https://example.com/>を見る", want: "を見る"},
+		{s: "``code``と`c`", want: "と"},
+		{s: "猫&amp;犬", want: "猫&犬"},
+		{s: "<b>猫</b>", want: "猫"},
+	}
+
+	for _, tt := range tests {
+		if got := markdownInline(tt.s); got != tt.want {
+			t.Errorf("markdownInline(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentRecords(t *testing.T) {
+	tests := []struct {
+		name string
+		rr   uonum.RecordReader
+		want []string
+	}{
+		{
+			name: "html",
+			rr:   newHTMLRecords(strings.NewReader("<nav>メニュー</nav><h1>猫</h1><p>猫が<b>鳴く</b>。</p><pre>code</pre><p>犬が吠える。</p>")),
+			want: []string{"猫", "猫が鳴く。", "犬が吠える。"},
+		},
+		{
+			name: "markdown",
+			rr:   newMarkdownRecords(strings.NewReader("# 猫\n\n猫が**鳴く**。\n\n犬が吠える。\n")),
+			want: []string{"猫が鳴く。", "犬が吠える。"},
+		},
+		{
+			name: "empty",
+			rr:   newMarkdownRecords(strings.NewReader("")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for {
+				rec, err := tt.rr.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, rec.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() = %q, want %q", got, tt.want)
+			}
+
+			// EOF again after the end
+			if _, err := tt.rr.Read(); !errors.Is(err, io.EOF) {
+				t.Errorf("Read() error = %v, want EOF", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// mastodonRetry is the wait before reconnecting to the streaming API.
+const mastodonRetry = 10 * time.Second
+
+type mastodonAccount struct {
+	ID   string `json:"id"`
+	Acct string `json:"acct"`
+	Bot  bool   `json:"bot"`
+}
+
+type mastodonStatus struct {
+	ID         string          `json:"id"`
+	Content    string          `json:"content"`
+	Visibility string          `json:"visibility"`
+	Account    mastodonAccount `json:"account"`
+	Reblog     *mastodonStatus `json:"reblog"`
+}
+
+type mastodonNotification struct {
+	Type   string          `json:"type"`
+	Status *mastodonStatus `json:"status"`
+}
+
+// mastodonClient calls the Mastodon REST and streaming API with a token.
+type mastodonClient struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+func (c *mastodonClient) request(ctx context.Context, method, p string, form url.Values) (*http.Response, error) {
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.server, "/")+p, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		res.Body.Close()
+		return nil, fmt.Errorf("[%s %s] Mastodon API error: %s.", method, p, res.Status)
+	}
+
+	return res, nil
+}
+
+func (c *mastodonClient) call(ctx context.Context, method, p string, form url.Values, v interface{}) error {
+	res, err := c.request(ctx, method, p, form)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if v == nil {
+		return nil
+	}
+	err = json.NewDecoder(res.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("[%s] JSON unmarshal error: %w", p, err)
+	}
+
+	return nil
+}
+
+// post posts a status, in reply to the status inReplyTo if it is not empty.
+func (c *mastodonClient) post(ctx context.Context, text, inReplyTo, visibility string) error {
+	form := url.Values{"status": {text}}
+	if inReplyTo != "" {
+		form.Set("in_reply_to_id", inReplyTo)
+	}
+	if visibility != "" {
+		form.Set("visibility", visibility)
+	}
+
+	return c.call(ctx, http.MethodPost, "/api/v1/statuses", form, nil)
+}
+
+// stream calls fn with each event of the streaming API at p until ctx is
+// done or the connection is lost.
+func (c *mastodonClient) stream(ctx context.Context, p string, fn func(event, data string)) error {
+	res, err := c.request(ctx, http.MethodGet, p, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var event string
+	var data []string
+	s := bufio.NewScanner(res.Body)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case line == "":
+			if event != "" {
+				fn(event, strings.Join(data, "\n"))
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	return s.Err()
+}
+
+// streamPath returns the path of the streaming API of timeline.
+func streamPath(timeline string) (string, error) {
+	switch {
+	case timeline == "home":
+		return "/api/v1/streaming/user", nil
+	case timeline == "local":
+		return "/api/v1/streaming/public/local", nil
+	case timeline == "public":
+		return "/api/v1/streaming/public", nil
+	case strings.HasPrefix(timeline, "hashtag:"):
+		return "/api/v1/streaming/hashtag?tag=" + url.QueryEscape(strings.TrimPrefix(timeline, "hashtag:")), nil
+	}
+
+	return "", fmt.Errorf("Unknown timeline [%s].", timeline)
+}
+
+func mastodon(fs *flag.FlagSet) runner {
+	conversationFlags(fs)
+	server := fs.String("server", "", "URL of the Mastodon server (e.g. https://mastodon.social).")
+	token := fs.String("token", "", "Access token of the account, with the read and write scopes.")
+	timeline := fs.String("timeline", "home", "Timeline learned from: home, local, public or hashtag:<tag>.")
+	learn := fs.Bool("learn", true, "Register the statuses in the timeline.")
+	reply := fs.Bool("reply", true, "Reply to the mentions.")
+	interval := fs.Duration("post-interval", 0, "Post a generated status at this interval (0 means never).")
+	trigger := fs.String("trigger", "", "Trigger word of the posted statuses (a random one if empty).")
+	visibility := fs.String("visibility", "unlisted", "Visibility of the posted statuses.")
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		if *server == "" || *token == "" {
+			return 2, errors.New("Server and token are required.")
+		}
+		p, err := streamPath(*timeline)
+		if err != nil {
+			return 2, err
+		}
+
+		g, err := openGenerator(termWordsOption(*tw)...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+		g = g.In(ns)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		c := &mastodonClient{server: *server, token: *token, http: http.DefaultClient}
+		var me mastodonAccount
+		err = c.call(ctx, http.MethodGet, "/api/v1/accounts/verify_credentials", nil, &me)
+		if err != nil {
+			return 1, err
+		}
+
+		b := &mastodonBot{
+			g:          g,
+			c:          c,
+			me:         me,
+			learn:      *learn,
+			reply:      *reply,
+			visibility: *visibility,
+			log:        logger.With("bot", "mastodon"),
+		}
+
+		var wg sync.WaitGroup
+		if *interval > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.postEvery(ctx, *interval, *trigger)
+			}()
+		}
+
+		// the mentions are notified only in the user stream
+		paths := []string{p}
+		if *reply && p != "/api/v1/streaming/user" {
+			paths = append(paths, "/api/v1/streaming/user/notification")
+		}
+		for _, p := range paths {
+			wg.Add(1)
+			go func(p string) {
+				defer wg.Done()
+				b.listen(ctx, p)
+			}(p)
+		}
+		wg.Wait()
+
+		return 0, nil
+	}
+}
+
+// mastodonBot learns from and posts to a Mastodon account.
+type mastodonBot struct {
+	// mu serializes the calls of g
+	mu         sync.Mutex
+	g          uonum.Generator
+	c          *mastodonClient
+	me         mastodonAccount
+	learn      bool
+	reply      bool
+	visibility string
+	log        *slog.Logger
+}
+
+// listen handles the events of the stream at p, reconnecting after errors,
+// until ctx is done.
+func (b *mastodonBot) listen(ctx context.Context, p string) {
+	for {
+		err := b.c.stream(ctx, p, b.handle(ctx))
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			b.log.Warn("Disconnected.", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(mastodonRetry):
+		}
+	}
+}
+
+func (b *mastodonBot) handle(ctx context.Context) func(event, data string) {
+	return func(event, data string) {
+		switch event {
+		case "update":
+			var st mastodonStatus
+			if err := json.Unmarshal([]byte(data), &st); err != nil {
+				b.log.Error("JSON unmarshal error.", "event", "update", "err", err)
+				return
+			}
+			b.register(&st)
+		case "notification":
+			var n mastodonNotification
+			if err := json.Unmarshal([]byte(data), &n); err != nil {
+				b.log.Error("JSON unmarshal error.", "event", "notification", "err", err)
+				return
+			}
+			if n.Type == "mention" && n.Status != nil && b.reply {
+				b.replyTo(ctx, n.Status)
+			}
+		}
+	}
+}
+
+// register registers the status, except for the boosts and the statuses
+// of bots including itself.
+func (b *mastodonBot) register(st *mastodonStatus) {
+	if !b.learn || st.Reblog != nil || st.Account.ID == b.me.ID || st.Account.Bot {
+		return
+	}
+	if st.Visibility != "public" && st.Visibility != "unlisted" {
+		return
+	}
+	text := htmlText(st.Content)
+	if text == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.g.RegisterWithMeta(text, uonum.Meta{Source: "mastodon", Author: st.Account.Acct})
+	if err != nil {
+		b.log.Error("Could not register the status.", "err", err)
+		return
+	}
+	b.log.Info("Registered.", "text", text)
+}
+
+// replyTo replies to the mention st.
+func (b *mastodonBot) replyTo(ctx context.Context, st *mastodonStatus) {
+	if st.Account.ID == b.me.ID {
+		return
+	}
+
+	b.mu.Lock()
+	text, err := b.g.Reply(stripMentions(htmlText(st.Content)))
+	b.mu.Unlock()
+	if err != nil {
+		b.log.Error("Could not generate a reply.", "err", err)
+		return
+	}
+	if text == "" {
+		return
+	}
+
+	err = b.c.post(ctx, "@"+st.Account.Acct+" "+text, st.ID, st.Visibility)
+	if err != nil {
+		b.log.Error("Could not post the reply.", "err", err)
+	}
+}
+
+var mentionPattern = regexp.MustCompile(`@[\w.-]+(@[\w.-]+)?`)
+
+func stripMentions(s string) string {
+	return strings.TrimSpace(mentionPattern.ReplaceAllString(s, ""))
+}
+
+// postEvery posts a generated status at interval until ctx is done.
+func (b *mastodonBot) postEvery(ctx context.Context, interval time.Duration, trigger string) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		text, err := b.generate(trigger)
+		if err != nil {
+			b.log.Error("Could not generate a status.", "err", err)
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		err = b.c.post(ctx, text, "", b.visibility)
+		if err != nil {
+			b.log.Error("Could not post the status.", "err", err)
+		}
+	}
+}
+
+// generate generates a status from trigger, or from a random trigger word.
+func (b *mastodonBot) generate(trigger string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return generateRandom(b.g, trigger)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/kechako/uonum/uonumtest"
+)
+
+func TestStreamPath(t *testing.T) {
+	tests := []struct {
+		timeline string
+		want     string
+		wantErr  bool
+	}{
+		{timeline: "home", want: "/api/v1/streaming/user"},
+		{timeline: "local", want: "/api/v1/streaming/public/local"},
+		{timeline: "public", want: "/api/v1/streaming/public"},
+		{timeline: "hashtag:猫", want: "/api/v1/streaming/hashtag?tag=%E7%8C%AB"},
+		{timeline: "federated", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeline, func(t *testing.T) {
+			got, err := streamPath(tt.timeline)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("streamPath() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("streamPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLText(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{content: "<p>猫が鳴く。</p>", want: "猫が鳴く。"},
+		{content: "<p>猫が<br>鳴く。</p><p>犬&amp;鳥</p>", want: "猫が\n鳴く。\n犬&鳥"},
+		{content: `<a href="https://example.com">link</a>`, want: "link"},
+	}
+
+	for _, tt := range tests {
+		if got := htmlText(tt.content); got != tt.want {
+			t.Errorf("htmlText(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestStripMentions(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "@uonum 猫は？", want: "猫は？"},
+		{s: "@uonum@example.com 猫は？ @bob", want: "猫は？"},
+		{s: "猫は？", want: "猫は？"},
+	}
+
+	for _, tt := range tests {
+		if got := stripMentions(tt.s); got != tt.want {
+			t.Errorf("stripMentions(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMastodonBot(t *testing.T) {
+	const events = `event: update
+data: {"id": "1", "content": "<p>猫 が 鳴く 。</p>", "visibility": "public", "account": {"id": "2", "acct": "alice"}}
+
+event: update
+data: {"id": "2", "content": "<p>犬 が 鳴く 。</p>", "visibility": "public", "account": {"id": "2"}, "reblog": {"id": "0"}}
+
+event: update
+data: {"id": "3", "content": "<p>鳥 が 鳴く 。</p>", "visibility": "public", "account": {"id": "9"}}
+
+event: update
+data: {"id": "4", "content": "<p>牛 が 鳴く 。</p>", "visibility": "private", "account": {"id": "2"}}
+
+event: update
+data: {"id": "5", "content": "<p>豚 が 鳴く 。</p>", "visibility": "public", "account": {"id": "4", "bot": true}}
+
+event: notification
+data: {"type": "mention", "status": {"id": "6", "content": "<p>@uonum 猫 は？</p>", "visibility": "unlisted", "account": {"id": "3", "acct": "bob@example.com"}}}
+
+`
+
+	var mu sync.Mutex
+	var posts []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/streaming/user":
+			io.WriteString(w, events)
+		case "/api/v1/statuses":
+			r.ParseForm()
+			mu.Lock()
+			posts = append(posts, r.PostForm)
+			mu.Unlock()
+			io.WriteString(w, "{}")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	g := uonumtest.New(1)
+	b := &mastodonBot{
+		g:     g,
+		c:     &mastodonClient{server: srv.URL + "/", token: "token", http: srv.Client()},
+		me:    mastodonAccount{ID: "9", Acct: "uonum"},
+		learn: true,
+		reply: true,
+		log:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx := context.Background()
+	if err := b.c.stream(ctx, "/api/v1/streaming/user", b.handle(ctx)); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := g.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Texts != 1 {
+		t.Errorf("registered %d statuses, want 1", st.Texts)
+	}
+
+	if len(posts) != 1 {
+		t.Fatalf("posted %d statuses, want 1", len(posts))
+	}
+	want := url.Values{
+		"status":         {"@bob@example.com 猫 が 鳴く 。"},
+		"in_reply_to_id": {"6"},
+		"visibility":     {"unlisted"},
+	}
+	for k, v := range want {
+		if got := posts[0].Get(k); got != v[0] {
+			t.Errorf("%s = %q, want %q", k, got, v[0])
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kechako/uonum"
+	"github.com/kechako/uonum/rpc"
+)
+
+// latencyBuckets are the upper bounds in seconds of the buckets of the
+// generation latency.
+var latencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metrics are the counters of the server, written in the Prometheus text
+// format by /metrics.
+type metrics struct {
+	mu            sync.Mutex
+	registrations map[[2]string]int64 // by endpoint and result
+	generations   map[[2]string]int64 // by endpoint and result
+	latency       []int64             // by latencyBuckets, and +Inf
+	latencySum    float64
+	latencyCount  int64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		registrations: make(map[[2]string]int64),
+		generations:   make(map[[2]string]int64),
+		latency:       make([]int64, len(latencyBuckets)+1),
+	}
+}
+
+// result is the label of the result of a request with err.
+func result(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, uonum.ErrUnknownTrigger), errors.Is(err, uonum.ErrEmptyModel):
+		return "not_found"
+	case errors.Is(err, uonum.ErrGenerationFailed):
+		return "failed"
+	}
+
+	return "error"
+}
+
+func (m *metrics) register(endpoint string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.registrations[[2]string{endpoint, result(err)}]++
+}
+
+// generate counts a generate request started at start.
+func (m *metrics) generate(endpoint string, start time.Time, err error) {
+	d := time.Since(start).Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.generations[[2]string{endpoint, result(err)}]++
+	m.latency[sort.SearchFloat64s(latencyBuckets, d)]++
+	m.latencySum += d
+	m.latencyCount++
+}
+
+// grpc counts a call of Register or Generate of the gRPC service started at
+// start.
+func (m *metrics) grpc(method string, start time.Time, err error) {
+	err = rpc.FromStatus(err)
+	switch strings.TrimPrefix(method, "/"+rpc.Uonum_ServiceDesc.ServiceName+"/") {
+	case "Register":
+		m.register("grpc", err)
+	case "Generate":
+		m.generate("grpc", start, err)
+	}
+}
+
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	m := s.metrics
+	m.mu.Lock()
+	writeCounters(bw, "uonum_registrations_total", "Registration requests.", m.registrations)
+	writeCounters(bw, "uonum_generate_requests_total", "Generate requests.", m.generations)
+
+	name := "uonum_generate_duration_seconds"
+	writeHeader(bw, name, "Latency of the generate requests.", "histogram")
+	var n int64
+	for i, le := range latencyBuckets {
+		n += m.latency[i]
+		fmt.Fprintf(bw, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(le), n)
+	}
+	fmt.Fprintf(bw, "%s_bucket{le=\"+Inf\"} %d\n", name, m.latencyCount)
+	fmt.Fprintf(bw, "%s_sum %s\n", name, formatFloat(m.latencySum))
+	fmt.Fprintf(bw, "%s_count %d\n", name, m.latencyCount)
+	m.mu.Unlock()
+
+	gm := s.tenants.metrics()
+	writeHeader(bw, "uonum_walks_total", "Walks of the chain, including retries.", "counter")
+	fmt.Fprintf(bw, "uonum_walks_total %d\n", gm.Walks)
+	writeHeader(bw, "uonum_dead_ends_total", "Walks ended by a word without an ending.", "counter")
+	fmt.Fprintf(bw, "uonum_dead_ends_total %d\n", gm.DeadEnds)
+	writeHeader(bw, "uonum_dead_end_ratio", "Ratio of the walks ended by a dead end.", "gauge")
+	fmt.Fprintf(bw, "uonum_dead_end_ratio %s\n", formatFloat(ratio(gm.DeadEnds, gm.Walks)))
+	writeHeader(bw, "uonum_rejected_total", "Sentences rejected by the safety filter.", "counter")
+	fmt.Fprintf(bw, "uonum_rejected_total %d\n", gm.Rejected)
+	writeHeader(bw, "uonum_cache_hits_total", "Words found in the cache.", "counter")
+	fmt.Fprintf(bw, "uonum_cache_hits_total %d\n", gm.CacheHits)
+	writeHeader(bw, "uonum_cache_misses_total", "Words not found in the cache.", "counter")
+	fmt.Fprintf(bw, "uonum_cache_misses_total %d\n", gm.CacheMisses)
+	writeHeader(bw, "uonum_cache_hit_ratio", "Ratio of the words found in the cache.", "gauge")
+	fmt.Fprintf(bw, "uonum_cache_hit_ratio %s\n", formatFloat(ratio(gm.CacheHits, gm.CacheHits+gm.CacheMisses)))
+	writeHeader(bw, "uonum_word_writes_total", "Words written by the registrations.", "counter")
+	fmt.Fprintf(bw, "uonum_word_writes_total %d\n", gm.WordWrites)
+	writeHeader(bw, "uonum_word_written_bytes_total", "Bytes of the words written by the registrations.", "counter")
+	fmt.Fprintf(bw, "uonum_word_written_bytes_total %d\n", gm.WordBytes)
+
+	// a Redis database has no file, and the tenants of their databases do not
+	// share it
+	if fi, err := os.Stat(dbName); err == nil && fi.Mode().IsRegular() && s.tenants.shared != nil {
+		writeHeader(bw, "uonum_db_size_bytes", "Size of the database file.", "gauge")
+		fmt.Fprintf(bw, "uonum_db_size_bytes %d\n", fi.Size())
+	}
+	if s.tenants.pattern != "" {
+		writeHeader(bw, "uonum_open_tenants", "Open databases of the tenants.", "gauge")
+		fmt.Fprintf(bw, "uonum_open_tenants %d\n", s.tenants.size())
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// writeCounters writes the counter name of each pair of the endpoint and the
+// result in c.
+func writeCounters(w *bufio.Writer, name, help string, c map[[2]string]int64) {
+	writeHeader(w, name, help, "counter")
+	keys := make([][2]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s{endpoint=%q,result=%q} %d\n", name, k[0], k[1], c[k])
+	}
+}
+
+func ratio(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(total)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kechako/uonum"
+	"github.com/kechako/uonum/uonumtest"
+)
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: "ok"},
+		{err: uonum.ErrUnknownTrigger, want: "not_found"},
+		{err: fmt.Errorf("wrapped: %w", uonum.ErrEmptyModel), want: "not_found"},
+		{err: uonum.ErrGenerationFailed, want: "failed"},
+		{err: errors.New("disk full"), want: "error"},
+	}
+
+	for _, tt := range tests {
+		if got := result(tt.err); got != tt.want {
+			t.Errorf("result(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := &server{
+		tenants: newTenantPool(uonumtest.New(1), "", 0, nil),
+		metrics: newMetrics(),
+		log:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	h := s.handler()
+
+	requests := []struct {
+		method string
+		target string
+		body   string
+	}{
+		{method: http.MethodGet, target: "/generate?trigger=猫"},
+		{method: http.MethodPost, target: "/register", body: "猫 が 鳴く 。\n"},
+		{method: http.MethodGet, target: "/generate?trigger=猫"},
+		{method: http.MethodGet, target: "/generate?trigger=猫"},
+		{method: http.MethodGet, target: "/generate?trigger=鳥"},
+	}
+	for _, req := range requests {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.target, strings.NewReader(req.body)))
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE uonum_registrations_total counter\n",
+		`uonum_registrations_total{endpoint="register",result="ok"} 1` + "\n",
+		`uonum_generate_requests_total{endpoint="generate",result="not_found"} 2` + "\n",
+		`uonum_generate_requests_total{endpoint="generate",result="ok"} 2` + "\n",
+		"# TYPE uonum_generate_duration_seconds histogram\n",
+		`uonum_generate_duration_seconds_bucket{le="+Inf"} 4` + "\n",
+		"uonum_generate_duration_seconds_count 4\n",
+		"# TYPE uonum_walks_total counter\n",
+		"# TYPE uonum_dead_end_ratio gauge\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+	// the results are sorted
+	if strings.Index(body, `result="not_found"`) > strings.Index(body, `endpoint="generate",result="ok"`) {
+		t.Errorf("counters are not sorted:\n%s", body)
+	}
+	// the pool of the tenant databases is not used
+	if strings.Contains(body, "uonum_open_tenants") {
+		t.Errorf("metrics contain uonum_open_tenants:\n%s", body)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+)
+
+// defaultDBName is the database used if -db is not given.
+var defaultDBName = defaultDBPath()
+
+// dataDir returns the directory of the data of uonum: $XDG_DATA_HOME/uonum,
+// or %LOCALAPPDATA%\uonum on Windows or ~/.local/share/uonum by default.
+func dataDir() string {
+	if d := os.Getenv("XDG_DATA_HOME"); d != "" && filepath.IsAbs(d) {
+		return filepath.Join(d, "uonum")
+	}
+	if runtime.GOOS == "windows" {
+		if d := os.Getenv("LOCALAPPDATA"); d != "" {
+			return filepath.Join(d, "uonum")
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "uonum"
+	}
+
+	return filepath.Join(home, ".local", "share", "uonum")
+}
+
+func defaultDBPath() string {
+	return filepath.Join(dataDir(), "uonum.db")
+}
+
+// legacyDBPath returns the path of the database used by default before,
+// or "" if there is no home directory.
+func legacyDBPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, "nonum.db")
+}
+
+// prepareDB creates the directory of the default database, and moves the
+// legacy database to it if there is no database yet.
+func prepareDB(name string) error {
+	if name != defaultDBName {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(name), 0700)
+	if err != nil {
+		return fmt.Errorf("could not create the data directory [%s]: %w", filepath.Dir(name), err)
+	}
+
+	if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	legacy := legacyDBPath()
+	if legacy == "" {
+		return nil
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return nil
+	}
+
+	err = moveFile(legacy, name)
+	if err != nil {
+		return fmt.Errorf("could not move the database [%s] to [%s]: %w", legacy, name, err)
+	}
+	fmt.Fprintf(os.Stderr, "Moved the database [%s] to [%s].\n", legacy, name)
+
+	return nil
+}
+
+// rename is os.Rename, replaced in the tests.
+var rename = os.Rename
+
+// moveFile moves the file src to dst, or copies it and removes src if they
+// are on different file systems, where it can not be renamed.
+func moveFile(src, dst string) error {
+	err := rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	err = copyFile(src, dst)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+// copyFile copies the file src to dst through a temporary file beside dst,
+// which is synced before it is renamed to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".move*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, in)
+	if err == nil {
+		err = tmp.Chmod(fi.Mode().Perm())
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), dst)
+}
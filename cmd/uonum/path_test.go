@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestMoveFile(t *testing.T) {
+	tests := []struct {
+		name string
+		// renameErr is the error of the rename, which is done if it is nil
+		renameErr error
+		wantErr   bool
+	}{
+		{name: "renamed"},
+		{name: "another file system", renameErr: syscall.EXDEV},
+		{name: "failed", renameErr: syscall.EACCES, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := rename
+			rename = func(src, dst string) error {
+				if tt.renameErr != nil {
+					return &os.LinkError{Op: "rename", Old: src, New: dst, Err: tt.renameErr}
+				}
+				return os.Rename(src, dst)
+			}
+			defer func() { rename = old }()
+
+			dir := t.TempDir()
+			src, dst := filepath.Join(dir, "nonum.db"), filepath.Join(dir, "data", "uonum.db")
+			if err := os.WriteFile(src, []byte("db"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Mkdir(filepath.Dir(dst), 0700); err != nil {
+				t.Fatal(err)
+			}
+
+			err := moveFile(src, dst)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("moveFile() succeeded, want an error")
+				}
+				if _, err := os.Stat(src); err != nil {
+					t.Errorf("the file is not left: %v", err)
+				}
+				if _, err := os.Stat(dst); !os.IsNotExist(err) {
+					t.Errorf("the destination is made: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, err := os.ReadFile(dst); err != nil || string(got) != "db" {
+				t.Errorf("moved file = %q, %v, want db", got, err)
+			}
+			fi, err := os.Stat(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode().Perm() != 0600 {
+				t.Errorf("mode of the moved file = %v, want 0600", fi.Mode().Perm())
+			}
+			if _, err := os.Stat(src); !os.IsNotExist(err) {
+				t.Errorf("the file is left: %v", err)
+			}
+			if files, _ := filepath.Glob(dst + ".move*"); len(files) > 0 {
+				t.Errorf("the temporary files %q are left", files)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const progressWidth = 30
+
+// progressBar renders the progress of register on a terminal.
+type progressBar struct {
+	w     io.Writer
+	start time.Time
+	last  time.Time
+	lines int
+	done  int
+	total int
+}
+
+func newProgressBar(w io.Writer) *progressBar {
+	now := time.Now()
+	return &progressBar{
+		w:     w,
+		start: now,
+		last:  now,
+	}
+}
+
+// update is called after each line is registered.
+func (p *progressBar) update(done, total int) {
+	p.lines++
+	p.done, p.total = done, total
+
+	now := time.Now()
+	if now.Sub(p.last) < 100*time.Millisecond && done != total {
+		return
+	}
+	p.last = now
+	p.render(done, total, now)
+}
+
+func (p *progressBar) render(done, total int, now time.Time) {
+	elapsed := now.Sub(p.start)
+	rate := float64(p.lines) / elapsed.Seconds()
+
+	if total <= 0 {
+		fmt.Fprintf(p.w, "\r%d lines %.0f lines/s %s", p.lines, rate, elapsed.Round(time.Second))
+		return
+	}
+
+	ratio := float64(done) / float64(total)
+	filled := int(ratio * progressWidth)
+	var eta time.Duration
+	if done > 0 {
+		eta = time.Duration(float64(elapsed) * float64(total-done) / float64(done))
+	}
+	fmt.Fprintf(p.w, "\r[%s%s] %3.0f%% %d lines %.0f lines/s ETA %s ",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled),
+		ratio*100, p.lines, rate, eta.Round(time.Second))
+}
+
+// finish renders the final progress and ends the line.
+func (p *progressBar) finish() {
+	p.render(p.done, p.total, time.Now())
+	fmt.Fprintln(p.w)
+}
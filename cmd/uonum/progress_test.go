@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestProgressBar(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		done, total int
+		want        string
+	}{
+		{
+			name: "half",
+			done: 50, total: 100,
+			want: "\r[===============               ]  50% 10 lines 5 lines/s ETA 2s ",
+		},
+		{
+			name: "done",
+			done: 100, total: 100,
+			want: "\r[==============================] 100% 10 lines 5 lines/s ETA 0s ",
+		},
+		{
+			name: "unknown total",
+			done: 50, total: -1,
+			want: "\r10 lines 5 lines/s 2s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &progressBar{w: &buf, start: start, last: start, lines: 10}
+			p.render(tt.done, tt.total, start.Add(2*time.Second))
+			if got := buf.String(); got != tt.want {
+				t.Errorf("render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// proxies are the networks of the trusted reverse proxies.
+type proxies []*net.IPNet
+
+// parseProxies parses the comma separated addresses or CIDR blocks of the
+// proxies.
+func parseProxies(s string) (proxies, error) {
+	var p proxies
+	for _, item := range splitList(s) {
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("Invalid address of a proxy [%s].", item)
+			}
+			bits := 8 * len(ip.To4())
+			if bits == 0 {
+				bits = 8 * net.IPv6len
+			}
+			p = append(p, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network of proxies [%s]: %w", item, err)
+		}
+		p = append(p, n)
+	}
+
+	return p, nil
+}
+
+func (p proxies) trusted(addr string) bool {
+	ip := net.ParseIP(strings.TrimSpace(addr))
+	if ip == nil {
+		return false
+	}
+	for _, n := range p {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// realIP replaces the address of the requests to h from the trusted proxies
+// with the client address in X-Forwarded-For (see client).
+func (p proxies) realIP(h http.Handler) http.Handler {
+	if len(p) == 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := clientAddr(r)
+		if client := p.client(addr, r.Header.Values("X-Forwarded-For")); client != addr {
+			r.RemoteAddr = client
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// client returns the address of the client of a request from addr, which is
+// the last one in the X-Forwarded-For values forwarded not of the proxies if
+// addr is a trusted proxy.
+func (p proxies) client(addr string, forwarded []string) string {
+	if !p.trusted(addr) {
+		return addr
+	}
+
+	var addrs []string
+	for _, v := range forwarded {
+		addrs = append(addrs, strings.Split(v, ",")...)
+	}
+	for i := len(addrs) - 1; i >= 0; i-- {
+		a := strings.TrimSpace(addrs[i])
+		if net.ParseIP(a) == nil {
+			break
+		}
+		addr = a
+		if !p.trusted(a) {
+			break
+		}
+	}
+
+	return addr
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kechako/uonum/uonumtest"
+)
+
+func TestParseProxies(t *testing.T) {
+	tests := []struct {
+		s           string
+		wantTrusted []string
+		wantOthers  []string
+		wantErr     bool
+	}{
+		{s: "", wantOthers: []string{"127.0.0.1"}},
+		{
+			s:           "127.0.0.1, 10.0.0.0/8",
+			wantTrusted: []string{"127.0.0.1", "10.1.2.3", " 10.0.0.1 "},
+			wantOthers:  []string{"127.0.0.2", "192.0.2.1", "localhost", ""},
+		},
+		{
+			s:           "::1,fd00::/8",
+			wantTrusted: []string{"::1", "fd12::1"},
+			wantOthers:  []string{"::2", "127.0.0.1"},
+		},
+		{s: "localhost", wantErr: true},
+		{s: "10.0.0.0/33", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			p, err := parseProxies(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProxies() error = %v, want error %v", err, tt.wantErr)
+			}
+			for _, addr := range tt.wantTrusted {
+				if !p.trusted(addr) {
+					t.Errorf("trusted(%q) = false, want true", addr)
+				}
+			}
+			for _, addr := range tt.wantOthers {
+				if p.trusted(addr) {
+					t.Errorf("trusted(%q) = true, want false", addr)
+				}
+			}
+		})
+	}
+}
+
+func TestProxiesClient(t *testing.T) {
+	p, err := parseProxies("127.0.0.1,10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		addr      string
+		forwarded []string
+		want      string
+	}{
+		{name: "direct", addr: "192.0.2.1", forwarded: []string{"198.51.100.1"}, want: "192.0.2.1"},
+		{name: "proxied", addr: "127.0.0.1", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{
+			name:      "spoofed",
+			addr:      "127.0.0.1",
+			forwarded: []string{"203.0.113.9, 198.51.100.1"},
+			want:      "198.51.100.1",
+		},
+		{
+			name:      "proxies",
+			addr:      "127.0.0.1",
+			forwarded: []string{"203.0.113.9", "198.51.100.1, 10.0.0.2"},
+			want:      "198.51.100.1",
+		},
+		{name: "all proxies", addr: "127.0.0.1", forwarded: []string{"10.0.0.2"}, want: "10.0.0.2"},
+		{name: "invalid", addr: "127.0.0.1", forwarded: []string{"unknown"}, want: "127.0.0.1"},
+		{name: "none", addr: "127.0.0.1", want: "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.client(tt.addr, tt.forwarded); got != tt.want {
+				t.Errorf("client(%q, %q) = %q, want %q", tt.addr, tt.forwarded, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxiesRealIP(t *testing.T) {
+	p, err := parseProxies("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		proxies   proxies
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{name: "proxied", proxies: p, remote: "127.0.0.1:1000", forwarded: "198.51.100.1", want: "198.51.100.1"},
+		{name: "direct", proxies: p, remote: "192.0.2.1:1000", forwarded: "198.51.100.1", want: "192.0.2.1:1000"},
+		{name: "no proxies", remote: "127.0.0.1:1000", forwarded: "198.51.100.1", want: "127.0.0.1:1000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := tt.proxies.realIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	s := &server{
+		tenants: newTenantPool(uonumtest.New(1), "", 0, nil),
+		metrics: newMetrics(),
+		log:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		base:    "/uonum",
+	}
+	h := s.handler()
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{target: "/uonum/stats", want: http.StatusOK},
+		{target: "/uonum/metrics", want: http.StatusOK},
+		{target: "/stats", want: http.StatusNotFound},
+		{target: "/other/stats", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kechako/uonum"
+)
+
+const (
+	// maxPageSize is the maximum size of a fetched page.
+	maxPageSize = 10 << 20
+	// minBlockLen is the minimum number of the characters of a block of
+	// an article.
+	minBlockLen = 25
+	// maxLinkDensity is the maximum ratio of the characters in the links
+	// to all of a block of an article.
+	maxLinkDensity = 0.5
+)
+
+// htmlBlock is the text of a block element.
+type htmlBlock struct {
+	text string
+	// linkLen is the number of the characters in the links
+	linkLen int
+	// article is true if the block is in an article or main element
+	article bool
+}
+
+// the elements whose contents are never sentences
+var htmlRawElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"textarea": true, "svg": true, "math": true, "iframe": true,
+	"pre": true,
+}
+
+// the elements of the boilerplate around an article
+var htmlBoilerplate = map[string]bool{
+	"nav": true, "header": true, "footer": true, "aside": true,
+	"form": true, "menu": true, "button": true, "select": true,
+}
+
+var htmlBlockElements = map[string]bool{
+	"p": true, "div": true, "li": true, "ul": true, "ol": true, "dl": true,
+	"dd": true, "dt": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "article": true, "section": true, "main": true,
+	"blockquote": true, "table": true, "tr": true, "td": true,
+	"th": true, "br": true, "hr": true, "body": true, "figcaption": true,
+	"title": true,
+}
+
+// htmlBlocks splits an HTML document into the texts of its block elements,
+// and returns them with the title. The contents of the boilerplate
+// elements such as nav and of the code blocks are skipped.
+func htmlBlocks(doc string) (string, []htmlBlock) {
+	var (
+		blocks  []htmlBlock
+		title   strings.Builder
+		text    strings.Builder
+		linkLen int
+		inTitle bool
+		inLink  int
+		skip    int
+		article int
+	)
+	flush := func() {
+		s := collapseSpace(html.UnescapeString(text.String()))
+		if s != "" {
+			blocks = append(blocks, htmlBlock{text: s, linkLen: linkLen, article: article > 0})
+		}
+		text.Reset()
+		linkLen = 0
+	}
+
+	for len(doc) > 0 {
+		i := strings.IndexByte(doc, '<')
+		if i < 0 {
+			i = len(doc)
+		}
+		if i > 0 {
+			switch {
+			case inTitle:
+				title.WriteString(doc[:i])
+			case skip == 0:
+				text.WriteString(doc[:i])
+				if inLink > 0 {
+					linkLen += utf8.RuneCountInString(strings.TrimSpace(doc[:i]))
+				}
+			}
+			doc = doc[i:]
+			continue
+		}
+
+		if strings.HasPrefix(doc, "<!--") {
+			end := strings.Index(doc, "-->")
+			if end < 0 {
+				break
+			}
+			doc = doc[end+3:]
+			continue
+		}
+		end := strings.IndexByte(doc, '>')
+		if end < 0 {
+			break
+		}
+		tag := doc[1:end]
+		doc = doc[end+1:]
+		if tag == "" || tag[0] == '!' || tag[0] == '?' {
+			continue
+		}
+
+		closing := tag[0] == '/'
+		selfClosing := strings.HasSuffix(tag, "/")
+		var name string
+		if f := strings.Fields(strings.TrimPrefix(tag, "/")); len(f) > 0 {
+			name = strings.ToLower(strings.TrimSuffix(f[0], "/"))
+		}
+
+		if htmlRawElements[name] && !closing && !selfClosing {
+			end := strings.Index(strings.ToLower(doc), "</"+name)
+			if end < 0 {
+				break
+			}
+			doc = doc[end:]
+			continue
+		}
+
+		if htmlBlockElements[name] {
+			flush()
+		}
+		switch {
+		case name == "title":
+			inTitle = !closing
+		case name == "a":
+			if closing {
+				inLink = max(inLink-1, 0)
+			} else if !selfClosing {
+				inLink++
+			}
+		case name == "article" || name == "main":
+			if closing {
+				article = max(article-1, 0)
+			} else {
+				article++
+			}
+		case htmlBoilerplate[name] && !selfClosing:
+			if closing {
+				skip = max(skip-1, 0)
+			} else {
+				skip++
+			}
+		}
+	}
+	flush()
+
+	return collapseSpace(html.UnescapeString(title.String())), blocks
+}
+
+// collapseSpace collapses the white spaces into a space, except between two
+// wide characters such as Japanese, where they are line breaks of the
+// source.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	var prev rune
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 && !(isWide(prev) && isWide(r)) {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+		prev = r
+	}
+
+	return b.String()
+}
+
+func isWide(r rune) bool {
+	return r >= 0x2e80
+}
+
+// articleText returns the paragraphs of the article in an HTML document,
+// without the blocks of the boilerplate, the short ones and the ones mostly
+// of links. The blocks in the article or main elements are preferred.
+func articleText(doc string) (string, []string) {
+	title, blocks := htmlBlocks(doc)
+
+	inArticle := false
+	for _, b := range blocks {
+		if b.article {
+			inArticle = true
+			break
+		}
+	}
+
+	var paragraphs []string
+	for _, b := range blocks {
+		if inArticle && !b.article {
+			continue
+		}
+		n := utf8.RuneCountInString(b.text)
+		if n < minBlockLen || float64(b.linkLen)/float64(n) > maxLinkDensity {
+			continue
+		}
+		paragraphs = append(paragraphs, b.text)
+	}
+
+	return title, paragraphs
+}
+
+// fetchArticle downloads the page at rawurl, and returns its title and the
+// paragraphs of its article.
+func fetchArticle(ctx context.Context, rawurl string) (string, []string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawurl, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid URL [%s]: %w", rawurl, err)
+	}
+	req.Header.Set("User-Agent", "uonum")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not fetch the page [%s]: %w", rawurl, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return "", nil, fmt.Errorf("Could not fetch the page [%s]: %s.", rawurl, res.Status)
+	}
+
+	charset := uonum.EncodingAuto
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil && params["charset"] != "" {
+		charset = params["charset"]
+	}
+	r, err := uonum.DecodeReader(io.LimitReader(res.Body, maxPageSize), charset)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not read the page [%s]: %w", rawurl, err)
+	}
+
+	title, paragraphs := articleText(string(data))
+	return title, paragraphs, nil
+}
+
+// registerPage registers the paragraphs of the article at rawurl.
+func registerPage(g uonum.Learner, rawurl string, meta uonum.Meta) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	title, paragraphs, err := fetchArticle(ctx, rawurl)
+	if err != nil {
+		return err
+	}
+	if len(paragraphs) == 0 {
+		return fmt.Errorf("No article is found in [%s].", rawurl)
+	}
+
+	if meta.Source == "" {
+		meta.Source = "web"
+	}
+	meta.Fields = map[string]string{"url": rawurl}
+	for _, p := range paragraphs {
+		if err := g.RegisterWithMeta(p, meta); err != nil {
+			return err
+		}
+	}
+	logger.Info("Registered the page.", "url", rawurl, "title", title, "paragraphs", len(paragraphs))
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/kechako/uonum"
+	"github.com/kechako/uonum/uonumtest"
+	"golang.org/x/text/encoding/japanese"
+)
+
+const testPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>猫の日記 &amp; 記録</title>
+<style>p { color: red; }</style>
+<script>document.write("<p>スクリプトの中の文章は登録されない文章です。</p>");</script>
+</head>
+<body>
+<header><p>ヘッダーの中の文章はサイトの全てのページにある文章です。</p></header>
+<nav><ul><li><a href="/">トップ</a></li><li><a href="/about">このサイトについて</a></li></ul></nav>
+<p>記事の外の段落は記事があるときには登録されない文章です。</p>
+<article>
+<h1>猫</h1>
+<p>吾輩は猫である。名前はまだ無い。
+どこで生れたかとんと見当がつかぬ。</p>
+<!-- <p>コメントの中の文章は登録されない文章です。</p> -->
+<p>何でも<a href="/dark">薄暗いじめじめした所</a>でニャーニャー泣いていた事だけは記憶している。</p>
+<p><a href="/a">リンクばかりの段落は記事の本文ではない</a>です。</p>
+<pre>コードの中の文章は登録されない文章です。</pre>
+<p>短い段落。</p>
+</article>
+<footer><p>フッターの中の文章はサイトの全てのページにある文章です。</p></footer>
+</body>
+</html>`
+
+var testPageParagraphs = []string{
+	"吾輩は猫である。名前はまだ無い。どこで生れたかとんと見当がつかぬ。",
+	"何でも薄暗いじめじめした所でニャーニャー泣いていた事だけは記憶している。",
+}
+
+func TestCollapseSpace(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "", want: ""},
+		{s: "  猫  ", want: "猫"},
+		{s: "吾輩は\n  猫である。", want: "吾輩は猫である。"},
+		{s: "I am\n\ta cat.", want: "I am a cat."},
+		{s: "猫 cat\n猫", want: "猫 cat 猫"},
+	}
+
+	for _, tt := range tests {
+		if got := collapseSpace(tt.s); got != tt.want {
+			t.Errorf("collapseSpace(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestArticleText(t *testing.T) {
+	tests := []struct {
+		name           string
+		doc            string
+		wantTitle      string
+		wantParagraphs []string
+	}{
+		{
+			name:           "article",
+			doc:            testPage,
+			wantTitle:      "猫の日記 & 記録",
+			wantParagraphs: testPageParagraphs,
+		},
+		{
+			name:      "no article",
+			doc:       "<body><p>記事の要素がないときには全ての段落から本文を探します。</p><div>短い段落。</div></body>",
+			wantTitle: "",
+			wantParagraphs: []string{
+				"記事の要素がないときには全ての段落から本文を探します。",
+			},
+		},
+		{
+			name:           "main",
+			doc:            "<p>記事の外の段落は記事があるときには登録されない文章です。</p><main><p>メインの要素の中の段落は記事の本文として登録されます。</p></main>",
+			wantParagraphs: []string{"メインの要素の中の段落は記事の本文として登録されます。"},
+		},
+		{
+			name: "unclosed",
+			doc:  "<p>閉じていないスクリプトの前の段落は本文として登録されます。</p><script>",
+			wantParagraphs: []string{
+				"閉じていないスクリプトの前の段落は本文として登録されます。",
+			},
+		},
+		{name: "empty", doc: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, paragraphs := articleText(tt.doc)
+			if title != tt.wantTitle {
+				t.Errorf("articleText() title = %q, want %q", title, tt.wantTitle)
+			}
+			if !reflect.DeepEqual(paragraphs, tt.wantParagraphs) {
+				t.Errorf("articleText() = %q, want %q", paragraphs, tt.wantParagraphs)
+			}
+		})
+	}
+}
+
+func TestFetchArticle(t *testing.T) {
+	sjis, err := japanese.ShiftJIS.NewEncoder().String(testPage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/utf8":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(testPage))
+		case "/sjis":
+			w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+			w.Write([]byte(sjis))
+		case "/auto":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(sjis))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: "/utf8"},
+		{path: "/sjis"},
+		{path: "/auto"},
+		{path: "/missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			title, paragraphs, err := fetchArticle(context.Background(), srv.URL+tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchArticle() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if title != "猫の日記 & 記録" {
+				t.Errorf("fetchArticle() title = %q", title)
+			}
+			if !reflect.DeepEqual(paragraphs, testPageParagraphs) {
+				t.Errorf("fetchArticle() = %q, want %q", paragraphs, testPageParagraphs)
+			}
+		})
+	}
+}
+
+func TestRegisterPage(t *testing.T) {
+	if logger == nil {
+		logger = newLogger()
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/empty" {
+			w.Write([]byte("<p>短い段落。</p>"))
+			return
+		}
+		w.Write([]byte(testPage))
+	}))
+	t.Cleanup(srv.Close)
+
+	g := &feedGenerator{Generator: uonumtest.New(1)}
+	if err := registerPage(g, srv.URL+"/page", uonum.Meta{Author: "たま"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []uonum.Record{
+		{Text: testPageParagraphs[0], Meta: uonum.Meta{Source: "web", Author: "たま", Fields: map[string]string{"url": srv.URL + "/page"}}},
+		{Text: testPageParagraphs[1], Meta: uonum.Meta{Source: "web", Author: "たま", Fields: map[string]string{"url": srv.URL + "/page"}}},
+	}
+	if !reflect.DeepEqual(g.records, want) {
+		t.Errorf("registered %+v, want %+v", g.records, want)
+	}
+
+	if err := registerPage(g, srv.URL+"/empty", uonum.Meta{}); err == nil {
+		t.Error("registerPage() = nil, want error of no article")
+	}
+}
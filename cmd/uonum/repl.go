@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kechako/uonum"
+)
+
+// maxHistory is the number of the lines kept in the history file.
+const maxHistory = 1000
+
+const replHelp = `Type a trigger word to generate a sentence, or a command:
+    :register <text>  register the text
+    :stats            print the sizes of the model
+    :seed <n>         seed the random numbers
+    :help             print this help
+    :quit             quit
+`
+
+func repl(fs *flag.FlagSet) runner {
+	conversationFlags(fs)
+	tw := fs.String("term-words", "", termWordsUsage)
+	history := fs.String("history", filepath.Join(dataDir(), "repl_history"), "History file of the lines (none if empty).")
+
+	return func(args []string) (int, error) {
+		g, err := openGenerator(termWordsOption(*tw)...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+		g = g.In(ns)
+
+		e := newLineEditor(os.Stdin, os.Stdout, readHistory(*history))
+		defer writeHistory(*history, e)
+
+		for {
+			line, err := e.readLine("uonum> ")
+			if errors.Is(err, errInterrupted) {
+				continue
+			}
+			if err == io.EOF {
+				return 0, nil
+			}
+			if err != nil {
+				return 1, err
+			}
+
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			e.addHistory(line)
+
+			quit, err := replLine(g, line)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			if quit {
+				return 0, nil
+			}
+		}
+	}
+}
+
+// replLine runs a line of the REPL. quit is true if the REPL should end.
+func replLine(g uonum.Generator, line string) (quit bool, err error) {
+	if !strings.HasPrefix(line, ":") {
+		text, err := g.Generate(line)
+		if err != nil {
+			return false, err
+		}
+		fmt.Println(text)
+		return false, nil
+	}
+
+	cmd, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		cmd, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch cmd {
+	case ":register", ":r":
+		if arg == "" {
+			return false, errors.New("Text is required.")
+		}
+		err := g.Register(arg)
+		if err != nil {
+			return false, err
+		}
+		err = g.Flush()
+		if err != nil {
+			return false, err
+		}
+		fmt.Println("Registered.")
+	case ":stats":
+		s, err := g.Stats()
+		if err != nil {
+			return false, err
+		}
+		fmt.Printf("words: %d\nlinks: %d\ncount: %d\ntexts: %d\n", s.Words, s.Links, s.Count, s.Texts)
+	case ":seed":
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("Invalid seed [%s].", arg)
+		}
+		uonum.Seed(n)
+	case ":help", ":h":
+		fmt.Print(replHelp)
+	case ":quit", ":q", ":exit":
+		return true, nil
+	default:
+		return false, fmt.Errorf("Unknown command [%s]. Type :help for the commands.", cmd)
+	}
+
+	return false, nil
+}
+
+// readHistory returns the lines in the history file, or nothing if
+// there is none.
+func readHistory(name string) []string {
+	if name == "" {
+		return nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var lines []string
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		if s.Text() != "" {
+			lines = append(lines, s.Text())
+		}
+	}
+
+	return lines
+}
+
+// writeHistory writes the last lines of the history of e to the file.
+func writeHistory(name string, e *lineEditor) {
+	if name == "" {
+		return
+	}
+	lines := e.history
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		return
+	}
+	os.WriteFile(name, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
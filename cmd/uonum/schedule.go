@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func schedule(fs *flag.FlagSet) runner {
+	speakFlags(fs)
+	expr := fs.String("cron", "", "Cron expression of the times to post at (e.g. \"0 9-21/3 * * *\" or @hourly).")
+	tz := fs.String("tz", "", "Time zone of the cron expression (e.g. Asia/Tokyo; the local one if empty).")
+	triggers := fs.String("trigger", "", "Comma separated trigger words, one of which is chosen each time (a random one if empty).")
+	out := addSinkFlags(fs)
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		if *expr == "" {
+			return 2, errors.New("Cron expression is required.")
+		}
+		loc := time.Local
+		if *tz != "" {
+			var err error
+			loc, err = time.LoadLocation(*tz)
+			if err != nil {
+				return 2, fmt.Errorf("invalid time zone [%s]: %w", *tz, err)
+			}
+		}
+		c, err := parseCron(*expr, loc)
+		if err != nil {
+			return 2, err
+		}
+
+		names, sinks, err := out.open()
+		if err != nil {
+			return 2, err
+		}
+		defer closeSinks(sinks)
+
+		g, err := openGenerator(termWordsOption(*tw)...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+		g = g.In(ns)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		words := splitList(*triggers)
+		for {
+			at := c.next(time.Now())
+			if at.IsZero() {
+				return 1, fmt.Errorf("The cron expression [%s] never matches.", *expr)
+			}
+			logger.Debug("Scheduled.", "at", at)
+
+			timer := time.NewTimer(time.Until(at))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return 0, nil
+			case <-timer.C:
+			}
+
+			var trigger string
+			if len(words) > 0 {
+				trigger = words[rand.Intn(len(words))]
+			}
+			text, err := generateRandom(g, trigger)
+			if err != nil {
+				logger.Error("Could not generate a sentence.", "trigger", trigger, "err", err)
+				continue
+			}
+			if text == "" {
+				continue
+			}
+			for i, s := range sinks {
+				if err := s.Write(ctx, text); err != nil {
+					logger.Error("Could not deliver the sentence.", "to", names[i], "err", err)
+					continue
+				}
+				logger.Info("Delivered.", "to", names[i], "text", text)
+			}
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kechako/uonum"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+)
+
+// maxRequestBody is the maximum size of a request body.
+const maxRequestBody = 1 << 20
+
+// server serves the generators of the tenants over HTTP.
+type server struct {
+	tenants *tenantPool
+	// tenantBy is where the tenant of a request is: "header", "path", or
+	// empty for the single tenant
+	tenantBy string
+	webhook  webhookConfig
+	metrics  *metrics
+	log      *slog.Logger
+	guard    guard
+	proxies  proxies
+	// base is the path prefix of the API, or empty
+	base string
+}
+
+func (s *server) handler() http.Handler {
+	prefix := ""
+	if s.tenantBy == "path" {
+		prefix = "/{tenant}"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+prefix+"/generate", s.handleGenerate)
+	mux.HandleFunc("POST "+prefix+"/reply", s.handleReply)
+	mux.HandleFunc("POST "+prefix+"/register", s.handleRegister)
+	mux.HandleFunc("GET "+prefix+"/stats", s.handleStats)
+	mux.HandleFunc("POST "+prefix+"/webhook", s.handleWebhook)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+
+	var h http.Handler = mux
+	if s.base != "" {
+		h = http.StripPrefix(s.base, mux)
+	}
+	return s.proxies.realIP(s.logRequests(s.guard.protect(h)))
+}
+
+// statusWriter records the status of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// tenant returns the tenant of r, which must be released.
+func (s *server) tenant(r *http.Request) (*tenant, error) {
+	var name string
+	switch s.tenantBy {
+	case "header":
+		name = r.Header.Get(tenantHeader)
+		if name == "" {
+			return nil, fmt.Errorf("no %s header: %w", tenantHeader, errBadRequest)
+		}
+	case "path":
+		name = r.PathValue("tenant")
+	}
+
+	return s.tenants.acquire(name)
+}
+
+// logRequests logs the requests to h at the debug level, and the ones
+// failed by the server as errors.
+func (s *server) logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r)
+
+		level := slog.LevelDebug
+		if sw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		s.log.Log(r.Context(), level, "Request.",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", sw.status,
+			"duration", time.Since(start),
+			"remote", r.RemoteAddr)
+	})
+}
+
+// handleGenerate generates a sentence from the trigger in the query, or from
+// a random trigger word.
+func (s *server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	t, err := s.tenant(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer s.tenants.release(t)
+
+	start := time.Now()
+	t.mu.Lock()
+	text, err := generateRandom(t.g, r.URL.Query().Get("trigger"))
+	t.mu.Unlock()
+	s.metrics.generate("generate", start, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"text": text})
+}
+
+// handleReply generates a reply to the message in the form.
+func (s *server) handleReply(w http.ResponseWriter, r *http.Request) {
+	t, err := s.tenant(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer s.tenants.release(t)
+
+	start := time.Now()
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	t.mu.Lock()
+	text, err := t.g.Reply(r.FormValue("message"))
+	t.mu.Unlock()
+	s.metrics.generate("reply", start, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"text": text})
+}
+
+// handleRegister registers the texts in the body, one per line.
+func (s *server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	t, err := s.tenant(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer s.tenants.release(t)
+
+	body := http.MaxBytesReader(w, r.Body, maxRequestBody)
+	meta := uonum.Meta{Source: r.URL.Query().Get("source"), Author: r.URL.Query().Get("author")}
+	t.mu.Lock()
+	err = t.g.RegisterReaderWithMeta(body, meta)
+	t.mu.Unlock()
+	s.metrics.register("register", err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) handleStats(w http.ResponseWriter, r *http.Request) {
+	t, err := s.tenant(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer s.tenants.release(t)
+
+	t.mu.Lock()
+	st, err := t.g.Stats()
+	t.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, st)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err with the status of its kind.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var mbe *http.MaxBytesError
+	switch {
+	case errors.Is(err, uonum.ErrUnknownTrigger), errors.Is(err, uonum.ErrEmptyModel):
+		status = http.StatusNotFound
+	case errors.Is(err, uonum.ErrGenerationFailed):
+		status = http.StatusUnprocessableEntity
+	case errors.As(err, &mbe):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, errBadRequest):
+		status = http.StatusBadRequest
+	}
+
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+var errBadRequest = errors.New("Bad request.")
+
+func serve(fs *flag.FlagSet) runner {
+	conversationFlags(fs)
+	addr := fs.String("addr", ":8080", "Address to listen on.")
+	grpcAddr := fs.String("grpc", "", "Address to serve the gRPC service on as well (e.g. :9090).")
+	cache := fs.Int("cache", 0, "Number of the words kept in memory for the generation.")
+	apiKeys := fs.String("api-key", "", "Comma separated API keys, one of which is required in the requests as \"Authorization: Bearer <key>\" or \"X-API-Key: <key>\".")
+	rate := fs.Float64("rate", 0, "Requests per second allowed for each API key, or each client address without -api-key. 0 for no limit.")
+	burst := fs.Int("burst", 10, "Requests allowed at once over -rate.")
+	tlsCert := fs.String("tls-cert", "", "Certificate file to serve HTTPS, and gRPC over TLS.")
+	tlsKey := fs.String("tls-key", "", "Key file of -tls-cert.")
+	acmeDomains := fs.String("acme-domain", "", "Comma separated domains to obtain the certificate of from an ACME server (e.g. Let's Encrypt) instead of -tls-cert.")
+	acmeEmail := fs.String("acme-email", "", "Contact email of the ACME account.")
+	acmeDir := fs.String("acme-directory", letsEncrypt, "Directory URL of the ACME server.")
+	acmeHTTP := fs.String("acme-http", ":80", "Address to answer the ACME challenges on, redirecting the other requests to HTTPS.")
+	acmeCache := fs.String("acme-cache", filepath.Join(dataDir(), "acme"), "Directory of the ACME account key and the certificate.")
+	trusted := fs.String("trusted-proxies", "", "Comma separated addresses or CIDR blocks of the reverse proxies whose X-Forwarded-For is trusted.")
+	base := fs.String("base-path", "", "Path prefix of the API behind a reverse proxy (e.g. /uonum).")
+	tenantBy := fs.String("tenant", "", "Serve a model of each tenant named by the X-Tenant \"header\", or the first segment of the \"path\" (e.g. /<tenant>/generate).")
+	tenantDB := fs.String("tenant-db", "", "Database of each tenant with {tenant} replaced by the name (e.g. /var/lib/uonum/{tenant}.db), instead of a namespace of -db.")
+	maxTenants := fs.Int("max-tenants", 100, "Maximum number of the open databases of -tenant-db.")
+	field := fs.String("webhook-field", "text", "Dot separated path of the text in the JSON payload of /webhook (e.g. \"comment.body\").")
+	author := fs.String("webhook-author", "", "Dot separated path of the author in the JSON payload of /webhook (e.g. \"comment.user.login\").")
+	reply := fs.Bool("webhook-reply", false, "Respond to /webhook with a generated reply.")
+	replyField := fs.String("webhook-reply-field", "text", "Field of the reply in the response of /webhook.")
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		if (*tlsCert == "") != (*tlsKey == "") {
+			return 1, errors.New("Both -tls-cert and -tls-key are required.")
+		}
+		domains := splitList(*acmeDomains)
+		if len(domains) > 0 && *tlsCert != "" {
+			return 1, errors.New("-acme-domain can not be used with -tls-cert.")
+		}
+		p, err := parseProxies(*trusted)
+		if err != nil {
+			return 1, err
+		}
+		switch *tenantBy {
+		case "", "header", "path":
+		default:
+			return 1, fmt.Errorf("Unknown tenant [%s].", *tenantBy)
+		}
+		if *tenantDB != "" && (*tenantBy == "" || !strings.Contains(*tenantDB, "{tenant}")) {
+			return 1, errors.New("-tenant-db needs -tenant and {tenant} in the name.")
+		}
+
+		opts, err := generatorOptions(append(termWordsOption(*tw), uonum.WithCache(*cache))...)
+		if err != nil {
+			return 1, err
+		}
+		// the tenants of their databases do not share -db
+		var shared uonum.Generator
+		if *tenantDB == "" {
+			g, err := openGeneratorAt(dbName, opts...)
+			if err != nil {
+				return 1, err
+			}
+			defer g.Close()
+			shared = g.In(ns)
+		}
+		tenantOpts := append(opts[:len(opts):len(opts)], uonum.WithLockTimeout(tenantLockTimeout))
+		tenants := newTenantPool(shared, *tenantDB, *maxTenants, func(name string) (uonum.Generator, error) {
+			if !strings.Contains(name, "://") {
+				if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+					return nil, fmt.Errorf("could not create the directory: %w", err)
+				}
+			}
+			return openGeneratorAt(name, tenantOpts...)
+		})
+		defer tenants.Close()
+
+		s := &server{
+			tenants:  tenants,
+			tenantBy: *tenantBy,
+			webhook: webhookConfig{
+				field:      *field,
+				author:     *author,
+				reply:      *reply,
+				replyField: *replyField,
+			},
+			metrics: newMetrics(),
+			log:     logger,
+			guard:   guard{keys: splitList(*apiKeys)},
+			proxies: p,
+			base:    strings.TrimRight(*base, "/"),
+		}
+		if s.base != "" && !strings.HasPrefix(s.base, "/") {
+			s.base = "/" + s.base
+		}
+		if *rate > 0 {
+			s.guard.limiter = newRateLimiter(*rate, *burst)
+		}
+		var tlsConfig *tls.Config
+		var acme *autocert.Manager
+		switch {
+		case *tlsCert != "":
+			cert, err := tls.LoadX509KeyPair(*tlsCert, *tlsKey)
+			if err != nil {
+				return 1, fmt.Errorf("could not load the certificate: %w", err)
+			}
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+		case len(domains) > 0:
+			acme = newACMEManager(*acmeDir, *acmeEmail, domains, *acmeCache)
+			tlsConfig = acme.TLSConfig()
+			tlsConfig.MinVersion = tls.VersionTLS12
+		}
+
+		srvs := []*http.Server{{
+			Addr:              *addr,
+			Handler:           s.handler(),
+			TLSConfig:         tlsConfig,
+			ReadHeaderTimeout: 10 * time.Second,
+		}}
+		if acme != nil {
+			srvs = append(srvs, &http.Server{
+				Addr:              *acmeHTTP,
+				Handler:           acme.HTTPHandler(httpsRedirect(*addr)),
+				ReadHeaderTimeout: 10 * time.Second,
+			})
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		errs := make(chan error, len(srvs)+1)
+		for _, srv := range srvs {
+			go func(srv *http.Server) {
+				s.log.Info("Listening.", "addr", srv.Addr, "tls", srv.TLSConfig != nil)
+				var err error
+				if srv.TLSConfig != nil {
+					err = srv.ListenAndServeTLS("", "")
+				} else {
+					err = srv.ListenAndServe()
+				}
+				if err != nil && !errors.Is(err, http.ErrServerClosed) {
+					err = fmt.Errorf("could not serve on [%s]: %w", srv.Addr, err)
+				} else {
+					err = nil
+				}
+				errs <- err
+			}(srv)
+		}
+		var gs *grpc.Server
+		if *grpcAddr != "" {
+			l, err := net.Listen("tcp", *grpcAddr)
+			if err != nil {
+				return 1, fmt.Errorf("could not listen on [%s]: %w", *grpcAddr, err)
+			}
+			gs = s.grpcServer(tlsConfig)
+			go func() {
+				s.log.Info("Listening.", "addr", *grpcAddr, "tls", tlsConfig != nil, "grpc", true)
+				if err := gs.Serve(l); err != nil {
+					errs <- fmt.Errorf("could not serve gRPC on [%s]: %w", *grpcAddr, err)
+				}
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+		case err = <-errs:
+		}
+		sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		for _, srv := range srvs {
+			srv.Shutdown(sctx)
+		}
+		if gs != nil {
+			stopGRPC(sctx, gs)
+		}
+		if err != nil {
+			return 1, err
+		}
+
+		return 0, nil
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const outputUsage = "Comma separated destinations of the sentences: stdout, [file:]<path>, post:<url>, mastodon or discord:<channel ID>."
+
+// sink is a destination of the generated sentences.
+type sink interface {
+	Write(ctx context.Context, text string) error
+	Close() error
+}
+
+// writerSink writes the sentences to w, one per line.
+type writerSink struct {
+	w io.WriteCloser
+}
+
+func (s *writerSink) Write(ctx context.Context, text string) error {
+	_, err := fmt.Fprintln(s.w, text)
+	return err
+}
+
+func (s *writerSink) Close() error {
+	if s.w == os.Stdout {
+		return nil
+	}
+
+	return s.w.Close()
+}
+
+// postSink posts each sentence as a JSON object {"text": text} to url.
+type postSink struct {
+	url  string
+	http *http.Client
+}
+
+func (s *postSink) Write(ctx context.Context, text string) error {
+	data, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("[POST %s] HTTP error: %s.", s.url, res.Status)
+	}
+
+	return nil
+}
+
+func (s *postSink) Close() error {
+	return nil
+}
+
+// mastodonSink posts each sentence as a status.
+type mastodonSink struct {
+	c          *mastodonClient
+	visibility string
+}
+
+func (s *mastodonSink) Write(ctx context.Context, text string) error {
+	return s.c.post(ctx, text, "", s.visibility)
+}
+
+func (s *mastodonSink) Close() error {
+	return nil
+}
+
+// discordSink sends each sentence as a message to a channel.
+type discordSink struct {
+	s       *discordgo.Session
+	channel string
+}
+
+func (s *discordSink) Write(ctx context.Context, text string) error {
+	_, err := s.s.ChannelMessageSend(s.channel, text, discordgo.WithContext(ctx))
+	return err
+}
+
+func (s *discordSink) Close() error {
+	return nil
+}
+
+// sinkFlags are the flags of -output and of the accounts of its chat
+// destinations.
+type sinkFlags struct {
+	output         *string
+	mastodonServer *string
+	mastodonToken  *string
+	visibility     *string
+	discordToken   *string
+}
+
+func addSinkFlags(fs *flag.FlagSet) *sinkFlags {
+	return &sinkFlags{
+		output:         fs.String("output", "stdout", outputUsage),
+		mastodonServer: fs.String("mastodon-server", "", "URL of the Mastodon server of the mastodon output."),
+		mastodonToken:  fs.String("mastodon-token", "", "Access token of the Mastodon account, with the write scope."),
+		visibility:     fs.String("visibility", "unlisted", "Visibility of the Mastodon statuses."),
+		discordToken:   fs.String("discord-token", "", "Token of the Discord bot of the discord outputs."),
+	}
+}
+
+// open returns the names and the sinks of -output. The sinks must be closed.
+func (f *sinkFlags) open() ([]string, []sink, error) {
+	names := splitList(*f.output)
+	if len(names) == 0 {
+		return nil, nil, errors.New("Output is required.")
+	}
+
+	var sinks []sink
+	for _, name := range names {
+		s, err := f.parse(name)
+		if err != nil {
+			closeSinks(sinks)
+			return nil, nil, err
+		}
+		sinks = append(sinks, s)
+	}
+
+	return names, sinks, nil
+}
+
+// parse parses an output: stdout (or -), file:<path> appended to (or a path
+// without a colon), post:<url>, mastodon, or discord:<channel ID>.
+func (f *sinkFlags) parse(s string) (sink, error) {
+	if s == "-" {
+		s = "stdout"
+	}
+	kind, arg, _ := strings.Cut(s, ":")
+	switch kind {
+	case "stdout":
+		return &writerSink{w: os.Stdout}, nil
+	case "file":
+		if arg == "" {
+			return nil, errors.New("Path of the file output is required.")
+		}
+		file, err := os.OpenFile(arg, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("could not open the output file [%s]: %w", arg, err)
+		}
+		return &writerSink{w: file}, nil
+	case "post":
+		if arg == "" {
+			return nil, errors.New("URL of the post output is required.")
+		}
+		return &postSink{url: arg, http: http.DefaultClient}, nil
+	case "mastodon":
+		if *f.mastodonServer == "" || *f.mastodonToken == "" {
+			return nil, errors.New("Mastodon server and token are required.")
+		}
+		c := &mastodonClient{server: *f.mastodonServer, token: *f.mastodonToken, http: http.DefaultClient}
+		return &mastodonSink{c: c, visibility: *f.visibility}, nil
+	case "discord":
+		if *f.discordToken == "" {
+			return nil, errors.New("Discord token is required.")
+		}
+		if arg == "" {
+			return nil, errors.New("Channel ID of the discord output is required.")
+		}
+		ds, err := discordgo.New("Bot " + *f.discordToken)
+		if err != nil {
+			return nil, err
+		}
+		return &discordSink{s: ds, channel: arg}, nil
+	}
+
+	if !strings.Contains(s, ":") {
+		return f.parse("file:" + s)
+	}
+
+	return nil, fmt.Errorf("Unknown output [%s].", s)
+}
+
+func closeSinks(sinks []sink) error {
+	var errs []error
+	for _, s := range sinks {
+		errs = append(errs, s.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// writeSinks writes the sentence to each of the sinks.
+func writeSinks(sinks []sink, text string) error {
+	for _, s := range sinks {
+		if err := s.Write(context.Background(), text); err != nil {
+			return fmt.Errorf("could not write the sentence: %w", err)
+		}
+	}
+
+	return nil
+}
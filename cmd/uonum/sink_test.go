@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newSinkFlags returns the sink flags parsed from args.
+func newSinkFlags(t *testing.T, args ...string) *sinkFlags {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := addSinkFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestSinkParse(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "default", want: []string{"*main.writerSink"}},
+		{name: "dash", args: []string{"-output", "-"}, want: []string{"*main.writerSink"}},
+		{
+			name: "files",
+			args: []string{"-output", "file:" + filepath.Join(dir, "a.txt") + "," + filepath.Join(dir, "b.txt")},
+			want: []string{"*main.writerSink", "*main.writerSink"},
+		},
+		{name: "post", args: []string{"-output", "post:http://127.0.0.1/hook"}, want: []string{"*main.postSink"}},
+		{
+			name: "mastodon",
+			args: []string{"-output", "mastodon", "-mastodon-server", "https://example.com", "-mastodon-token", "token"},
+			want: []string{"*main.mastodonSink"},
+		},
+		{
+			name: "discord",
+			args: []string{"-output", "discord:123", "-discord-token", "token"},
+			want: []string{"*main.discordSink"},
+		},
+		{name: "empty", args: []string{"-output", ""}, wantErr: true},
+		{name: "no path", args: []string{"-output", "file:"}, wantErr: true},
+		{name: "no directory", args: []string{"-output", filepath.Join(dir, "missing", "a.txt")}, wantErr: true},
+		{name: "no url", args: []string{"-output", "post:"}, wantErr: true},
+		{name: "no mastodon token", args: []string{"-output", "mastodon"}, wantErr: true},
+		{name: "no discord token", args: []string{"-output", "discord:123"}, wantErr: true},
+		{name: "no channel", args: []string{"-output", "discord", "-discord-token", "token"}, wantErr: true},
+		{name: "unknown", args: []string{"-output", "stdout,slack:general"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, sinks, err := newSinkFlags(t, tt.args...).open()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("open() error = %v, want error %v", err, tt.wantErr)
+			}
+			defer closeSinks(sinks)
+
+			var got []string
+			for _, s := range sinks {
+				got = append(got, reflect.TypeOf(s).String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("open() = %q, want %q", got, tt.want)
+			}
+			if len(names) != len(sinks) {
+				t.Errorf("open() names = %q", names)
+			}
+		})
+	}
+}
+
+func TestWriteSinks(t *testing.T) {
+	var posted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hook":
+			var v map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&v); err != nil || r.Header.Get("Content-Type") != "application/json" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			posted = append(posted, v["text"])
+		case "/api/v1/statuses":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			posted = append(posted, r.FormValue("status")+" ("+r.FormValue("visibility")+")")
+			w.Write([]byte("{}"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	file := filepath.Join(t.TempDir(), "out.txt")
+	if err := os.WriteFile(file, []byte("前の文。\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newSinkFlags(t,
+		"-output", file+",post:"+srv.URL+"/hook,mastodon",
+		"-mastodon-server", srv.URL,
+		"-mastodon-token", "token",
+		"-visibility", "private")
+	_, sinks, err := f.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"猫が鳴く。", "犬が吠える。"} {
+		if err := writeSinks(sinks, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := closeSinks(sinks); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the file is appended to
+	if want := "前の文。\n猫が鳴く。\n犬が吠える。\n"; string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+	want := []string{"猫が鳴く。", "猫が鳴く。 (private)", "犬が吠える。", "犬が吠える。 (private)"}
+	if !reflect.DeepEqual(posted, want) {
+		t.Errorf("posted %q, want %q", posted, want)
+	}
+
+	// an error of a sink
+	_, sinks, err = newSinkFlags(t, "-output", "post:"+srv.URL+"/missing").open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeSinks(sinks)
+	if err := writeSinks(sinks, "猫が鳴く。"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("writeSinks() error = %v, want 404", err)
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kechako/uonum"
+)
+
+const (
+	slackAPI = "https://slack.com/api"
+	// slackRetry is the wait before reconnecting after an error.
+	slackRetry = 10 * time.Second
+)
+
+type slackEvent struct {
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype"`
+	Channel  string `json:"channel"`
+	User     string `json:"user"`
+	BotID    string `json:"bot_id"`
+	Text     string `json:"text"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts"`
+}
+
+type slackEnvelope struct {
+	Type       string `json:"type"`
+	EnvelopeID string `json:"envelope_id"`
+	Payload    struct {
+		Event slackEvent `json:"event"`
+	} `json:"payload"`
+}
+
+// slackClient calls the Slack Web API.
+type slackClient struct {
+	api  string
+	http *http.Client
+}
+
+// call calls the method with token, and decodes the response into v if it
+// is not nil.
+func (c *slackClient) call(ctx context.Context, token, method string, form url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.api, "/")+"/"+method, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("[%s] Slack API error: %s.", method, res.Status)
+	}
+
+	var data json.RawMessage
+	err = json.NewDecoder(res.Body).Decode(&data)
+	if err != nil {
+		return fmt.Errorf("[%s] JSON unmarshal error: %w", method, err)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return fmt.Errorf("[%s] JSON unmarshal error: %w", method, err)
+	}
+	if !status.OK {
+		return fmt.Errorf("[%s] Slack API error: %s.", method, status.Error)
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("[%s] JSON unmarshal error: %w", method, err)
+	}
+
+	return nil
+}
+
+func slack(fs *flag.FlagSet) runner {
+	conversationFlags(fs)
+	botToken := fs.String("bot-token", "", "Bot token of the app (xoxb-...).")
+	appToken := fs.String("app-token", "", "App-level token with the connections:write scope (xapp-...).")
+	channels := fs.String("channels", "", "Comma separated IDs of the channels learned from.")
+	history := fs.Int("history", 0, "Register up to N past messages of each channel at the start.")
+	learn := fs.Bool("learn", true, "Register the new messages in the channels.")
+	api := fs.String("api", slackAPI, "URL of the Slack Web API.")
+	tw := fs.String("term-words", "", termWordsUsage)
+
+	return func(args []string) (int, error) {
+		if *botToken == "" || *appToken == "" {
+			return 2, errors.New("Bot token and app token are required.")
+		}
+
+		g, err := openGenerator(termWordsOption(*tw)...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+		g = g.In(ns)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		b := &slackBot{
+			g:        g,
+			c:        &slackClient{api: *api, http: http.DefaultClient},
+			botToken: *botToken,
+			appToken: *appToken,
+			learned:  make(map[string]bool),
+			learn:    *learn,
+			log:      logger.With("bot", "slack"),
+		}
+		for _, id := range splitList(*channels) {
+			b.learned[id] = true
+		}
+
+		var auth struct {
+			UserID string `json:"user_id"`
+		}
+		err = b.c.call(ctx, b.botToken, "auth.test", url.Values{}, &auth)
+		if err != nil {
+			return 1, err
+		}
+		b.me = auth.UserID
+
+		if *history > 0 {
+			for id := range b.learned {
+				n, err := b.registerHistory(ctx, id, *history)
+				if err != nil {
+					return 1, err
+				}
+				b.log.Info("Registered the history.", "channel", id, "messages", n)
+			}
+		}
+
+		for {
+			err := b.connect(ctx)
+			if ctx.Err() != nil {
+				return 0, nil
+			}
+			if err == nil {
+				// disconnected by Slack, reconnect at once
+				continue
+			}
+			b.log.Warn("Disconnected.", "err", err)
+			select {
+			case <-ctx.Done():
+				return 0, nil
+			case <-time.After(slackRetry):
+			}
+		}
+	}
+}
+
+// slackBot learns from the Slack channels and replies to the mentions.
+type slackBot struct {
+	g        uonum.Generator
+	c        *slackClient
+	botToken string
+	appToken string
+	learned  map[string]bool
+	learn    bool
+	me       string
+	log      *slog.Logger
+}
+
+// registerHistory registers up to limit past messages of the channel, and
+// returns the number of the registered messages.
+func (b *slackBot) registerHistory(ctx context.Context, channel string, limit int) (int, error) {
+	var n int
+	var cursor string
+	for n < limit {
+		var page struct {
+			Messages []slackEvent `json:"messages"`
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		form := url.Values{
+			"channel": {channel},
+			"limit":   {strconv.Itoa(min(limit-n, 200))},
+		}
+		if cursor != "" {
+			form.Set("cursor", cursor)
+		}
+		err := b.c.call(ctx, b.botToken, "conversations.history", form, &page)
+		if err != nil {
+			return n, err
+		}
+
+		// the messages are newest first
+		for i := len(page.Messages) - 1; i >= 0; i-- {
+			ok, err := b.register(channel, &page.Messages[i])
+			if err != nil {
+				return n, err
+			}
+			if ok {
+				n++
+			}
+		}
+
+		cursor = page.Metadata.NextCursor
+		if cursor == "" || len(page.Messages) == 0 {
+			break
+		}
+	}
+
+	return n, nil
+}
+
+// connect handles the events of a Socket Mode connection until ctx is done
+// or the connection is lost. It returns nil if Slack asks to reconnect.
+func (b *slackBot) connect(ctx context.Context) error {
+	var open struct {
+		URL string `json:"url"`
+	}
+	err := b.c.call(ctx, b.appToken, "apps.connections.open", url.Values{}, &open)
+	if err != nil {
+		return err
+	}
+
+	ws, _, err := websocket.DefaultDialer.DialContext(ctx, open.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		ws.Close()
+	}()
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var env slackEnvelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return fmt.Errorf("[socket mode] JSON unmarshal error: %w", err)
+		}
+
+		// the events are acknowledged before they are handled
+		if env.EnvelopeID != "" {
+			ack, _ := json.Marshal(map[string]string{"envelope_id": env.EnvelopeID})
+			if err := ws.WriteMessage(websocket.TextMessage, ack); err != nil {
+				return err
+			}
+		}
+
+		switch env.Type {
+		case "disconnect":
+			return nil
+		case "events_api":
+			b.handle(ctx, &env.Payload.Event)
+		}
+	}
+}
+
+func (b *slackBot) handle(ctx context.Context, ev *slackEvent) {
+	switch ev.Type {
+	case "app_mention":
+		b.replyTo(ctx, ev)
+	case "message":
+		// the mentions are handled as app_mention
+		if !b.learn || !b.learned[ev.Channel] || strings.Contains(ev.Text, "<@"+b.me+">") {
+			return
+		}
+		ok, err := b.register(ev.Channel, ev)
+		if err != nil {
+			b.log.Error("Could not register the message.", "err", err)
+			return
+		}
+		if ok {
+			b.log.Info("Registered.", "text", slackText(ev.Text))
+		}
+	}
+}
+
+// register registers the message, except for the messages of the bots and
+// the ones with a subtype such as joins. It returns false if the message is
+// skipped.
+func (b *slackBot) register(channel string, ev *slackEvent) (bool, error) {
+	if ev.Subtype != "" || ev.BotID != "" || ev.User == b.me {
+		return false, nil
+	}
+	text := slackText(ev.Text)
+	if text == "" {
+		return false, nil
+	}
+
+	meta := uonum.Meta{Source: "slack", Author: ev.User, Fields: map[string]string{"channel": channel}}
+	if sec, err := strconv.ParseFloat(ev.TS, 64); err == nil {
+		meta.Time = time.Unix(int64(sec), 0)
+	}
+	err := b.g.RegisterWithMeta(text, meta)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// replyTo replies to the mention in its thread.
+func (b *slackBot) replyTo(ctx context.Context, ev *slackEvent) {
+	if ev.User == b.me {
+		return
+	}
+
+	text, err := b.g.Reply(slackText(ev.Text))
+	if err != nil {
+		b.log.Error("Could not generate a reply.", "err", err)
+		return
+	}
+	if text == "" {
+		return
+	}
+
+	thread := ev.ThreadTS
+	if thread == "" {
+		thread = ev.TS
+	}
+	err = b.c.call(ctx, b.botToken, "chat.postMessage", url.Values{
+		"channel":   {ev.Channel},
+		"text":      {text},
+		"thread_ts": {thread},
+	}, nil)
+	if err != nil {
+		b.log.Error("Could not post the reply.", "err", err)
+	}
+}
+
+// slackMarkup matches the mentions, the channel links and the URLs.
+var slackMarkup = regexp.MustCompile(`<[^>]*>`)
+
+// slackText returns the plain text of a message.
+func slackText(text string) string {
+	s := slackMarkup.ReplaceAllString(text, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
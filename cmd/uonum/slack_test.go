@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/kechako/uonum/uonumtest"
+)
+
+func TestSlackText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "猫が鳴く。", want: "猫が鳴く。"},
+		{text: "<@U1> 猫 &amp; 犬 <https://example.com|link>", want: "猫 & 犬"},
+		{text: "<#C1>", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := slackText(tt.text); got != tt.want {
+			t.Errorf("slackText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// slackServer is a fake Slack Web API, which returns the pages of history
+// and records the posted messages.
+type slackServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	posts []url.Values
+}
+
+func newSlackServer(t *testing.T, history []slackEvent) *slackServer {
+	s := &slackServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		var res interface{}
+		switch r.URL.Path {
+		case "/conversations.history":
+			// the cursor is the offset of the page
+			off, _ := strconv.Atoi(r.Form.Get("cursor"))
+			limit, _ := strconv.Atoi(r.Form.Get("limit"))
+			end := min(off+limit, len(history))
+			next := ""
+			if end < len(history) {
+				next = strconv.Itoa(end)
+			}
+			res = map[string]interface{}{
+				"ok":                true,
+				"messages":          history[off:end],
+				"response_metadata": map[string]string{"next_cursor": next},
+			}
+		case "/chat.postMessage":
+			s.mu.Lock()
+			s.posts = append(s.posts, r.Form)
+			s.mu.Unlock()
+			res = map[string]bool{"ok": true}
+		default:
+			res = map[string]interface{}{"ok": false, "error": "unknown_method"}
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func newSlackBot(srv *slackServer, g *uonumtest.Fake) *slackBot {
+	return &slackBot{
+		g:        g,
+		c:        &slackClient{api: srv.URL, http: srv.Client()},
+		botToken: "bot",
+		learned:  map[string]bool{"C1": true},
+		learn:    true,
+		me:       "U9",
+		log:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestSlackHistory(t *testing.T) {
+	// newest first
+	history := []slackEvent{
+		{Type: "message", User: "U1", Text: "鳥 が 鳴く 。", TS: "3"},
+		{Type: "message", Subtype: "channel_join", User: "U2", Text: "joined", TS: "2"},
+		{Type: "message", BotID: "B1", Text: "牛 が 鳴く 。", TS: "1"},
+		{Type: "message", User: "U1", Text: "猫 が 鳴く 。", TS: "0"},
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{name: "all", limit: 10, want: 2},
+		{name: "limited", limit: 1, want: 1},
+		{name: "next pages", limit: 2, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := uonumtest.New(1)
+			b := newSlackBot(newSlackServer(t, history), g)
+			n, err := b.registerHistory(context.Background(), "C1", tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st, err := g.Stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.want || st.Texts != tt.want {
+				t.Errorf("registered %d messages, %d texts, want %d", n, st.Texts, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackHandle(t *testing.T) {
+	srv := newSlackServer(t, nil)
+	g := uonumtest.New(1)
+	b := newSlackBot(srv, g)
+
+	ctx := context.Background()
+	for _, ev := range []*slackEvent{
+		{Type: "message", Channel: "C1", User: "U1", Text: "猫 が 鳴く 。", TS: "1"},
+		{Type: "message", Channel: "C2", User: "U1", Text: "犬 が 鳴く 。", TS: "2"},
+		{Type: "message", Channel: "C1", User: "U1", Text: "<@U9> 鳥 が 鳴く 。", TS: "3"},
+		{Type: "message", Channel: "C1", User: "U9", Text: "牛 が 鳴く 。", TS: "4"},
+		{Type: "app_mention", Channel: "C1", User: "U1", Text: "<@U9> 猫 は？", TS: "5", ThreadTS: "1"},
+		{Type: "app_mention", Channel: "C1", User: "U9", Text: "<@U9> 猫 は？", TS: "6"},
+	} {
+		b.handle(ctx, ev)
+	}
+
+	st, err := g.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Texts != 1 {
+		t.Errorf("registered %d messages, want 1", st.Texts)
+	}
+
+	if len(srv.posts) != 1 {
+		t.Fatalf("posted %d messages, want 1", len(srv.posts))
+	}
+	want := map[string]string{"channel": "C1", "text": "猫 が 鳴く 。", "thread_ts": "1"}
+	for k, v := range want {
+		if got := srv.posts[0].Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestSlackCallError(t *testing.T) {
+	srv := newSlackServer(t, nil)
+	c := &slackClient{api: srv.URL, http: srv.Client()}
+	err := c.call(context.Background(), "bot", "unknown.method", url.Values{}, nil)
+	if err == nil || err.Error() != "[unknown.method] Slack API error: unknown_method." {
+		t.Errorf("call() error = %v, want the error of the API", err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// tenantHeader is the header of the tenant of a request with -tenant header,
+// and of a gRPC call with -tenant.
+const tenantHeader = "X-Tenant"
+
+// tenantLockTimeout is the time the database of a tenant waits for the lock
+// held by another process.
+const tenantLockTimeout = 10 * time.Second
+
+var tenantName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// tenant is the generator of a tenant.
+type tenant struct {
+	name string
+	// mu serializes the calls of g
+	mu *sync.Mutex
+	g  uonum.Generator
+
+	// pooled tenants are closed after they are evicted and released
+	pooled bool
+	refs   int
+	// ready is closed when the database is opened, or failed to open with
+	// err
+	ready chan struct{}
+	err   error
+}
+
+// tenantPool opens the tenants lazily, each in the database named by
+// pattern, or in the namespace of shared if pattern is empty. The least
+// recently used databases are closed when more than max are open.
+type tenantPool struct {
+	shared  *tenant
+	pattern string
+	max     int
+	open    func(name string) (uonum.Generator, error)
+
+	mu     sync.Mutex
+	order  *list.List
+	items  map[string]*list.Element
+	closed uonum.Metrics // of the closed tenants
+}
+
+func newTenantPool(shared uonum.Generator, pattern string, max int, open func(name string) (uonum.Generator, error)) *tenantPool {
+	p := &tenantPool{
+		pattern: pattern,
+		max:     max,
+		open:    open,
+		order:   list.New(),
+		items:   make(map[string]*list.Element),
+	}
+	if shared != nil {
+		p.shared = &tenant{mu: new(sync.Mutex), g: shared}
+	}
+
+	return p
+}
+
+// acquire returns the tenant name, which is the shared generator if name is
+// empty. It must be released.
+func (p *tenantPool) acquire(name string) (*tenant, error) {
+	if name == "" {
+		if p.shared == nil {
+			return nil, fmt.Errorf("no tenant: %w", errBadRequest)
+		}
+		return p.shared, nil
+	}
+	if !tenantName.MatchString(name) {
+		return nil, fmt.Errorf("invalid tenant [%s]: %w", name, errBadRequest)
+	}
+	if p.pattern == "" {
+		return &tenant{name: name, mu: p.shared.mu, g: p.shared.g.In(name)}, nil
+	}
+
+	p.mu.Lock()
+	if e, ok := p.items[name]; ok {
+		p.order.MoveToFront(e)
+		t := e.Value.(*tenant)
+		t.refs++
+		p.mu.Unlock()
+
+		<-t.ready
+		if t.err != nil {
+			return nil, t.err
+		}
+		return t, nil
+	}
+
+	// the database is opened without the lock, which may wait for another
+	// process, while the requests of the same tenant wait for ready
+	t := &tenant{name: name, mu: new(sync.Mutex), pooled: true, refs: 1, ready: make(chan struct{})}
+	e := p.order.PushFront(t)
+	p.items[name] = e
+	p.mu.Unlock()
+
+	g, err := p.open(strings.ReplaceAll(p.pattern, "{tenant}", name))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer close(t.ready)
+
+	if err != nil {
+		t.err = fmt.Errorf("[%s] could not open the database of the tenant: %w", name, err)
+		p.order.Remove(e)
+		delete(p.items, name)
+		return nil, t.err
+	}
+	if p.items[name] != e {
+		// the pool is closed meanwhile
+		g.Close()
+		t.err = fmt.Errorf("[%s] the tenants are closed", name)
+		return nil, t.err
+	}
+	t.g = g
+	p.evict()
+
+	return t, nil
+}
+
+func (p *tenantPool) release(t *tenant) {
+	if !t.pooled {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t.refs--
+	p.evict()
+}
+
+// evict closes the least recently used tenants not in use over max. The
+// tenants being opened are in use.
+func (p *tenantPool) evict() {
+	for e := p.order.Back(); e != nil && p.order.Len() > p.max; {
+		prev := e.Prev()
+		if t := e.Value.(*tenant); t.refs == 0 {
+			p.order.Remove(e)
+			delete(p.items, t.name)
+			p.closed = addMetrics(p.closed, t.g.Metrics())
+			t.g.Close()
+		}
+		e = prev
+	}
+}
+
+// metrics returns the sum of the metrics of the tenants, including the
+// closed ones.
+func (p *tenantPool) metrics() uonum.Metrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m := p.closed
+	if p.shared != nil {
+		m = addMetrics(m, p.shared.g.Metrics())
+	}
+	for e := p.order.Front(); e != nil; e = e.Next() {
+		if g := e.Value.(*tenant).g; g != nil {
+			m = addMetrics(m, g.Metrics())
+		}
+	}
+
+	return m
+}
+
+// size returns the number of the open databases of the tenants.
+func (p *tenantPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.order.Len()
+}
+
+func (p *tenantPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for e := p.order.Front(); e != nil; e = e.Next() {
+		if g := e.Value.(*tenant).g; g != nil {
+			g.Close()
+		}
+	}
+	p.order.Init()
+	p.items = make(map[string]*list.Element)
+}
+
+func addMetrics(a, b uonum.Metrics) uonum.Metrics {
+	return uonum.Metrics{
+		Walks:       a.Walks + b.Walks,
+		DeadEnds:    a.DeadEnds + b.DeadEnds,
+		Rejected:    a.Rejected + b.Rejected,
+		CacheHits:   a.CacheHits + b.CacheHits,
+		CacheMisses: a.CacheMisses + b.CacheMisses,
+		WordWrites:  a.WordWrites + b.WordWrites,
+		WordBytes:   a.WordBytes + b.WordBytes,
+	}
+}
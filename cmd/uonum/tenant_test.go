@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kechako/uonum"
+	"github.com/kechako/uonum/uonumtest"
+)
+
+func TestTenantPoolOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		openErr error
+	}{
+		{name: "opened"},
+		{name: "failed", openErr: errors.New("locked")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the database of "slow" is opened until unblock is closed
+			unblock := make(chan struct{})
+			var mu sync.Mutex
+			opens := make(map[string]int)
+			p := newTenantPool(nil, "{tenant}.db", 10, func(name string) (uonum.Generator, error) {
+				mu.Lock()
+				opens[name]++
+				mu.Unlock()
+				if name == "slow.db" {
+					<-unblock
+					if tt.openErr != nil {
+						return nil, tt.openErr
+					}
+				}
+				return uonumtest.New(1), nil
+			})
+			defer p.Close()
+
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					tn, err := p.acquire("slow")
+					if err == nil {
+						p.release(tn)
+					}
+					errs[i] = err
+				}(i)
+			}
+
+			// the other tenants are not blocked by the one being opened
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				tn, err := p.acquire("fast")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				p.release(tn)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("acquire(fast) is blocked by the tenant being opened")
+			}
+
+			// both the requests of slow wait for the same database
+			for refs := 0; refs < 2; time.Sleep(time.Millisecond) {
+				p.mu.Lock()
+				if e, ok := p.items["slow"]; ok {
+					refs = e.Value.(*tenant).refs
+				}
+				p.mu.Unlock()
+			}
+			close(unblock)
+			wg.Wait()
+			for _, err := range errs {
+				if !errors.Is(err, tt.openErr) {
+					t.Errorf("acquire(slow) = %v, want %v", err, tt.openErr)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if opens["slow.db"] != 1 {
+				t.Errorf("slow.db is opened %d times, want once", opens["slow.db"])
+			}
+			wantSize := 2
+			if tt.openErr != nil {
+				wantSize = 1
+			}
+			if got := p.size(); got != wantSize {
+				t.Errorf("size() = %d, want %d", got, wantSize)
+			}
+		})
+	}
+}
+
+// closeGenerator records the names of the closed databases.
+type closeGenerator struct {
+	uonum.Generator
+	name   string
+	closed *[]string
+}
+
+func (g *closeGenerator) Close() error {
+	*g.closed = append(*g.closed, g.name)
+	return g.Generator.Close()
+}
+
+func TestTenantPoolEvict(t *testing.T) {
+	var closed []string
+	p := newTenantPool(nil, "{tenant}.db", 2, func(name string) (uonum.Generator, error) {
+		return &closeGenerator{Generator: uonumtest.New(1), name: name, closed: &closed}, nil
+	})
+	defer p.Close()
+
+	acquire := func(name string) *tenant {
+		t.Helper()
+		tn, err := p.acquire(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tn
+	}
+
+	// a is used recently, and b is evicted
+	for _, name := range []string{"a", "b", "a"} {
+		p.release(acquire(name))
+	}
+	c := acquire("c")
+	if err := c.g.Register("猫 が 鳴く 。"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.g.Generate("猫"); err != nil {
+		t.Fatal(err)
+	}
+	p.release(c)
+	if want := []string{"b.db"}; strings.Join(closed, ",") != strings.Join(want, ",") {
+		t.Errorf("closed %q, want %q", closed, want)
+	}
+
+	// the tenants in use are not evicted over max
+	d := acquire("d")
+	e := acquire("e")
+	if got := p.size(); got != 2 {
+		t.Errorf("size() = %d, want 2", got)
+	}
+	p.release(d)
+	p.release(e)
+
+	// the metrics of the closed tenants are kept
+	if got := p.metrics().Walks; got != 1 {
+		t.Errorf("metrics() walks = %d, want 1", got)
+	}
+
+	tests := []struct {
+		name    string
+		wantErr error
+	}{
+		{name: "", wantErr: errBadRequest},
+		{name: "../a", wantErr: errBadRequest},
+		{name: ".hidden", wantErr: errBadRequest},
+		{name: strings.Repeat("a", 65), wantErr: errBadRequest},
+		{name: "user_1.test-2"},
+	}
+	for _, tt := range tests {
+		tn, err := p.acquire(tt.name)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("acquire(%q) error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil {
+			p.release(tn)
+		}
+	}
+}
+
+func TestServeTenants(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenantBy string
+		// register and stats are the requests of a tenant
+		register func() *http.Request
+		stats    func(tenant string) *http.Request
+	}{
+		{
+			name:     "header",
+			tenantBy: "header",
+			register: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader("猫 が 鳴く 。\n"))
+				r.Header.Set(tenantHeader, "cats")
+				return r
+			},
+			stats: func(tenant string) *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/stats", nil)
+				if tenant != "" {
+					r.Header.Set(tenantHeader, tenant)
+				}
+				return r
+			},
+		},
+		{
+			name:     "path",
+			tenantBy: "path",
+			register: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/cats/register", strings.NewReader("猫 が 鳴く 。\n"))
+			},
+			stats: func(tenant string) *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/"+tenant+"/stats", nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{
+				tenants:  newTenantPool(uonumtest.New(1), "", 0, nil),
+				tenantBy: tt.tenantBy,
+				metrics:  newMetrics(),
+				log:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			h := s.handler()
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, tt.register())
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("register status = %d, want %d", w.Code, http.StatusNoContent)
+			}
+
+			stats := []struct {
+				tenant     string
+				wantStatus int
+				wantBody   string
+			}{
+				{tenant: "cats", wantStatus: http.StatusOK, wantBody: `"texts":1}`},
+				{tenant: "dogs", wantStatus: http.StatusOK, wantBody: `"texts":0}`},
+				{tenant: "", wantStatus: http.StatusBadRequest},
+				{tenant: "-dogs", wantStatus: http.StatusBadRequest},
+			}
+			for _, st := range stats {
+				if tt.tenantBy == "path" && st.tenant == "" {
+					// a path always has a tenant
+					continue
+				}
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, tt.stats(st.tenant))
+				if w.Code != st.wantStatus {
+					t.Errorf("stats of %q status = %d, want %d", st.tenant, w.Code, st.wantStatus)
+				}
+				if !strings.Contains(w.Body.String(), st.wantBody) {
+					t.Errorf("stats of %q = %s, want %s", st.tenant, w.Body, st.wantBody)
+				}
+			}
+		})
+	}
+}
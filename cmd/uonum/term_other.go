@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// makeRaw is not supported, so the lines are read without editing.
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("Raw mode is not supported.")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kechako/uonum"
+)
+
+func tokenize(fs *flag.FlagSet) runner {
+	features := fs.Bool("features", false, "Print all the features of the morphemes as the last column.")
+
+	return func(args []string) (int, error) {
+		opts, err := generatorOptions()
+		if err != nil {
+			return 1, err
+		}
+
+		var texts []string
+		if len(args) > 0 {
+			texts = []string{strings.Join(args, " ")}
+		} else {
+			s := bufio.NewScanner(os.Stdin)
+			for s.Scan() {
+				texts = append(texts, s.Text())
+			}
+			if err := s.Err(); err != nil {
+				return 1, fmt.Errorf("could not read the texts: %w", err)
+			}
+		}
+
+		w := bufio.NewWriter(os.Stdout)
+		defer w.Flush()
+
+		for i, text := range texts {
+			ms, err := uonum.Analyze(text, opts...)
+			if err != nil {
+				return 1, err
+			}
+			if jsonOutput {
+				if ms == nil {
+					ms = []uonum.Morpheme{}
+				}
+				if err := printJSON(ms); err != nil {
+					return 1, err
+				}
+				continue
+			}
+
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			for _, m := range ms {
+				class := m.Class
+				if m.Unknown {
+					class += " (unknown)"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s", m.Surface, class, m.BaseForm, m.Reading)
+				if *features {
+					fmt.Fprintf(w, "\t%s", strings.Join(m.Features, ","))
+				}
+				fmt.Fprintln(w)
+			}
+		}
+
+		return 0, nil
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kechako/uonum"
+)
+
+// registerTree registers the files whose names match glob in the directory
+// tree of root, and prints the result of each file.
+func registerTree(g uonum.Learner, root, glob string, meta uonum.Meta) error {
+	if _, err := filepath.Match(glob, ""); err != nil {
+		return fmt.Errorf("invalid pattern [%s]: %w", glob, err)
+	}
+
+	var files, skipped, lines int
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("could not read the directory [%s]: %w", path, err)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if ok, _ := filepath.Match(glob, d.Name()); !ok {
+			return nil
+		}
+
+		res, err := g.RegisterFile(path, meta)
+		if err != nil {
+			return err
+		}
+		if res.Skipped {
+			skipped++
+			fmt.Fprintf(os.Stderr, "%s: skipped, already registered as %s\n", path, res.Previous)
+			return nil
+		}
+		files++
+		lines += res.Lines
+		fmt.Fprintf(os.Stderr, "%s: %d lines, %d bytes\n", path, res.Lines, res.Bytes)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Registered %d lines in %d files, skipped %d files.\n", lines, files, skipped)
+
+	return nil
+}
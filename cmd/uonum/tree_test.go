@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/kechako/uonum"
+)
+
+// treeLearner records the files registered by registerTree.
+type treeLearner struct {
+	uonum.Learner
+	files []string
+}
+
+func (l *treeLearner) RegisterFile(name string, meta uonum.Meta) (*uonum.FileResult, error) {
+	l.files = append(l.files, name)
+	return &uonum.FileResult{Name: name, Lines: 1}, nil
+}
+
+func TestRegisterTree(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.txt", "b.log", "sub/c.txt", "sub/deep/d.txt", "sub/e.md"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("猫が鳴く。\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		glob    string
+		want    []string
+		wantErr bool
+	}{
+		{glob: "*", want: []string{"a.txt", "b.log", "sub/c.txt", "sub/deep/d.txt", "sub/e.md"}},
+		{glob: "*.txt", want: []string{"a.txt", "sub/c.txt", "sub/deep/d.txt"}},
+		{glob: "[cd].*", want: []string{"sub/c.txt", "sub/deep/d.txt"}},
+		{glob: "*.go"},
+		{glob: "[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			l := &treeLearner{}
+			err := registerTree(l, root, tt.glob, uonum.Meta{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("registerTree() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, f := range l.files {
+				rel, err := filepath.Rel(root, f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, filepath.ToSlash(rel))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("registered %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// twitterTweet is a tweet in data/tweets.js of a Twitter/X archive.
+type twitterTweet struct {
+	FullText          string `json:"full_text"`
+	CreatedAt         string `json:"created_at"`
+	InReplyToStatusID string `json:"in_reply_to_status_id_str"`
+}
+
+func importTwitter(fs *flag.FlagSet) runner {
+	learnFlags(fs)
+	rts := fs.Bool("rts", false, "Register the retweets as well.")
+	replies := fs.Bool("replies", true, "Register the replies.")
+	source := fs.String("source", "twitter", "Source of the texts.")
+	split := fs.Bool("split", false, "Split the tweets into sentences at the term words.")
+
+	return func(args []string) (int, error) {
+		if len(args) == 0 {
+			return 2, errors.New("Archive file is required.")
+		}
+
+		// the URLs and the mentions are removed unless -filter says otherwise
+		if filter == "" {
+			filter = "url,mention"
+		}
+		var opts []uonum.Option
+		if *split {
+			opts = append(opts, uonum.WithSentenceSplit())
+		}
+
+		z, err := zip.OpenReader(args[0])
+		if err != nil {
+			return 1, fmt.Errorf("could not open the archive [%s]: %w", args[0], err)
+		}
+		defer z.Close()
+
+		author, err := twitterAccount(&z.Reader)
+		if err != nil {
+			return 1, err
+		}
+		tweets, err := twitterTweets(&z.Reader)
+		if err != nil {
+			return 1, err
+		}
+
+		var records []uonum.Record
+		skipped := 0
+		for _, t := range tweets {
+			if (!*rts && strings.HasPrefix(t.FullText, "RT @")) || (!*replies && t.InReplyToStatusID != "") {
+				skipped++
+				continue
+			}
+			rec := uonum.Record{
+				Text: html.UnescapeString(strings.ReplaceAll(t.FullText, "\n", " ")),
+				Meta: uonum.Meta{Source: *source, Author: author},
+			}
+			if tm, err := time.Parse(time.RubyDate, t.CreatedAt); err == nil {
+				rec.Meta.Time = tm
+			}
+			records = append(records, rec)
+		}
+
+		g, err := openGenerator(opts...)
+		if err != nil {
+			return 1, err
+		}
+		defer g.Close()
+
+		err = g.In(ns).RegisterRecords(&sliceRecords{records: records}, uonum.Meta{})
+		if err != nil {
+			return 1, err
+		}
+		fmt.Fprintf(os.Stderr, "Registered %d tweets, skipped %d.\n", len(records), skipped)
+
+		return 0, nil
+	}
+}
+
+// sliceRecords is a RecordReader of records.
+type sliceRecords struct {
+	records []uonum.Record
+}
+
+func (s *sliceRecords) Read() (uonum.Record, error) {
+	if len(s.records) == 0 {
+		return uonum.Record{}, io.EOF
+	}
+	rec := s.records[0]
+	s.records = s.records[1:]
+
+	return rec, nil
+}
+
+// readArchiveJS reads a file of the archive, which is a JavaScript assignment
+// of a JSON value like "window.YTD.tweets.part0 = [...]", into v.
+func readArchiveJS(f *zip.File, v interface{}) error {
+	r, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("could not open [%s] in the archive: %w", f.Name, err)
+	}
+	defer r.Close()
+
+	d, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("could not read [%s] in the archive: %w", f.Name, err)
+	}
+	if i := bytes.IndexByte(d, '='); i >= 0 && bytes.IndexAny(d[:i], "[{") < 0 {
+		d = d[i+1:]
+	}
+
+	err = json.Unmarshal(d, v)
+	if err != nil {
+		return fmt.Errorf("[%s] JSON unmarshal error: %w", f.Name, err)
+	}
+
+	return nil
+}
+
+// twitterTweets returns the tweets in the archive, which are split into
+// data/tweets.js, data/tweets-part1.js and so on (tweet.js in older ones).
+func twitterTweets(z *zip.Reader) ([]twitterTweet, error) {
+	var files []*zip.File
+	for _, f := range z.File {
+		name := path.Base(f.Name)
+		if path.Base(path.Dir(f.Name)) != "data" || !strings.HasSuffix(name, ".js") {
+			continue
+		}
+		name = strings.TrimSuffix(name, ".js")
+		if i := strings.Index(name, "-part"); i >= 0 {
+			name = name[:i]
+		}
+		if name == "tweets" || name == "tweet" {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New("No tweets in the archive.")
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	var tweets []twitterTweet
+	for _, f := range files {
+		var items []struct {
+			Tweet *twitterTweet `json:"tweet"`
+		}
+		err := readArchiveJS(f, &items)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if item.Tweet != nil {
+				tweets = append(tweets, *item.Tweet)
+			}
+		}
+	}
+
+	return tweets, nil
+}
+
+// twitterAccount returns the user name of the account of the archive, or ""
+// if it is unknown.
+func twitterAccount(z *zip.Reader) (string, error) {
+	for _, f := range z.File {
+		if path.Base(f.Name) != "account.js" {
+			continue
+		}
+		var items []struct {
+			Account struct {
+				Username string `json:"username"`
+			} `json:"account"`
+		}
+		err := readArchiveJS(f, &items)
+		if err != nil {
+			return "", err
+		}
+		if len(items) > 0 {
+			return items[0].Account.Username, nil
+		}
+	}
+
+	return "", nil
+}
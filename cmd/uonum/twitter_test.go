@@ -0,0 +1,110 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// newZip returns the reader of a zip archive of files by their names.
+func newZip(t *testing.T, files map[string]string) *zip.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return z
+}
+
+func TestTwitterTweets(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "parts",
+			files: map[string]string{
+				"data/tweets-part1.js": `window.YTD.tweets.part1 = [{"tweet": {"full_text": "犬が鳴く。"}}]`,
+				"data/tweets.js":       `window.YTD.tweets.part0 = [{"tweet": {"full_text": "猫が鳴く。"}}, {"other": {}}]`,
+				"data/like.js":         `window.YTD.like.part0 = [{"tweet": {"full_text": "liked"}}]`,
+			},
+			// in the order of the file names
+			want: []string{"犬が鳴く。", "猫が鳴く。"},
+		},
+		{
+			name:  "old archive",
+			files: map[string]string{"archive/data/tweet.js": `window.YTD.tweet.part0 = [{"tweet": {"full_text": "猫が鳴く。"}}]`},
+			want:  []string{"猫が鳴く。"},
+		},
+		{
+			name:  "plain JSON",
+			files: map[string]string{"data/tweets.js": `[{"tweet": {"full_text": "a = b"}}]`},
+			want:  []string{"a = b"},
+		},
+		{name: "no tweets", files: map[string]string{"data/like.js": "[]"}, wantErr: true},
+		{name: "broken", files: map[string]string{"data/tweets.js": "window.YTD.tweets.part0 = [{"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tweets, err := twitterTweets(newZip(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("twitterTweets() error = %v, want error %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, tw := range tweets {
+				got = append(got, tw.FullText)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tweets = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTwitterAccount(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "account",
+			files: map[string]string{"data/account.js": `window.YTD.account.part0 = [{"account": {"username": "tama"}}]`},
+			want:  "tama",
+		},
+		{name: "none", files: map[string]string{"data/tweets.js": "[]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := twitterAccount(newZip(t, tt.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("twitterAccount() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
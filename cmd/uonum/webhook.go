@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// webhookConfig is the fields of the payloads of /webhook.
+type webhookConfig struct {
+	// field and author are the dot separated paths of the text and the
+	// author in the payload
+	field  string
+	author string
+	// reply makes the response a generated reply in replyField
+	reply      bool
+	replyField string
+}
+
+// handleWebhook registers the text in the JSON or form payload of a webhook
+// of another service (e.g. a GitHub comment or a Mattermost outgoing
+// webhook). The paths of the fields can be overridden by the query
+// parameters field and author, and the reply by reply=true or false.
+//
+// It responds with 204 if the payload has no text, to accept the events of
+// other kinds.
+func (s *server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	c := s.webhook
+	q := r.URL.Query()
+	if v := q.Get("field"); v != "" {
+		c.field = v
+	}
+	if v := q.Get("author"); v != "" {
+		c.author = v
+	}
+	if v := q.Get("reply"); v != "" {
+		reply, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, fmt.Errorf("[reply] invalid value: %w", errBadRequest))
+			return
+		}
+		c.reply = reply
+	}
+
+	t, err := s.tenant(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer s.tenants.release(t)
+
+	payload, err := readPayload(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	text, _ := jsonField(payload, c.field)
+	text = strings.TrimSpace(text)
+	if text == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	meta := uonum.Meta{Source: q.Get("source")}
+	if meta.Source == "" {
+		meta.Source = "webhook"
+	}
+	if c.author != "" {
+		meta.Author, _ = jsonField(payload, c.author)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err = t.g.RegisterWithMeta(text, meta)
+	s.metrics.register("webhook", err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !c.reply {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	start := time.Now()
+	reply, err := t.g.Reply(text)
+	s.metrics.generate("webhook", start, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{c.replyField: reply})
+}
+
+// readPayload decodes the JSON body, or the form as an object of strings.
+func readPayload(r *http.Request) (interface{}, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if err := r.ParseForm(); err != nil {
+			return nil, fmt.Errorf("invalid form: %w", errBadRequest)
+		}
+		payload := make(map[string]interface{})
+		for k := range r.PostForm {
+			payload[k] = r.PostForm.Get(k)
+		}
+		return payload, nil
+	}
+
+	var payload interface{}
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid JSON payload: %v: %w", err, errBadRequest)
+	}
+
+	return payload, nil
+}
+
+// jsonField returns the string, number or boolean at the dot separated path
+// of the object keys and the array indexes in v.
+func jsonField(v interface{}, path string) (string, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			v = t[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return "", false
+			}
+			v = t[i]
+		default:
+			return "", false
+		}
+	}
+
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(t), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kechako/uonum/uonumtest"
+)
+
+func TestJSONField(t *testing.T) {
+	var v interface{}
+	err := json.Unmarshal([]byte(`{"comment": {"body": "猫", "user": {"login": "alice"}}, "items": [{"n": 1.5}, {"ok": true}]}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{path: "comment.body", want: "猫", wantOK: true},
+		{path: "comment.user.login", want: "alice", wantOK: true},
+		{path: "items.0.n", want: "1.5", wantOK: true},
+		{path: "items.1.ok", want: "true", wantOK: true},
+		{path: "items.2.ok"},
+		{path: "items.x"},
+		{path: "comment"},
+		{path: "comment.body.text"},
+		{path: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := jsonField(v, tt.path)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("jsonField(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+		wantTexts   int
+	}{
+		{
+			name:       "registered",
+			body:       `{"text": "猫 が 鳴く 。"}`,
+			wantStatus: http.StatusNoContent,
+			wantTexts:  1,
+		},
+		{
+			name:       "field",
+			query:      "?field=comment.body",
+			body:       `{"comment": {"body": "猫 が 鳴く 。"}}`,
+			wantStatus: http.StatusNoContent,
+			wantTexts:  1,
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "text=%E7%8C%AB+%E3%81%8C+%E9%B3%B4%E3%81%8F+%E3%80%82",
+			wantStatus:  http.StatusNoContent,
+			wantTexts:   1,
+		},
+		{
+			name:       "reply",
+			query:      "?reply=true",
+			body:       `{"text": "猫 が 鳴く 。"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"text":"猫 が 鳴く 。"}`,
+			wantTexts:  1,
+		},
+		{name: "no text", body: `{"action": "opened"}`, wantStatus: http.StatusNoContent},
+		{name: "invalid JSON", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "invalid reply", query: "?reply=maybe", body: `{}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := uonumtest.New(1)
+			s := &server{
+				tenants: newTenantPool(g, "", 0, nil),
+				webhook: webhookConfig{field: "text", replyField: "text"},
+				metrics: newMetrics(),
+				log:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook"+tt.query, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			s.handler().ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && strings.TrimSpace(w.Body.String()) != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+			st, err := g.Stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.Texts != tt.wantTexts {
+				t.Errorf("registered %d texts, want %d", st.Texts, tt.wantTexts)
+			}
+		})
+	}
+}
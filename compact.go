@@ -0,0 +1,151 @@
+package uonum
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/boltdb/bolt"
+)
+
+// compactTxSize is the number of bytes Compact copies in a transaction.
+const compactTxSize = 64 * 1024 * 1024
+
+// Compact rewrites the Bolt database file name into a fresh file without the
+// free pages, and replaces name with it. The database must not be opened:
+// it fails if the lock of the database is not taken in lockTimeout, and holds
+// the lock until the file is replaced, so that no writes go to the old file.
+// It returns the sizes of the file before and after.
+func Compact(name string) (before, after int64, err error) {
+	if isRedisDSN(name) {
+		return 0, 0, errors.New("The database does not support compaction.")
+	}
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not open database: %w", err)
+	}
+	before = fi.Size()
+
+	src, err := bolt.Open(name, 0600, &bolt.Options{Timeout: lockTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return 0, 0, fmt.Errorf("The database [%s] is in use.", name)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not open database: %w", err)
+	}
+	defer src.Close()
+
+	tmp := filepath.Join(filepath.Dir(name), filepath.Base(name)+".compact")
+	os.Remove(tmp)
+	dst, err := bolt.Open(tmp, fi.Mode(), nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not create the compacted database: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	err = copyBolt(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not compact the database: %w", err)
+	}
+
+	fi, err = os.Stat(tmp)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not compact the database: %w", err)
+	}
+	after = fi.Size()
+
+	err = os.Rename(tmp, name)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not replace the database: %w", err)
+	}
+
+	return before, after, nil
+}
+
+// copyBolt copies all the buckets of src into dst, committing every
+// compactTxSize bytes.
+func copyBolt(dst, src *bolt.DB) error {
+	tx, err := dst.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tx.Rollback()
+	}()
+
+	size := 0
+	// bucket returns the bucket at path in the current transaction of dst.
+	bucket := func(path [][]byte) (*bolt.Bucket, error) {
+		b, err := tx.CreateBucketIfNotExists(path[0])
+		for _, n := range path[1:] {
+			if err != nil {
+				break
+			}
+			b, err = b.CreateBucketIfNotExists(n)
+		}
+		return b, err
+	}
+
+	var walk func(path [][]byte, sb *bolt.Bucket) error
+	walk = func(path [][]byte, sb *bolt.Bucket) error {
+		db, err := bucket(path)
+		if err != nil {
+			return err
+		}
+		err = db.SetSequence(sb.Sequence())
+		if err != nil {
+			return err
+		}
+
+		c := sb.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if v == nil {
+				child := append(append([][]byte(nil), path...), k)
+				err = walk(child, sb.Bucket(k))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			if size+len(k)+len(v) > compactTxSize {
+				err = tx.Commit()
+				if err != nil {
+					return err
+				}
+				tx, err = dst.Begin(true)
+				if err != nil {
+					return err
+				}
+				size = 0
+			}
+			db, err = bucket(path)
+			if err != nil {
+				return err
+			}
+			err = db.Put(k, v)
+			if err != nil {
+				return err
+			}
+			size += len(k) + len(v)
+		}
+
+		return nil
+	}
+
+	err = src.View(func(stx *bolt.Tx) error {
+		return stx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			return walk([][]byte{name}, b)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
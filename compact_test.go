@@ -0,0 +1,93 @@
+package uonum
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		// locked keeps the database opened during the compaction
+		locked  bool
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "texts", texts: []string{"猫が魚を食べる。", "犬が肉を食べる。"}},
+		{name: "no database", wantErr: true},
+		{name: "locked", texts: []string{"猫が魚を食べる。"}, locked: true, wantErr: true},
+	}
+
+	old := lockTimeout
+	lockTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = old })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.db")
+			if !tt.wantErr || tt.locked {
+				g := New()
+				if err := g.Open(name); err != nil {
+					t.Fatal(err)
+				}
+				for _, text := range tt.texts {
+					if err := g.Register(text); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if tt.locked {
+					defer g.Close()
+				} else if err := g.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			before, after, err := Compact(name)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Compact() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			fi, err := os.Stat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after != fi.Size() || after > before {
+				t.Errorf("Compact() = %d, %d, want the size %d not above the size before", before, after, fi.Size())
+			}
+			if _, err := os.Stat(name + ".compact"); !os.IsNotExist(err) {
+				t.Errorf("the compacted file is left: %v", err)
+			}
+
+			g := New().(*generator)
+			if err := g.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+			if got := textsIn(t, g); got != len(tt.texts) {
+				t.Errorf("texts = %d, want %d", got, len(tt.texts))
+			}
+
+			// the sequences are kept, so that the new texts do not overwrite
+			if err := g.Register("鳥が虫を食べる。"); err != nil {
+				t.Fatal(err)
+			}
+			if got := textsIn(t, g); got != len(tt.texts)+1 {
+				t.Errorf("texts after a registration = %d, want %d", got, len(tt.texts)+1)
+			}
+		})
+	}
+}
+
+func TestCompactRedis(t *testing.T) {
+	if _, _, err := Compact("redis://localhost:6379"); err == nil {
+		t.Error("Compact() of a Redis DSN succeeded, want an error")
+	}
+}
@@ -0,0 +1,131 @@
+package uonum
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// flateMarker starts the values of the words compressed with DEFLATE.
+// The JSON of a word always starts with '{', so that the values without it
+// are read as they are.
+const flateMarker = 0x01
+
+var (
+	flateWriters = sync.Pool{
+		New: func() any {
+			w, _ := flate.NewWriter(nil, flate.BestSpeed)
+			return w
+		},
+	}
+	flateReaders sync.Pool
+	// jsonBuffers are the buffers of the JSON of the compressed words, which
+	// is discarded after the compression or the decoding
+	jsonBuffers = sync.Pool{
+		New: func() any {
+			return new(bytes.Buffer)
+		},
+	}
+)
+
+// maxPooledBuffer is the capacity of the largest buffer put back in
+// jsonBuffers, so that a few huge words do not stay in memory.
+const maxPooledBuffer = 1 << 20
+
+func getBuffer() *bytes.Buffer {
+	buf := jsonBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		jsonBuffers.Put(buf)
+	}
+}
+
+// WithCompression makes the registrations compress the words whose JSON is
+// at least min bytes long, which are mostly the particles and the auxiliary
+// verbs linked to most of the words. The compressed words are read
+// regardless of this option, so that it can be turned on and off on a
+// database.
+func WithCompression(min int) Option {
+	return func(g *generator) {
+		g.compress = min
+	}
+}
+
+// marshalWordLink returns the value of w in the words bucket, compressed if
+// min > 0 and its JSON is at least min bytes long.
+func marshalWordLink(w *wordLink, min int) ([]byte, error) {
+	if min <= 0 {
+		d, err := json.Marshal(w)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] JSON marshal error: %w", w.Word, err)
+		}
+		return d, nil
+	}
+
+	js := getBuffer()
+	defer putBuffer(js)
+	if err := json.NewEncoder(js).Encode(w); err != nil {
+		return nil, fmt.Errorf("[%s] JSON marshal error: %w", w.Word, err)
+	}
+	// without the newline of Encode
+	d := js.Bytes()[:js.Len()-1]
+	if len(d) < min {
+		return bytes.Clone(d), nil
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(d) / 2)
+	buf.WriteByte(flateMarker)
+	fw := flateWriters.Get().(*flate.Writer)
+	defer flateWriters.Put(fw)
+	fw.Reset(&buf)
+	if _, err := fw.Write(d); err != nil {
+		return nil, fmt.Errorf("[%s] compression error: %w", w.Word, err)
+	}
+	if err := fw.Close(); err != nil {
+		return nil, fmt.Errorf("[%s] compression error: %w", w.Word, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// unmarshalWordLink decodes the value d of the words bucket to w.
+func unmarshalWordLink(d []byte, w *wordLink) error {
+	if len(d) == 0 || d[0] != flateMarker {
+		return json.Unmarshal(d, w)
+	}
+
+	src := bytes.NewReader(d[1:])
+	fr, _ := flateReaders.Get().(io.ReadCloser)
+	if fr == nil {
+		fr = flate.NewReader(src)
+	} else if err := fr.(flate.Resetter).Reset(src, nil); err != nil {
+		return err
+	}
+	defer flateReaders.Put(fr)
+
+	js := getBuffer()
+	defer putBuffer(js)
+	if _, err := js.ReadFrom(fr); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(js.Bytes(), w)
+}
+
+// keepCompression returns the min of marshalWordLink rewriting the value d,
+// so that the maintenance keeps the compressed words compressed.
+func keepCompression(d []byte) int {
+	if len(d) > 0 && d[0] == flateMarker {
+		return 1
+	}
+
+	return 0
+}
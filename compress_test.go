@@ -0,0 +1,127 @@
+package uonum
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// benchWordLink returns a word linked to n words.
+func benchWordLink(n int) *wordLink {
+	w := newWordLinkWithFeatures("は", []string{"助詞"})
+	w.Prev = make(map[string]int64)
+	for i := 0; i < n; i++ {
+		k := encodeKey(fmt.Sprintf("word%d", i), "名詞")
+		w.Links[k] = int64(i + 1)
+		w.Prev[k] = int64(n - i)
+	}
+
+	return w
+}
+
+func TestMarshalWordLink(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		min  int
+		// compressed is whether the value is compressed
+		compressed bool
+	}{
+		{name: "off", n: 100, min: 0},
+		{name: "short", n: 1, min: 1000},
+		{name: "long", n: 100, min: 1000, compressed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := benchWordLink(tt.n)
+			d, err := marshalWordLink(w, tt.min)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d[0] == flateMarker; got != tt.compressed {
+				t.Errorf("compressed = %v, want %v", got, tt.compressed)
+			}
+			if got := keepCompression(d); (got > 0) != tt.compressed {
+				t.Errorf("keepCompression() = %d, want compressed %v", got, tt.compressed)
+			}
+
+			// the value is not shared with the next one
+			if _, err := marshalWordLink(benchWordLink(tt.n+1), tt.min); err != nil {
+				t.Fatal(err)
+			}
+			got := new(wordLink)
+			if err := unmarshalWordLink(d, got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, w) {
+				t.Errorf("unmarshalWordLink() = %+v, want %+v", got, w)
+			}
+		})
+	}
+}
+
+func BenchmarkMarshalWordLink(b *testing.B) {
+	w := benchWordLink(500)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := marshalWordLink(w, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalWordLink(b *testing.B) {
+	d, err := marshalWordLink(benchWordLink(500), 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := unmarshalWordLink(d, new(wordLink)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWriteAmplification reports the bytes of the words written by a
+// registration into a database of 1000 lines, without and with the
+// compression and the parts.
+func BenchmarkWriteAmplification(b *testing.B) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "plain"},
+		{name: "compress=512", opts: []Option{WithCompression(512)}},
+		{name: "part-size=4", opts: []Option{WithPartSize(4)}},
+	}
+
+	texts := strings.Split(strings.TrimSpace(benchText(2000)), "\n")
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			name := benchDB(b, 1000, tt.opts...)
+			g := New(tt.opts...).(*generator)
+			if err := g.Open(name); err != nil {
+				b.Fatal(err)
+			}
+			defer g.Close()
+
+			start := g.Metrics()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := g.Register(texts[1000+i%1000]); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			m := g.Metrics()
+			b.ReportMetric(float64(m.WordBytes-start.WordBytes)/float64(b.N), "written-B/op")
+			b.ReportMetric(float64(m.WordWrites-start.WordWrites)/float64(b.N), "words/op")
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package uonum
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var keyDecayedAt = []byte("decayed_at")
+
+// WithDecay makes Open decay the counts of the links with halfLife by the
+// time since the last decay. See Decay.
+func WithDecay(halfLife time.Duration) Option {
+	return func(g *generator) {
+		g.halfLife = halfLife
+	}
+}
+
+// Decay reduces the counts of the links of all the models exponentially by
+// their age, so that they are halved every halfLife. The age of the links
+// registered on a day is counted from the day, or from the last decay if
+// later. The links registered before the timestamps were recorded decay
+// only from the last decay.
+func (g *generator) Decay(halfLife time.Duration) error {
+	if g.s == nil {
+		return ErrNotOpen
+	}
+
+	var w written
+	err := g.s.Update(func(tx tx) error {
+		err := decay(tx, halfLife, time.Now())
+		if err != nil {
+			return err
+		}
+		w.addAll(tx)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the database: %w", err)
+	}
+	g.invalidate(&w)
+
+	return nil
+}
+
+func decay(tx tx, halfLife time.Duration, now time.Time) error {
+	if halfLife <= 0 {
+		return errors.New("The half-life must be positive.")
+	}
+
+	sb, err := tx.CreateBucketIfNotExists(bucketSettings)
+	if err != nil {
+		return err
+	}
+	var last time.Time
+	if d := sb.Get(keyDecayedAt); d != nil {
+		err = last.UnmarshalText(d)
+		if err != nil {
+			return fmt.Errorf("invalid time of the last decay: %w", err)
+		}
+	}
+
+	factor := func(since time.Time) float64 {
+		if since.Before(last) {
+			since = last
+		}
+		age := now.Sub(since)
+		if since.IsZero() || age <= 0 {
+			return 1
+		}
+		return math.Pow(0.5, float64(age)/float64(halfLife))
+	}
+
+	for _, c := range models(tx) {
+		b := c.Bucket(bucketWords)
+		if b == nil {
+			continue
+		}
+		for _, e := range entries(b) {
+			wl, err := decodeWord(b, e.k, e.v)
+			if err != nil {
+				return err
+			}
+			if len(wl.Links) == 0 {
+				continue
+			}
+
+			wl.decay(factor)
+			_, err = putWordLink(b, e.k, wl, keepCompression(e.v))
+			if err != nil {
+				return err
+			}
+		}
+		err = rebuildPrev(b)
+		if err != nil {
+			return err
+		}
+		err = rebuildClasses(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	d, err := now.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	return sb.Put(keyDecayedAt, d)
+}
+
+// decay reduces the counts of w by factor of the time since which they decay.
+func (w *wordLink) decay(factor func(since time.Time) float64) {
+	old := w.Links
+	w.Links = make(map[string]int64, len(old))
+
+	// the counts registered before the timestamps were recorded
+	untracked := make(map[string]int64, len(old))
+	for k, v := range old {
+		untracked[k] = v
+	}
+	for day, links := range w.History {
+		t, err := time.Parse(dayLayout, day)
+		if err != nil {
+			continue
+		}
+		// the middle of the day, on average
+		f := factor(t.Add(12 * time.Hour))
+		for k, v := range links {
+			untracked[k] -= v
+			n := scale(v, f)
+			if n == 0 {
+				delete(links, k)
+				continue
+			}
+			links[k] = n
+			w.Links[k] += n
+		}
+		if len(links) == 0 {
+			delete(w.History, day)
+		}
+	}
+	f := factor(time.Time{})
+	for k, v := range untracked {
+		if v > 0 {
+			w.Links[k] += scale(v, f)
+		}
+	}
+	for k, v := range w.Links {
+		if v == 0 {
+			delete(w.Links, k)
+		}
+	}
+
+	w.Sources = w.scaleLinkMaps(w.Sources, old)
+	w.Surfaces = w.scaleLinkMaps(w.Surfaces, old)
+}
+
+// scaleLinkMaps scales the counts of the links in m by the ratio of the
+// counts of w to those in old, and returns m.
+func (w *wordLink) scaleLinkMaps(m map[string]map[string]int64, old map[string]int64) map[string]map[string]int64 {
+	for s, links := range m {
+		for k, v := range links {
+			var n int64
+			if old[k] > 0 {
+				n = scale(v, float64(w.Links[k])/float64(old[k]))
+			}
+			if n > w.Links[k] {
+				n = w.Links[k]
+			}
+			if n == 0 {
+				delete(links, k)
+				continue
+			}
+			links[k] = n
+		}
+		if len(links) == 0 {
+			delete(m, s)
+		}
+	}
+
+	return m
+}
+
+// scale returns v * f rounded at random, so that the expected value is kept
+// even if the counts are small.
+func scale(v int64, f float64) int64 {
+	x := float64(v) * f
+	n := math.Floor(x)
+	if random.Float64() < x-n {
+		n++
+	}
+
+	return int64(n)
+}
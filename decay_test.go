@@ -0,0 +1,162 @@
+package uonum
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWordLinkDecay(t *testing.T) {
+	const day = "20240101"
+
+	tests := []struct {
+		name string
+		w    *wordLink
+		// f is the factor of the counts with a timestamp, and untracked of
+		// the ones without
+		f, untracked float64
+		want         *wordLink
+	}{
+		{
+			name:      "untracked",
+			w:         &wordLink{Links: map[string]int64{"a": 4, "b": 2}},
+			f:         1,
+			untracked: 0.5,
+			want:      &wordLink{Links: map[string]int64{"a": 2, "b": 1}},
+		},
+		{
+			name: "history",
+			w: &wordLink{
+				Links:   map[string]int64{"a": 6, "b": 2},
+				History: map[string]map[string]int64{day: {"a": 4, "b": 2}},
+			},
+			f:         0.5,
+			untracked: 1,
+			want: &wordLink{
+				Links:   map[string]int64{"a": 4, "b": 1},
+				History: map[string]map[string]int64{day: {"a": 2, "b": 1}},
+			},
+		},
+		{
+			name: "sources",
+			w: &wordLink{
+				Links:    map[string]int64{"a": 4},
+				Sources:  map[string]map[string]int64{"s": {"a": 2}},
+				Surfaces: map[string]map[string]int64{"x": {"a": 4}},
+			},
+			f:         1,
+			untracked: 0.5,
+			want: &wordLink{
+				Links:    map[string]int64{"a": 2},
+				Sources:  map[string]map[string]int64{"s": {"a": 1}},
+				Surfaces: map[string]map[string]int64{"x": {"a": 2}},
+			},
+		},
+		{
+			name: "forgotten",
+			w: &wordLink{
+				Links:   map[string]int64{"a": 4},
+				History: map[string]map[string]int64{day: {"a": 4}},
+				Sources: map[string]map[string]int64{"s": {"a": 4}},
+			},
+			f:         0,
+			untracked: 1,
+			want: &wordLink{
+				Links:   map[string]int64{},
+				History: map[string]map[string]int64{},
+				Sources: map[string]map[string]int64{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.w.decay(func(since time.Time) float64 {
+				if since.IsZero() {
+					return tt.untracked
+				}
+				return tt.f
+			})
+			if !reflect.DeepEqual(tt.w, tt.want) {
+				t.Errorf("decay() = %+v, want %+v", tt.w, tt.want)
+			}
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		v    int64
+		f    float64
+		want int64
+	}{
+		{v: 10, f: 0.5, want: 5},
+		{v: 3, f: 1, want: 3},
+		{v: 5, f: 0, want: 0},
+		{v: 0, f: 0.5, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := scale(tt.v, tt.f); got != tt.want {
+			t.Errorf("scale(%d, %v) = %d, want %d", tt.v, tt.f, got, tt.want)
+		}
+	}
+
+	// the fractions are rounded up at random by their size
+	up := 0
+	for i := 0; i < 1000; i++ {
+		up += int(scale(1, 0.5))
+	}
+	if up < 400 || up > 600 {
+		t.Errorf("scale(1, 0.5) rounded up %d times in 1000, want about 500", up)
+	}
+}
+
+func TestDecayHalfLife(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		halfLife time.Duration
+		wantErr  bool
+	}{
+		{name: "positive", halfLife: 24 * time.Hour},
+		{name: "zero", halfLife: 0, wantErr: true},
+		{name: "negative", halfLife: -time.Hour, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := openBoltStore(filepath.Join(t.TempDir(), "test.db"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			err = s.Update(func(tx tx) error {
+				return decay(tx, tt.halfLife, now)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decay() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			err = s.View(func(tx tx) error {
+				var last time.Time
+				if err := last.UnmarshalText(tx.Bucket(bucketSettings).Get(keyDecayedAt)); err != nil {
+					return err
+				}
+				if !last.Equal(now) {
+					t.Errorf("decayed at %v, want %v", last, now)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
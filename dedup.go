@@ -0,0 +1,149 @@
+package uonum
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+var bucketHashes = []byte("hashes")
+
+// Dedup is how Register treats a text registered before.
+type Dedup string
+
+const (
+	// DedupAllow registers the duplicates as usual, which is the default.
+	DedupAllow Dedup = "allow"
+	// DedupSkip ignores the duplicates.
+	DedupSkip Dedup = "skip"
+	// DedupDiminish registers the n-th duplicate with the weight 1/(n+1).
+	DedupDiminish Dedup = "diminish"
+)
+
+// ParseDedup returns the Dedup named s.
+func ParseDedup(s string) (Dedup, error) {
+	switch d := Dedup(strings.ToLower(s)); d {
+	case DedupAllow, DedupSkip, DedupDiminish:
+		return d, nil
+	}
+
+	return "", fmt.Errorf("Unknown dedup mode [%s].", s)
+}
+
+// WithDedup sets how Register treats the texts registered before, which are
+// detected by the hashes of the normalized texts.
+func WithDedup(d Dedup) Option {
+	return func(g *generator) {
+		g.dedupMode = d
+	}
+}
+
+func (g *generator) hashText(text string) []byte {
+	h := sha256.Sum256([]byte(g.normalize(g.filter(text))))
+	return h[:]
+}
+
+func hashCount(hb bucket, h []byte) uint64 {
+	if hb == nil {
+		return 0
+	}
+	d := hb.Get(h)
+	if len(d) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(d)
+}
+
+// addHashes adds the counts of the hashes of the texts in the model c.
+func addHashes(c container, hashes map[string]uint64) error {
+	hb := c.Bucket(bucketHashes)
+	if hb == nil {
+		return nil
+	}
+	for h, n := range hashes {
+		err := hb.Put([]byte(h), itob(hashCount(hb, []byte(h))+n))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dedup counts text in the model c, and returns its words wlmap weighted
+// by the number of the times it was registered, or nil if it is skipped.
+// The texts are counted in any mode, so that the texts registered with
+// DedupAllow are detected later with the other modes.
+func (g *generator) dedup(c container, text string, wlmap map[string]*wordLink) (map[string]*wordLink, error) {
+	h := g.hashText(text)
+	n := hashCount(c.Bucket(bucketHashes), h)
+	err := addHashes(c, map[string]uint64{string(h): 1})
+	if err != nil {
+		return nil, err
+	}
+
+	return g.weigh(wlmap, n), nil
+}
+
+// dedupBuffered is dedup for the texts added to the buffer, which counts
+// the buffered texts as well as the registered ones.
+func (g *generator) dedupBuffered(text string, wlmap map[string]*wordLink) (map[string]*wordLink, error) {
+	h := g.hashText(text)
+	var n uint64
+	err := g.viewNS(func(c container) error {
+		n = hashCount(c.Bucket(bucketHashes), h)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	n += g.buf.addHash(g.ns, h)
+
+	return g.weigh(wlmap, n), nil
+}
+
+// weigh returns wlmap of a text registered n times before. The words are
+// weighed in copies, since wlmap is registered again if the transaction is
+// retried.
+func (g *generator) weigh(wlmap map[string]*wordLink, n uint64) map[string]*wordLink {
+	if n == 0 || g.dedupMode == "" || g.dedupMode == DedupAllow {
+		return wlmap
+	}
+	if g.dedupMode == DedupSkip {
+		return nil
+	}
+
+	f := 1 / float64(n+1)
+	weighed := make(map[string]*wordLink, len(wlmap))
+	for k, wl := range wlmap {
+		w := wl.clone()
+		old := w.Links
+		w.Links = scaleLinks(old, f)
+		w.Prev = scaleLinks(w.Prev, f)
+		w.Sources = w.scaleLinkMaps(w.Sources, old)
+		w.History = w.scaleLinkMaps(w.History, old)
+		w.Surfaces = w.scaleLinkMaps(w.Surfaces, old)
+		weighed[k] = w
+	}
+
+	return weighed
+}
+
+// scaleLinks returns the counts of links scaled by f, without the links
+// scaled to 0.
+func scaleLinks(links map[string]int64, f float64) map[string]int64 {
+	if links == nil {
+		return nil
+	}
+
+	scaled := make(map[string]int64, len(links))
+	for k, v := range links {
+		if c := scale(v, f); c > 0 {
+			scaled[k] = c
+		}
+	}
+
+	return scaled
+}
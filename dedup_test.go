@@ -0,0 +1,122 @@
+package uonum
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDedup(t *testing.T) {
+	const text = "猫が魚を食べる。"
+
+	tests := []struct {
+		name string
+		// first registers the text once, then second registers it again
+		first, second Dedup
+		wantTexts     int
+	}{
+		{name: "allow", first: DedupAllow, second: DedupAllow, wantTexts: 2},
+		{name: "skip", first: DedupSkip, second: DedupSkip, wantTexts: 1},
+		{name: "skip after allow", first: DedupAllow, second: DedupSkip, wantTexts: 1},
+		{name: "skip after default", first: "", second: DedupSkip, wantTexts: 1},
+		{name: "diminish", first: DedupDiminish, second: DedupDiminish, wantTexts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.db")
+			for _, d := range []Dedup{tt.first, tt.second} {
+				g := New(WithDedup(d))
+				if err := g.Open(name); err != nil {
+					t.Fatal(err)
+				}
+				if err := g.Register(text); err != nil {
+					t.Fatal(err)
+				}
+				if err := g.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			g := New().(*generator)
+			if err := g.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+
+			texts := 0
+			err := g.s.View(func(tx tx) error {
+				c := tx.Bucket(bucketTexts).Cursor()
+				for k, _ := c.First(); k != nil; k, _ = c.Next() {
+					texts++
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if texts != tt.wantTexts {
+				t.Errorf("texts = %d, want %d", texts, tt.wantTexts)
+			}
+		})
+	}
+}
+
+func TestWeigh(t *testing.T) {
+	newWord := func() *wordLink {
+		return &wordLink{
+			Word:     "食べる",
+			Links:    map[string]int64{"a": 4, "b": 2},
+			Prev:     map[string]int64{"c": 6},
+			Sources:  map[string]map[string]int64{"s": {"a": 4}},
+			History:  map[string]map[string]int64{"2024-01-01": {"a": 4, "b": 2}},
+			Surfaces: map[string]map[string]int64{"食べ": {"a": 2, "b": 2}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		mode Dedup
+		n    uint64
+		want *wordLink
+		skip bool
+	}{
+		{name: "first", mode: DedupDiminish, n: 0, want: newWord()},
+		{name: "allow", mode: DedupAllow, n: 1, want: newWord()},
+		{name: "skip", mode: DedupSkip, n: 1, skip: true},
+		{
+			name: "diminish",
+			mode: DedupDiminish,
+			n:    1,
+			want: &wordLink{
+				Word:     "食べる",
+				Links:    map[string]int64{"a": 2, "b": 1},
+				Prev:     map[string]int64{"c": 3},
+				Sources:  map[string]map[string]int64{"s": {"a": 2}},
+				History:  map[string]map[string]int64{"2024-01-01": {"a": 2, "b": 1}},
+				Surfaces: map[string]map[string]int64{"食べ": {"a": 1, "b": 1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(WithDedup(tt.mode)).(*generator)
+			wlmap := map[string]*wordLink{"w": newWord()}
+
+			got := g.weigh(wlmap, tt.n)
+			if tt.skip {
+				if got != nil {
+					t.Errorf("weigh() = %v, want nil", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got["w"], tt.want) {
+				t.Errorf("weigh() = %+v, want %+v", got["w"], tt.want)
+			}
+			if !reflect.DeepEqual(wlmap["w"], newWord()) {
+				t.Errorf("the words given are changed to %+v", wlmap["w"])
+			}
+		})
+	}
+}
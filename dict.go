@@ -0,0 +1,114 @@
+package uonum
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ikawaha/kagome-dict/dict"
+	"github.com/ikawaha/kagome-dict/ipa"
+	"github.com/ikawaha/kagome-dict/uni"
+	"github.com/ikawaha/kagome/v2/tokenizer"
+)
+
+var dicts = map[string]func() *dict.Dict{
+	"ipa": ipa.Dict,
+	"uni": uni.Dict,
+}
+
+// LoadDict returns the dictionary named name: "ipa" for the IPA dictionary
+// or "uni" for UniDic. Otherwise name is the path of a kagome dictionary
+// file, e.g. one built from mecab-ipadic-NEologd.
+func LoadDict(name string) (*dict.Dict, error) {
+	if d, ok := dicts[name]; ok {
+		return d(), nil
+	}
+
+	d, err := dict.LoadDictFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the dictionary [%s]: %w", name, err)
+	}
+
+	return d, nil
+}
+
+// WithUserDict adds the user dictionary at path to the tokenizer, so that
+// the words in it are tokenized as single units. The file is a CSV of
+// "text,tokens,readings,class" like kagome's user dictionaries.
+func WithUserDict(path string) Option {
+	return func(g *generator) {
+		g.udictPath = path
+	}
+}
+
+// lazyTokenizer is a tokenizer whose system dictionary is loaded at its
+// first use, since loading the IPA dictionary takes hundreds of milliseconds
+// and tens of MB, which the commands not tokenizing (e.g. dump) need not pay.
+type lazyTokenizer struct {
+	once  sync.Once
+	dict  *dict.Dict // the IPA dictionary if nil
+	udict *dict.UserDict
+	t     *tokenizer.Tokenizer
+}
+
+func (l *lazyTokenizer) get() *tokenizer.Tokenizer {
+	l.once.Do(func() {
+		d := l.dict
+		if d == nil {
+			d = ipa.Dict()
+		}
+		opts := []tokenizer.Option{tokenizer.OmitBosEos()}
+		if l.udict != nil {
+			opts = append(opts, tokenizer.UserDict(l.udict))
+		}
+		// New fails only without a dictionary
+		l.t, _ = tokenizer.New(d, opts...)
+	})
+
+	return l.t
+}
+
+type tokenizerKey struct {
+	dict      *dict.Dict
+	udictPath string
+}
+
+// tokenizers are the tokenizers shared by the generators of the same
+// dictionaries.
+var tokenizers = struct {
+	sync.Mutex
+	m map[tokenizerKey]*lazyTokenizer
+}{m: make(map[tokenizerKey]*lazyTokenizer)}
+
+// sharedTokenizer returns the tokenizer with the dictionaries of g. The user
+// dictionary is loaded at once to report its errors, and the system one at
+// the first tokenization.
+func (g *generator) sharedTokenizer() (*lazyTokenizer, error) {
+	tokenizers.Lock()
+	defer tokenizers.Unlock()
+
+	key := tokenizerKey{dict: g.dict, udictPath: g.udictPath}
+	if l, ok := tokenizers.m[key]; ok {
+		return l, nil
+	}
+
+	l := &lazyTokenizer{dict: g.dict}
+	if g.udictPath != "" {
+		u, err := dict.NewUserDict(g.udictPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the user dictionary [%s]: %w", g.udictPath, err)
+		}
+		l.udict = u
+	}
+	tokenizers.m[key] = l
+
+	return l, nil
+}
+
+// WithDict sets the dictionary of the tokenizer. The default is the IPA
+// dictionary. All the commands against a database should use the same one,
+// since the keys in the database depend on it.
+func WithDict(d *dict.Dict) Option {
+	return func(g *generator) {
+		g.dict = d
+	}
+}
@@ -0,0 +1,143 @@
+package uonum
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDict(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "ipa"},
+		{name: "uni"},
+		{name: filepath.Join(t.TempDir(), "missing.dict"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.name), func(t *testing.T) {
+			d, err := LoadDict(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadDict(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+			}
+			if !tt.wantErr && d == nil {
+				t.Errorf("LoadDict(%q) = nil", tt.name)
+			}
+		})
+	}
+}
+
+func TestWithDict(t *testing.T) {
+	for _, name := range []string{"ipa", "uni"} {
+		t.Run(name, func(t *testing.T) {
+			d, err := LoadDict(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := openModel(t, []string{"猫が鳴く。"}, WithDict(d))
+			got, err := g.Generate("猫")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != "猫が鳴く。" {
+				t.Errorf("Generate() = %q, want %q", got, "猫が鳴く。")
+			}
+		})
+	}
+}
+
+func TestUserDict(t *testing.T) {
+	udict := filepath.Join(t.TempDir(), "user.csv")
+	err := os.WriteFile(udict, []byte("うおぬむ,うおぬむ,ウオヌム,カスタム名詞\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    string
+		wantErr error
+	}{
+		{name: "user dict", opts: []Option{WithUserDict(udict)}, want: "うおぬむが鳴く。"},
+		{name: "split", wantErr: ErrUnknownTrigger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, []string{"うおぬむが鳴く。"}, tt.opts...)
+			got, err := g.GenerateWithClasses("うおぬむ", "カスタム名詞")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Generate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Generate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		g := New(WithUserDict(filepath.Join(t.TempDir(), "missing.csv")))
+		if err := g.Open(filepath.Join(t.TempDir(), "test.db")); err == nil {
+			g.Close()
+			t.Error("Open() error = nil, want the user dictionary error")
+		}
+	})
+}
+
+func TestSharedTokenizer(t *testing.T) {
+	udict := filepath.Join(t.TempDir(), "user.csv")
+	err := os.WriteFile(udict, []byte("うおぬむ,うおぬむ,ウオヌム,カスタム名詞\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uni, err := LoadDict("uni")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shared := func(t *testing.T, opts ...Option) *lazyTokenizer {
+		t.Helper()
+		l, err := New(opts...).(*generator).sharedTokenizer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	}
+
+	tests := []struct {
+		name       string
+		a, b       []Option
+		wantShared bool
+	}{
+		{name: "default", wantShared: true},
+		{name: "same dict", a: []Option{WithDict(uni)}, b: []Option{WithDict(uni)}, wantShared: true},
+		{name: "other dict", b: []Option{WithDict(uni)}},
+		{name: "same user dict", a: []Option{WithUserDict(udict)}, b: []Option{WithUserDict(udict)}, wantShared: true},
+		{name: "user dict", b: []Option{WithUserDict(udict)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shared(t, tt.a...) == shared(t, tt.b...); got != tt.wantShared {
+				t.Errorf("shared = %v, want %v", got, tt.wantShared)
+			}
+		})
+	}
+
+	// the system dictionary is loaded at the first use
+	l := shared(t, WithUserDict(udict))
+	if l.t != nil {
+		t.Error("the tokenizer is loaded before its use")
+	}
+	if l.get() == nil || l.get() != l.t {
+		t.Error("get() does not load the tokenizer once")
+	}
+
+	if _, err := New(WithUserDict(filepath.Join(t.TempDir(), "missing.csv"))).(*generator).sharedTokenizer(); err == nil {
+		t.Error("sharedTokenizer() of a missing user dictionary = nil error")
+	}
+}
@@ -0,0 +1,112 @@
+package uonum
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// DiffReport is the difference between two models.
+type DiffReport struct {
+	// OnlyA and OnlyB are the words, in "word_class" form, in only one of
+	// the models.
+	OnlyA []string
+	OnlyB []string
+	// Links are the links whose counts differ.
+	Links []LinkDiff
+}
+
+// LinkDiff is a link whose count is A in one model and B in the other.
+type LinkDiff struct {
+	Link
+	A, B int64
+}
+
+// Diff compares the models of a and b, which must be keyed on the same
+// forms (see WithLemma).
+func Diff(a, b Generator) (*DiffReport, error) {
+	ga, ok1 := a.(*generator)
+	gb, ok2 := b.(*generator)
+	if !ok1 || !ok2 {
+		return nil, errors.New("Could not compare a Generator of another implementation.")
+	}
+	if ga.lemma != gb.lemma {
+		return nil, fmt.Errorf("Could not compare a model keyed on its %s with a model keyed on its %s.", keyedOn(ga.lemma), keyedOn(gb.lemma))
+	}
+
+	wa, err := ga.wordLinks()
+	if err != nil {
+		return nil, err
+	}
+	wb, err := gb.wordLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for k := range wa {
+		keys[k] = true
+	}
+	for k := range wb {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	r := new(DiffReport)
+	for _, k := range sorted {
+		la, lb := wa[k], wb[k]
+		switch {
+		case la == nil:
+			r.OnlyB = append(r.OnlyB, displayKey(k))
+			la = newWordLink("")
+		case lb == nil:
+			r.OnlyA = append(r.OnlyA, displayKey(k))
+			lb = newWordLink("")
+		}
+
+		to := make(map[string]bool)
+		for t := range la.Links {
+			to[t] = true
+		}
+		for t := range lb.Links {
+			to[t] = true
+		}
+		ts := make([]string, 0, len(to))
+		for t := range to {
+			ts = append(ts, t)
+		}
+		sort.Strings(ts)
+
+		for _, t := range ts {
+			ca, cb := la.Links[t], lb.Links[t]
+			if ca == cb {
+				continue
+			}
+			r.Links = append(r.Links, LinkDiff{
+				Link: Link{From: displayKey(k), To: displayKey(t)},
+				A:    ca,
+				B:    cb,
+			})
+		}
+	}
+
+	return r, nil
+}
+
+// wordLinks returns all the words of the model by the keys.
+func (g *generator) wordLinks() (map[string]*wordLink, error) {
+	wlmap := make(map[string]*wordLink)
+	err := g.eachWordLink(func(wl *wordLink) error {
+		wlmap[wl.key()] = wl
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wlmap, nil
+}
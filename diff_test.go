@@ -0,0 +1,69 @@
+package uonum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      []string
+		wantOnlyA []string
+		wantOnlyB []string
+		wantLinks []LinkDiff
+	}{
+		{name: "empty"},
+		{name: "same", a: []string{"猫が鳴く。"}, b: []string{"猫が鳴く。"}},
+		{
+			name:      "only a",
+			a:         []string{"猫が鳴く。", "犬が鳴く。"},
+			b:         []string{"猫が鳴く。"},
+			wantOnlyA: []string{"犬_名詞"},
+			wantLinks: []LinkDiff{
+				{Link: Link{From: "が_助詞", To: "鳴く_動詞"}, A: 2, B: 1},
+				{Link: Link{From: "犬_名詞", To: "が_助詞"}, A: 1, B: 0},
+				{Link: Link{From: "鳴く_動詞", To: "。_記号"}, A: 2, B: 1},
+			},
+		},
+		{
+			name:      "only b",
+			a:         []string{"猫が鳴く。"},
+			b:         []string{"猫が鳴く。", "猫が鳴く。", "鳥が鳴く。"},
+			wantOnlyB: []string{"鳥_名詞"},
+			wantLinks: []LinkDiff{
+				{Link: Link{From: "が_助詞", To: "鳴く_動詞"}, A: 1, B: 3},
+				{Link: Link{From: "猫_名詞", To: "が_助詞"}, A: 1, B: 2},
+				{Link: Link{From: "鳥_名詞", To: "が_助詞"}, A: 0, B: 1},
+				{Link: Link{From: "鳴く_動詞", To: "。_記号"}, A: 1, B: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := openModel(t, tt.a), openModel(t, tt.b)
+			r, err := Diff(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(r.OnlyA, tt.wantOnlyA) || !reflect.DeepEqual(r.OnlyB, tt.wantOnlyB) {
+				t.Errorf("Diff() words = %q, %q, want %q, %q", r.OnlyA, r.OnlyB, tt.wantOnlyA, tt.wantOnlyB)
+			}
+			if !reflect.DeepEqual(r.Links, tt.wantLinks) {
+				t.Errorf("Diff() links = %v, want %v", r.Links, tt.wantLinks)
+			}
+		})
+	}
+}
+
+func TestDiffLemma(t *testing.T) {
+	a := openModel(t, []string{"猫が魚を食べる。"})
+	b := openModel(t, []string{"猫が魚を食べる。"}, WithLemma())
+	if _, err := Diff(a, b); err == nil {
+		t.Error("Diff() of a model keyed on the base forms succeeded, want an error")
+	}
+	if _, err := Diff(b, a); err == nil {
+		t.Error("Diff() with a model keyed on the base forms succeeded, want an error")
+	}
+}
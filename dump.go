@@ -0,0 +1,390 @@
+package uonum
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format is an output format of Dump.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatDOT  Format = "dot"
+)
+
+// ParseFormat returns the Format named s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatText, FormatJSON, FormatCSV, FormatDOT:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("Unknown format [%s].", s)
+}
+
+type dumper interface {
+	begin() error
+	write(wl *wordLink) error
+	end() error
+}
+
+func newDumper(w io.Writer, f Format) (dumper, error) {
+	switch f {
+	case FormatText, "":
+		return &textDumper{w: w}, nil
+	case FormatJSON:
+		return &jsonDumper{w: w}, nil
+	case FormatCSV:
+		return &csvDumper{w: csv.NewWriter(w)}, nil
+	case FormatDOT:
+		return &dotDumper{w: w}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown format [%s].", f)
+}
+
+func (g *generator) DumpFormat(w io.Writer, f Format) error {
+	d, err := newDumper(w, f)
+	if err != nil {
+		return err
+	}
+
+	err = d.begin()
+	if err != nil {
+		return fmt.Errorf("could not write the dump: %w", err)
+	}
+	err = g.eachWordLink(d.write)
+	if err != nil {
+		return err
+	}
+	err = d.end()
+	if err != nil {
+		return fmt.Errorf("could not write the dump: %w", err)
+	}
+
+	return nil
+}
+
+// sortedLinks returns the link keys of wl in key order.
+func (w *wordLink) sortedLinks() []string {
+	keys := make([]string, 0, len(w.Links))
+	for k := range w.Links {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+type textDumper struct {
+	w io.Writer
+}
+
+func (d *textDumper) begin() error {
+	return nil
+}
+
+func (d *textDumper) write(wl *wordLink) error {
+	fmt.Fprintln(d.w, displayKey(wl.key()))
+	for link, count := range wl.Links {
+		fmt.Fprintf(d.w, "  %s : %d\n", displayKey(link), count)
+	}
+	_, err := fmt.Fprintln(d.w)
+
+	return err
+}
+
+func (d *textDumper) end() error {
+	return nil
+}
+
+// jsonDumper writes a JSON array of the words.
+type jsonDumper struct {
+	w     io.Writer
+	count int
+}
+
+type jsonWord struct {
+	Key string `json:"key"`
+	*wordLink
+	Links    map[string]int64            `json:"links"`
+	Prev     map[string]int64            `json:"prev,omitempty"`
+	Sources  map[string]map[string]int64 `json:"sources,omitempty"`
+	History  map[string]map[string]int64 `json:"history,omitempty"`
+	Surfaces map[string]map[string]int64 `json:"surfaces,omitempty"`
+}
+
+func (d *jsonDumper) begin() error {
+	_, err := io.WriteString(d.w, "[")
+	return err
+}
+
+func (d *jsonDumper) write(wl *wordLink) error {
+	b, err := json.Marshal(&jsonWord{
+		Key:      displayKey(wl.key()),
+		wordLink: wl,
+		Links:    displayLinks(wl.Links),
+		Prev:     displayLinks(wl.Prev),
+		Sources:  displayLinkMaps(wl.Sources),
+		History:  displayLinkMaps(wl.History),
+		Surfaces: displayLinkMaps(wl.Surfaces),
+	})
+	if err != nil {
+		return fmt.Errorf("[%s] JSON marshal error: %w", wl.Word, err)
+	}
+
+	if d.count > 0 {
+		_, err = io.WriteString(d.w, ",\n")
+	} else {
+		_, err = io.WriteString(d.w, "\n")
+	}
+	if err != nil {
+		return err
+	}
+	d.count++
+
+	_, err = d.w.Write(b)
+	return err
+}
+
+// displayLinks returns links whose keys are in "word_class" form.
+func displayLinks(links map[string]int64) map[string]int64 {
+	m := make(map[string]int64, len(links))
+	for k, v := range links {
+		m[displayKey(k)] = v
+	}
+
+	return m
+}
+
+// displayLinkMaps is displayLinks for each map in m.
+func displayLinkMaps(m map[string]map[string]int64) map[string]map[string]int64 {
+	if len(m) == 0 {
+		return nil
+	}
+
+	dm := make(map[string]map[string]int64, len(m))
+	for s, links := range m {
+		dm[s] = displayLinks(links)
+	}
+
+	return dm
+}
+
+func (d *jsonDumper) end() error {
+	_, err := io.WriteString(d.w, "\n]\n")
+	return err
+}
+
+// csvDumper writes one record per link: from, to, count.
+type csvDumper struct {
+	w *csv.Writer
+}
+
+func (d *csvDumper) begin() error {
+	return d.w.Write([]string{"from", "to", "count"})
+}
+
+func (d *csvDumper) write(wl *wordLink) error {
+	from := displayKey(wl.key())
+	for _, to := range wl.sortedLinks() {
+		err := d.w.Write([]string{from, displayKey(to), strconv.FormatInt(wl.Links[to], 10)})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *csvDumper) end() error {
+	d.w.Flush()
+	return d.w.Error()
+}
+
+// dotDumper writes a Graphviz digraph whose edges are weighted by count.
+type dotDumper struct {
+	w io.Writer
+}
+
+func (d *dotDumper) begin() error {
+	_, err := io.WriteString(d.w, "digraph uonum {\n")
+	return err
+}
+
+func (d *dotDumper) write(wl *wordLink) error {
+	from := displayKey(wl.key())
+	for _, to := range wl.sortedLinks() {
+		c := wl.Links[to]
+		_, err := fmt.Fprintf(d.w, "  %s -> %s [label=%d, weight=%d];\n",
+			strconv.Quote(from), strconv.Quote(displayKey(to)), c, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *dotDumper) end() error {
+	_, err := io.WriteString(d.w, "}\n")
+	return err
+}
+
+// DumpSort is an order of the words dumped by DumpRange.
+type DumpSort string
+
+const (
+	// SortKey is the key order of the database.
+	SortKey DumpSort = "key"
+	// SortCount is the descending order of the total outgoing count.
+	SortCount DumpSort = "count"
+)
+
+// ParseDumpSort returns the DumpSort named s.
+func ParseDumpSort(s string) (DumpSort, error) {
+	switch o := DumpSort(strings.ToLower(s)); o {
+	case SortKey, SortCount:
+		return o, nil
+	}
+
+	return "", fmt.Errorf("Unknown sort order [%s].", s)
+}
+
+// DumpRange selects the words written by DumpRange.
+type DumpRange struct {
+	Prefix   string   // words which start with Prefix
+	Class    string   // words of Class
+	MinCount int64    // words whose total outgoing count is at least MinCount
+	Sort     DumpSort // SortKey if empty
+	Offset   int      // number of the matched words to skip
+	Limit    int      // maximum number of the words (no limit if <= 0)
+}
+
+// errStop stops the iteration of eachWordLink.
+var errStop = errors.New("Stop.")
+
+func (r *DumpRange) match(wl *wordLink) bool {
+	if _, cl := splitKey(wl.key()); r.Class != "" && cl != r.Class {
+		return false
+	}
+	if !strings.HasPrefix(wl.Word, r.Prefix) {
+		return false
+	}
+
+	return r.MinCount <= 0 || wl.total() >= r.MinCount
+}
+
+// selectWords returns the words selected by r, and the offset of the next
+// page.
+func (g *generator) selectWords(r DumpRange) ([]*wordLink, int, error) {
+	r.Prefix = g.normalize(r.Prefix)
+	if r.Offset < 0 {
+		r.Offset = 0
+	}
+
+	var words []*wordLink
+	next := 0
+	n := 0
+	each := func(wl *wordLink) error {
+		if !r.match(wl) {
+			return nil
+		}
+		if r.Sort == SortCount {
+			words = append(words, wl)
+			return nil
+		}
+
+		// the words are in key order, so enough of them are kept
+		n++
+		if n <= r.Offset {
+			return nil
+		}
+		if r.Limit > 0 && len(words) >= r.Limit {
+			next = r.Offset + r.Limit
+			return errStop
+		}
+		words = append(words, wl)
+		return nil
+	}
+	// the index of the classes finds the words of r.Class
+	err := g.viewWords(func(b bucket) error {
+		return eachWordOfClass(b, r.Class, each)
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, 0, err
+	}
+
+	if r.Sort == SortCount {
+		sort.SliceStable(words, func(i, j int) bool {
+			return words[i].total() > words[j].total()
+		})
+		if r.Offset >= len(words) {
+			words = nil
+		} else {
+			words = words[r.Offset:]
+		}
+		if r.Limit > 0 && len(words) > r.Limit {
+			words = words[:r.Limit]
+			next = r.Offset + r.Limit
+		}
+	}
+
+	return words, next, nil
+}
+
+// Words calls fn with each of the words selected by r, like DumpRange.
+func (g *generator) Words(r DumpRange, fn func(WordInfo) error) (int, error) {
+	words, next, err := g.selectWords(r)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, wl := range words {
+		if err := fn(wl.info()); err != nil {
+			return 0, err
+		}
+	}
+
+	return next, nil
+}
+
+// DumpRange writes the words selected by r in format f.
+// It returns the offset of the next page like a cursor, or 0 if there are no
+// more words.
+func (g *generator) DumpRange(w io.Writer, f Format, r DumpRange) (int, error) {
+	words, next, err := g.selectWords(r)
+	if err != nil {
+		return 0, err
+	}
+
+	d, err := newDumper(w, f)
+	if err != nil {
+		return 0, err
+	}
+	err = d.begin()
+	if err != nil {
+		return 0, fmt.Errorf("could not write the dump: %w", err)
+	}
+	for _, wl := range words {
+		err = d.write(wl)
+		if err != nil {
+			return 0, fmt.Errorf("could not write the dump: %w", err)
+		}
+	}
+	err = d.end()
+	if err != nil {
+		return 0, fmt.Errorf("could not write the dump: %w", err)
+	}
+
+	return next, nil
+}
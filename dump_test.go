@@ -0,0 +1,148 @@
+package uonum
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Format
+		wantErr bool
+	}{
+		{s: "text", want: FormatText},
+		{s: "JSON", want: FormatJSON},
+		{s: "csv", want: FormatCSV},
+		{s: "Dot", want: FormatDOT},
+		{s: "xml", wantErr: true},
+		{s: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDumpFormat(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。"})
+
+	tests := []struct {
+		f    Format
+		want string
+	}{
+		{
+			f: FormatCSV,
+			want: "from,to,count\n" +
+				"が_助詞,鳴く_動詞,1\n" +
+				"猫_名詞,が_助詞,1\n" +
+				"鳴く_動詞,。_記号,1\n",
+		},
+		{
+			f: FormatDOT,
+			want: "digraph uonum {\n" +
+				"  \"が_助詞\" -> \"鳴く_動詞\" [label=1, weight=1];\n" +
+				"  \"猫_名詞\" -> \"が_助詞\" [label=1, weight=1];\n" +
+				"  \"鳴く_動詞\" -> \"。_記号\" [label=1, weight=1];\n" +
+				"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.f), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := g.DumpFormat(&buf, tt.f); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("DumpFormat(%s) = %q, want %q", tt.f, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := g.DumpFormat(&buf, FormatJSON); err != nil {
+			t.Fatal(err)
+		}
+		var words []struct {
+			Key   string           `json:"key"`
+			Links map[string]int64 `json:"links"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &words); err != nil {
+			t.Fatalf("the dump is not JSON: %v\n%s", err, buf.String())
+		}
+		links := make(map[string]map[string]int64)
+		for _, w := range words {
+			links[w.Key] = w.Links
+		}
+		if got := links["猫_名詞"]["が_助詞"]; got != 1 || len(words) != 4 {
+			t.Errorf("DumpFormat(json) = %s, want 4 words with 猫_名詞 -> が_助詞", buf.String())
+		}
+	})
+
+	if err := g.DumpFormat(new(bytes.Buffer), Format("xml")); err == nil {
+		t.Error("DumpFormat() of an unknown format succeeded, want an error")
+	}
+}
+
+func TestDumpRange(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "猫が走る。", "犬が鳴く。"})
+
+	tests := []struct {
+		name     string
+		r        DumpRange
+		want     []string
+		wantNext int
+	}{
+		{name: "all", want: []string{"。", "が", "犬", "猫", "走る", "鳴く"}},
+		{name: "prefix", r: DumpRange{Prefix: "鳴"}, want: []string{"鳴く"}},
+		{name: "class", r: DumpRange{Class: "動詞"}, want: []string{"走る", "鳴く"}},
+		{name: "min count", r: DumpRange{MinCount: 2}, want: []string{"が", "猫", "鳴く"}},
+		{name: "limit", r: DumpRange{Limit: 2}, want: []string{"。", "が"}, wantNext: 2},
+		{name: "offset", r: DumpRange{Offset: 2, Limit: 2}, want: []string{"犬", "猫"}, wantNext: 4},
+		{name: "last page", r: DumpRange{Offset: 4, Limit: 2}, want: []string{"走る", "鳴く"}},
+		{name: "count", r: DumpRange{Sort: SortCount}, want: []string{"が", "猫", "鳴く", "犬", "走る", "。"}},
+		{name: "count limit", r: DumpRange{Sort: SortCount, Offset: 1, Limit: 2}, want: []string{"猫", "鳴く"}, wantNext: 3},
+		{name: "count past end", r: DumpRange{Sort: SortCount, Offset: 9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			next, err := g.Words(tt.r, func(wi WordInfo) error {
+				got = append(got, wi.Word)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("words = %q, want %q", got, tt.want)
+			}
+			if next != tt.wantNext {
+				t.Errorf("next = %d, want %d", next, tt.wantNext)
+			}
+		})
+	}
+
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		next, err := g.DumpRange(&buf, FormatCSV, DumpRange{Class: "名詞", Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "from,to,count\n犬_名詞,が_助詞,1\n"
+		if got := buf.String(); got != want || next != 1 {
+			t.Errorf("DumpRange() = %q, %d, want %q, 1", got, next, want)
+		}
+	})
+}
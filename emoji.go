@@ -0,0 +1,53 @@
+package uonum
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// reEmojiSeq matches an emoji with its modifiers, including the sequences
+	// joined with ZWJ, the flags and the keycaps.
+	reEmojiSeq = regexp.MustCompile(`[\x{1F1E6}-\x{1F1FF}]{2}|[#*0-9]\x{FE0F}?\x{20E3}|` +
+		`[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}][\x{FE0F}\x{1F3FB}-\x{1F3FF}]*` +
+		`(?:\x{200D}[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}][\x{FE0F}\x{1F3FB}-\x{1F3FF}]*)*`)
+	// reKaomoji matches the common kaomoji such as (´・ω・`), (^_^;) and
+	// ヽ(・∀・)ﾉ: a face of symbols in parentheses with the arms if any.
+	reKaomoji = regexp.MustCompile(`[ヽ\\٩o]?[(（][^\p{L}\p{N}\s()（）]*[ω∀ー_・▽◡‿´｀^＾°≧≦;；oｰ][^\p{L}\p{N}\s()（）]*[)）][ﾉノ/۶]?`)
+)
+
+// WithEmoji makes the emoji and the kaomoji, and the matches of patterns,
+// single tokens, so that they are not split into the fragments of symbols
+// and are generated intact.
+func WithEmoji(patterns ...*regexp.Regexp) Option {
+	return func(g *generator) {
+		// the patterns given are tried first
+		var exprs []string
+		for _, p := range append(patterns[:len(patterns):len(patterns)], reKaomoji, reEmojiSeq) {
+			exprs = append(exprs, "(?:"+p.String()+")")
+		}
+		g.emoji = regexp.MustCompile(strings.Join(exprs, "|"))
+	}
+}
+
+// emojiTokens tokenizes text with tokenize, except the emoji.
+func (g *generator) emojiTokens(text string, tokenize func(string) []token) []token {
+	return preTokenize(text, g.emoji, tokenize, func(m []int) *token {
+		if m[0] == m[1] {
+			return nil
+		}
+		return &token{Surface: text[m[0]:m[1]], Features: g.emojiFeatures()}
+	})
+}
+
+// emojiFeatures returns the features of the emoji in the mode of the model.
+func (g *generator) emojiFeatures() []string {
+	switch g.mode {
+	case ModeChar:
+		return []string{charClass, "絵文字"}
+	case ModeWhitespace:
+		return []string{"SYM"}
+	}
+
+	return []string{"記号", "絵文字"}
+}
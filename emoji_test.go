@@ -0,0 +1,78 @@
+package uonum
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestEmojiTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     Mode
+		patterns []*regexp.Regexp
+		text     string
+		want     []string
+	}{
+		{name: "emoji", mode: ModeChar, text: "猫😺だ", want: []string{"猫", "😺", "だ"}},
+		{name: "skin tone", mode: ModeChar, text: "👍🏽", want: []string{"👍🏽"}},
+		{name: "zwj", mode: ModeChar, text: "👨‍👩‍👧です", want: []string{"👨‍👩‍👧", "で", "す"}},
+		{name: "flag", mode: ModeChar, text: "🇯🇵🇺🇸", want: []string{"🇯🇵", "🇺🇸"}},
+		{name: "keycap", mode: ModeChar, text: "1️⃣", want: []string{"1️⃣"}},
+		{name: "kaomoji", mode: ModeChar, text: "猫(´・ω・`)", want: []string{"猫", "(´・ω・`)"}},
+		{name: "arms", mode: ModeChar, text: "ヽ(・∀・)ﾉ", want: []string{"ヽ(・∀・)ﾉ"}},
+		{name: "sweat", mode: ModeWhitespace, text: "ok (^_^;) fine", want: []string{"ok", "(^_^;)", "fine"}},
+		{name: "parentheses", mode: ModeWhitespace, text: "a (note) b", want: []string{"a", "(", "note", ")", "b"}},
+		{
+			name:     "pattern",
+			mode:     ModeWhitespace,
+			patterns: []*regexp.Regexp{regexp.MustCompile(`:[a-z_]+:`)},
+			text:     "hi :smile: there",
+			want:     []string{"hi", ":smile:", "there"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, nil, WithMode(tt.mode), WithEmoji(tt.patterns...))
+			var got []string
+			for _, tok := range g.tokenize(tt.text) {
+				got = append(got, tok.Surface)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmoji(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    string
+		wantErr error
+	}{
+		{name: "emoji", opts: []Option{WithEmoji()}, want: "猫が鳴く(^_^;)"},
+		{name: "without", want: "猫が鳴く(^_^;)", wantErr: ErrUnknownTrigger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, []string{"猫が鳴く(^_^;)"}, tt.opts...)
+			got, err := g.Generate("猫")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Generate() = %q, want %q", got, tt.want)
+			}
+			// the kaomoji is a word only WithEmoji
+			_, err = g.GenerateWithClasses("(^_^;)", "記号")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Generate() of the kaomoji error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
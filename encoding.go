@@ -0,0 +1,114 @@
+package uonum
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// EncodingAuto makes RegisterReader detect the encoding of the input by
+// DetectEncoding.
+const EncodingAuto = "auto"
+
+// detectSize is the size of the beginning of an input DetectEncoding is
+// given.
+const detectSize = 64 * 1024
+
+// WithEncoding makes RegisterReader decode the input from the character
+// encoding name (e.g. shift_jis, euc-jp, or EncodingAuto). The input is
+// UTF-8 by default.
+func WithEncoding(name string) Option {
+	return func(g *generator) {
+		g.encoding = name
+	}
+}
+
+// DetectEncoding guesses the encoding of data from UTF-8, UTF-16 with a BOM,
+// ISO-2022-JP, Shift_JIS and EUC-JP, and returns its name.
+func DetectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		return "utf-8"
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return "utf-16be"
+	// ISO-2022-JP is valid UTF-8 as well
+	case bytes.Contains(data, []byte("\x1b$B")) || bytes.Contains(data, []byte("\x1b$@")):
+		return "iso-2022-jp"
+	case validUTF8Prefix(data):
+		return "utf-8"
+	}
+
+	if japaneseScore(japanese.EUCJP, data) > japaneseScore(japanese.ShiftJIS, data) {
+		return "euc-jp"
+	}
+
+	return "shift_jis"
+}
+
+// validUTF8Prefix reports whether data is UTF-8, allowing a rune cut at the
+// end.
+func validUTF8Prefix(data []byte) bool {
+	for i := 0; i < utf8.UTFMax && i < len(data); i++ {
+		if utf8.Valid(data[:len(data)-i]) {
+			return true
+		}
+	}
+
+	return len(data) == 0
+}
+
+// japaneseScore scores how much data decoded by enc looks like Japanese:
+// kana count for it, and invalid characters against it.
+func japaneseScore(enc encoding.Encoding, data []byte) int {
+	s, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return -len(data)
+	}
+
+	score := 0
+	for _, r := range string(s) {
+		switch {
+		case r == utf8.RuneError:
+			score -= 10
+		case r >= 0x3041 && r <= 0x30ff: // hiragana and katakana
+			score++
+		}
+	}
+
+	return score
+}
+
+// DecodeReader returns the reader of r decoded from the character encoding
+// name, or EncodingAuto to detect it. It is r itself for UTF-8.
+func DecodeReader(r io.Reader, name string) (io.Reader, error) {
+	switch strings.ToLower(name) {
+	case "", "utf-8", "utf8":
+		return r, nil
+	case EncodingAuto:
+		br := bufio.NewReaderSize(r, detectSize)
+		data, _ := br.Peek(detectSize)
+		name = DetectEncoding(data)
+		r = br
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown encoding [%s].", name)
+	}
+	if enc == encoding.Nop || enc == unicode.UTF8 {
+		// skip the BOM
+		return unicode.UTF8BOM.NewDecoder().Reader(r), nil
+	}
+
+	return enc.NewDecoder().Reader(r), nil
+}
@@ -0,0 +1,106 @@
+package uonum
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const encodingText = "吾輩は猫である。名前はまだ無い。\n"
+
+// encode returns encodingText encoded by enc.
+func encode(t *testing.T, enc encoding.Encoding) []byte {
+	t.Helper()
+
+	b, err := enc.NewEncoder().Bytes([]byte(encodingText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestDetectEncoding(t *testing.T) {
+	utf8Text := []byte(encodingText)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "utf-8", data: utf8Text, want: "utf-8"},
+		{name: "utf-8 cut", data: utf8Text[:len(utf8Text)-5], want: "utf-8"},
+		{name: "utf-8 bom", data: append([]byte{0xef, 0xbb, 0xbf}, utf8Text...), want: "utf-8"},
+		{name: "utf-16le", data: encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)), want: "utf-16le"},
+		{name: "utf-16be", data: encode(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM)), want: "utf-16be"},
+		{name: "iso-2022-jp", data: encode(t, japanese.ISO2022JP), want: "iso-2022-jp"},
+		{name: "shift_jis", data: encode(t, japanese.ShiftJIS), want: "shift_jis"},
+		{name: "euc-jp", data: encode(t, japanese.EUCJP), want: "euc-jp"},
+		{name: "empty", want: "utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectEncoding(tt.data); got != tt.want {
+				t.Errorf("DetectEncoding() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		data     []byte
+		wantErr  bool
+	}{
+		{name: "utf-8", encoding: "UTF-8", data: []byte(encodingText)},
+		{name: "default", data: []byte(encodingText)},
+		{name: "shift_jis", encoding: "shift_jis", data: encode(t, japanese.ShiftJIS)},
+		{name: "auto shift_jis", encoding: EncodingAuto, data: encode(t, japanese.ShiftJIS)},
+		{name: "auto euc-jp", encoding: EncodingAuto, data: encode(t, japanese.EUCJP)},
+		{name: "auto bom", encoding: EncodingAuto, data: append([]byte{0xef, 0xbb, 0xbf}, encodingText...)},
+		{name: "unknown", encoding: "ebcdic-jp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := DecodeReader(bytes.NewReader(tt.data), tt.encoding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeReader() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != encodingText {
+				t.Errorf("decoded %q, want %q", got, encodingText)
+			}
+		})
+	}
+}
+
+func TestRegisterEncoding(t *testing.T) {
+	g := openModel(t, nil, WithEncoding(EncodingAuto))
+	if err := g.RegisterReader(bytes.NewReader(encode(t, japanese.ShiftJIS))); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, word := range []string{"吾輩", "猫", "名前"} {
+		infos, err := g.Lookup(word)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) == 0 {
+			t.Errorf("%s is not registered", word)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package uonum
+
+// DefaultEnding matches the words a Japanese sentence naturally ends with:
+// auxiliary verbs and sentence-final particles.
+var DefaultEnding = ParseClasses("助動詞,助詞/終助詞")
+
+// WithEnding makes the generated sentences end on a term word, the end of a
+// registered sentence or a word matched by m. The sentences which dead-end
+// on another word are regenerated within the limits. It does not apply to
+// GenerateFunc and GenerateStream, which emit the words as they are chosen.
+func WithEnding(m FeatureMatcher) Option {
+	return func(g *generator) {
+		g.ending = m
+	}
+}
+
+// endsNaturally reports whether a sentence can end with w after the chain
+// dead-ends on it.
+func (g *generator) endsNaturally(w *wordLink) bool {
+	return g.ending == nil || w == nil || g.ending(w.Features)
+}
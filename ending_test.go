@@ -0,0 +1,42 @@
+package uonum
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEnding(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		opts    []Option
+		want    string
+		wantErr error
+	}{
+		{name: "any", text: "猫が鳴く", want: "猫が鳴く"},
+		{name: "dead end", text: "猫が鳴く", opts: []Option{WithEnding(DefaultEnding)}, wantErr: ErrGenerationFailed},
+		{name: "term word", text: "猫が鳴く。", opts: []Option{WithEnding(DefaultEnding)}, want: "猫が鳴く。"},
+		{name: "particle", text: "猫が鳴くよ", opts: []Option{WithEnding(DefaultEnding)}, want: "猫が鳴くよ"},
+		{name: "auxiliary verb", text: "猫が鳴きます", opts: []Option{WithEnding(DefaultEnding)}, want: "猫が鳴きます"},
+		{
+			name: "end of sentence",
+			text: "猫が鳴く",
+			opts: []Option{WithEnding(DefaultEnding), WithSentenceSplit()},
+			want: "猫が鳴く",
+		},
+		{name: "other ending", text: "猫が鳴く", opts: []Option{WithEnding(Class("動詞"))}, want: "猫が鳴く"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, []string{tt.text}, tt.opts...)
+			got, err := g.Generate("猫")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Generate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Generate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
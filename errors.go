@@ -0,0 +1,48 @@
+package uonum
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotOpen is returned when the database of a Generator is used
+	// before Open.
+	ErrNotOpen = errors.New("Database is not opened.")
+	// ErrUnknownTrigger is returned when the trigger word is not in the
+	// model.
+	ErrUnknownTrigger = errors.New("Unknown trigger word.")
+	// ErrEmptyModel is returned when the model has no words.
+	ErrEmptyModel = errors.New("The model is empty.")
+	// ErrDecode is matched by the errors of the values which could not be
+	// decoded.
+	ErrDecode = errors.New("Could not decode the value.")
+	// ErrReadOnly is returned when the model of a Generator opened with
+	// WithReadOnly is changed.
+	ErrReadOnly = errors.New("The database is opened read-only.")
+)
+
+// DecodeError is an error of a value in the database which could not be
+// decoded. It matches ErrDecode.
+type DecodeError struct {
+	// Key is the key or the name of the value. The keys of the words are in
+	// the form "word_class" of displayKey.
+	Key string
+	Err error
+}
+
+func newDecodeError(key string, err error) error {
+	return &DecodeError{Key: key, Err: err}
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("[%s] could not decode the value: %v", e.Key, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
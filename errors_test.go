@@ -0,0 +1,115 @@
+package uonum
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeError(t *testing.T) {
+	cat := []byte(encodeKey("猫", "名詞"))
+
+	tests := []struct {
+		name    string
+		value   []byte
+		wantErr string
+	}{
+		{
+			name:    "json",
+			value:   []byte("{"),
+			wantErr: "[猫_名詞] could not decode the value: unexpected end of JSON input",
+		},
+		{
+			name:    "flate",
+			value:   []byte{flateMarker, 0xff},
+			wantErr: "[猫_名詞] could not decode the value: flate: corrupt input before offset 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := openBoltStore(filepath.Join(t.TempDir(), "test.db"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			err = s.Update(func(tx tx) error {
+				b, err := tx.CreateBucketIfNotExists(bucketWords)
+				if err != nil {
+					return err
+				}
+				if err := b.Put(cat, tt.value); err != nil {
+					return err
+				}
+				_, err = getWordLink(b, cat)
+				return err
+			})
+			if err == nil {
+				t.Fatal("getWordLink() succeeded, want an error")
+			}
+			if !errors.Is(err, ErrDecode) {
+				t.Errorf("errors.Is(%v, ErrDecode) = false", err)
+			}
+			var de *DecodeError
+			if !errors.As(err, &de) || de.Key != "猫_名詞" {
+				t.Errorf("errors.As(%v) = %v, want the key 猫_名詞", err, de)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("error = %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		texts   []string
+		closed  bool
+		call    func(g Generator) error
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:    "not open",
+			closed:  true,
+			call:    func(g Generator) error { _, err := g.Generate("猫"); return err },
+			wantErr: ErrNotOpen,
+		},
+		{
+			name:    "register not open",
+			closed:  true,
+			call:    func(g Generator) error { return g.Register("猫が鳴く。") },
+			wantErr: ErrNotOpen,
+		},
+		{
+			name:    "empty model",
+			call:    func(g Generator) error { _, err := g.Generate("猫"); return err },
+			wantErr: ErrEmptyModel,
+		},
+		{
+			name:    "unknown trigger",
+			texts:   []string{"猫が鳴く。"},
+			call:    func(g Generator) error { _, err := g.Generate("犬"); return err },
+			wantErr: ErrUnknownTrigger,
+			wantMsg: "could not read the database: [犬_名詞] Unknown trigger word.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g Generator = New()
+			if !tt.closed {
+				g = openModel(t, tt.texts)
+			}
+			err := tt.call(g)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantMsg != "" && err.Error() != tt.wantMsg {
+				t.Errorf("error = %q, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
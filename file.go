@@ -0,0 +1,96 @@
+package uonum
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+var bucketFiles = []byte("files")
+
+// FileResult is the result of RegisterFile.
+type FileResult struct {
+	Name  string
+	Bytes int64
+	Lines int
+	// Skipped is true if a file of the same content was registered before
+	// as Previous.
+	Skipped  bool
+	Previous string
+}
+
+// fileRecord is a registered file, stored by the hash of its content.
+type fileRecord struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+}
+
+// RegisterFile registers the lines in the file name like RegisterReader,
+// unless a file of the same content has been registered in the model.
+func (g *generator) RegisterFile(name string, meta Meta) (*FileResult, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the file [%s]: %w", name, err)
+	}
+	sum := sha256.Sum256(data)
+	res := &FileResult{
+		Name:  name,
+		Bytes: int64(len(data)),
+		Lines: bytes.Count(data, []byte("\n")),
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		res.Lines++
+	}
+
+	err = g.viewNS(func(c container) error {
+		fb := c.Bucket(bucketFiles)
+		if fb == nil {
+			return nil
+		}
+		d := fb.Get(sum[:])
+		if d == nil {
+			return nil
+		}
+		var rec fileRecord
+		err := json.Unmarshal(d, &rec)
+		if err != nil {
+			return newDecodeError(name, err)
+		}
+		res.Skipped = true
+		res.Previous = rec.Name
+		return nil
+	})
+	if err != nil || res.Skipped {
+		return res, err
+	}
+
+	err = g.RegisterReaderWithMeta(bytes.NewReader(data), meta)
+	if err != nil {
+		return nil, err
+	}
+	// the file is recorded only after its texts are written
+	err = g.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.updateNS(func(c container) error {
+		fb, err := c.CreateBucketIfNotExists(bucketFiles)
+		if err != nil {
+			return err
+		}
+		d, err := json.Marshal(&fileRecord{Name: name, Time: time.Now()})
+		if err != nil {
+			return fmt.Errorf("[%s] JSON marshal error: %w", name, err)
+		}
+		return fb.Put(sum[:], d)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
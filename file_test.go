@@ -0,0 +1,52 @@
+package uonum
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegisterFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a.txt", "猫が鳴く。\n犬が鳴く")
+	b := write("b.txt", "猫が鳴く。\n犬が鳴く")
+	c := write("c.txt", "鳥が鳴く。\n")
+
+	g := openModel(t, nil)
+
+	tests := []struct {
+		name      string
+		file      string
+		want      FileResult
+		wantTexts int
+		wantErr   bool
+	}{
+		{name: "registered", file: a, want: FileResult{Name: a, Bytes: 28, Lines: 2}, wantTexts: 2},
+		{name: "same content", file: b, want: FileResult{Name: b, Bytes: 28, Lines: 2, Skipped: true, Previous: a}, wantTexts: 2},
+		{name: "same file", file: a, want: FileResult{Name: a, Bytes: 28, Lines: 2, Skipped: true, Previous: a}, wantTexts: 2},
+		{name: "other content", file: c, want: FileResult{Name: c, Bytes: 16, Lines: 1}, wantTexts: 3},
+		{name: "missing", file: filepath.Join(dir, "missing.txt"), wantTexts: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := g.RegisterFile(tt.file, Meta{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RegisterFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && *res != tt.want {
+				t.Errorf("RegisterFile() = %+v, want %+v", *res, tt.want)
+			}
+			if got := textsIn(t, g); got != tt.wantTexts {
+				t.Errorf("texts = %d, want %d", got, tt.wantTexts)
+			}
+		})
+	}
+}
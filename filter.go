@@ -0,0 +1,83 @@
+package uonum
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter removes unwanted parts from a text before it is registered.
+type Filter func(text string) string
+
+var (
+	reURL     = regexp.MustCompile(`https?://[^\s　]+`)
+	reMention = regexp.MustCompile(`@[A-Za-z0-9_]+`)
+	reHashtag = regexp.MustCompile(`[#＃][\p{L}\p{N}_]+`)
+	reEmoji   = regexp.MustCompile(`[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{1F1E6}-\x{1F1FF}\x{FE0F}\x{200D}]+`)
+)
+
+// StripURLs removes http and https URLs.
+func StripURLs(text string) string {
+	return reURL.ReplaceAllString(text, " ")
+}
+
+// StripMentions removes @mentions.
+func StripMentions(text string) string {
+	return reMention.ReplaceAllString(text, " ")
+}
+
+// StripHashtags removes #hashtags.
+func StripHashtags(text string) string {
+	return reHashtag.ReplaceAllString(text, " ")
+}
+
+// StripEmoji removes emoji.
+func StripEmoji(text string) string {
+	return reEmoji.ReplaceAllString(text, "")
+}
+
+var filters = map[string]Filter{
+	"url":     StripURLs,
+	"mention": StripMentions,
+	"hashtag": StripHashtags,
+	"emoji":   StripEmoji,
+}
+
+// ParseFilters returns the filters named in the comma separated list s:
+// url, mention, hashtag and emoji. "all" means all of them.
+func ParseFilters(s string) ([]Filter, error) {
+	var fs []Filter
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case "all":
+			fs = append(fs, StripURLs, StripMentions, StripHashtags, StripEmoji)
+			continue
+		}
+
+		f, ok := filters[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown filter [%s].", name)
+		}
+		fs = append(fs, f)
+	}
+
+	return fs, nil
+}
+
+// WithFilters sets the filters applied in order to the registered texts.
+func WithFilters(fs ...Filter) Option {
+	return func(g *generator) {
+		g.filters = append(g.filters, fs...)
+	}
+}
+
+func (g *generator) filter(text string) string {
+	for _, f := range g.filters {
+		text = f(text)
+	}
+
+	return text
+}
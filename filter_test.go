@@ -0,0 +1,73 @@
+package uonum
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Filter
+		text string
+		want string
+	}{
+		{name: "url", f: StripURLs, text: "猫 https://example.com/a?b=c　鳴く", want: "猫  　鳴く"},
+		{name: "http", f: StripURLs, text: "猫http://example.com", want: "猫 "},
+		{name: "mention", f: StripMentions, text: "@cat_1 鳴く", want: "  鳴く"},
+		{name: "hashtag", f: StripHashtags, text: "猫 #猫_好き ＃ねこ", want: "猫    "},
+		{name: "emoji", f: StripEmoji, text: "猫🐈‍⬛が鳴く❤️", want: "猫⬛が鳴く"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f(tt.text); got != tt.want {
+				t.Errorf("%s(%q) = %q, want %q", tt.name, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	const text = "@cat #猫 https://example.com 🐈"
+
+	tests := []struct {
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{s: "", want: text},
+		{s: "url", want: "@cat #猫   🐈"},
+		{s: "Mention, hashtag", want: "    https://example.com 🐈"},
+		{s: "all", want: "      "},
+		{s: "url,unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			fs, err := ParseFilters(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFilters(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			g := New(WithFilters(fs...)).(*generator)
+			if got := g.filter(text); got != tt.want {
+				t.Errorf("filter(%q) = %q, want %q", text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilteredModel(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く https://example.com/"}, WithFilters(StripURLs))
+
+	got, err := g.Generate("猫")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "example") {
+		t.Errorf("Generate() = %q, want no URL", got)
+	}
+}
@@ -0,0 +1,116 @@
+package uonum
+
+// CheckReport is the result of Check.
+type CheckReport struct {
+	// Words and Links are the numbers of the words and the links checked.
+	Words int `json:"words"`
+	Links int `json:"links"`
+	// Invalid are the keys whose values could not be decoded.
+	Invalid []string `json:"invalid,omitempty"`
+	// Dangling are the links to the words which do not exist.
+	Dangling []Link `json:"dangling,omitempty"`
+	// Repaired is true if the problems were removed from the database.
+	Repaired bool `json:"repaired"`
+}
+
+// Link is a link between two words, which are the keys in "word_class" form.
+type Link struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// OK reports whether no problem was found.
+func (r *CheckReport) OK() bool {
+	return len(r.Invalid) == 0 && len(r.Dangling) == 0
+}
+
+// Check validates the words of the model: every value must decode, and every
+// link must point to an existing word. If repair is true, the invalid words
+// and the dangling links are removed.
+func (g *generator) Check(repair bool) (*CheckReport, error) {
+	r := new(CheckReport)
+	var w written
+	check := func(c container) error {
+		// the report of a transaction retried starts over
+		*r = CheckReport{Repaired: repair}
+		b := c.Bucket(bucketWords)
+		if b == nil {
+			return nil
+		}
+		err := checkWords(b, r, repair)
+		if err != nil || !repair {
+			return err
+		}
+		w.addAll(c)
+		return nil
+	}
+
+	var err error
+	if repair {
+		err = g.updateNS(check)
+	} else {
+		err = g.viewNS(check)
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.invalidate(&w)
+
+	return r, nil
+}
+
+func checkWords(b bucket, r *CheckReport, repair bool) error {
+	var keys []string
+	words := make(map[string]*wordLink)
+	compress := make(map[string]int)
+	for _, e := range entries(b) {
+		wl, err := decodeWord(b, e.k, e.v)
+		if err != nil || len(wl.Features) == 0 {
+			r.Invalid = append(r.Invalid, displayKey(string(e.k)))
+			if repair {
+				err = deleteWordLink(b, e.k)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		keys = append(keys, string(e.k))
+		words[string(e.k)] = wl
+		compress[string(e.k)] = keepCompression(e.v)
+	}
+	r.Words = len(words)
+
+	for _, k := range keys {
+		wl := words[k]
+		dangling := false
+		for _, to := range wl.sortedLinks() {
+			r.Links++
+			if _, ok := words[to]; ok || to == eosKey {
+				continue
+			}
+			r.Dangling = append(r.Dangling, Link{From: displayKey(k), To: displayKey(to)})
+			delete(wl.Links, to)
+			for _, links := range wl.Sources {
+				delete(links, to)
+			}
+			for _, links := range wl.History {
+				delete(links, to)
+			}
+			for _, links := range wl.Surfaces {
+				delete(links, to)
+			}
+			dangling = true
+		}
+		if !repair || !dangling {
+			continue
+		}
+
+		_, err := putWordLink(b, []byte(k), wl, compress[k])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package uonum
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	word := func(k string, links ...string) *wordLink {
+		w := newWordLinkWithFeatures(k, []string{"名詞"})
+		for _, l := range links {
+			w.Links[encodeKey(l, "名詞")] = 1
+		}
+		return w
+	}
+
+	tests := []struct {
+		name  string
+		words []*wordLink
+		// invalid are the keys put with a value which does not decode
+		invalid      []string
+		wantLinks    int
+		wantInvalid  []string
+		wantDangling []Link
+	}{
+		{
+			name:      "ok",
+			words:     []*wordLink{word("猫", "魚"), word("魚")},
+			wantLinks: 1,
+		},
+		{
+			name:        "invalid",
+			words:       []*wordLink{word("猫")},
+			invalid:     []string{encodeKey("犬", "名詞")},
+			wantInvalid: []string{"犬_名詞"},
+		},
+		{
+			name:         "dangling",
+			words:        []*wordLink{word("猫", "魚", "肉"), word("魚")},
+			wantLinks:    2,
+			wantDangling: []Link{{From: "猫_名詞", To: "肉_名詞"}},
+		},
+		{
+			name:         "dangling to invalid",
+			words:        []*wordLink{word("猫", "犬")},
+			invalid:      []string{encodeKey("犬", "名詞")},
+			wantLinks:    1,
+			wantInvalid:  []string{"犬_名詞"},
+			wantDangling: []Link{{From: "猫_名詞", To: "犬_名詞"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New().(*generator)
+			if err := g.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+
+			err := g.updateNS(func(c container) error {
+				b, err := c.CreateBucketIfNotExists(bucketWords)
+				if err != nil {
+					return err
+				}
+				for _, w := range tt.words {
+					if _, err := putWordLink(b, []byte(w.key()), w, 0); err != nil {
+						return err
+					}
+				}
+				for _, k := range tt.invalid {
+					if err := b.Put([]byte(k), []byte("{")); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, repair := range []bool{false, true} {
+				r, err := g.Check(repair)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Words != len(tt.words) || r.Links != tt.wantLinks {
+					t.Errorf("Check(%v) checked %d words and %d links, want %d and %d", repair, r.Words, r.Links, len(tt.words), tt.wantLinks)
+				}
+				if !reflect.DeepEqual(r.Invalid, tt.wantInvalid) {
+					t.Errorf("Check(%v).Invalid = %q, want %q", repair, r.Invalid, tt.wantInvalid)
+				}
+				if !reflect.DeepEqual(r.Dangling, tt.wantDangling) {
+					t.Errorf("Check(%v).Dangling = %v, want %v", repair, r.Dangling, tt.wantDangling)
+				}
+				if r.Repaired != repair {
+					t.Errorf("Check(%v).Repaired = %v", repair, r.Repaired)
+				}
+			}
+
+			// the problems repaired are not found again
+			r, err := g.Check(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !r.OK() {
+				t.Errorf("Check after the repair = %+v, want OK", r)
+			}
+			if want := tt.wantLinks - len(tt.wantDangling); r.Links != want {
+				t.Errorf("links after the repair = %d, want %d", r.Links, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+module github.com/kechako/uonum
+
+go 1.24.0
+
+require (
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/boltdb/bolt v1.3.1
+	github.com/bwmarrin/discordgo v0.29.0
+	github.com/gomodule/redigo v1.9.3
+	github.com/gorilla/websocket v1.4.2
+	github.com/ikawaha/kagome-dict v1.1.7
+	github.com/ikawaha/kagome-dict/ipa v1.2.6
+	github.com/ikawaha/kagome-dict/uni v1.2.6
+	github.com/ikawaha/kagome/v2 v2.11.0
+	golang.org/x/crypto v0.40.0
+	golang.org/x/sys v0.36.0
+	golang.org/x/text v0.32.0
+	google.golang.org/grpc v1.76.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/net v0.42.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250804133106-a7a43d27e69b // indirect
+)
@@ -0,0 +1,83 @@
+package uonum
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Graph writes the word chain as a Graphviz DOT graph.
+// If word is not empty, the graph is limited to the words reachable from any
+// class of word within hops links (no limit if hops <= 0).
+func (g *generator) Graph(w io.Writer, word string, hops int) error {
+	word = g.normalize(word)
+	if word == "" {
+		return g.DumpFormat(w, FormatDOT)
+	}
+
+	d := &dotDumper{w: w}
+	err := d.begin()
+	if err != nil {
+		return fmt.Errorf("could not write the graph: %w", err)
+	}
+
+	err = g.viewWords(func(b bucket) error {
+		var queue []string
+		prefix := wordPrefix(word)
+		c := b.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			queue = append(queue, string(k))
+		}
+
+		depth := make(map[string]int)
+		for _, k := range queue {
+			depth[k] = 0
+			_, err := fmt.Fprintf(w, "  %s [style=filled];\n", strconv.Quote(displayKey(k)))
+			if err != nil {
+				return err
+			}
+		}
+
+		for len(queue) > 0 {
+			k := queue[0]
+			queue = queue[1:]
+
+			if hops > 0 && depth[k] >= hops {
+				continue
+			}
+
+			wl, err := getWordLink(b, []byte(k))
+			if err != nil {
+				return err
+			}
+			if wl == nil {
+				continue
+			}
+			err = d.write(wl)
+			if err != nil {
+				return err
+			}
+
+			for _, n := range wl.sortedLinks() {
+				if _, ok := depth[n]; ok {
+					continue
+				}
+				depth[n] = depth[k] + 1
+				queue = append(queue, n)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.end()
+	if err != nil {
+		return fmt.Errorf("could not write the graph: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package uonum
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "犬が走る。"})
+
+	tests := []struct {
+		name string
+		word string
+		hops int
+		// want and notWant are the lines in the graph and not in it
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "one hop",
+			word: "猫",
+			hops: 1,
+			want: []string{
+				`"猫_名詞" [style=filled];`,
+				`"猫_名詞" -> "が_助詞" [label=1, weight=1];`,
+			},
+			notWant: []string{`"が_助詞" ->`, `"犬_名詞" ->`},
+		},
+		{
+			name: "reachable",
+			word: "猫",
+			want: []string{
+				`"猫_名詞" -> "が_助詞" [label=1, weight=1];`,
+				`"が_助詞" -> "走る_動詞" [label=1, weight=1];`,
+				`"が_助詞" -> "鳴く_動詞" [label=1, weight=1];`,
+				`"鳴く_動詞" -> "。_記号" [label=1, weight=1];`,
+			},
+			notWant: []string{`"犬_名詞" ->`},
+		},
+		{
+			name:    "unknown",
+			word:    "鳥",
+			notWant: []string{"->", "[style=filled]"},
+		},
+		{
+			name: "whole",
+			want: []string{
+				`"犬_名詞" -> "が_助詞" [label=1, weight=1];`,
+				`"猫_名詞" -> "が_助詞" [label=1, weight=1];`,
+			},
+			notWant: []string{"[style=filled]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := g.Graph(&buf, tt.word, tt.hops); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.String()
+			if !strings.HasPrefix(got, "digraph uonum {\n") || !strings.HasSuffix(got, "}\n") {
+				t.Errorf("Graph() = %q, want a digraph", got)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("Graph() = %q, want %q in it", got, s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("Graph() = %q, want no %q in it", got, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package uonum
+
+import (
+	"time"
+)
+
+// dayLayout is the layout of the keys of wordLink.History.
+const dayLayout = "20060102"
+
+func dayKey(t time.Time) string {
+	return t.UTC().Format(dayLayout)
+}
+
+// tagTime records the links of wlmap, which are of a text, as made at t.
+func tagTime(wlmap map[string]*wordLink, t time.Time) {
+	day := dayKey(t)
+	for _, wl := range wlmap {
+		if len(wl.Links) == 0 {
+			continue
+		}
+		links := make(map[string]int64, len(wl.Links))
+		for k, v := range wl.Links {
+			links[k] = v
+		}
+		wl.History = map[string]map[string]int64{day: links}
+	}
+}
+
+// linksSince returns the counts of the links made on or after the day of
+// since.
+func (w *wordLink) linksSince(since time.Time) map[string]int64 {
+	from := dayKey(since)
+	links := make(map[string]int64)
+	for day, l := range w.History {
+		if day < from {
+			continue
+		}
+		for k, v := range l {
+			links[k] += v
+		}
+	}
+
+	return links
+}
+
+// WithSince makes the generation use only the links registered on or after
+// the day of since.
+func WithSince(since time.Time) Option {
+	return func(g *generator) {
+		g.since = since
+	}
+}
+
+func (g *generator) GenerateSince(trigger string, since time.Time) (string, error) {
+	c := *g
+	c.since = since
+	return c.Generate(trigger)
+}
@@ -0,0 +1,105 @@
+package uonum
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTagTime(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{name: "utc", t: time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), want: "20240101"},
+		{name: "local", t: time.Date(2024, 1, 2, 8, 0, 0, 0, time.FixedZone("JST", 9*60*60)), want: "20240101"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWordLink("猫")
+			w.Links["a"] = 2
+			end := newWordLink("。")
+			wlmap := map[string]*wordLink{"w": w, "end": end}
+
+			tagTime(wlmap, tt.t)
+			want := map[string]map[string]int64{tt.want: {"a": 2}}
+			if !reflect.DeepEqual(w.History, want) {
+				t.Errorf("history = %v, want %v", w.History, want)
+			}
+			if end.History != nil {
+				t.Errorf("history of the word without links = %v, want nil", end.History)
+			}
+		})
+	}
+}
+
+func TestLinksSince(t *testing.T) {
+	w := &wordLink{
+		Word:  "猫",
+		Links: map[string]int64{"a": 6, "b": 1},
+		History: map[string]map[string]int64{
+			"20240101": {"a": 3, "b": 1},
+			"20240102": {"a": 2},
+			"20240201": {"a": 1},
+		},
+	}
+
+	tests := []struct {
+		since time.Time
+		want  map[string]int64
+	}{
+		{since: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), want: map[string]int64{"a": 6, "b": 1}},
+		{since: time.Date(2024, 1, 1, 23, 59, 0, 0, time.UTC), want: map[string]int64{"a": 6, "b": 1}},
+		{since: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), want: map[string]int64{"a": 3}},
+		{since: time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC), want: map[string]int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(dayKey(tt.since), func(t *testing.T) {
+			if got := w.linksSince(tt.since); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("linksSince(%v) = %v, want %v", tt.since, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateSince(t *testing.T) {
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := openModel(t, nil)
+	if err := g.RegisterWithMeta("猫が鳴く。", Meta{Time: old}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RegisterWithMeta("猫が吠える。", Meta{Time: old.AddDate(0, 1, 0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		since time.Time
+		want  []string
+	}{
+		{since: old, want: []string{"猫が鳴く。", "猫が吠える。"}},
+		{since: old.AddDate(0, 0, 1), want: []string{"猫が吠える。"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(dayKey(tt.since), func(t *testing.T) {
+			got := make(map[string]bool)
+			for i := 0; i < 50; i++ {
+				s, err := g.GenerateSince("猫", tt.since)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[s] = true
+			}
+			want := make(map[string]bool)
+			for _, s := range tt.want {
+				want[s] = true
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GenerateSince(%v) = %v, want %v", tt.since, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package uonum
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// A key of the words bucket is a composite of the word and its class:
+// the length of the word in bytes, the word, and the class. The length keeps
+// the words containing "_" apart from the class, and makes all the keys of a
+// word share a prefix. It is encoded in runes rather than bytes so that the
+// keys in the links stay valid UTF-8 in JSON: a length below the surrogates
+// (U+D800) is a rune of its value, and a longer one is a rune from longLen
+// with its bits above 15 followed by a rune of its lower 15 bits.
+
+// longLen is the first rune of a length of two runes.
+const longLen = 0xe000
+
+// appendKeyLen appends the encoded length n of a word to p.
+func appendKeyLen(p []byte, n int) []byte {
+	if n < 0xd800 {
+		return utf8.AppendRune(p, rune(n))
+	}
+
+	p = utf8.AppendRune(p, longLen+rune(n>>15))
+	return utf8.AppendRune(p, rune(n&0x7fff))
+}
+
+// keyLen decodes the length of the word at the start of key, and returns it
+// with the size of its encoding, or size 0 if it is malformed.
+func keyLen(key string) (n, size int) {
+	r, size := utf8.DecodeRuneInString(key)
+	switch {
+	case r == utf8.RuneError && size <= 1:
+		return 0, 0
+	case r < 0xd800:
+		return int(r), size
+	case r < longLen:
+		return 0, 0
+	}
+
+	lo, s := utf8.DecodeRuneInString(key[size:])
+	if (lo == utf8.RuneError && s <= 1) || lo > 0x7fff {
+		return 0, 0
+	}
+
+	return int(r-longLen)<<15 | int(lo), size + s
+}
+
+// encodeKey returns the key of word of class.
+func encodeKey(word, class string) string {
+	return string(append(wordPrefix(word), class...))
+}
+
+// wordPrefix returns the prefix of the keys of word.
+func wordPrefix(word string) []byte {
+	p := make([]byte, 0, 2*utf8.UTFMax+len(word))
+	p = appendKeyLen(p, len(word))
+	return append(p, word...)
+}
+
+// splitKey splits a key of the words bucket into the word and its class.
+// A malformed key is returned as the word.
+func splitKey(key string) (word, class string) {
+	n, size := keyLen(key)
+	if size == 0 || size+n > len(key) {
+		return key, ""
+	}
+
+	return key[size : size+n], key[size+n:]
+}
+
+// displayKey returns key in the human readable form "word_class".
+func displayKey(key string) string {
+	w, c := splitKey(key)
+	return w + "_" + c
+}
+
+// keyKeyFormat marks the databases whose keys were converted before the
+// schema version was introduced.
+var keyKeyFormat = []byte("key_format")
+
+// legacyKey converts a key in the old "word_class" form.
+func legacyKey(key string) string {
+	i := strings.LastIndex(key, "_")
+	if i < 0 {
+		return encodeKey(key, "")
+	}
+
+	return encodeKey(key[:i], key[i+1:])
+}
+
+// migrateKeys converts the keys of the models in the old "word_class" form
+// to the composite keys.
+func migrateKeys(tx tx) error {
+	if sb := tx.Bucket(bucketSettings); sb != nil && sb.Get(keyKeyFormat) != nil {
+		return nil
+	}
+
+	for _, m := range models(tx) {
+		err := migrateWords(m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type entry struct {
+	k, v []byte
+}
+
+// entries returns all the keys and values in b, so that b can be modified
+// while they are processed.
+func entries(b bucket) []entry {
+	var es []entry
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			continue
+		}
+		es = append(es, entry{
+			k: append([]byte(nil), k...),
+			v: append([]byte(nil), v...),
+		})
+	}
+
+	return es
+}
+
+func migrateWords(c container) error {
+	b := c.Bucket(bucketWords)
+	if b == nil {
+		return nil
+	}
+
+	for _, e := range entries(b) {
+		wl := new(wordLink)
+		err := json.Unmarshal(e.v, wl)
+		if err != nil {
+			return newDecodeError(string(e.k), err)
+		}
+		links := make(map[string]int64, len(wl.Links))
+		for k, v := range wl.Links {
+			links[legacyKey(k)] += v
+		}
+		wl.Links = links
+
+		d, err := json.Marshal(wl)
+		if err != nil {
+			return fmt.Errorf("[%s] JSON marshal error: %w", e.k, err)
+		}
+		err = b.Delete(e.k)
+		if err != nil {
+			return err
+		}
+		err = b.Put([]byte(legacyKey(string(e.k))), d)
+		if err != nil {
+			return err
+		}
+	}
+
+	rb := c.Bucket(bucketReadings)
+	if rb == nil {
+		return nil
+	}
+	for _, e := range entries(rb) {
+		i := strings.Index(string(e.k), readingSep)
+		if i < 0 {
+			continue
+		}
+		err := rb.Delete(e.k)
+		if err != nil {
+			return err
+		}
+		key := string(e.k[:i+len(readingSep)]) + legacyKey(string(e.k[i+len(readingSep):]))
+		err = rb.Put([]byte(key), e.v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package uonum
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		word, class string
+		display     string
+	}{
+		{name: "word", word: "猫", class: "名詞", display: "猫_名詞"},
+		{name: "no class", word: "猫", display: "猫_"},
+		{name: "underscore", word: "a_b", class: "名詞", display: "a_b_名詞"},
+		{name: "empty", display: "_"},
+		{name: "below surrogates", word: strings.Repeat("a", 0xd7ff), class: "名詞"},
+		{name: "surrogate", word: strings.Repeat("a", 0xd800), class: "名詞"},
+		{name: "last surrogate", word: strings.Repeat("a", 0xdfff), class: "名詞"},
+		{name: "beyond runes", word: strings.Repeat("a", 0x110000), class: "名詞"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := encodeKey(tt.word, tt.class)
+			if w, c := splitKey(key); w != tt.word || c != tt.class {
+				t.Errorf("splitKey() = %q, %q, want %q, %q", w, c, tt.word, tt.class)
+			}
+			if tt.display == "" {
+				tt.display = tt.word + "_" + tt.class
+			}
+			if got := displayKey(key); got != tt.display {
+				t.Errorf("displayKey() = %q, want %q", got, tt.display)
+			}
+			if p := string(wordPrefix(tt.word)); key[:len(p)] != p {
+				t.Errorf("wordPrefix() = %q, not a prefix of %q", p, key)
+			}
+
+			// the keys in the links are kept through JSON
+			d, err := json.Marshal(map[string]int64{key: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var links map[string]int64
+			if err := json.Unmarshal(d, &links); err != nil {
+				t.Fatal(err)
+			}
+			if links[key] != 1 {
+				t.Error("the key is changed through JSON")
+			}
+		})
+	}
+}
+
+func TestSplitMalformedKey(t *testing.T) {
+	tests := []string{"", "\x05ab", "\xff"}
+
+	for _, key := range tests {
+		if w, c := splitKey(key); w != key || c != "" {
+			t.Errorf("splitKey(%q) = %q, %q, want the key as the word", key, w, c)
+		}
+	}
+}
+
+func TestLegacyKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "猫_名詞", want: encodeKey("猫", "名詞")},
+		{key: "a_b_名詞", want: encodeKey("a_b", "名詞")},
+		{key: "猫", want: encodeKey("猫", "")},
+	}
+
+	for _, tt := range tests {
+		if got := legacyKey(tt.key); got != tt.want {
+			t.Errorf("legacyKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestMigrateWords(t *testing.T) {
+	s, err := openBoltStore(filepath.Join(t.TempDir(), "test.db"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	words := map[string]map[string]int64{
+		"猫_名詞": {"が_助詞": 2},
+		"が_助詞": {"鳴く_動詞": 1, "<EOS>_": 1},
+	}
+	err = s.Update(func(tx tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketWords)
+		if err != nil {
+			return err
+		}
+		for k, links := range words {
+			d, _ := json.Marshal(&wordLink{Word: k, Links: links})
+			if err := b.Put([]byte(k), d); err != nil {
+				return err
+			}
+		}
+		return migrateWords(tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.View(func(tx tx) error {
+		b := tx.Bucket(bucketWords)
+		for k, links := range words {
+			if b.Get([]byte(k)) != nil {
+				t.Errorf("old key %q is left", k)
+			}
+			var wl wordLink
+			if err := json.Unmarshal(b.Get([]byte(legacyKey(k))), &wl); err != nil {
+				t.Fatalf("%s: %v", k, err)
+			}
+			want := make(map[string]int64)
+			for l, n := range links {
+				want[legacyKey(l)] = n
+			}
+			if !reflect.DeepEqual(wl.Links, want) {
+				t.Errorf("links of %q = %q, want %q", k, wl.Links, want)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
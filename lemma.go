@@ -0,0 +1,145 @@
+package uonum
+
+import "fmt"
+
+// In the lemma mode, the words of the chain are keyed on their base forms,
+// so that the inflections of a word (e.g. 食べる, 食べ, 食べて) strengthen the
+// same word. The surfaces seen of a word are counted by the word following
+// them in wordLink.Surfaces, and a generated sentence writes a surface chosen
+// at random by its count before the next word chosen, so that 食べ is written
+// before ます and 食べる before 。.
+
+var keyLemma = []byte("lemma")
+
+// WithLemma keys the words on their base forms in the dictionary instead of
+// their surfaces. It is saved in the database when the database is created,
+// and can not be changed later. The words without a base form, such as the
+// unknown words, are keyed on their surfaces.
+func WithLemma() Option {
+	return func(g *generator) {
+		g.lemma = true
+		g.lemmaSet = true
+	}
+}
+
+// syncLemma loads the lemma mode of the database, or saves the one given to
+// the generator if the database has none yet.
+func (g *generator) syncLemma(tx tx, b bucket) error {
+	if d := b.Get(keyLemma); d != nil {
+		lemma := string(d) == "true"
+		if g.lemmaSet && lemma != g.lemma {
+			return fmt.Errorf("The words of the database are keyed on their %s, not on their %s.", keyedOn(lemma), keyedOn(g.lemma))
+		}
+		g.lemma = lemma
+		return nil
+	}
+
+	// An existing database without the setting is keyed on the surfaces.
+	if g.lemma {
+		if k, _ := tx.Bucket(bucketWords).Cursor().First(); k != nil {
+			return fmt.Errorf("The words of the database are keyed on their %s, not on their %s.", keyedOn(false), keyedOn(true))
+		}
+	}
+
+	return b.Put(keyLemma, []byte(fmt.Sprint(g.lemma)))
+}
+
+func keyedOn(lemma bool) string {
+	if lemma {
+		return "base forms"
+	}
+
+	return "surfaces"
+}
+
+// triggerWord returns trigger normalized, and in the lemma mode its base form
+// if it is a single word, so that the inflections of a word start from it.
+func (g *generator) triggerWord(trigger string) string {
+	trigger = g.normalize(trigger)
+	if !g.lemma || g.mode != ModeWord || g.t == nil || trigger == "" {
+		return trigger
+	}
+
+	tokens := cleanTokens(g.t.get().Tokenize(trigger), true)
+	if len(tokens) != 1 {
+		return trigger
+	}
+
+	return tokens[0].Base
+}
+
+// addSurface counts surface of w seen before the word next.
+func (w *wordLink) addSurface(surface, next string) {
+	if w.Surfaces == nil {
+		w.Surfaces = make(map[string]map[string]int64)
+	}
+	if w.Surfaces[surface] == nil {
+		w.Surfaces[surface] = make(map[string]int64)
+	}
+	w.Surfaces[surface][next]++
+}
+
+// surface returns a surface of w chosen by the counts of the surfaces seen
+// before the word next, or before any word if none is seen before it. It
+// returns the word itself if no surface is recorded.
+func (w *wordLink) surface(next string) string {
+	choose := func(count func(links map[string]int64) int64) string {
+		var total int64
+		for _, links := range w.Surfaces {
+			total += count(links)
+		}
+		if total <= 0 {
+			return ""
+		}
+
+		n := random.Int63n(total)
+		for s, links := range w.Surfaces {
+			if n -= count(links); n < 0 {
+				return s
+			}
+		}
+		return ""
+	}
+
+	if s := choose(func(links map[string]int64) int64 { return links[next] }); s != "" {
+		return s
+	}
+	s := choose(func(links map[string]int64) int64 {
+		var n int64
+		for _, c := range links {
+			n += c
+		}
+		return n
+	})
+	if s == "" {
+		return w.Word
+	}
+
+	return s
+}
+
+// realize returns the words of keys in b, each as a surface seen before the
+// key following it, and the last one before last.
+func (g *generator) realize(b bucket, keys []string, last string) ([]string, error) {
+	words := make([]string, len(keys))
+	for i, k := range keys {
+		words[i], _ = splitKey(k)
+		if !g.lemma {
+			continue
+		}
+
+		next := last
+		if i+1 < len(keys) {
+			next = keys[i+1]
+		}
+		wl, err := g.wordLink(b, []byte(k))
+		if err != nil {
+			return nil, err
+		}
+		if wl != nil {
+			words[i] = wl.surface(next)
+		}
+	}
+
+	return words, nil
+}
@@ -0,0 +1,125 @@
+package uonum
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestTriggerWord(t *testing.T) {
+	tests := []struct {
+		trigger string
+		lemma   bool
+		want    string
+	}{
+		{trigger: "食べ", lemma: true, want: "食べる"},
+		{trigger: "食べ", lemma: false, want: "食べ"},
+		{trigger: "猫", lemma: true, want: "猫"},
+		{trigger: "猫が", lemma: true, want: "猫が"},
+		{trigger: "", lemma: true, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v", tt.trigger, tt.lemma), func(t *testing.T) {
+			var opts []Option
+			if tt.lemma {
+				opts = append(opts, WithLemma())
+			}
+			g := New(opts...).(*generator)
+			if err := g.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+
+			if got := g.triggerWord(tt.trigger); got != tt.want {
+				t.Errorf("triggerWord(%q) = %q, want %q", tt.trigger, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLemmaInflectedTrigger(t *testing.T) {
+	g := New(WithLemma(), WithTriggerMatch(Class("動詞"))).(*generator)
+	if err := g.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if err := g.Register("猫が魚を食べました。"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, trigger := range []string{"食べ", "食べる"} {
+		text, err := g.Generate(trigger)
+		if errors.Is(err, ErrUnknownTrigger) {
+			t.Errorf("Generate(%q) = %v", trigger, err)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != "食べました。" {
+			t.Errorf("Generate(%q) = %q, want 食べました。", trigger, text)
+		}
+	}
+}
+
+func TestChooseNextLemmaParts(t *testing.T) {
+	s, err := openBoltStore(filepath.Join(t.TempDir(), "test.db"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	key := []byte(encodeKey("食べる", "動詞"))
+	w := newWordLinkWithFeatures("食べる", []string{"動詞"})
+	for i := 0; i < 8; i++ {
+		next := encodeKey(fmt.Sprint(i), "名詞")
+		w.Links[next] = 1
+		w.addSurface(fmt.Sprintf("食べ%d", i), next)
+	}
+
+	g := New(WithLemma()).(*generator)
+	err = s.Update(func(tx tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketWords)
+		if err != nil {
+			return err
+		}
+		// mergeWordLink splits the links of w into the parts of the head
+		head := newWordLinkWithFeatures("食べる", []string{"動詞"})
+		head.Parts = 4
+		if _, err := putWordLink(b, key, head, 0); err != nil {
+			return err
+		}
+		_, err = mergeWordLink(b, key, w, 0, 0)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.View(func(tx tx) error {
+		b := tx.Bucket(bucketWords)
+		head, err := getWordHead(b, key)
+		if err != nil {
+			return err
+		}
+		if !head.partial {
+			t.Fatal("the word is not split")
+		}
+		next, full, err := g.chooseNext(b, key, head, nil)
+		if err != nil {
+			return err
+		}
+		if full.partial || len(full.Surfaces) != 8 {
+			t.Errorf("chooseNext() = %d surfaces, want 8", len(full.Surfaces))
+		}
+		if s := full.surface(next); s == full.Word {
+			t.Errorf("surface(%q) = %q, want an inflection", next, s)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
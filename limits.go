@@ -0,0 +1,38 @@
+package uonum
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrGenerationFailed is returned when no sentence could be generated within
+// the limits.
+var ErrGenerationFailed = errors.New("Could not generate a sentence.")
+
+// Limits are the safeguards of a generation call. Zero values mean no limit.
+type Limits struct {
+	// Timeout is the wall-clock time of a call including the retries.
+	Timeout time.Duration
+	// MaxRetries is the number of regenerations of a rejected sentence.
+	MaxRetries int
+	// MaxWords is the number of words in a sentence. A longer sentence
+	// is rejected.
+	MaxWords int
+}
+
+// WithLimits sets the limits of the generation calls.
+func WithLimits(l Limits) Option {
+	return func(g *generator) {
+		g.limits = l
+	}
+}
+
+// deadline returns the time by which a generation call starting now must
+// end, or the zero time if it has no time limit.
+func (g *generator) deadline() time.Time {
+	if g.limits.Timeout <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(g.limits.Timeout)
+}
@@ -0,0 +1,54 @@
+package uonum
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    Limits
+		want      string
+		wantErr   error
+		wantWalks int64
+	}{
+		{name: "none", want: "猫が鳴く。", wantWalks: 1},
+		{name: "words", limits: Limits{MaxWords: 4}, want: "猫が鳴く。", wantWalks: 1},
+		{name: "too many words", limits: Limits{MaxWords: 3}, wantErr: ErrGenerationFailed, wantWalks: 1},
+		{name: "retries", limits: Limits{MaxWords: 3, MaxRetries: 2}, wantErr: ErrGenerationFailed, wantWalks: 3},
+		{name: "timeout", limits: Limits{Timeout: time.Nanosecond}, wantErr: ErrGenerationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, []string{"猫が鳴く。"}, WithLimits(tt.limits))
+			got, err := g.Generate("猫")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Generate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Generate() = %q, want %q", got, tt.want)
+			}
+			if walks := g.Metrics().Walks; walks != tt.wantWalks {
+				t.Errorf("walks = %d, want %d", walks, tt.wantWalks)
+			}
+		})
+	}
+}
+
+func TestLimitsRetried(t *testing.T) {
+	// half of the walks from 猫 are longer than 4 words
+	g := openModel(t, []string{"猫が鳴く。", "猫が犬と遊ぶ。"}, WithLimits(Limits{MaxWords: 4, MaxRetries: 100}))
+
+	for range 20 {
+		got, err := g.Generate("猫")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "猫が鳴く。" {
+			t.Fatalf("Generate() = %q, want %q", got, "猫が鳴く。")
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package uonum
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger makes the Generator write the debug logs of the tokenization,
+// the writes to the database and the choices of the words to l.
+func WithLogger(l *slog.Logger) Option {
+	return func(g *generator) {
+		g.log = l
+	}
+}
+
+// debugging reports whether the debug logs are written, to skip building
+// their attributes.
+func (g *generator) debugging() bool {
+	return g.log != nil && g.log.Enabled(context.Background(), slog.LevelDebug)
+}
+
+func (g *generator) debug(msg string, args ...any) {
+	if g.log != nil {
+		g.log.Debug(msg, args...)
+	}
+}
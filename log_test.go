@@ -0,0 +1,60 @@
+package uonum
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWithLogger(t *testing.T) {
+	tests := []struct {
+		name  string
+		level slog.Level
+		want  []string
+	}{
+		{
+			name:  "debug",
+			level: slog.LevelDebug,
+			want: []string{
+				`msg=Tokenized. text=猫が鳴く。 tokens=猫|が|鳴く|。`,
+				`msg="Wrote the texts." ns="" texts=1`,
+				`msg="Chose the next word." word=猫`,
+			},
+		},
+		{name: "info", level: slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: tt.level}))
+			g := openModel(t, []string{"猫が鳴く。"}, WithLogger(l))
+			if _, err := g.Generate("猫"); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := g.debugging(), tt.level <= slog.LevelDebug; got != want {
+				t.Errorf("debugging() = %v, want %v", got, want)
+			}
+			logs := buf.String()
+			if len(tt.want) == 0 && logs != "" {
+				t.Errorf("logs = %q, want none", logs)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(logs, want) {
+					t.Errorf("logs do not contain %q:\n%s", want, logs)
+				}
+			}
+		})
+	}
+
+	t.Run("none", func(t *testing.T) {
+		g := openModel(t, []string{"猫が鳴く。"})
+		if g.debugging() {
+			t.Error("debugging() = true without a logger")
+		}
+		// no logger to write to
+		g.debug("Dropped.")
+	})
+}
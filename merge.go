@@ -0,0 +1,60 @@
+package uonum
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Merge adds the model of other to the model of the Generator: the counts of
+// the links are summed, and the texts of other are appended.
+// Both must be in the same mode, and keyed on the same forms (see WithLemma).
+func (g *generator) Merge(other Generator) error {
+	o, ok := other.(*generator)
+	if !ok {
+		return errors.New("Could not merge a Generator of another implementation.")
+	}
+	if o.mode != g.mode {
+		return fmt.Errorf("Could not merge a model in %s mode into a model in %s mode.", o.mode, g.mode)
+	}
+	if o.lemma != g.lemma {
+		return fmt.Errorf("Could not merge a model keyed on its %s into a model keyed on its %s.", keyedOn(o.lemma), keyedOn(g.lemma))
+	}
+
+	wlmap, err := o.wordLinks()
+	if err != nil {
+		return err
+	}
+
+	var texts []textRecord
+	err = o.viewNS(func(c container) error {
+		cur := c.Bucket(bucketTexts).Cursor()
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			meta, err := getMeta(c, k)
+			if err != nil {
+				return err
+			}
+			texts = append(texts, textRecord{text: string(v), meta: meta})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var w written
+	err = g.updateNS(func(c container) error {
+		w = written{}
+		err := g.putText(c, wlmap, texts...)
+		if err != nil {
+			return err
+		}
+		w.add(c, g.ns, wlmap)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	g.invalidate(&w)
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package uonum
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// openModel returns a Generator opened on a new database with texts
+// registered.
+func openModel(t *testing.T, texts []string, opts ...Option) *generator {
+	t.Helper()
+
+	g := New(opts...).(*generator)
+	if err := g.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { g.Close() })
+	for _, text := range texts {
+		if err := g.Register(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return g
+}
+
+func TestMerge(t *testing.T) {
+	cat, ga := encodeKey("猫", "名詞"), encodeKey("が", "助詞")
+
+	tests := []struct {
+		name      string
+		a, b      []string
+		wantTexts int
+		// wantLinks is the count of the link from 猫 to が
+		wantLinks int64
+	}{
+		{name: "empty", wantTexts: 0},
+		{name: "into empty", b: []string{"猫が鳴く。"}, wantTexts: 1, wantLinks: 1},
+		{name: "from empty", a: []string{"猫が鳴く。"}, wantTexts: 1, wantLinks: 1},
+		{
+			name:      "summed",
+			a:         []string{"猫が鳴く。", "犬が鳴く。"},
+			b:         []string{"猫が魚を食べる。"},
+			wantTexts: 3,
+			wantLinks: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := openModel(t, tt.a), openModel(t, tt.b)
+			if err := a.Merge(b); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := textsIn(t, a); got != tt.wantTexts {
+				t.Errorf("texts = %d, want %d", got, tt.wantTexts)
+			}
+			wlmap, err := a.wordLinks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got int64
+			if wl := wlmap[cat]; wl != nil {
+				got = wl.Links[ga]
+			}
+			if got != tt.wantLinks {
+				t.Errorf("links from 猫 to が = %d, want %d", got, tt.wantLinks)
+			}
+
+			// the other model is left as it is
+			if got := textsIn(t, b); got != len(tt.b) {
+				t.Errorf("texts of the other model = %d, want %d", got, len(tt.b))
+			}
+		})
+	}
+}
+
+func TestMergeMode(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "mode", opts: []Option{WithMode(ModeChar)}},
+		{name: "lemma", opts: []Option{WithLemma()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := openModel(t, nil)
+			b := openModel(t, []string{"猫が鳴く。"}, tt.opts...)
+			if err := a.Merge(b); err == nil {
+				t.Error("Merge() of a model in another mode succeeded, want an error")
+			}
+			if err := b.Merge(a); err == nil {
+				t.Error("Merge() into a model in another mode succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package uonum
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+var bucketTextMeta = []byte("text_meta")
+
+// Meta is the metadata of a registered text.
+type Meta struct {
+	// Source is where the text came from, such as "twitter".
+	Source string `json:"source,omitempty"`
+	Author string `json:"author,omitempty"`
+	// Time is when the text was registered. It is the current time if
+	// zero.
+	Time time.Time `json:"time,omitempty"`
+	// Fields are the other attributes of the text, such as the columns of
+	// a CSV record.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+func (m Meta) isZero() bool {
+	return m.Source == "" && m.Author == "" && m.Time.IsZero() && len(m.Fields) == 0
+}
+
+// withDefaults returns m with the attributes of def which m does not have.
+func (m Meta) withDefaults(def Meta) Meta {
+	if m.Source == "" {
+		m.Source = def.Source
+	}
+	if m.Author == "" {
+		m.Author = def.Author
+	}
+	if m.Time.IsZero() {
+		m.Time = def.Time
+	}
+	if len(def.Fields) > 0 {
+		fields := make(map[string]string, len(def.Fields)+len(m.Fields))
+		for k, v := range def.Fields {
+			fields[k] = v
+		}
+		for k, v := range m.Fields {
+			fields[k] = v
+		}
+		m.Fields = fields
+	}
+
+	return m
+}
+
+// withTime returns m with the current time if it has no time.
+func (m Meta) withTime() Meta {
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+	return m
+}
+
+// textRecord is a text to be put with its metadata.
+type textRecord struct {
+	text string
+	meta Meta
+	// links are the links made by the text, recorded WithProvenance.
+	links [][2]string
+}
+
+// WithSources makes the generation use only the links contributed by the
+// texts registered with one of sources by RegisterWithMeta.
+func WithSources(sources ...string) Option {
+	return func(g *generator) {
+		g.sources = sources
+	}
+}
+
+func (g *generator) RegisterWithMeta(text string, meta Meta) (err error) {
+	if g.spans != nil {
+		var span Span
+		g, span = g.span("uonum.Register")
+		defer func() { endSpan(span, err) }()
+	}
+
+	s := g.s
+	if s == nil {
+		return ErrNotOpen
+	}
+
+	wlmap := g.buildLinks(text)
+	if len(wlmap) == 0 {
+		return nil
+	}
+
+	return g.putLines(s, []line{{
+		textRecord: textRecord{text: text, meta: meta.withTime()},
+		wlmap:      wlmap,
+	}})
+}
+
+// tag records the links of wlmap, which are of the text rec, with its
+// metadata.
+func (g *generator) tag(wlmap map[string]*wordLink, rec *textRecord) {
+	tagSource(wlmap, rec.meta.Source)
+	tagTime(wlmap, rec.meta.Time)
+	if g.provenance {
+		rec.links = linkPairs(wlmap)
+	}
+}
+
+// tagSource records the links of wlmap, which are of a text, as the
+// contributions of source.
+func tagSource(wlmap map[string]*wordLink, source string) {
+	if source == "" {
+		return
+	}
+
+	for _, wl := range wlmap {
+		if len(wl.Links) == 0 {
+			continue
+		}
+		links := make(map[string]int64, len(wl.Links))
+		for k, v := range wl.Links {
+			links[k] = v
+		}
+		wl.Sources = map[string]map[string]int64{source: links}
+	}
+}
+
+// restrict returns w with only the links contributed by the sources and in
+// the time window of the generator. If both are given, the count of a link is
+// the smaller one.
+func (g *generator) restrict(w *wordLink) *wordLink {
+	if len(g.sources) == 0 && g.since.IsZero() {
+		return w
+	}
+
+	var links map[string]int64
+	if len(g.sources) > 0 {
+		links = make(map[string]int64)
+		for _, s := range g.sources {
+			for k, v := range w.Sources[s] {
+				links[k] += v
+			}
+		}
+	}
+	if !g.since.IsZero() {
+		recent := w.linksSince(g.since)
+		if links == nil {
+			links = recent
+		} else {
+			for k, v := range links {
+				if recent[k] < v {
+					links[k] = recent[k]
+				}
+			}
+		}
+	}
+
+	c := *w
+	c.Links = links
+
+	return &c
+}
+
+// putMeta puts the metadata of the text id.
+func putMeta(c container, id []byte, meta Meta) error {
+	if meta.isZero() {
+		return nil
+	}
+
+	mb := c.Bucket(bucketTextMeta)
+	if mb == nil {
+		return nil
+	}
+	d, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("[meta] JSON marshal error: %w", err)
+	}
+
+	return mb.Put(id, d)
+}
+
+// getMeta returns the metadata of the text id.
+func getMeta(c container, id []byte) (Meta, error) {
+	var meta Meta
+	mb := c.Bucket(bucketTextMeta)
+	if mb == nil {
+		return meta, nil
+	}
+	d := mb.Get(id)
+	if d == nil {
+		return meta, nil
+	}
+	err := json.Unmarshal(d, &meta)
+	if err != nil {
+		return meta, newDecodeError("meta", err)
+	}
+
+	return meta, nil
+}
@@ -0,0 +1,155 @@
+package uonum
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMetaWithDefaults(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		meta Meta
+		def  Meta
+		want Meta
+	}{
+		{name: "zero"},
+		{
+			name: "defaults",
+			def:  Meta{Source: "twitter", Author: "a", Time: day},
+			want: Meta{Source: "twitter", Author: "a", Time: day},
+		},
+		{
+			name: "kept",
+			meta: Meta{Source: "irc", Time: day.AddDate(0, 0, 1)},
+			def:  Meta{Source: "twitter", Author: "a", Time: day},
+			want: Meta{Source: "irc", Author: "a", Time: day.AddDate(0, 0, 1)},
+		},
+		{
+			name: "fields",
+			meta: Meta{Fields: map[string]string{"a": "1", "b": "2"}},
+			def:  Meta{Fields: map[string]string{"b": "def", "c": "3"}},
+			want: Meta{Fields: map[string]string{"a": "1", "b": "2", "c": "3"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.meta.withDefaults(tt.def); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withDefaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestrict(t *testing.T) {
+	w := &wordLink{
+		Word:  "猫",
+		Links: map[string]int64{"a": 5, "b": 3},
+		Sources: map[string]map[string]int64{
+			"twitter": {"a": 4, "b": 1},
+			"irc":     {"a": 1, "b": 2},
+		},
+		History: map[string]map[string]int64{
+			"20240101": {"a": 3, "b": 3},
+			"20240201": {"a": 2},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		sources []string
+		since   time.Time
+		want    map[string]int64
+	}{
+		{name: "all", want: w.Links},
+		{name: "source", sources: []string{"irc"}, want: map[string]int64{"a": 1, "b": 2}},
+		{name: "sources", sources: []string{"irc", "twitter"}, want: map[string]int64{"a": 5, "b": 3}},
+		{name: "unknown source", sources: []string{"slack"}, want: map[string]int64{}},
+		{
+			name:  "since",
+			since: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			want:  map[string]int64{"a": 2},
+		},
+		{
+			name:    "source since",
+			sources: []string{"twitter"},
+			since:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			want:    map[string]int64{"a": 2, "b": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(WithSources(tt.sources...), WithSince(tt.since)).(*generator)
+			if got := g.restrict(w).Links; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("restrict() = %v, want %v", got, tt.want)
+			}
+			if len(w.Links) != 2 || w.Links["a"] != 5 {
+				t.Errorf("the word is changed to %v", w.Links)
+			}
+		})
+	}
+}
+
+func TestRegisterWithMeta(t *testing.T) {
+	day := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		meta        Meta
+		wantSources []string
+	}{
+		{name: "no meta"},
+		{
+			name:        "source",
+			meta:        Meta{Source: "twitter", Author: "a", Time: day, Fields: map[string]string{"id": "1"}},
+			wantSources: []string{"twitter"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, nil)
+			if err := g.RegisterWithMeta("猫が鳴く。", tt.meta); err != nil {
+				t.Fatal(err)
+			}
+
+			err := g.viewNS(func(c container) error {
+				id, _ := c.Bucket(bucketTexts).Cursor().First()
+				got, err := getMeta(c, id)
+				if err != nil {
+					return err
+				}
+				if tt.meta.Time.IsZero() {
+					// the time of the registration is recorded
+					if got.Time.IsZero() {
+						t.Error("no time is recorded")
+					}
+					got.Time = time.Time{}
+				}
+				if !reflect.DeepEqual(got, tt.meta) {
+					t.Errorf("meta = %+v, want %+v", got, tt.meta)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wlmap, err := g.wordLinks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sources []string
+			for s := range wlmap[encodeKey("猫", "名詞")].Sources {
+				sources = append(sources, s)
+			}
+			if !reflect.DeepEqual(sources, tt.wantSources) {
+				t.Errorf("sources = %q, want %q", sources, tt.wantSources)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package uonum
+
+import "sync/atomic"
+
+// Metrics are the counters of the generation and the writes since the Generator was made,
+// shared by the generators of the namespaces.
+type Metrics struct {
+	Walks       int64 `json:"walks"`        // number of the walks of the chain, including retries
+	DeadEnds    int64 `json:"dead_ends"`    // number of the walks ended by a word without an ending
+	Rejected    int64 `json:"rejected"`     // number of the sentences rejected by the safety filter
+	CacheHits   int64 `json:"cache_hits"`   // number of the words found in the cache
+	CacheMisses int64 `json:"cache_misses"` // number of the words read from the database with the cache
+	WordWrites  int64 `json:"word_writes"`  // number of the words written by the registrations
+	WordBytes   int64 `json:"word_bytes"`   // bytes of the words written by the registrations
+}
+
+type counters struct {
+	walks       atomic.Int64
+	deadEnds    atomic.Int64
+	rejected    atomic.Int64
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
+	wordWrites  atomic.Int64
+	wordBytes   atomic.Int64
+}
+
+// Metrics returns the counters of the generation and the writes.
+func (g *generator) Metrics() Metrics {
+	c := g.counters
+	return Metrics{
+		Walks:       c.walks.Load(),
+		DeadEnds:    c.deadEnds.Load(),
+		Rejected:    c.rejected.Load(),
+		CacheHits:   c.cacheHits.Load(),
+		CacheMisses: c.cacheMisses.Load(),
+		WordWrites:  c.wordWrites.Load(),
+		WordBytes:   c.wordBytes.Load(),
+	}
+}
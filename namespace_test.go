@@ -0,0 +1,113 @@
+package uonum
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNamespaces(t *testing.T) {
+	g := openModel(t, nil)
+	texts := map[string][]string{
+		"":    {"猫が鳴く。"},
+		"dog": {"犬が吠える。", "犬が走る。"},
+		"cat": {"猫が眠る。"},
+	}
+	for ns, ts := range texts {
+		for _, text := range ts {
+			if err := g.RegisterIn(ns, text); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		ns        string
+		wantTexts int
+		// want and notWant are in and not in the dump of the model
+		want, notWant string
+		trigger       string
+		wantErr       error
+	}{
+		{ns: "", wantTexts: 1, want: "鳴く", notWant: "吠える", trigger: "猫"},
+		{ns: "dog", wantTexts: 2, want: "吠える", notWant: "鳴く", trigger: "犬"},
+		{ns: "cat", wantTexts: 1, want: "眠る", notWant: "鳴く", trigger: "猫"},
+		{ns: "dog", trigger: "猫", wantTexts: 2, want: "走る", notWant: "眠る", wantErr: ErrUnknownTrigger},
+		{ns: "bird", trigger: "鳥", wantErr: ErrEmptyModel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ns+"/"+tt.trigger, func(t *testing.T) {
+			n := 0
+			err := g.in(tt.ns).viewNS(func(c container) error {
+				cur := c.Bucket(bucketTexts).Cursor()
+				for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+					n++
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.wantTexts {
+				t.Errorf("texts = %d, want %d", n, tt.wantTexts)
+			}
+
+			var dump bytes.Buffer
+			if err := g.DumpIn(tt.ns, &dump); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(dump.String(), tt.want) || (tt.notWant != "" && strings.Contains(dump.String(), tt.notWant)) {
+				t.Errorf("DumpIn(%q) = %q, want %q and not %q", tt.ns, dump.String(), tt.want, tt.notWant)
+			}
+
+			text, err := g.GenerateIn(tt.ns, tt.trigger)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("GenerateIn(%q, %q) error = %v, want %v", tt.ns, tt.trigger, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Contains(texts[tt.ns], text) {
+				t.Errorf("GenerateIn(%q, %q) = %q, want one of %q", tt.ns, tt.trigger, text, texts[tt.ns])
+			}
+		})
+	}
+}
+
+func TestModels(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。"})
+	texts := map[string]string{"b": "犬が吠える。", "a": "鳥が鳴く。"}
+	for ns, text := range texts {
+		if err := g.RegisterIn(ns, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := g.s.View(func(tx tx) error {
+		ms := models(tx)
+		if len(ms) != 3 {
+			t.Fatalf("models = %d, want 3", len(ms))
+		}
+		if ms[0] != tx {
+			t.Error("the first model is not the default model")
+		}
+		for i, ns := range []string{"a", "b"} {
+			if got := string(ms[i+1].Bucket(bucketTexts).Get(itob(1))); got != texts[ns] {
+				t.Errorf("text of model %d = %q, want %q of %s", i+1, got, texts[ns], ns)
+			}
+		}
+		if c, err := namespace(tx, "c", false); c != nil || err != nil {
+			t.Errorf("namespace(c) = %v, %v, want nil", c, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
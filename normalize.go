@@ -0,0 +1,94 @@
+package uonum
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// Normalizer transforms a text before it is tokenized, so that the variants
+// of a word land on the same key.
+type Normalizer func(text string) string
+
+// NFKC applies Unicode normalization form KC.
+func NFKC(text string) string {
+	return norm.NFKC.String(text)
+}
+
+// FoldWidth unifies full-width and half-width characters: full-width Latin
+// letters and digits become half-width, and half-width katakana becomes
+// full-width.
+func FoldWidth(text string) string {
+	return width.Fold.String(text)
+}
+
+// LowerLatin lowercases Latin letters.
+func LowerLatin(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Latin, r) {
+			return unicode.ToLower(r)
+		}
+		return r
+	}, text)
+}
+
+// CollapseSpace replaces each run of white spaces with a single space and
+// trims the leading and trailing ones. Newlines are kept.
+func CollapseSpace(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+var normalizers = map[string]Normalizer{
+	"nfkc":  NFKC,
+	"width": FoldWidth,
+	"lower": LowerLatin,
+	"space": CollapseSpace,
+}
+
+// ParseNormalizers returns the normalizers named in the comma separated
+// list s: nfkc, width, lower and space. "all" means all of them.
+func ParseNormalizers(s string) ([]Normalizer, error) {
+	var ns []Normalizer
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case "all":
+			ns = append(ns, NFKC, FoldWidth, LowerLatin, CollapseSpace)
+			continue
+		}
+
+		n, ok := normalizers[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown normalizer [%s].", name)
+		}
+		ns = append(ns, n)
+	}
+
+	return ns, nil
+}
+
+// WithNormalizers sets the normalizers applied in order to the registered
+// texts and to the words given to generate.
+func WithNormalizers(ns ...Normalizer) Option {
+	return func(g *generator) {
+		g.normalizers = ns
+	}
+}
+
+func (g *generator) normalize(text string) string {
+	for _, n := range g.normalizers {
+		text = n(text)
+	}
+
+	return text
+}
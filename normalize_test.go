@@ -0,0 +1,83 @@
+package uonum
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizers(t *testing.T) {
+	tests := []struct {
+		name string
+		n    Normalizer
+		text string
+		want string
+	}{
+		{name: "nfkc", n: NFKC, text: "ｶﾞＡ①㌔", want: "ガA1キロ"},
+		{name: "width", n: FoldWidth, text: "ｶＡ１", want: "カA1"},
+		{name: "lower", n: LowerLatin, text: "ABC Éte Ω", want: "abc éte Ω"},
+		{name: "space", n: CollapseSpace, text: "  猫が\t 鳴く \n 犬 ", want: "猫が 鳴く\n犬"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.n(tt.text); got != tt.want {
+				t.Errorf("%s(%q) = %q, want %q", tt.name, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNormalizers(t *testing.T) {
+	tests := []struct {
+		s       string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{s: "", text: "Ａ  Ｂ", want: "Ａ  Ｂ"},
+		{s: "width", text: "Ａ  Ｂ", want: "A  B"},
+		{s: "width, Lower", text: "Ａ  Ｂ", want: "a  b"},
+		{s: "lower,width", text: "Ａ  Ｂ", want: "a  b"},
+		{s: "all", text: "Ａ  Ｂ", want: "a b"},
+		{s: "width,unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			ns, err := ParseNormalizers(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseNormalizers(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+			}
+			g := New(WithNormalizers(ns...)).(*generator)
+			if got := g.normalize(tt.text); got != tt.want {
+				t.Errorf("normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizedModel(t *testing.T) {
+	g := openModel(t, []string{"ＵＯＮＵＭが鳴く。"}, WithNormalizers(FoldWidth, LowerLatin))
+
+	tests := []struct {
+		trigger string
+		want    string
+		wantErr error
+	}{
+		{trigger: "uonum", want: "uonumが鳴く。"},
+		{trigger: "ＵＯＮＵＭ", want: "uonumが鳴く。"},
+		{trigger: "ＵＯＮＵ", wantErr: ErrUnknownTrigger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.trigger, func(t *testing.T) {
+			got, err := g.Generate(tt.trigger)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Generate(%q) error = %v, want %v", tt.trigger, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Generate(%q) = %q, want %q", tt.trigger, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package uonum
+
+import (
+	"bytes"
+	"strings"
+)
+
+// WithMaxOverlap makes generation reject the sentences whose longest common
+// substring with any registered text is longer than ratio of the sentence
+// (e.g. 0.8), regenerating at most retries times.
+func WithMaxOverlap(ratio float64, retries int) Option {
+	return func(g *generator) {
+		g.maxOverlap = ratio
+		g.overlapRetries = retries
+	}
+}
+
+// The shingles bucket, nested in the words bucket like the classes, indexes
+// the registered texts by their substrings of shingleSize runes, so that the
+// texts sharing a substring with a sentence are found without scanning all
+// of them. Its keys are a shingle and the ID of the text containing it, and
+// its values are empty. The shingles at the end of a text are shorter, so
+// that any substring of a text up to shingleSize runes is a prefix of one.
+var bucketShingles = []byte("\xffshingles")
+
+const shingleSize = 4
+
+// putShingles indexes the shingles of text of id in the words bucket b.
+func putShingles(b bucket, id []byte, text string) error {
+	sb, err := b.CreateBucketIfNotExists(bucketShingles)
+	if err != nil {
+		return err
+	}
+
+	r := []rune(text)
+	for i := range r {
+		k := append([]byte(string(r[i:min(i+shingleSize, len(r))])), id...)
+		err := sb.Put(k, []byte{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shingleTexts returns the IDs of the texts in the shingles bucket sb which
+// contain s, a prefix of a shingle if exact is false.
+func shingleTexts(sb bucket, s string, exact bool) map[string]bool {
+	ids := make(map[string]bool)
+	prefix := []byte(s)
+	c := sb.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if len(k) < len(prefix)+8 || (exact && len(k) != len(prefix)+8) {
+			continue
+		}
+		ids[string(k[len(k)-8:])] = true
+	}
+
+	return ids
+}
+
+// tooSimilar reports whether text overlaps more than ratio with any text in
+// tb, looking up the shingles indexed in the words bucket b.
+func tooSimilar(b, tb bucket, text string, ratio float64) bool {
+	if b == nil || tb == nil {
+		return false
+	}
+	sb := b.Bucket(bucketShingles)
+	if sb == nil {
+		return false
+	}
+
+	r := []rune(text)
+	// a text overlaps too much if it contains any substring of n runes
+	n := int(ratio*float64(len(r))) + 1
+	if n > len(r) {
+		return false
+	}
+
+	found := make(map[string]map[string]bool)
+	lookup := func(s string, exact bool) map[string]bool {
+		ids, ok := found[s]
+		if !ok {
+			ids = shingleTexts(sb, s, exact)
+			found[s] = ids
+		}
+		return ids
+	}
+
+	for i := 0; i+n <= len(r); i++ {
+		sub := r[i : i+n]
+		if n <= shingleSize {
+			if len(lookup(string(sub), false)) > 0 {
+				return true
+			}
+			continue
+		}
+
+		// the texts containing sub contain all the shingles covering it
+		var candidates map[string]bool
+		for j := 0; ; j = min(j+shingleSize, n-shingleSize) {
+			ids := lookup(string(sub[j:j+shingleSize]), true)
+			if candidates == nil {
+				candidates = ids
+			} else {
+				candidates = intersect(candidates, ids)
+			}
+			if len(candidates) == 0 || j == n-shingleSize {
+				break
+			}
+		}
+		for id := range candidates {
+			if strings.Contains(string(tb.Get([]byte(id))), string(sub)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// intersect returns the keys in both a and b.
+func intersect(a, b map[string]bool) map[string]bool {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	both := make(map[string]bool, len(a))
+	for k := range a {
+		if b[k] {
+			both[k] = true
+		}
+	}
+
+	return both
+}
+
+// migrateShingles indexes the texts registered before the shingles bucket.
+func migrateShingles(tx tx) error {
+	for _, m := range models(tx) {
+		err := rebuildShingles(m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildShingles indexes all the texts of the model c.
+func rebuildShingles(c container) error {
+	b, tb := c.Bucket(bucketWords), c.Bucket(bucketTexts)
+	if b == nil || tb == nil {
+		return nil
+	}
+
+	cur := tb.Cursor()
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		err := putShingles(b, k, string(v))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package uonum
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// similar reports whether text overlaps more than ratio with the texts of g.
+func similar(t *testing.T, g *generator, text string, ratio float64) bool {
+	t.Helper()
+
+	var got bool
+	err := g.viewModel(func(b, tb bucket) error {
+		got = tooSimilar(b, tb, text, ratio)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return got
+}
+
+func TestTooSimilar(t *testing.T) {
+	g := openModel(t, []string{"猫が魚を食べる。", "犬が公園で走り回る。"})
+
+	tests := []struct {
+		name  string
+		text  string
+		ratio float64
+		want  bool
+	}{
+		{name: "same", text: "猫が魚を食べる。", ratio: 0.8, want: true},
+		{name: "partly", text: "猫が公園で走る。", ratio: 0.8},
+		{name: "mostly", text: "猫が公園で走り回る。", ratio: 0.8, want: true},
+		{name: "mostly under ratio", text: "猫が公園で走り回る。", ratio: 0.9},
+		{name: "short", text: "猫が魚", ratio: 0.5, want: true},
+		{name: "short at end", text: "る。", ratio: 0.5, want: true},
+		{name: "unknown", text: "鳥", ratio: 0.5},
+		{name: "across texts", text: "猫が魚を食べる。犬が公園で走り回る。", ratio: 0.6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := similar(t, g, tt.text, tt.ratio); got != tt.want {
+				t.Errorf("tooSimilar(%q, %v) = %v, want %v", tt.text, tt.ratio, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShingleIndex(t *testing.T) {
+	const text = "猫が魚を食べる。"
+
+	tests := []struct {
+		name string
+		// open returns the model of text
+		open func(t *testing.T) *generator
+	}{
+		{
+			name: "registered",
+			open: func(t *testing.T) *generator { return openModel(t, []string{text}) },
+		},
+		{
+			name: "merged",
+			open: func(t *testing.T) *generator {
+				g := openModel(t, nil)
+				if err := g.Merge(openModel(t, []string{text})); err != nil {
+					t.Fatal(err)
+				}
+				return g
+			},
+		},
+		{
+			name: "rebuilt",
+			open: func(t *testing.T) *generator {
+				g := openModel(t, []string{text})
+				if _, err := g.Rebuild(); err != nil {
+					t.Fatal(err)
+				}
+				return g
+			},
+		},
+		{
+			name: "migrated",
+			open: func(t *testing.T) *generator {
+				name := filepath.Join(t.TempDir(), "test.db")
+				g := New().(*generator)
+				if err := g.Open(name); err != nil {
+					t.Fatal(err)
+				}
+				if err := g.Register(text); err != nil {
+					t.Fatal(err)
+				}
+				err := g.s.Update(func(tx tx) error {
+					err := clearBucket(tx.Bucket(bucketWords).Bucket(bucketShingles))
+					if err != nil {
+						return err
+					}
+					return tx.Bucket(bucketMeta).Put(keySchemaVersion, itob(uint64(SchemaVersion-1)))
+				})
+				g.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				g = New().(*generator)
+				if err := g.Open(name); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { g.Close() })
+				return g
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if g := tt.open(t); !similar(t, g, text, 0.8) {
+				t.Errorf("tooSimilar(%q) = false, want true", text)
+			}
+		})
+	}
+}
+
+func TestMaxOverlap(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantErr     error
+		wantRetries int
+		wantLimit   int
+	}{
+		{name: "overlapping", opts: []Option{WithMaxOverlap(0.5, 3)}, wantErr: ErrGenerationFailed, wantRetries: 3},
+		{name: "whole", opts: []Option{WithMaxOverlap(1, 3)}, wantRetries: 3},
+		{
+			name:        "limits after",
+			opts:        []Option{WithMaxOverlap(0.5, 3), WithLimits(Limits{MaxRetries: 7})},
+			wantErr:     ErrGenerationFailed,
+			wantRetries: 3,
+			wantLimit:   7,
+		},
+		{
+			name:        "limits before",
+			opts:        []Option{WithLimits(Limits{MaxRetries: 7}), WithMaxOverlap(0.5, 3)},
+			wantErr:     ErrGenerationFailed,
+			wantRetries: 3,
+			wantLimit:   7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, []string{"猫が鳴く。"}, tt.opts...)
+			if g.overlapRetries != tt.wantRetries || g.limits.MaxRetries != tt.wantLimit {
+				t.Errorf("retries = %d, %d, want %d, %d", g.overlapRetries, g.limits.MaxRetries, tt.wantRetries, tt.wantLimit)
+			}
+
+			text, err := g.Generate("猫")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Generate() = %q, %v, want error %v", text, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package uonum
+
+import (
+	"errors"
+	"strings"
+)
+
+// GenerateParagraph generates up to sentences sentences starting from
+// trigger. Each sentence after the first is seeded from one of the nouns in
+// the previous one, so the paragraph ends early if there is no such noun
+// not used as a seed yet.
+func (g *generator) GenerateParagraph(trigger string, sentences int) (string, error) {
+	trigger = g.triggerWord(trigger)
+	if trigger == "" || sentences <= 0 {
+		return "", nil
+	}
+
+	var texts []string
+	err := g.viewChain(func(b, tb bucket) error {
+		key, err := g.startKey(b, trigger)
+		if err != nil {
+			return err
+		}
+		if key == nil || b.Get(key) == nil {
+			return missing(b, key)
+		}
+
+		used := make(map[string]bool)
+		for len(texts) < sentences {
+			used[string(key)] = true
+			text, err := g.generate(b, tb, key)
+			if errors.Is(err, ErrGenerationFailed) && len(texts) > 0 {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if text == "" {
+				break
+			}
+			texts = append(texts, text)
+
+			key, err = g.seedKey(b, g.tokenize(text), used)
+			if err != nil {
+				return err
+			}
+			if key == nil {
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if g.mode == ModeWhitespace {
+		return strings.Join(texts, " "), nil
+	}
+
+	return strings.Join(texts, ""), nil
+}
@@ -0,0 +1,38 @@
+package uonum
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGenerateParagraph(t *testing.T) {
+	g := openModel(t, []string{"猫が魚を食べる。"})
+
+	tests := []struct {
+		name      string
+		trigger   string
+		sentences int
+		want      string
+		wantErr   error
+	}{
+		{name: "one", trigger: "猫", sentences: 1, want: "猫が魚を食べる。"},
+		// the second sentence is seeded from 魚, and the paragraph ends
+		// since 猫 and 魚 are used already
+		{name: "seeded", trigger: "猫", sentences: 3, want: "猫が魚を食べる。魚を食べる。"},
+		{name: "none", trigger: "猫", sentences: 0},
+		{name: "empty trigger", trigger: "", sentences: 3},
+		{name: "unknown", trigger: "犬", sentences: 3, wantErr: ErrUnknownTrigger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.GenerateParagraph(tt.trigger, tt.sentences)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateParagraph() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GenerateParagraph() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package uonum
+
+import "fmt"
+
+// The links of a word linked to many others, like a particle, may be split
+// into parts, so that a registration rewrites only the parts of the links it
+// adds, and a walk reads only the part of the link it follows. The value of
+// the word in the words bucket is the first part with the word itself, and
+// the others are in the nested parts bucket as "<key>/part-NN". A link is in
+// the part chosen by the hash of the key of the other word.
+
+// bucketParts is the nested bucket of the parts in the words bucket. It
+// starts with a byte which no rune starts with, so that it is never the key
+// of a word.
+var bucketParts = []byte("\xffparts")
+
+// WithPartSize makes the registrations split the links of a word into parts
+// of about n links, once it has more than n of them. The parts are read
+// regardless of this option, and the words already split keep their parts.
+func WithPartSize(n int) Option {
+	return func(g *generator) {
+		g.partSize = n
+	}
+}
+
+// partKey returns the key of the part i of the word key in the parts bucket.
+func partKey(key []byte, i int) []byte {
+	return fmt.Appendf(append([]byte(nil), key...), "/part-%02d", i)
+}
+
+// partOf returns the part of the link to (or from) the word key out of n
+// parts, by the FNV-1a hash of key.
+func partOf(key string, n int) int {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+
+	return int(h % uint32(n))
+}
+
+// partCount returns the number of the parts of size for links.
+func partCount(links, size int) int {
+	return (links + size - 1) / size
+}
+
+// linkCount returns the number of the links of w which may be followed.
+func (w *wordLink) linkCount() int {
+	n := 0
+	for _, c := range w.Links {
+		if c != 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// size returns the number of the links of w, including those of the parts
+// not read.
+func (w *wordLink) size() int {
+	if !w.partial {
+		return len(w.Links)
+	}
+
+	n := 0
+	for _, c := range w.PartLinks {
+		n += c
+	}
+
+	return n
+}
+
+// split splits the links of w into n parts. The first one has the word.
+func (w *wordLink) split(n int) []*wordLink {
+	parts := make([]*wordLink, n)
+	for i := range parts {
+		parts[i] = &wordLink{Links: make(map[string]int64)}
+	}
+	parts[0].Word, parts[0].Features, parts[0].Reading = w.Word, w.Features, w.Reading
+
+	for k, v := range w.Links {
+		parts[partOf(k, n)].Links[k] = v
+	}
+	for k, v := range w.Prev {
+		p := parts[partOf(k, n)]
+		if p.Prev == nil {
+			p.Prev = make(map[string]int64)
+		}
+		p.Prev[k] = v
+	}
+	splitLinkMaps(w.Sources, n, func(p int) *map[string]map[string]int64 { return &parts[p].Sources })
+	splitLinkMaps(w.History, n, func(p int) *map[string]map[string]int64 { return &parts[p].History })
+	splitLinkMaps(w.Surfaces, n, func(p int) *map[string]map[string]int64 { return &parts[p].Surfaces })
+
+	return parts
+}
+
+// splitLinkMaps adds the links in m to the maps of their parts out of n.
+func splitLinkMaps(m map[string]map[string]int64, n int, part func(p int) *map[string]map[string]int64) {
+	for s, links := range m {
+		for k, v := range links {
+			pm := part(partOf(k, n))
+			if *pm == nil {
+				*pm = make(map[string]map[string]int64)
+			}
+			if (*pm)[s] == nil {
+				(*pm)[s] = make(map[string]int64)
+			}
+			(*pm)[s][k] = v
+		}
+	}
+}
+
+// empty reports whether w has no links.
+func (w *wordLink) empty() bool {
+	return len(w.Links) == 0 && len(w.Prev) == 0 && len(w.Sources) == 0 && len(w.History) == 0 && len(w.Surfaces) == 0
+}
+
+// decodeWord decodes the value v of the word key in the words bucket b, with
+// all the parts of its links.
+func decodeWord(b bucket, key, v []byte) (*wordLink, error) {
+	wl := new(wordLink)
+	err := unmarshalWordLink(v, wl)
+	if err != nil {
+		return nil, newDecodeError(displayKey(string(key)), err)
+	}
+
+	pb := b.Bucket(bucketParts)
+	for i := 1; i < wl.Parts; i++ {
+		part, err := getPart(pb, key, i)
+		if err != nil {
+			return nil, err
+		}
+		wl.merge(part)
+	}
+
+	return wl, nil
+}
+
+// getWordHead is like getWordLink but does not read the other parts of a word
+// split into parts.
+func getWordHead(b bucket, key []byte) (*wordLink, error) {
+	v := b.Get(key)
+	if v == nil {
+		return nil, nil
+	}
+
+	wl := new(wordLink)
+	err := unmarshalWordLink(v, wl)
+	if err != nil {
+		return nil, newDecodeError(displayKey(string(key)), err)
+	}
+	wl.partial = wl.Parts > 1
+
+	return wl, nil
+}
+
+// getPart returns the part i of the word key in the parts bucket pb. A
+// missing part has no links.
+func getPart(pb bucket, key []byte, i int) (*wordLink, error) {
+	part := &wordLink{Links: make(map[string]int64)}
+	if pb == nil {
+		return part, nil
+	}
+	k := partKey(key, i)
+	v := pb.Get(k)
+	if v == nil {
+		return part, nil
+	}
+
+	err := unmarshalWordLink(v, part)
+	if err != nil {
+		return nil, newDecodeError(fmt.Sprintf("%s/part-%02d", displayKey(string(key)), i), err)
+	}
+	if part.Links == nil {
+		part.Links = make(map[string]int64)
+	}
+
+	return part, nil
+}
+
+// putWordLink puts the word w as key in the words bucket b, split into
+// w.Parts parts, and returns the number of the bytes written.
+func putWordLink(b bucket, key []byte, w *wordLink, compress int) (int, error) {
+	if w.Parts <= 1 {
+		w.Parts, w.PartLinks = 0, nil
+		d, err := marshalWordLink(w, compress)
+		if err != nil {
+			return 0, err
+		}
+		return len(d), b.Put(key, d)
+	}
+
+	parts := w.split(w.Parts)
+	head := parts[0]
+	head.Parts = w.Parts
+	head.PartLinks = make([]int, len(parts))
+	written := 0
+	pb, err := b.CreateBucketIfNotExists(bucketParts)
+	if err != nil {
+		return 0, err
+	}
+	for i, p := range parts {
+		head.PartLinks[i] = p.linkCount()
+		if i == 0 {
+			continue
+		}
+		n, err := putPart(pb, key, i, p, compress)
+		if err != nil {
+			return 0, err
+		}
+		written += n
+	}
+
+	d, err := marshalWordLink(head, compress)
+	if err != nil {
+		return 0, err
+	}
+	w.PartLinks = head.PartLinks
+
+	return written + len(d), b.Put(key, d)
+}
+
+func putPart(pb bucket, key []byte, i int, p *wordLink, compress int) (int, error) {
+	d, err := marshalWordLink(p, compress)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(d), pb.Put(partKey(key, i), d)
+}
+
+// mergeWordLink adds the links of w to the word key in the words bucket b,
+// rewriting only the parts with new links, and returns the number of the
+// bytes written. If partSize > 0, the word is split, or split into more
+// parts, once it has more than partSize links per part.
+func mergeWordLink(b bucket, key []byte, w *wordLink, partSize, compress int) (int, error) {
+	head := new(wordLink)
+	if d := b.Get(key); d != nil {
+		err := unmarshalWordLink(d, head)
+		if err != nil {
+			return 0, newDecodeError(displayKey(string(key)), err)
+		}
+	}
+
+	if head.Parts <= 1 {
+		// w is not changed, since the store may call the transaction again
+		w = w.clone()
+		w.merge(head)
+		if partSize > 0 && len(w.Links) > partSize {
+			w.Parts = partCount(len(w.Links), partSize)
+		}
+		return putWordLink(b, key, w, compress)
+	}
+
+	n := head.Parts
+	added := w.split(n)
+	pb, err := b.CreateBucketIfNotExists(bucketParts)
+	if err != nil {
+		return 0, err
+	}
+	partLinks := make([]int, n)
+	copy(partLinks, head.PartLinks)
+	written := 0
+	for i := 1; i < n; i++ {
+		p := added[i]
+		if p.empty() {
+			continue
+		}
+		old, err := getPart(pb, key, i)
+		if err != nil {
+			return 0, err
+		}
+		p.merge(old)
+		partLinks[i] = p.linkCount()
+		m, err := putPart(pb, key, i, p, compress)
+		if err != nil {
+			return 0, err
+		}
+		written += m
+	}
+
+	h := added[0]
+	h.merge(head)
+	partLinks[0] = h.linkCount()
+	total := 0
+	for _, c := range partLinks {
+		total += c
+	}
+	if partSize > 0 && total > 2*n*partSize {
+		// the parts written above are read back with the others
+		for i := 1; i < n; i++ {
+			part, err := getPart(pb, key, i)
+			if err != nil {
+				return 0, err
+			}
+			h.merge(part)
+		}
+		h.Parts = partCount(total, partSize)
+		m, err := putWordLink(b, key, h, compress)
+		return written + m, err
+	}
+
+	h.Parts, h.PartLinks = n, partLinks
+	d, err := marshalWordLink(h, compress)
+	if err != nil {
+		return 0, err
+	}
+
+	return written + len(d), b.Put(key, d)
+}
+
+// deleteWordLink deletes the word key, its parts and its class in the words
+// bucket b.
+func deleteWordLink(b bucket, key []byte) error {
+	head, err := getWordHead(b, key)
+	if err == nil && head != nil && head.Parts > 1 {
+		if pb := b.Bucket(bucketParts); pb != nil {
+			for i := 1; i < head.Parts; i++ {
+				err := pb.Delete(partKey(key, i))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if cb := b.Bucket(bucketClasses); cb != nil {
+		_, class := splitKey(string(key))
+		err := cb.Delete(classKey(class, string(key)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.Delete(key)
+}
+
+// chooseNext returns the key of the next word of w chosen by g with the
+// brackets open, and w with all its links if they had to be read. The part
+// of the links of a word split into parts is read alone if it is chosen
+// uniformly at random, except in the lemma mode, where the surfaces of the
+// word are spread over all the parts.
+func (g *generator) chooseNext(b bucket, key []byte, w *wordLink, open []rune) (string, *wordLink, error) {
+	if w.partial && !g.lemma && g.sampling == (Sampling{}) && len(g.banned) == 0 && len(g.sources) == 0 && g.since.IsZero() && g.closeBias == 0 {
+		n, err := w.nextInParts(b, key)
+		return n, w, err
+	}
+	if w.partial {
+		full, err := getWordLink(b, key)
+		if err != nil {
+			return "", nil, err
+		}
+		w = full
+	}
+
+	r := g.restrict(w)
+	if g.closeBias > 0 {
+		r = g.balanceLinks(r, open)
+	}
+
+	return g.choose(r), w, nil
+}
+
+// nextInParts is like next but reads only the part of the chosen link.
+func (w *wordLink) nextInParts(b bucket, key []byte) (string, error) {
+	i := w.size()
+	if i == 0 {
+		return "", nil
+	}
+	i = random.Intn(i)
+
+	for p, n := range w.PartLinks {
+		if i >= n {
+			i -= n
+			continue
+		}
+		part := w
+		if p > 0 {
+			var err error
+			part, err = getPart(b.Bucket(bucketParts), key, p)
+			if err != nil {
+				return "", err
+			}
+		}
+		for k, c := range part.Links {
+			if c == 0 {
+				continue
+			}
+			if i == 0 {
+				return k, nil
+			}
+			i--
+		}
+		// the counts are out of date
+		return part.next(), nil
+	}
+
+	return "", nil
+}
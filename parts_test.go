@@ -0,0 +1,166 @@
+package uonum
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// linksOf returns a word linked to the words "<prefix>0" to "<prefix>n-1".
+func linksOf(prefix string, n int) *wordLink {
+	w := newWordLinkWithFeatures("は", []string{"助詞"})
+	w.Prev = make(map[string]int64)
+	for i := 0; i < n; i++ {
+		k := encodeKey(fmt.Sprintf("%s%d", prefix, i), "名詞")
+		w.Links[k] = 1
+		w.Prev[k] = 1
+	}
+
+	return w
+}
+
+func TestMergeWordLinkParts(t *testing.T) {
+	tests := []struct {
+		name     string
+		partSize int
+		// merges are the words merged in turn
+		merges    []*wordLink
+		wantParts int
+		wantLinks int
+	}{
+		{
+			name:      "no parts",
+			partSize:  0,
+			merges:    []*wordLink{linksOf("a", 10), linksOf("b", 10)},
+			wantParts: 0,
+			wantLinks: 20,
+		},
+		{
+			name:      "under the size",
+			partSize:  10,
+			merges:    []*wordLink{linksOf("a", 10)},
+			wantParts: 0,
+			wantLinks: 10,
+		},
+		{
+			name:      "split",
+			partSize:  4,
+			merges:    []*wordLink{linksOf("a", 10)},
+			wantParts: 3,
+			wantLinks: 10,
+		},
+		{
+			name:      "merged into the parts",
+			partSize:  4,
+			merges:    []*wordLink{linksOf("a", 10), linksOf("a", 10), linksOf("b", 2)},
+			wantParts: 3,
+			wantLinks: 12,
+		},
+		{
+			name:      "split again",
+			partSize:  4,
+			merges:    []*wordLink{linksOf("a", 10), linksOf("b", 20)},
+			wantParts: 8,
+			wantLinks: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := openBoltStore(filepath.Join(t.TempDir(), "test.db"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			key := []byte(encodeKey("は", "助詞"))
+			want := newWordLinkWithFeatures("は", []string{"助詞"})
+			want.Prev = make(map[string]int64)
+			for _, w := range tt.merges {
+				want.merge(w.clone())
+				err := s.Update(func(tx tx) error {
+					b, err := tx.CreateBucketIfNotExists(bucketWords)
+					if err != nil {
+						return err
+					}
+					_, err = mergeWordLink(b, key, w, tt.partSize, 0)
+					return err
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = s.Update(func(tx tx) error {
+				b := tx.Bucket(bucketWords)
+				head, err := getWordHead(b, key)
+				if err != nil {
+					return err
+				}
+				if head.Parts != tt.wantParts {
+					t.Errorf("parts = %d, want %d", head.Parts, tt.wantParts)
+				}
+				if got := head.size(); got != tt.wantLinks {
+					t.Errorf("size() of the head = %d, want %d", got, tt.wantLinks)
+				}
+
+				got, err := getWordLink(b, key)
+				if err != nil {
+					return err
+				}
+				if !reflect.DeepEqual(got.Links, want.Links) || !reflect.DeepEqual(got.Prev, want.Prev) {
+					t.Errorf("links = %v, prev = %v, want %v, %v", got.Links, got.Prev, want.Links, want.Prev)
+				}
+
+				if err := deleteWordLink(b, key); err != nil {
+					return err
+				}
+				if b.Get(key) != nil {
+					t.Error("the word is left after deleteWordLink")
+				}
+				if pb := b.Bucket(bucketParts); pb != nil {
+					if k, _ := pb.Cursor().First(); k != nil {
+						t.Errorf("part %q is left after deleteWordLink", k)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestWordLinkSplit(t *testing.T) {
+	w := linksOf("a", 20)
+	w.Sources = map[string]map[string]int64{"s": {encodeKey("a0", "名詞"): 1}}
+	w.History = map[string]map[string]int64{"20240101": {encodeKey("a1", "名詞"): 1}}
+
+	for _, n := range []int{1, 2, 3, 7} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			parts := w.split(n)
+			if len(parts) != n {
+				t.Fatalf("split() = %d parts, want %d", len(parts), n)
+			}
+			if parts[0].Word != w.Word {
+				t.Errorf("word of the first part = %q, want %q", parts[0].Word, w.Word)
+			}
+
+			got := &wordLink{Links: make(map[string]int64)}
+			for i, p := range parts {
+				for k := range p.Links {
+					if partOf(k, n) != i {
+						t.Errorf("link %q in part %d, want %d", k, i, partOf(k, n))
+					}
+				}
+				got.merge(p)
+			}
+			if !reflect.DeepEqual(got.Links, w.Links) || !reflect.DeepEqual(got.Prev, w.Prev) ||
+				!reflect.DeepEqual(got.Sources, w.Sources) || !reflect.DeepEqual(got.History, w.History) {
+				t.Errorf("merged parts = %+v, want %+v", got, w)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package uonum
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+const (
+	// numPlaceholder and datePlaceholder are the words which the numbers
+	// and the dates are registered as WithPlaceholders.
+	numPlaceholder  = "<NUM>"
+	datePlaceholder = "<DATE>"
+)
+
+var rePlaceholder = regexp.MustCompile(
+	// dates first, since they are made of numbers
+	`(?P<date>[0-9０-９]{4}[/\-年][0-9０-９]{1,2}[/\-月][0-9０-９]{1,2}日?|[0-9０-９]{1,2}月[0-9０-９]{1,2}日)` +
+		`|(?P<num>[0-9０-９]+(?:[,.，．][0-9０-９]+)*)`)
+
+// WithPlaceholders makes the numbers and the dates in the texts registered
+// as the words <NUM> and <DATE>, so that the model learns where they are
+// used instead of thousands of distinct values. The generated sentences
+// have plausible values in place of them. The numbers in the Latin words
+// (e.g. "mp3") are kept. It should be used for the registration and the
+// generation alike, as WithNormalizers.
+func WithPlaceholders() Option {
+	return func(g *generator) {
+		g.placeholders = true
+	}
+}
+
+// placeholderTokens tokenizes text, which has the numbers and the dates
+// replaced by the placeholders, with tokenize.
+func (g *generator) placeholderTokens(text string, tokenize func(string) []token) []token {
+	return preTokenize(text, rePlaceholder, tokenize, func(m []int) *token {
+		if inLatinWord(text, m[0], m[1]) {
+			return nil
+		}
+		word := numPlaceholder
+		if m[2] >= 0 {
+			word = datePlaceholder
+		}
+		return &token{Surface: word, Features: g.placeholderFeatures()}
+	})
+}
+
+// inLatinWord reports whether text[i:j] is a part of a Latin word.
+func inLatinWord(text string, i, j int) bool {
+	latin := func(s string, last bool) bool {
+		r := []rune(s)
+		if len(r) == 0 {
+			return false
+		}
+		if last {
+			return unicode.Is(unicode.Latin, r[len(r)-1])
+		}
+		return unicode.Is(unicode.Latin, r[0])
+	}
+
+	return latin(text[:i], true) || latin(text[j:], false)
+}
+
+// placeholderFeatures returns the features of the placeholders in the mode
+// of the model.
+func (g *generator) placeholderFeatures() []string {
+	switch g.mode {
+	case ModeChar:
+		return []string{charClass, "数"}
+	case ModeWhitespace:
+		return []string{"NUM"}
+	}
+
+	return []string{"名詞", "数"}
+}
+
+// fill returns a plausible value for word if it is a placeholder, or word.
+func (g *generator) fill(word string) string {
+	switch word {
+	case numPlaceholder:
+		// mostly small numbers, as in the texts
+		return strconv.Itoa(random.Intn(pow10(1 + random.Intn(3))))
+	case datePlaceholder:
+		t := time.Now().AddDate(0, 0, -random.Intn(365))
+		if g.mode == ModeWhitespace {
+			return t.Format("2006-01-02")
+		}
+		return fmt.Sprintf("%d月%d日", t.Month(), t.Day())
+	}
+
+	return word
+}
+
+func pow10(n int) int {
+	p := 1
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+
+	return p
+}
@@ -0,0 +1,111 @@
+package uonum
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestPlaceholderTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		mode Mode
+		text string
+		want []string
+	}{
+		{name: "num", mode: ModeWhitespace, text: "I have 3 cats", want: []string{"I", "have", "<NUM>", "cats"}},
+		{name: "separators", mode: ModeWhitespace, text: "It costs 1,000.5 yen", want: []string{"It", "costs", "<NUM>", "yen"}},
+		{name: "date", mode: ModeWhitespace, text: "On 2024-01-02 it rained", want: []string{"On", "<DATE>", "it", "rained"}},
+		{name: "latin", mode: ModeWhitespace, text: "Play mp3 files", want: []string{"Play", "mp3", "files"}},
+		{name: "kanji date", mode: ModeChar, text: "1月2日と３匹", want: []string{"<DATE>", "と", "<NUM>", "匹"}},
+		{name: "full date", mode: ModeChar, text: "2024年1月2日", want: []string{"<DATE>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, nil, WithMode(tt.mode), WithPlaceholders())
+			var got []string
+			for _, tok := range g.tokenize(tt.text) {
+				got = append(got, tok.Surface)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInLatinWord(t *testing.T) {
+	tests := []struct {
+		text string
+		i, j int
+		want bool
+	}{
+		{text: "mp3", i: 2, j: 3, want: true},
+		{text: "3d", i: 0, j: 1, want: true},
+		{text: "a 3", i: 2, j: 3},
+		{text: "猫3匹", i: 3, j: 4},
+		{text: "3", i: 0, j: 1},
+	}
+
+	for _, tt := range tests {
+		if got := inLatinWord(tt.text, tt.i, tt.j); got != tt.want {
+			t.Errorf("inLatinWord(%q, %d, %d) = %v, want %v", tt.text, tt.i, tt.j, got, tt.want)
+		}
+	}
+}
+
+func TestFill(t *testing.T) {
+	tests := []struct {
+		name string
+		mode Mode
+		word string
+		want *regexp.Regexp
+	}{
+		{name: "num", word: numPlaceholder, want: regexp.MustCompile(`^[0-9]{1,3}$`)},
+		{name: "date", word: datePlaceholder, want: regexp.MustCompile(`^[0-9]{1,2}月[0-9]{1,2}日$`)},
+		{name: "whitespace date", mode: ModeWhitespace, word: datePlaceholder, want: regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)},
+		{name: "word", word: "猫", want: regexp.MustCompile(`^猫$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(WithMode(tt.mode)).(*generator)
+			for i := 0; i < 20; i++ {
+				if got := g.fill(tt.word); !tt.want.MatchString(got) {
+					t.Errorf("fill(%q) = %q, want %v", tt.word, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	texts := []string{"猫が3匹いる。", "猫が120匹いる。", "犬は2024年1月2日に来た。"}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		trigger string
+		want    *regexp.Regexp
+	}{
+		{name: "num", opts: []Option{WithPlaceholders()}, trigger: "猫", want: regexp.MustCompile(`^猫が[0-9]{1,3}匹いる。$`)},
+		{name: "date", opts: []Option{WithPlaceholders()}, trigger: "犬", want: regexp.MustCompile(`^犬は[0-9]{1,2}月[0-9]{1,2}日に来た。$`)},
+		{name: "without", trigger: "猫", want: regexp.MustCompile(`^猫が(3|120)匹いる。$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, texts, tt.opts...)
+			for i := 0; i < 10; i++ {
+				got, err := g.Generate(tt.trigger)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !tt.want.MatchString(got) {
+					t.Errorf("Generate(%q) = %q, want %v", tt.trigger, got, tt.want)
+				}
+			}
+		})
+	}
+}
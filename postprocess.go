@@ -0,0 +1,183 @@
+package uonum
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PostProcessor transforms a generated sentence. tokens are the words of
+// text tokenized in the mode of the model.
+type PostProcessor func(text string, tokens []Token) string
+
+// SpaceLatin puts a space between the Latin words and the Japanese around
+// them, as in "iPhone を買う".
+func SpaceLatin(text string, _ []Token) string {
+	latin := func(r rune) bool { return unicode.Is(unicode.Latin, r) }
+	japanese := func(r rune) bool {
+		return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+	}
+
+	var sb strings.Builder
+	var prev rune
+	for i, r := range text {
+		if i > 0 && (latin(prev) && japanese(r) || japanese(prev) && latin(r)) {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(r)
+		prev = r
+	}
+
+	return sb.String()
+}
+
+// TrimParticles removes the particles, the conjunctions and the determiners
+// left at the end of a sentence cut short, as in "猫が". The sentence-final
+// particles such as "よ" are kept.
+func TrimParticles(text string, tokens []Token) string {
+	text = strings.TrimRightFunc(text, unicode.IsSpace)
+	for i := len(tokens) - 1; i >= 0; i-- {
+		t := tokens[i]
+		if !dangling(t) || !strings.HasSuffix(text, t.Surface) {
+			break
+		}
+		text = strings.TrimRightFunc(strings.TrimSuffix(text, t.Surface), unicode.IsSpace)
+	}
+
+	return text
+}
+
+// dangling reports whether t can not end a sentence.
+func dangling(t Token) bool {
+	switch t.Class {
+	case "助詞":
+		return len(t.Features) < 2 || t.Features[1] != "終助詞"
+	case "接続詞", "ADP", "CONJ", "DET":
+		return true
+	}
+
+	return false
+}
+
+// brackets are the closing brackets of the opening ones.
+var brackets = map[rune]rune{
+	'(': ')', '[': ']', '{': '}',
+	'（': '）', '［': '］', '｛': '｝',
+	'「': '」', '『': '』', '【': '】',
+	'〈': '〉', '《': '》', '〔': '〕',
+	'“': '”', '‘': '’',
+}
+
+var closing = func() map[rune]rune {
+	m := make(map[rune]rune, len(brackets))
+	for o, c := range brackets {
+		m[c] = o
+	}
+	return m
+}()
+
+// BalanceBrackets removes the closing brackets and quotes which close
+// nothing, and closes the ones left open at the end of text.
+func BalanceBrackets(text string, _ []Token) string {
+	var sb strings.Builder
+	var open []rune
+	for _, r := range text {
+		if _, ok := brackets[r]; ok {
+			open = append(open, r)
+		} else if o, ok := closing[r]; ok {
+			if len(open) == 0 || open[len(open)-1] != o {
+				continue
+			}
+			open = open[:len(open)-1]
+		}
+		sb.WriteRune(r)
+	}
+
+	closers := closeBrackets(open)
+	if closers == "" {
+		return sb.String()
+	}
+	// the closing brackets go before the punctuation ending the sentence
+	s := sb.String()
+	body := strings.TrimRightFunc(s, isTerminal)
+
+	return body + closers + s[len(body):]
+}
+
+// closeBrackets returns the closing brackets of open in reverse order.
+func closeBrackets(open []rune) string {
+	var sb strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		sb.WriteRune(brackets[open[i]])
+	}
+
+	return sb.String()
+}
+
+func isTerminal(r rune) bool {
+	return strings.ContainsRune("。．.！？!?", r)
+}
+
+var postProcessors = map[string]PostProcessor{
+	"latin":    SpaceLatin,
+	"particle": TrimParticles,
+	"bracket":  BalanceBrackets,
+}
+
+// ParsePostProcessors returns the post-processors named in the comma
+// separated list s: latin, particle and bracket. "all" means all of them.
+func ParsePostProcessors(s string) ([]PostProcessor, error) {
+	var ps []PostProcessor
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case "all":
+			ps = append(ps, TrimParticles, BalanceBrackets, SpaceLatin)
+			continue
+		}
+
+		p, ok := postProcessors[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown post-processor [%s].", name)
+		}
+		ps = append(ps, p)
+	}
+
+	return ps, nil
+}
+
+// WithPostProcessors sets the post-processors applied in order to the
+// generated sentences. The words given to GenerateFunc and GenerateStream
+// are not post-processed.
+func WithPostProcessors(ps ...PostProcessor) Option {
+	return func(g *generator) {
+		g.post = append(g.post, ps...)
+	}
+}
+
+func (g *generator) postProcess(text string) string {
+	if g.closeBias > 0 {
+		text = BalanceBrackets(text, nil)
+	}
+	for _, p := range g.post {
+		text = p(text, g.outputTokens(text))
+	}
+
+	return text
+}
+
+// outputTokens tokenizes the generated text in the mode of the model.
+func (g *generator) outputTokens(text string) []Token {
+	tokens := g.modeTokens(text)
+	ts := make([]Token, len(tokens))
+	for i, t := range tokens {
+		ts[i] = Token{Surface: t.Surface, Features: t.Features}
+		if len(t.Features) > 0 {
+			ts[i].Class = t.Features[0]
+		}
+	}
+
+	return ts
+}
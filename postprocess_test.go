@@ -0,0 +1,141 @@
+package uonum
+
+import "testing"
+
+func TestSpaceLatin(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "iPhoneを買う", want: "iPhone を買う"},
+		{text: "猫とcatと犬", want: "猫と cat と犬"},
+		{text: "猫と cat", want: "猫と cat"},
+		{text: "3匹のcat。", want: "3匹の cat。"},
+		{text: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := SpaceLatin(tt.text, nil); got != tt.want {
+			t.Errorf("SpaceLatin(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTrimParticles(t *testing.T) {
+	noun := Token{Surface: "猫", Class: "名詞", Features: []string{"名詞", "一般"}}
+	ga := Token{Surface: "が", Class: "助詞", Features: []string{"助詞", "格助詞"}}
+	yo := Token{Surface: "よ", Class: "助詞", Features: []string{"助詞", "終助詞"}}
+	conj := Token{Surface: "しかし", Class: "接続詞", Features: []string{"接続詞"}}
+
+	tests := []struct {
+		name   string
+		text   string
+		tokens []Token
+		want   string
+	}{
+		{name: "particle", text: "猫が", tokens: []Token{noun, ga}, want: "猫"},
+		{name: "final particle", text: "猫よ", tokens: []Token{noun, yo}, want: "猫よ"},
+		{name: "conjunctions", text: "猫がしかし ", tokens: []Token{noun, ga, conj}, want: "猫"},
+		{name: "noun", text: "猫", tokens: []Token{noun}, want: "猫"},
+		{
+			name:   "whitespace",
+			text:   "the cat of the",
+			tokens: []Token{{Surface: "the", Class: "DET"}, {Surface: "cat", Class: "NOUN"}, {Surface: "of", Class: "ADP"}, {Surface: "the", Class: "DET"}},
+			want:   "the cat",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TrimParticles(tt.text, tt.tokens); got != tt.want {
+				t.Errorf("TrimParticles(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBalanceBrackets(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "「猫が鳴く」", want: "「猫が鳴く」"},
+		{text: "「猫が鳴く。", want: "「猫が鳴く」。"},
+		{text: "猫が鳴く」。", want: "猫が鳴く。"},
+		{text: "（「猫）」", want: "（「猫」）"},
+		{text: "(猫『犬!?", want: "(猫『犬』)!?"},
+		{text: "“cat”", want: "“cat”"},
+	}
+
+	for _, tt := range tests {
+		if got := BalanceBrackets(tt.text, nil); got != tt.want {
+			t.Errorf("BalanceBrackets(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParsePostProcessors(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int
+		wantErr bool
+	}{
+		{s: "", want: 0},
+		{s: "latin", want: 1},
+		{s: " Particle , bracket ", want: 2},
+		{s: "all", want: 3},
+		{s: "latin,unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			ps, err := ParsePostProcessors(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePostProcessors(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+			}
+			if len(ps) != tt.want {
+				t.Errorf("ParsePostProcessors(%q) = %d post-processors, want %d", tt.s, len(ps), tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPostProcessors(t *testing.T) {
+	exclaim := func(text string, tokens []Token) string {
+		if len(tokens) == 0 {
+			return text
+		}
+		return text + "!"
+	}
+
+	tests := []struct {
+		name  string
+		texts []string
+		opts  []Option
+		want  string
+	}{
+		{name: "custom", texts: []string{"猫が鳴く。"}, opts: []Option{WithPostProcessors(exclaim)}, want: "猫が鳴く。!"},
+		{name: "in order", texts: []string{"猫が鳴く。"}, opts: []Option{WithPostProcessors(exclaim, exclaim)}, want: "猫が鳴く。!!"},
+		// the walk stops before the banned word
+		{
+			name:  "particle",
+			texts: []string{"猫が走る。"},
+			opts:  []Option{WithBannedWords([]string{"走る"}), WithPostProcessors(TrimParticles)},
+			want:  "猫",
+		},
+		{name: "latin", texts: []string{"猫はcatだ。"}, opts: []Option{WithPostProcessors(SpaceLatin)}, want: "猫は cat だ。"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, tt.texts, tt.opts...)
+			got, err := g.Generate("猫")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Generate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
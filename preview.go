@@ -0,0 +1,108 @@
+package uonum
+
+import "sort"
+
+// Preview is what Register would learn from a text, to check the
+// tokenization and the filters without writing to the database.
+type Preview struct {
+	// Text is the text after the filters and the normalizers.
+	Text string
+	// Sentences are the tokens of the sentences whose links are registered.
+	// The text is one sentence unless it is split at the term words.
+	Sentences [][]PreviewToken
+	// Links are the links which would be added, in key order.
+	Links []PreviewLink
+}
+
+// PreviewToken is a token of a text previewed.
+type PreviewToken struct {
+	Surface  string
+	Class    string
+	Features []string
+	Reading  string `json:",omitempty"`
+	// Key is the key of the word in the model, as "word_class".
+	Key string
+}
+
+// PreviewLink is a link of a text previewed.
+type PreviewLink struct {
+	From  string
+	To    string
+	Count int64
+	// New is true if the model does not have the link yet.
+	New bool
+}
+
+// Preview tokenizes text as Register does, and returns the tokens and the
+// links it would add to the model, without writing them. The texts
+// registered before are not checked for WithDedup.
+func (g *generator) Preview(text string) (*Preview, error) {
+	if g.s == nil {
+		return nil, ErrNotOpen
+	}
+
+	p := &Preview{Text: g.normalize(g.filter(text))}
+	sentences, markers := g.textTokens(p.Text)
+	wlmap := make(map[string]*wordLink)
+	for _, sentence := range sentences {
+		tokens := make([]PreviewToken, len(sentence))
+		for i, t := range sentence {
+			tokens[i] = PreviewToken{
+				Surface:  t.Surface,
+				Class:    t.Features[0],
+				Features: t.Features,
+				Reading:  t.Reading,
+				Key:      displayKey(t.key()),
+			}
+		}
+		p.Sentences = append(p.Sentences, tokens)
+		addLinks(wlmap, sentence, markers)
+	}
+	if len(wlmap) == 0 {
+		return p, nil
+	}
+
+	keys := make([]string, 0, len(wlmap))
+	for k := range wlmap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// the links of the words known to the model, by the keys of the words
+	known := make(map[string]map[string]int64)
+	err := g.viewWords(func(b bucket) error {
+		for _, k := range keys {
+			wl, err := g.wordLink(b, []byte(k))
+			if err != nil {
+				return err
+			}
+			if wl != nil {
+				known[k] = wl.Links
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		links := wlmap[k].Links
+		to := make([]string, 0, len(links))
+		for n := range links {
+			to = append(to, n)
+		}
+		sort.Strings(to)
+
+		for _, n := range to {
+			p.Links = append(p.Links, PreviewLink{
+				From:  displayKey(k),
+				To:    displayKey(n),
+				Count: links[n],
+				New:   known[k][n] == 0,
+			})
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,77 @@
+package uonum
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。"})
+
+	tests := []struct {
+		text      string
+		wantKeys  [][]string
+		wantLinks []PreviewLink
+	}{
+		{
+			text:     "猫が走る。",
+			wantKeys: [][]string{{"猫_名詞", "が_助詞", "走る_動詞", "。_記号"}},
+			wantLinks: []PreviewLink{
+				{From: "が_助詞", To: "走る_動詞", Count: 1, New: true},
+				{From: "猫_名詞", To: "が_助詞", Count: 1},
+				{From: "走る_動詞", To: "。_記号", Count: 1, New: true},
+			},
+		},
+		{
+			text:     "猫が猫が",
+			wantKeys: [][]string{{"猫_名詞", "が_助詞", "猫_名詞", "が_助詞"}},
+			wantLinks: []PreviewLink{
+				{From: "が_助詞", To: "猫_名詞", Count: 1, New: true},
+				{From: "猫_名詞", To: "が_助詞", Count: 2},
+			},
+		},
+		{text: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			p, err := g.Preview(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Text != tt.text {
+				t.Errorf("Preview() text = %q, want %q", p.Text, tt.text)
+			}
+
+			var keys [][]string
+			for _, tokens := range p.Sentences {
+				var ks []string
+				for _, tok := range tokens {
+					ks = append(ks, tok.Key)
+					if tok.Class != tok.Features[0] || tok.Reading == "" {
+						t.Errorf("token = %+v", tok)
+					}
+				}
+				keys = append(keys, ks)
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("Preview() keys = %q, want %q", keys, tt.wantKeys)
+			}
+			if len(p.Links) != 0 || len(tt.wantLinks) != 0 {
+				if !reflect.DeepEqual(p.Links, tt.wantLinks) {
+					t.Errorf("Preview() links = %+v, want %+v", p.Links, tt.wantLinks)
+				}
+			}
+		})
+	}
+
+	// nothing is registered
+	if _, err := g.Generate("走る"); !errors.Is(err, ErrUnknownTrigger) {
+		t.Errorf("Generate() of a previewed word error = %v, want %v", err, ErrUnknownTrigger)
+	}
+
+	if _, err := New().Preview("猫が鳴く。"); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("Preview() before Open error = %v, want %v", err, ErrNotOpen)
+	}
+}
@@ -0,0 +1,142 @@
+package uonum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+)
+
+var bucketProvenance = []byte("provenance")
+
+// maxTraceIDs is the maximum number of the texts reported for a step of
+// GenerateTraced.
+const maxTraceIDs = 100
+
+// Trace is a generated sentence with the texts each transition came from.
+type Trace struct {
+	Text  string
+	Steps []Step
+}
+
+// Step is a transition in a generated sentence.
+type Step struct {
+	Link
+	// TextIDs are the IDs of the texts which made the link, recorded only
+	// for the texts registered WithProvenance.
+	TextIDs []uint64
+	// Sources are the sources which contributed the link.
+	Sources []string
+}
+
+// WithProvenance makes Register record which texts made each link, for
+// GenerateTraced. It needs a record per link of every text.
+func WithProvenance() Option {
+	return func(g *generator) {
+		g.provenance = true
+	}
+}
+
+// tracer records the keys visited by the last walk, the number of the walks
+// and whether the last one came to a dead end.
+type tracer struct {
+	keys    []string
+	walks   int
+	deadEnd bool
+}
+
+// provenanceKey returns the key of the link from -> to made by the text id,
+// or the prefix of the keys of the link if id is nil.
+func provenanceKey(from, to string, id []byte) []byte {
+	k := wordPrefix(from)
+	k = append(k, wordPrefix(to)...)
+	return append(k, id...)
+}
+
+// linkPairs returns the links in wlmap.
+func linkPairs(wlmap map[string]*wordLink) [][2]string {
+	var links [][2]string
+	for from, wl := range wlmap {
+		for to := range wl.Links {
+			links = append(links, [2]string{from, to})
+		}
+	}
+
+	return links
+}
+
+// putProvenance records that the text id made links.
+func putProvenance(c container, id []byte, links [][2]string) error {
+	if len(links) == 0 {
+		return nil
+	}
+	pb, err := c.CreateBucketIfNotExists(bucketProvenance)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range links {
+		err := pb.Put(provenanceKey(l[0], l[1], id), []byte("1"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GenerateTraced is like Generate but also returns where each transition of
+// the sentence came from.
+func (g *generator) GenerateTraced(trigger string) (*Trace, error) {
+	c := *g
+	c.tracer = new(tracer)
+	text, err := c.Generate(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Trace{Text: text}
+	keys := c.tracer.keys
+	if len(keys) < 2 {
+		return t, nil
+	}
+
+	err = g.viewNS(func(c container) error {
+		b := c.Bucket(bucketWords)
+		pb := c.Bucket(bucketProvenance)
+		for i := 0; i+1 < len(keys); i++ {
+			from, to := keys[i], keys[i+1]
+			s := Step{Link: Link{From: displayKey(from), To: displayKey(to)}}
+
+			if pb != nil {
+				prefix := provenanceKey(from, to, nil)
+				cur := pb.Cursor()
+				for k, _ := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix) && len(s.TextIDs) < maxTraceIDs; k, _ = cur.Next() {
+					if id := k[len(prefix):]; len(id) == 8 {
+						s.TextIDs = append(s.TextIDs, binary.BigEndian.Uint64(id))
+					}
+				}
+			}
+
+			wl, err := getWordLink(b, []byte(from))
+			if err != nil {
+				return err
+			}
+			if wl != nil {
+				for src, links := range wl.Sources {
+					if links[to] > 0 {
+						s.Sources = append(s.Sources, src)
+					}
+				}
+				sort.Strings(s.Sources)
+			}
+
+			t.Steps = append(t.Steps, s)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
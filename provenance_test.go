@@ -0,0 +1,63 @@
+package uonum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateTraced(t *testing.T) {
+	records := []struct {
+		text   string
+		source string
+	}{
+		{text: "猫が鳴く。", source: "a"},
+		{text: "猫が鳴く。", source: "b"},
+		{text: "犬が鳴く。", source: "a"},
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []Step
+	}{
+		{
+			name: "provenance",
+			opts: []Option{WithProvenance()},
+			want: []Step{
+				{Link: Link{From: "犬_名詞", To: "が_助詞"}, TextIDs: []uint64{3}, Sources: []string{"a"}},
+				{Link: Link{From: "が_助詞", To: "鳴く_動詞"}, TextIDs: []uint64{1, 2, 3}, Sources: []string{"a", "b"}},
+				{Link: Link{From: "鳴く_動詞", To: "。_記号"}, TextIDs: []uint64{1, 2, 3}, Sources: []string{"a", "b"}},
+			},
+		},
+		{
+			name: "no provenance",
+			want: []Step{
+				{Link: Link{From: "犬_名詞", To: "が_助詞"}, Sources: []string{"a"}},
+				{Link: Link{From: "が_助詞", To: "鳴く_動詞"}, Sources: []string{"a", "b"}},
+				{Link: Link{From: "鳴く_動詞", To: "。_記号"}, Sources: []string{"a", "b"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, nil, tt.opts...)
+			for _, r := range records {
+				if err := g.RegisterWithMeta(r.text, Meta{Source: r.source}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := g.GenerateTraced("犬")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Text != "犬が鳴く。" {
+				t.Errorf("text = %q, want 犬が鳴く。", got.Text)
+			}
+			if !reflect.DeepEqual(got.Steps, tt.want) {
+				t.Errorf("steps = %+v, want %+v", got.Steps, tt.want)
+			}
+		})
+	}
+}
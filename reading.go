@@ -0,0 +1,99 @@
+package uonum
+
+import (
+	"bytes"
+	"strings"
+)
+
+// The readings bucket indexes the words by reading. Its keys are the reading
+// in hiragana and the key of the word separated by readingSep.
+const readingSep = "\x00"
+
+// toHiragana converts the katakana in s to hiragana.
+func toHiragana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ァ' && r <= 'ヶ' {
+			return r - 'ァ' + 'ぁ'
+		}
+		return r
+	}, s)
+}
+
+func readingKey(reading, key string) []byte {
+	return []byte(toHiragana(reading) + readingSep + key)
+}
+
+// putReadings adds the readings of the words in wlmap to the index rb.
+func putReadings(rb bucket, wlmap map[string]*wordLink) error {
+	if rb == nil {
+		return nil
+	}
+
+	for k, w := range wlmap {
+		if w.Reading == "" || w.Reading == "*" {
+			continue
+		}
+		err := rb.Put(readingKey(w.Reading, k), []byte("1"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindByReading returns the words whose reading is reading, in hiragana or
+// katakana.
+func (g *generator) FindByReading(reading string) ([]Trigger, error) {
+	var triggers []Trigger
+	prefix := []byte(toHiragana(g.normalize(reading)) + readingSep)
+	err := g.viewNS(func(c container) error {
+		rb := c.Bucket(bucketReadings)
+		if rb == nil {
+			return nil
+		}
+
+		cur := rb.Cursor()
+		for k, _ := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cur.Next() {
+			w, cl := splitKey(string(k[len(prefix):]))
+			triggers = append(triggers, Trigger{Word: w, Class: cl})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return triggers, nil
+}
+
+// GenerateByReading generates a sentence starting from a word whose reading
+// is reading, chosen at random from the trigger words.
+func (g *generator) GenerateByReading(reading string) (string, error) {
+	triggers, err := g.FindByReading(reading)
+	if err != nil {
+		return "", err
+	}
+
+	match := g.trigger
+	if match == nil {
+		match = Class(g.defaultClass())
+	}
+
+	var words []string
+	for _, t := range triggers {
+		words = append(words, t.Word)
+	}
+	random.Shuffle(len(words), func(i, j int) {
+		words[i], words[j] = words[j], words[i]
+	})
+
+	for _, w := range words {
+		text, err := g.GenerateMatch(w, match)
+		if err != nil || text != "" {
+			return text, err
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,85 @@
+package uonum
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestToHiragana(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "ハシ", want: "はし"},
+		{s: "はし", want: "はし"},
+		{s: "ヴァイオリン", want: "ゔぁいおりん"},
+		{s: "ケーキ", want: "けーき"},
+		{s: "ABC", want: "ABC"},
+	}
+
+	for _, tt := range tests {
+		if got := toHiragana(tt.s); got != tt.want {
+			t.Errorf("toHiragana(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestFindByReading(t *testing.T) {
+	g := openModel(t, []string{"橋を渡る。", "箸で食べる。", "猫が鳴く。"})
+
+	tests := []struct {
+		reading string
+		want    []Trigger
+	}{
+		{reading: "はし", want: []Trigger{{"橋", "名詞"}, {"箸", "名詞"}}},
+		{reading: "ハシ", want: []Trigger{{"橋", "名詞"}, {"箸", "名詞"}}},
+		{reading: "ねこ", want: []Trigger{{"猫", "名詞"}}},
+		{reading: "は"},
+		{reading: "いぬ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reading, func(t *testing.T) {
+			got, err := g.FindByReading(tt.reading)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].Word < got[j].Word })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindByReading(%q) = %v, want %v", tt.reading, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateByReading(t *testing.T) {
+	g := openModel(t, []string{"橋を渡る。", "箸で食べる。", "猫が鳴く。"})
+
+	tests := []struct {
+		reading string
+		want    []string
+	}{
+		{reading: "はし", want: []string{"橋を渡る。", "箸で食べる。"}},
+		{reading: "ネコ", want: []string{"猫が鳴く。"}},
+		{reading: "いぬ", want: []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reading, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				got, err := g.GenerateByReading(tt.reading)
+				if err != nil {
+					t.Fatal(err)
+				}
+				found := false
+				for _, w := range tt.want {
+					found = found || got == w
+				}
+				if !found {
+					t.Errorf("GenerateByReading(%q) = %q, want one of %q", tt.reading, got, tt.want)
+				}
+			}
+		})
+	}
+}
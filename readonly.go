@@ -0,0 +1,102 @@
+package uonum
+
+import (
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// readOnlyTimeout is the time Open waits for the lock of a Bolt database
+// held by a writer with WithReadOnly, unless WithLockTimeout is given.
+const readOnlyTimeout = 5 * time.Second
+
+// WithReadOnly makes Open read the database without writing to it: it is
+// neither created nor upgraded, and the settings given are used without
+// being saved. The changes of the model fail with ErrReadOnly. Open fails
+// with an error matching os.ErrNotExist if the database does not exist.
+func WithReadOnly() Option {
+	return func(g *generator) {
+		g.readOnly = true
+	}
+}
+
+// openReadOnlyStore opens the existing store specified by name for reading.
+func openReadOnlyStore(name string, timeout time.Duration) (store, error) {
+	if isRedisDSN(name) {
+		s, err := dialRedis(name)
+		if err != nil {
+			return nil, err
+		}
+		return readOnlyStore{s}, nil
+	}
+
+	// bolt.Open creates a missing file even if it is read-only
+	if _, err := os.Stat(name); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(name, 0600, &bolt.Options{ReadOnly: true, Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	return readOnlyStore{&boltStore{db: db}}, nil
+}
+
+// readOnlyStore is a store whose Update fails.
+type readOnlyStore struct {
+	store
+}
+
+func (s readOnlyStore) Update(fn func(tx) error) error {
+	return ErrReadOnly
+}
+
+// readOnlyBucket is a bucket of a read-only store which ignores the writes.
+// It is empty if b is nil.
+type readOnlyBucket struct {
+	b bucket
+}
+
+func (b readOnlyBucket) Bucket(name []byte) bucket {
+	if b.b == nil {
+		return nil
+	}
+	return b.b.Bucket(name)
+}
+
+func (b readOnlyBucket) CreateBucketIfNotExists(name []byte) (bucket, error) {
+	return nil, ErrReadOnly
+}
+
+func (b readOnlyBucket) Get(key []byte) []byte {
+	if b.b == nil {
+		return nil
+	}
+	return b.b.Get(key)
+}
+
+func (b readOnlyBucket) Put(key, value []byte) error {
+	return nil
+}
+
+func (b readOnlyBucket) Delete(key []byte) error {
+	return nil
+}
+
+func (b readOnlyBucket) NextSequence() (uint64, error) {
+	return 0, ErrReadOnly
+}
+
+func (b readOnlyBucket) Cursor() cursor {
+	if b.b == nil {
+		return emptyCursor{}
+	}
+	return b.b.Cursor()
+}
+
+type emptyCursor struct{}
+
+func (emptyCursor) First() ([]byte, []byte)           { return nil, nil }
+func (emptyCursor) Next() ([]byte, []byte)            { return nil, nil }
+func (emptyCursor) Seek(seek []byte) ([]byte, []byte) { return nil, nil }
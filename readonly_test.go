@@ -0,0 +1,68 @@
+package uonum
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithReadOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		// create registers a text in the database before it is opened
+		create  bool
+		opts    []Option
+		wantErr error
+	}{
+		{name: "existing", create: true},
+		{name: "other settings", create: true, opts: []Option{WithTermWords([]string{"!"})}},
+		{name: "missing", wantErr: os.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.db")
+			if tt.create {
+				g := New()
+				if err := g.Open(name); err != nil {
+					t.Fatal(err)
+				}
+				if err := g.Register("猫が魚を食べる。"); err != nil {
+					t.Fatal(err)
+				}
+				g.Close()
+			}
+			before, _ := os.ReadFile(name)
+
+			g := New(append(tt.opts, WithReadOnly())...)
+			err := g.Open(name)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Open() = %v, want %v", err, tt.wantErr)
+				}
+				if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("the database is created")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := g.Preview("犬が魚を食べる。"); err != nil {
+				t.Errorf("Preview() = %v", err)
+			}
+			if err := g.Register("犬が魚を食べる。"); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("Register() = %v, want ErrReadOnly", err)
+			}
+			g.Close()
+
+			after, _ := os.ReadFile(name)
+			if !bytes.Equal(before, after) {
+				t.Error("the database is changed")
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package uonum
+
+import "fmt"
+
+// Rebuild builds the links of the model again from its registered texts,
+// under the current options such as WithStopWords, WithNormalizers and
+// WithFilters. It returns the number of the texts which made links. The
+// counts of the links are those of the texts, without the effect of Decay.
+func (g *generator) Rebuild() (int, error) {
+	// the buffered texts are rebuilt as well
+	err := g.Flush()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	var w written
+	err = g.updateNS(func(c container) error {
+		n, w = 0, written{}
+		var batch []line
+		var ids [][]byte
+		cur := c.Bucket(bucketTexts).Cursor()
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			meta, err := getMeta(c, k)
+			if err != nil {
+				return err
+			}
+			batch = append(batch, line{textRecord: textRecord{text: string(v), meta: meta}})
+			ids = append(ids, append([]byte(nil), k...))
+		}
+		g.buildLines(batch)
+
+		for _, name := range [][]byte{bucketWords, bucketReadings, bucketProvenance} {
+			if b := c.Bucket(name); b != nil {
+				err := clearBucket(b)
+				if err != nil {
+					return fmt.Errorf("could not clear the %s bucket: %w", name, err)
+				}
+			}
+		}
+		err := rebuildShingles(c)
+		if err != nil {
+			return err
+		}
+
+		for i, l := range batch {
+			if len(l.wlmap) == 0 {
+				continue
+			}
+			g.tag(l.wlmap, &l.textRecord)
+			err := g.putWords(c, l.wlmap)
+			if err != nil {
+				return err
+			}
+			err = putProvenance(c, ids[i], l.links)
+			if err != nil {
+				return err
+			}
+			n++
+		}
+		w.addAll(c)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	g.invalidate(&w)
+	g.debug("Rebuilt the model.", "ns", g.ns, "texts", n)
+
+	return n, nil
+}
+
+// clearBucket deletes all the keys in b and in its nested buckets.
+func clearBucket(b bucket) error {
+	var keys, nested [][]byte
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			nested = append(nested, append([]byte(nil), k...))
+		} else {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+	}
+
+	for _, k := range nested {
+		if nb := b.Bucket(k); nb != nil {
+			err := clearBucket(nb)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, k := range keys {
+		err := b.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
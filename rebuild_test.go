@@ -0,0 +1,107 @@
+package uonum
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRebuild(t *testing.T) {
+	const text = "猫が魚を食べる。"
+
+	tests := []struct {
+		name string
+		// opts are the options of the rebuild
+		opts []Option
+		// want are the words linked from 猫
+		want []string
+	}{
+		{name: "no stop words", want: []string{"が_助詞"}},
+		{
+			name: "stop word",
+			opts: []Option{WithStopWords([]string{"が"}, nil)},
+			want: []string{"魚_名詞"},
+		},
+		{
+			name: "stop class",
+			opts: []Option{WithStopWords(nil, Class("助詞"))},
+			want: []string{"魚_名詞"},
+		},
+		{
+			name: "term word",
+			opts: []Option{WithStopWords(nil, Class("助詞")), WithTermWords([]string{"が"})},
+			want: []string{"が_助詞"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.db")
+			g := New()
+			if err := g.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			if err := g.Register(text); err != nil {
+				t.Fatal(err)
+			}
+			if err := g.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r := New(tt.opts...).(*generator)
+			if err := r.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			n, err := r.Rebuild()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Errorf("Rebuild() = %d, want 1", n)
+			}
+
+			wlmap, err := r.wordLinks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for k := range wlmap[encodeKey("猫", "名詞")].Links {
+				got = append(got, displayKey(k))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("links from 猫 = %q, want %q", got, tt.want)
+			}
+			if got := textsIn(t, r); got != 1 {
+				t.Errorf("texts = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestClearBucket(t *testing.T) {
+	g := openModel(t, []string{"猫が魚を食べる。"})
+
+	err := g.updateNS(func(c container) error {
+		// the words have the nested bucket of the classes
+		b := c.Bucket(bucketWords)
+		if err := clearBucket(b); err != nil {
+			return err
+		}
+		cur := b.Cursor()
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			if v != nil {
+				t.Errorf("key %q is left", k)
+			}
+		}
+		return eachClassKey(b, "名詞", func(key []byte, count int64) error {
+			t.Errorf("class key %q is left", key)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
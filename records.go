@@ -0,0 +1,230 @@
+package uonum
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Record is a text to register with its metadata.
+type Record struct {
+	Text string
+	Meta Meta
+}
+
+// RecordReader reads the records registered by RegisterRecords.
+// Read returns io.EOF at the end of the records.
+type RecordReader interface {
+	Read() (Record, error)
+}
+
+// sizer is a RecordReader which knows the size in bytes of the last record
+// in the input.
+type sizer interface {
+	size() int
+}
+
+// lineReader reads each line as a record.
+type lineReader struct {
+	s    *bufio.Scanner
+	last int
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &lineReader{s: s}
+}
+
+func (r *lineReader) Read() (Record, error) {
+	if !r.s.Scan() {
+		if err := r.s.Err(); err != nil {
+			return Record{}, err
+		}
+		return Record{}, io.EOF
+	}
+	r.last = len(r.s.Bytes()) + 1
+
+	return Record{Text: r.s.Text()}, nil
+}
+
+func (r *lineReader) size() int {
+	return r.last
+}
+
+// CSVFormat is the format of the CSV records read by NewCSVReader.
+type CSVFormat struct {
+	// Comma is the separator of the fields, such as '\t' for TSV.
+	// It is ',' if zero.
+	Comma rune
+	// Column is the number of the column of the texts, starting at 1.
+	Column int
+	// Name is the name of the column of the texts in the header, used
+	// instead of Column if not empty.
+	Name string
+	// Header is true if the first record names the columns.
+	Header bool
+	// Fields keeps the other columns in Meta.Fields by their names in the
+	// header, or their numbers.
+	Fields bool
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	f      CSVFormat
+	names  []string
+	column int
+}
+
+// NewCSVReader returns a RecordReader of the CSV records in r.
+func NewCSVReader(r io.Reader, f CSVFormat) RecordReader {
+	cr := csv.NewReader(r)
+	if f.Comma != 0 {
+		cr.Comma = f.Comma
+	}
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	return &csvReader{r: cr, f: f, column: f.Column - 1}
+}
+
+func (r *csvReader) Read() (Record, error) {
+	if r.f.Header && r.names == nil {
+		names, err := r.r.Read()
+		if err != nil {
+			return Record{}, err
+		}
+		r.names = names
+		if r.f.Name != "" {
+			r.column = -1
+			for i, n := range names {
+				if n == r.f.Name {
+					r.column = i
+				}
+			}
+			if r.column < 0 {
+				return Record{}, fmt.Errorf("No column [%s] in the header.", r.f.Name)
+			}
+		}
+	}
+	if r.column < 0 {
+		return Record{}, fmt.Errorf("Invalid column [%d].", r.f.Column)
+	}
+
+	for {
+		fields, err := r.r.Read()
+		if err != nil {
+			return Record{}, err
+		}
+		// skip the records without the text
+		if r.column >= len(fields) {
+			continue
+		}
+
+		rec := Record{Text: fields[r.column]}
+		if r.f.Fields {
+			rec.Meta.Fields = make(map[string]string, len(fields)-1)
+			for i, v := range fields {
+				if i == r.column {
+					continue
+				}
+				name := strconv.Itoa(i + 1)
+				if i < len(r.names) {
+					name = r.names[i]
+				}
+				rec.Meta.Fields[name] = v
+			}
+		}
+		return rec, nil
+	}
+}
+
+// JSONLFormat is the format of the JSON Lines read by NewJSONLReader.
+type JSONLFormat struct {
+	// Field is the name of the field of the texts. The names of nested
+	// objects are separated by ".", e.g. "status.text".
+	Field string
+	// Fields keeps the other top-level fields in Meta.Fields. The values
+	// other than strings are kept in JSON.
+	Fields bool
+}
+
+type jsonlReader struct {
+	s    *bufio.Scanner
+	f    JSONLFormat
+	path []string
+	n    int
+}
+
+// NewJSONLReader returns a RecordReader of the JSON objects in r, one per
+// line.
+func NewJSONLReader(r io.Reader, f JSONLFormat) RecordReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &jsonlReader{s: s, f: f, path: strings.Split(f.Field, ".")}
+}
+
+func (r *jsonlReader) Read() (Record, error) {
+	for r.s.Scan() {
+		r.n++
+		b := r.s.Bytes()
+		if len(strings.TrimSpace(string(b))) == 0 {
+			continue
+		}
+
+		var obj map[string]interface{}
+		err := json.Unmarshal(b, &obj)
+		if err != nil {
+			return Record{}, fmt.Errorf("[line %d] JSON unmarshal error: %w", r.n, err)
+		}
+
+		text, ok := lookupField(obj, r.path).(string)
+		// skip the objects without the text
+		if !ok {
+			continue
+		}
+
+		rec := Record{Text: text}
+		if r.f.Fields {
+			rec.Meta.Fields = make(map[string]string, len(obj))
+			for k, v := range obj {
+				if len(r.path) == 1 && k == r.path[0] {
+					continue
+				}
+				if s, ok := v.(string); ok {
+					rec.Meta.Fields[k] = s
+					continue
+				}
+				d, err := json.Marshal(v)
+				if err != nil {
+					return Record{}, fmt.Errorf("[line %d] JSON marshal error: %w", r.n, err)
+				}
+				rec.Meta.Fields[k] = string(d)
+			}
+		}
+		return rec, nil
+	}
+	if err := r.s.Err(); err != nil {
+		return Record{}, err
+	}
+
+	return Record{}, io.EOF
+}
+
+// lookupField returns the value at path in obj, or nil if there is none.
+func lookupField(obj map[string]interface{}, path []string) interface{} {
+	var v interface{} = obj
+	for _, name := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+
+	return v
+}
@@ -0,0 +1,139 @@
+package uonum
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readAll returns all the records read from rr.
+func readAll(rr RecordReader) ([]Record, error) {
+	var records []Record
+	for {
+		rec, err := rr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		f       CSVFormat
+		want    []Record
+		wantErr bool
+	}{
+		{
+			name:  "column",
+			input: "1,猫が鳴く。\n2,\"犬が, 鳴く。\"\n3\n",
+			f:     CSVFormat{Column: 2},
+			want:  []Record{{Text: "猫が鳴く。"}, {Text: "犬が, 鳴く。"}},
+		},
+		{
+			name:  "tsv",
+			input: "猫が鳴く。\tcat\n",
+			f:     CSVFormat{Comma: '\t', Column: 1},
+			want:  []Record{{Text: "猫が鳴く。"}},
+		},
+		{
+			name:  "header name",
+			input: "id,text,user\n1,猫が鳴く。,tama\n",
+			f:     CSVFormat{Name: "text", Header: true, Fields: true},
+			want: []Record{
+				{Text: "猫が鳴く。", Meta: Meta{Fields: map[string]string{"id": "1", "user": "tama"}}},
+			},
+		},
+		{
+			name:  "numbered fields",
+			input: "1,猫が鳴く。,tama\n",
+			f:     CSVFormat{Column: 2, Fields: true},
+			want: []Record{
+				{Text: "猫が鳴く。", Meta: Meta{Fields: map[string]string{"1": "1", "3": "tama"}}},
+			},
+		},
+		{name: "no column", input: "id,text\n", f: CSVFormat{Name: "body", Header: true}, wantErr: true},
+		{name: "invalid column", input: "猫\n", f: CSVFormat{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readAll(NewCSVReader(strings.NewReader(tt.input), tt.f))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONLReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		f       JSONLFormat
+		want    []Record
+		wantErr bool
+	}{
+		{
+			name:  "field",
+			input: `{"text": "猫が鳴く。"}` + "\n\n" + `{"body": "none"}` + "\n" + `{"text": "犬が鳴く。"}`,
+			f:     JSONLFormat{Field: "text"},
+			want:  []Record{{Text: "猫が鳴く。"}, {Text: "犬が鳴く。"}},
+		},
+		{
+			name:  "nested",
+			input: `{"status": {"text": "猫が鳴く。"}, "id": 1}`,
+			f:     JSONLFormat{Field: "status.text"},
+			want:  []Record{{Text: "猫が鳴く。"}},
+		},
+		{
+			name:  "fields",
+			input: `{"text": "猫が鳴く。", "user": "tama", "id": 1, "tags": ["cat"]}`,
+			f:     JSONLFormat{Field: "text", Fields: true},
+			want: []Record{
+				{Text: "猫が鳴く。", Meta: Meta{Fields: map[string]string{"user": "tama", "id": "1", "tags": `["cat"]`}}},
+			},
+		},
+		{
+			name:    "invalid",
+			input:   `{"text": "猫が鳴く。"}` + "\n{",
+			f:       JSONLFormat{Field: "text"},
+			want:    []Record{{Text: "猫が鳴く。"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readAll(NewJSONLReader(strings.NewReader(tt.input), tt.f))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterRecords(t *testing.T) {
+	g := openModel(t, nil)
+	rr := NewCSVReader(strings.NewReader("1,猫が鳴く。\n2,犬が鳴く。\n"), CSVFormat{Column: 2})
+	if err := g.RegisterRecords(rr, Meta{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := textsIn(t, g); got != 2 {
+		t.Errorf("texts = %d, want 2", got)
+	}
+}
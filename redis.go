@@ -0,0 +1,720 @@
+package uonum
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	defaultRedisPrefix = "uonum"
+	redisMaxRetries    = 10
+	// redisPageSize is the largest number of the keys a cursor reads at
+	// once. The first read of a cursor is smaller, since most of them only
+	// look at the first key.
+	redisPageSize = 512
+)
+
+// redisStore is a store backed by Redis, so that several processes on
+// different hosts can share one model.
+//
+// Each value of a bucket is stored as a string "<prefix>:<path>:v:<key>", and
+// the keys of the bucket in a sorted set "<prefix>:<path>:keys" to iterate
+// over them in order. The texts buckets are streams "<prefix>:<path>:stream"
+// whose entry IDs are the sequence numbers of the texts. The sequence of a
+// bucket is "<prefix>:<path>:seq", and the set of bucket paths is
+// "<prefix>:buckets". The path of a nested bucket is the names of its
+// ancestors joined by "/".
+//
+// Update transactions are optimistic: the values read are WATCHed, and the
+// transaction is retried when another client modified one of them
+// concurrently. The sequence of a bucket is WATCHed as well when a number is
+// taken from it, so two registrations of texts conflict, and the one which
+// commits second is retried with the next ID.
+type redisStore struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+func openRedisStore(dsn string) (store, error) {
+	s, err := dialRedis(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := s.pool.Get()
+	defer conn.Close()
+	if err := s.convertHashes(conn); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// dialRedis connects to the Redis server of dsn.
+func dialRedis(dsn string) (*redisStore, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis DSN: %w", err)
+	}
+
+	prefix := u.Query().Get("prefix")
+	if prefix == "" {
+		prefix = defaultRedisPrefix
+	}
+	u.RawQuery = ""
+	addr := u.String()
+
+	pool := &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.DialURL(addr)
+		},
+	}
+
+	s := &redisStore{
+		pool:   pool,
+		prefix: prefix,
+	}
+
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("could not connect to Redis: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *redisStore) View(fn func(tx) error) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	t := newRedisTx(s, conn, false)
+	err := fn(t)
+	if err != nil {
+		return err
+	}
+
+	// the errors of the reads, which are reported as missing values to fn
+	return t.err
+}
+
+// Update calls fn in a transaction, and again if another client modified
+// the values it read. fn must not change the values it is given by the
+// caller, since it may be called more than once.
+func (s *redisStore) Update(fn func(tx) error) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	for i := 0; i < redisMaxRetries; i++ {
+		t := newRedisTx(s, conn, true)
+		err := fn(t)
+		if err != nil {
+			conn.Do("UNWATCH")
+			return err
+		}
+
+		ok, err := t.commit()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("Too many conflicts with other Redis clients.")
+}
+
+func (s *redisStore) Close() error {
+	return s.pool.Close()
+}
+
+func (s *redisStore) key(name string) string {
+	return s.prefix + ":" + name
+}
+
+// isStreamBucket reports whether the bucket at path is stored as a stream.
+func isStreamBucket(path string) bool {
+	return path == string(bucketTexts) || strings.HasSuffix(path, "/"+string(bucketTexts))
+}
+
+// convertHashes converts the buckets stored as hashes by the older versions
+// into the current layout. The other clients must not use the database
+// meanwhile.
+func (s *redisStore) convertHashes(conn redis.Conn) error {
+	paths, err := redis.Strings(conn.Do("SMEMBERS", s.key("buckets")))
+	if err != nil {
+		return fmt.Errorf("could not read the buckets: %w", err)
+	}
+
+	for _, p := range paths {
+		typ, err := redis.String(conn.Do("TYPE", s.key(p)))
+		if err != nil {
+			return fmt.Errorf("[%s] could not read the bucket: %w", p, err)
+		}
+		if typ != "hash" {
+			continue
+		}
+
+		m, err := redis.StringMap(conn.Do("HGETALL", s.key(p)))
+		if err != nil {
+			return fmt.Errorf("[%s] could not read the bucket: %w", p, err)
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b := &redisBucket{s: s, path: p, stream: isStreamBucket(p)}
+		b.setKeys()
+		conn.Send("MULTI")
+		for _, k := range keys {
+			b.sendPut(conn, k, []byte(m[k]))
+		}
+		conn.Send("DEL", s.key(p))
+		if _, err := conn.Do("EXEC"); err != nil {
+			return fmt.Errorf("[%s] could not convert the bucket: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+type redisTx struct {
+	s        *redisStore
+	conn     redis.Conn
+	writable bool
+	watched  map[string]bool
+	created  map[string]bool
+	buckets  map[string]*redisBucket
+	err      error
+}
+
+func newRedisTx(s *redisStore, conn redis.Conn, writable bool) *redisTx {
+	return &redisTx{
+		s:        s,
+		conn:     conn,
+		writable: writable,
+		watched:  make(map[string]bool),
+		created:  make(map[string]bool),
+		buckets:  make(map[string]*redisBucket),
+	}
+}
+
+// watch WATCHes key in a writable transaction, before it is read.
+func (t *redisTx) watch(key string) {
+	if !t.writable || t.watched[key] {
+		return
+	}
+	if _, err := t.conn.Do("WATCH", key); err != nil {
+		t.fail(err)
+	}
+	t.watched[key] = true
+}
+
+// fail records the first error of a read which the bucket interface can not
+// return, so that the transaction fails instead of taking the value as
+// missing.
+func (t *redisTx) fail(err error) {
+	if t.err == nil {
+		t.err = fmt.Errorf("could not read from Redis: %w", err)
+	}
+}
+
+func (t *redisTx) Bucket(name []byte) bucket {
+	return t.bucket(string(name))
+}
+
+func (t *redisTx) CreateBucketIfNotExists(name []byte) (bucket, error) {
+	return t.createBucket(string(name))
+}
+
+func (t *redisTx) bucket(path string) bucket {
+	if b, ok := t.buckets[path]; ok {
+		return b
+	}
+
+	if !t.created[path] {
+		ok, err := redis.Bool(t.conn.Do("SISMEMBER", t.s.key("buckets"), path))
+		if err != nil {
+			// the bucket is taken as existing, since the callers expect
+			// the buckets of the models to, and the transaction fails
+			t.fail(err)
+		} else if !ok {
+			return nil
+		}
+	}
+
+	b := &redisBucket{
+		s:       t.s,
+		t:       t,
+		path:    path,
+		stream:  isStreamBucket(path),
+		puts:    make(map[string][]byte),
+		deletes: make(map[string]bool),
+	}
+	b.setKeys()
+	t.buckets[path] = b
+
+	return b
+}
+
+func (t *redisTx) createBucket(path string) (bucket, error) {
+	if !t.writable {
+		return nil, errors.New("Transaction is not writable.")
+	}
+
+	t.created[path] = true
+
+	return t.bucket(path), nil
+}
+
+// commit executes the buffered writes atomically.
+// It returns false if a watched key was modified by another client.
+func (t *redisTx) commit() (bool, error) {
+	if t.err != nil {
+		t.conn.Do("UNWATCH")
+		return false, t.err
+	}
+
+	t.conn.Send("MULTI")
+	for n := range t.created {
+		t.conn.Send("SADD", t.s.key("buckets"), n)
+	}
+	for _, b := range t.buckets {
+		if b.seq > 0 {
+			t.conn.Send("SET", b.seqKey, b.seq)
+		}
+		for k := range b.deletes {
+			b.sendDelete(t.conn, k)
+		}
+		// the entries of a stream are added in the order of their IDs
+		keys := make([]string, 0, len(b.puts))
+		for k := range b.puts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.sendPut(t.conn, k, b.puts[k])
+		}
+	}
+	reply, err := redis.Values(t.conn.Do("EXEC"))
+	if err == redis.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not execute Redis transaction: %w", err)
+	}
+	for _, r := range reply {
+		if err, ok := r.(redis.Error); ok {
+			return false, fmt.Errorf("could not execute Redis transaction: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+type redisBucket struct {
+	s      *redisStore
+	t      *redisTx
+	path   string
+	stream bool
+	// index is the sorted set of the keys, or the stream of the values
+	index  string
+	seqKey string
+	// seq is the last sequence number taken in the transaction, written
+	// with the values so that an ID is never used by two clients
+	seq     uint64
+	puts    map[string][]byte
+	deletes map[string]bool
+}
+
+func (b *redisBucket) setKeys() {
+	if b.stream {
+		b.index = b.s.key(b.path + ":stream")
+	} else {
+		b.index = b.s.key(b.path + ":keys")
+	}
+	b.seqKey = b.s.key(b.path + ":seq")
+}
+
+// valueKey returns the Redis key of the value of key.
+func (b *redisBucket) valueKey(key string) string {
+	return b.s.key(b.path + ":v:" + key)
+}
+
+// streamID returns the ID of the stream entry of key, the big endian
+// sequence number of a text.
+func streamID(key string) string {
+	var d [8]byte
+	copy(d[:], key)
+	return strconv.FormatUint(binary.BigEndian.Uint64(d[:]), 10) + "-1"
+}
+
+// streamKey returns the key of the stream entry id.
+func streamKey(id string) string {
+	n, _ := strconv.ParseUint(strings.TrimSuffix(id, "-1"), 10, 64)
+	return string(itob(n))
+}
+
+func (b *redisBucket) sendPut(conn redis.Conn, key string, value []byte) {
+	if b.stream {
+		conn.Send("XADD", b.index, streamID(key), "v", value)
+		return
+	}
+	conn.Send("SET", b.valueKey(key), value)
+	conn.Send("ZADD", b.index, 0, key)
+}
+
+func (b *redisBucket) sendDelete(conn redis.Conn, key string) {
+	if b.stream {
+		conn.Send("XDEL", b.index, streamID(key))
+		return
+	}
+	conn.Send("DEL", b.valueKey(key))
+	conn.Send("ZREM", b.index, key)
+}
+
+func (b *redisBucket) Bucket(name []byte) bucket {
+	return b.t.bucket(b.path + "/" + string(name))
+}
+
+func (b *redisBucket) CreateBucketIfNotExists(name []byte) (bucket, error) {
+	return b.t.createBucket(b.path + "/" + string(name))
+}
+
+func (b *redisBucket) Get(key []byte) []byte {
+	if v, ok := b.puts[string(key)]; ok {
+		return v
+	}
+	if b.deletes[string(key)] {
+		return nil
+	}
+
+	if b.stream {
+		// the texts are never changed, so they need not be watched
+		id := streamID(string(key))
+		_, values, err := b.streamPage(id, id, 1)
+		if err != nil {
+			b.t.fail(err)
+			return nil
+		}
+		return values[string(key)]
+	}
+
+	vk := b.valueKey(string(key))
+	b.t.watch(vk)
+	v, err := redis.Bytes(b.t.conn.Do("GET", vk))
+	if err == redis.ErrNil {
+		return nil
+	}
+	if err != nil {
+		b.t.fail(err)
+		return nil
+	}
+
+	return v
+}
+
+func (b *redisBucket) Put(key, value []byte) error {
+	if !b.t.writable {
+		return errors.New("Transaction is not writable.")
+	}
+
+	b.puts[string(key)] = value
+	delete(b.deletes, string(key))
+
+	return nil
+}
+
+func (b *redisBucket) Delete(key []byte) error {
+	if !b.t.writable {
+		return errors.New("Transaction is not writable.")
+	}
+
+	delete(b.puts, string(key))
+	b.deletes[string(key)] = true
+
+	return nil
+}
+
+func (b *redisBucket) NextSequence() (uint64, error) {
+	if !b.t.writable {
+		return 0, errors.New("Transaction is not writable.")
+	}
+
+	if b.seq == 0 {
+		// a client which takes the same number commits first, and the
+		// transaction is retried with the next one
+		b.t.watch(b.seqKey)
+		seq, err := redis.Uint64(b.t.conn.Do("GET", b.seqKey))
+		if err != nil && err != redis.ErrNil {
+			return 0, err
+		}
+		b.seq = seq
+	}
+	b.seq++
+
+	return b.seq, nil
+}
+
+func (b *redisBucket) Cursor() cursor {
+	// a transaction iterating over the keys conflicts with the keys added
+	// or removed meanwhile
+	if !b.stream {
+		b.t.watch(b.index)
+	}
+
+	children := b.children()
+	extra := append([]string(nil), children...)
+	for k := range b.puts {
+		extra = append(extra, k)
+	}
+	sort.Strings(extra)
+
+	return &redisCursor{
+		b:        b,
+		extra:    extra,
+		children: toSet(children),
+		size:     16,
+	}
+}
+
+// page returns at most n keys from min in order, and their values.
+func (b *redisBucket) page(min string, inclusive bool, n int) ([]string, map[string][]byte, error) {
+	if b.stream {
+		start := streamID(min)
+		if !inclusive {
+			start = "(" + start
+		}
+		return b.streamPage(start, "+", n)
+	}
+
+	start := "[" + min
+	if !inclusive {
+		start = "(" + min
+	} else if min == "" {
+		start = "-"
+	}
+	keys, err := redis.Strings(b.t.conn.Do("ZRANGEBYLEX", b.index, start, "+", "LIMIT", 0, n))
+	if err != nil || len(keys) == 0 {
+		return nil, nil, err
+	}
+
+	args := make(redis.Args, len(keys))
+	for i, k := range keys {
+		args[i] = b.valueKey(k)
+	}
+	vs, err := redis.ByteSlices(b.t.conn.Do("MGET", args...))
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make(map[string][]byte, len(keys))
+	for i, k := range keys {
+		values[k] = vs[i]
+	}
+
+	return keys, values, nil
+}
+
+// streamPage returns at most n entries of the stream from start to end.
+func (b *redisBucket) streamPage(start, end string, n int) ([]string, map[string][]byte, error) {
+	entries, err := redis.Values(b.t.conn.Do("XRANGE", b.index, start, end, "COUNT", n))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0, len(entries))
+	values := make(map[string][]byte, len(entries))
+	for _, e := range entries {
+		var id string
+		var fields [][]byte
+		if _, err := redis.Scan(e.([]interface{}), &id, &fields); err != nil {
+			return nil, nil, err
+		}
+		k := streamKey(id)
+		keys = append(keys, k)
+		if len(fields) == 2 {
+			values[k] = fields[1]
+		}
+	}
+
+	return keys, values, nil
+}
+
+// children returns the names of the buckets nested in b.
+func (b *redisBucket) children() []string {
+	paths, err := redis.Strings(b.t.conn.Do("SMEMBERS", b.t.s.key("buckets")))
+	if err != nil {
+		b.t.fail(err)
+		paths = nil
+	}
+	for p := range b.t.created {
+		paths = append(paths, p)
+	}
+
+	prefix := b.path + "/"
+	seen := make(map[string]bool)
+	var names []string
+	for _, p := range paths {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		n := p[len(prefix):]
+		if n == "" || strings.Contains(n, "/") || seen[n] {
+			continue
+		}
+		seen[n] = true
+		names = append(names, n)
+	}
+
+	return names
+}
+
+func toSet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// redisCursor iterates over the keys of a bucket in key order, reading them
+// from Redis a page at a time, merged with the nested buckets and the keys
+// written in the transaction.
+type redisCursor struct {
+	b        *redisBucket
+	extra    []string
+	children map[string]bool
+
+	// buf is the page of the keys read from lo, and values their values
+	buf    []string
+	values map[string][]byte
+	lo     string
+	loIncl bool
+	loaded bool
+	end    bool
+	size   int
+
+	key  string
+	done bool
+}
+
+func (c *redisCursor) First() ([]byte, []byte) {
+	return c.seek("", true)
+}
+
+func (c *redisCursor) Next() ([]byte, []byte) {
+	if c.done {
+		return nil, nil
+	}
+	return c.seek(c.key, false)
+}
+
+func (c *redisCursor) Seek(seek []byte) ([]byte, []byte) {
+	return c.seek(string(seek), true)
+}
+
+// seek moves the cursor to the first key from min which is not deleted.
+func (c *redisCursor) seek(min string, inclusive bool) ([]byte, []byte) {
+	for {
+		r, rok := c.remote(min, inclusive)
+		e, eok := c.local(min, inclusive)
+		if !rok && !eok {
+			c.key, c.done = "", true
+			return nil, nil
+		}
+		c.done = false
+
+		k := r
+		if !rok || (eok && e < r) {
+			k = e
+		}
+		c.key = k
+		if c.children[k] {
+			return []byte(k), nil
+		}
+		if v, ok := c.b.puts[k]; ok {
+			return []byte(k), v
+		}
+		if c.b.deletes[k] {
+			min, inclusive = k, false
+			continue
+		}
+
+		return []byte(k), c.values[k]
+	}
+}
+
+// local returns the first key from min of the nested buckets and the keys
+// written in the transaction.
+func (c *redisCursor) local(min string, inclusive bool) (string, bool) {
+	i := sort.SearchStrings(c.extra, min)
+	if !inclusive && i < len(c.extra) && c.extra[i] == min {
+		i++
+	}
+	if i < len(c.extra) {
+		return c.extra[i], true
+	}
+
+	return "", false
+}
+
+// remote returns the first key from min in Redis.
+func (c *redisCursor) remote(min string, inclusive bool) (string, bool) {
+	if c.covers(min) {
+		i := sort.SearchStrings(c.buf, min)
+		if !inclusive && i < len(c.buf) && c.buf[i] == min {
+			i++
+		}
+		if i < len(c.buf) {
+			return c.buf[i], true
+		}
+		if c.end {
+			return "", false
+		}
+		min, inclusive = c.buf[len(c.buf)-1], false
+	}
+
+	keys, values, err := c.b.page(min, inclusive, c.size)
+	if err != nil {
+		c.b.t.fail(err)
+		keys, values = nil, nil
+	}
+	c.buf, c.values = keys, values
+	c.lo, c.loIncl, c.loaded = min, inclusive, true
+	c.end = len(keys) < c.size
+	if c.size < redisPageSize {
+		c.size *= 4
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+
+	return keys[0], true
+}
+
+// covers reports whether the page read has the keys from min.
+func (c *redisCursor) covers(min string) bool {
+	if !c.loaded || min < c.lo || (min == c.lo && !c.loIncl) {
+		return false
+	}
+	if c.end {
+		return true
+	}
+
+	return len(c.buf) > 0 && min <= c.buf[len(c.buf)-1]
+}
@@ -0,0 +1,513 @@
+package uonum
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func newTestRedisStore(t *testing.T) (*redisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	s, err := openRedisStore("redis://" + mr.Addr() + "?prefix=test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s.(*redisStore), mr
+}
+
+// cursorKeys returns the keys from seek (from the first if nil) in order.
+func cursorKeys(c cursor, seek []byte) []string {
+	var keys []string
+	k, _ := c.First()
+	if seek != nil {
+		k, _ = c.Seek(seek)
+	}
+	for ; k != nil; k, _ = c.Next() {
+		keys = append(keys, string(k))
+	}
+
+	return keys
+}
+
+func TestRedisBucket(t *testing.T) {
+	tests := []struct {
+		name string
+		// committed are written in a first transaction, then puts and
+		// deletes in the transaction which reads the bucket
+		committed []string
+		puts      []string
+		deletes   []string
+		children  []string
+		seek      []byte
+		want      []string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:      "committed",
+			committed: []string{"c", "a", "b"},
+			want:      []string{"a", "b", "c"},
+		},
+		{
+			name:      "puts",
+			committed: []string{"a", "c"},
+			puts:      []string{"b", "d"},
+			want:      []string{"a", "b", "c", "d"},
+		},
+		{
+			name:      "deletes",
+			committed: []string{"a", "b", "c"},
+			deletes:   []string{"a", "c"},
+			want:      []string{"b"},
+		},
+		{
+			name:      "children",
+			committed: []string{"a", "c"},
+			children:  []string{"b", "d"},
+			want:      []string{"a", "b", "c", "d"},
+		},
+		{
+			name:      "seek",
+			committed: []string{"a", "b", "d"},
+			puts:      []string{"c"},
+			seek:      []byte("bb"),
+			want:      []string{"c", "d"},
+		},
+		{
+			name:      "seek past the end",
+			committed: []string{"a", "b"},
+			seek:      []byte("z"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestRedisStore(t)
+
+			err := s.Update(func(tx tx) error {
+				b, err := tx.CreateBucketIfNotExists([]byte("words"))
+				if err != nil {
+					return err
+				}
+				for _, k := range tt.committed {
+					if err := b.Put([]byte(k), []byte("v"+k)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.Update(func(tx tx) error {
+				b := tx.Bucket([]byte("words"))
+				for _, k := range tt.puts {
+					if err := b.Put([]byte(k), []byte("v"+k)); err != nil {
+						return err
+					}
+				}
+				for _, k := range tt.deletes {
+					if err := b.Delete([]byte(k)); err != nil {
+						return err
+					}
+				}
+				for _, k := range tt.children {
+					if _, err := b.CreateBucketIfNotExists([]byte(k)); err != nil {
+						return err
+					}
+				}
+
+				if got := cursorKeys(b.Cursor(), tt.seek); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("keys in the transaction = %q, want %q", got, tt.want)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.View(func(tx tx) error {
+				b := tx.Bucket([]byte("words"))
+				if got := cursorKeys(b.Cursor(), tt.seek); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("keys after the commit = %q, want %q", got, tt.want)
+				}
+				for _, k := range tt.want {
+					if b.Bucket([]byte(k)) != nil {
+						continue
+					}
+					if got := string(b.Get([]byte(k))); got != "v"+k {
+						t.Errorf("Get(%q) = %q, want %q", k, got, "v"+k)
+					}
+				}
+				for _, k := range tt.deletes {
+					if got := b.Get([]byte(k)); got != nil {
+						t.Errorf("Get(%q) = %q after Delete, want nil", k, got)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRedisCursorPages(t *testing.T) {
+	tests := []struct {
+		n    int
+		seek int
+	}{
+		{n: 15},
+		{n: 16},
+		{n: 17},
+		{n: redisPageSize + 100, seek: 10},
+		{n: 2*redisPageSize + 1, seek: redisPageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.n), func(t *testing.T) {
+			s, _ := newTestRedisStore(t)
+
+			var keys []string
+			err := s.Update(func(tx tx) error {
+				b, err := tx.CreateBucketIfNotExists([]byte("words"))
+				if err != nil {
+					return err
+				}
+				for i := 0; i < tt.n; i++ {
+					k := fmt.Sprintf("%05d", i)
+					keys = append(keys, k)
+					if err := b.Put([]byte(k), []byte(k)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.View(func(tx tx) error {
+				c := tx.Bucket([]byte("words")).Cursor()
+				var seek []byte
+				if tt.seek > 0 {
+					seek = []byte(keys[tt.seek])
+				}
+				if got := cursorKeys(c, seek); !reflect.DeepEqual(got, keys[tt.seek:]) {
+					t.Errorf("got %d keys, want %d", len(got), tt.n-tt.seek)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRedisTexts(t *testing.T) {
+	s, mr := newTestRedisStore(t)
+
+	texts := []string{"一", "二", "三"}
+	err := s.Update(func(tx tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketTexts)
+		if err != nil {
+			return err
+		}
+		for _, text := range texts {
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			if err := b.Put(itob(id), []byte(text)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if typ := mr.Type("test:texts:stream"); typ != "stream" {
+		t.Errorf("type of the texts = %q, want stream", typ)
+	}
+
+	err = s.View(func(tx tx) error {
+		b := tx.Bucket(bucketTexts)
+		var got []string
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			got = append(got, string(v))
+		}
+		if k, v := c.Seek(itob(3)); string(k) != string(itob(3)) || string(v) != texts[2] {
+			t.Errorf("Seek(3) = %q, %q, want %q", k, v, texts[2])
+		}
+		if !reflect.DeepEqual(got, texts) {
+			t.Errorf("texts = %q, want %q", got, texts)
+		}
+		if got := string(b.Get(itob(2))); got != texts[1] {
+			t.Errorf("Get(2) = %q, want %q", got, texts[1])
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedisConvertHashes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.SAdd("test:buckets", "words", "texts")
+	mr.HSet("test:words", "b", "vb", "a", "va")
+	mr.HSet("test:texts", string(itob(1)), "一")
+
+	st, err := openRedisStore("redis://" + mr.Addr() + "?prefix=test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	if mr.Exists("test:words") || mr.Exists("test:texts") {
+		t.Error("the hashes are not removed")
+	}
+	err = st.View(func(tx tx) error {
+		words := tx.Bucket([]byte("words"))
+		if got, want := cursorKeys(words.Cursor(), nil), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("words = %q, want %q", got, want)
+		}
+		if got := string(words.Get([]byte("b"))); got != "vb" {
+			t.Errorf("Get(b) = %q, want vb", got)
+		}
+		if got := string(tx.Bucket(bucketTexts).Get(itob(1))); got != "一" {
+			t.Errorf("text = %q, want 一", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedisUpdateConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		// other is the word another client modifies during the first call
+		other     string
+		wantCalls int
+	}{
+		{name: "same word", other: "a", wantCalls: 2},
+		{name: "other word", other: "b", wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mr := newTestRedisStore(t)
+
+			put := func(w *wordLink) error {
+				return s.Update(func(tx tx) error {
+					b, err := tx.CreateBucketIfNotExists(bucketWords)
+					if err != nil {
+						return err
+					}
+					_, err = mergeWordLink(b, []byte(w.key()), w, 0, 0)
+					return err
+				})
+			}
+			for _, k := range []string{"a", "b"} {
+				w := newWordLinkWithFeatures(k, []string{"名詞"})
+				w.Links["x"] = 1
+				if err := put(w); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := newWordLinkWithFeatures("a", []string{"名詞"})
+			w.Links["x"] = 1
+			w.Prev = map[string]int64{"y": 1}
+			calls := 0
+			err := s.Update(func(tx tx) error {
+				calls++
+				b := tx.Bucket(bucketWords)
+				if _, err := mergeWordLink(b, []byte(w.key()), w, 0, 0); err != nil {
+					return err
+				}
+				if calls == 1 {
+					// another client writes the same value meanwhile
+					conn, err := redis.Dial("tcp", mr.Addr())
+					if err != nil {
+						return err
+					}
+					defer conn.Close()
+					k := "test:" + string(bucketWords) + ":v:" + newWordLinkWithFeatures(tt.other, []string{"名詞"}).key()
+					v, err := redis.Bytes(conn.Do("GET", k))
+					if err != nil {
+						return err
+					}
+					if _, err := conn.Do("SET", k, v); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+
+			err = s.View(func(tx tx) error {
+				got := new(wordLink)
+				if err := unmarshalWordLink(tx.Bucket(bucketWords).Get([]byte(w.key())), got); err != nil {
+					return err
+				}
+				if got.Links["x"] != 2 || got.Prev["y"] != 1 {
+					t.Errorf("links = %v, prev = %v, want x:2, y:1", got.Links, got.Prev)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.Links["x"] != 1 {
+				t.Errorf("the links of the caller are changed to %v", w.Links)
+			}
+		})
+	}
+}
+
+func TestRedisSequenceConflict(t *testing.T) {
+	s, _ := newTestRedisStore(t)
+
+	// put puts text as the next text in b, and the word of the text
+	put := func(tx tx, text string) error {
+		b, err := tx.CreateBucketIfNotExists(bucketTexts)
+		if err != nil {
+			return err
+		}
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := b.Put(itob(id), []byte(text)); err != nil {
+			return err
+		}
+		wb, err := tx.CreateBucketIfNotExists(bucketWords)
+		if err != nil {
+			return err
+		}
+		return wb.Put([]byte(text), []byte(text))
+	}
+
+	calls := 0
+	err := s.Update(func(t1 tx) error {
+		calls++
+		if err := put(t1, "a"); err != nil {
+			return err
+		}
+		if calls == 1 {
+			// another client takes the same ID and commits first
+			return s.Update(func(other tx) error {
+				return put(other, "b")
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+
+	err = s.View(func(tx tx) error {
+		var got []string
+		c := tx.Bucket(bucketTexts).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			got = append(got, string(v))
+		}
+		if want := []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("texts = %q, want %q", got, want)
+		}
+		if got := cursorKeys(tx.Bucket(bucketWords).Cursor(), nil); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("words = %q, want [a b]", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedisReadError(t *testing.T) {
+	tests := []struct {
+		name     string
+		writable bool
+	}{
+		{name: "view"},
+		{name: "update", writable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mr := newTestRedisStore(t)
+			err := s.Update(func(tx tx) error {
+				b, err := tx.CreateBucketIfNotExists(bucketWords)
+				if err != nil {
+					return err
+				}
+				return b.Put([]byte("a"), []byte("1"))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			read := func(tx tx) error {
+				mr.SetError("LOADING Redis is loading the dataset in memory")
+				defer mr.SetError("")
+				// the bucket is not looked up, but the callers expect it
+				b := tx.Bucket(bucketWords)
+				if b == nil {
+					t.Fatal("Bucket() = nil in an error, want the bucket")
+				}
+				if v := b.Get([]byte("a")); v != nil {
+					t.Errorf("Get(a) = %q in an error, want nil", v)
+				}
+				if tt.writable {
+					// a missing value would reset the word
+					return b.Put([]byte("a"), []byte("0"))
+				}
+				return nil
+			}
+			if tt.writable {
+				err = s.Update(read)
+			} else {
+				err = s.View(read)
+			}
+			if err == nil {
+				t.Fatal("the transaction succeeded, want the error of the read")
+			}
+
+			err = s.View(func(tx tx) error {
+				if got := string(tx.Bucket(bucketWords).Get([]byte("a"))); got != "1" {
+					t.Errorf("Get(a) = %q, want 1", got)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
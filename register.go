@@ -0,0 +1,283 @@
+package uonum
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+)
+
+const (
+	// maxLineSize is the maximum size of a line read by RegisterReader.
+	maxLineSize = 1024 * 1024
+	// batchSize is the number of lines RegisterReader writes in a
+	// transaction.
+	batchSize = 256
+)
+
+// WithProgress makes RegisterReader call fn after each line is registered.
+// done is the number of bytes read so far, and total is the size of the
+// input in bytes, or -1 if it is unknown.
+func WithProgress(fn func(done, total int)) Option {
+	return func(g *generator) {
+		g.progress = fn
+	}
+}
+
+// WithWorkers sets the number of goroutines tokenizing the lines in
+// RegisterReader. If n <= 0, GOMAXPROCS is used, which is the default.
+// The normalizers and the filters are called from the goroutines, so they
+// must be safe for concurrent use.
+func WithWorkers(n int) Option {
+	return func(g *generator) {
+		g.workers = n
+	}
+}
+
+// line is a line read by RegisterReader.
+type line struct {
+	textRecord
+	size  int
+	wlmap map[string]*wordLink
+}
+
+// RegisterReader registers each line read from r.
+// The lines are tokenized on the worker goroutines, and written to the
+// database in batches in the order they are read.
+func (g *generator) RegisterReader(r io.Reader) error {
+	return g.RegisterReaderWithMeta(r, Meta{})
+}
+
+// RegisterReaderWithMeta is like RegisterReader but registers the lines
+// with meta.
+func (g *generator) RegisterReaderWithMeta(r io.Reader, meta Meta) error {
+	// the size of the input before it is decoded, which is the same as or
+	// a bit smaller than the texts in UTF-8
+	total := inputSize(r)
+	r, err := DecodeReader(r, g.encoding)
+	if err != nil {
+		return err
+	}
+
+	return g.registerRecords(newLineReader(r), meta, total)
+}
+
+// RegisterRecords is like RegisterReaderWithMeta but registers the records
+// read from rr. The attributes of meta are used for the records which do
+// not have them.
+func (g *generator) RegisterRecords(rr RecordReader, meta Meta) error {
+	return g.registerRecords(rr, meta, -1)
+}
+
+// registerRecords registers the records read from rr, whose size in bytes
+// is total, or -1 if it is unknown.
+func (g *generator) registerRecords(rr RecordReader, meta Meta, total int) (err error) {
+	if g.spans != nil {
+		var span Span
+		g, span = g.span("uonum.RegisterRecords")
+		span.SetAttributes(slog.String("ns", g.ns))
+		defer func() { endSpan(span, err) }()
+	}
+
+	meta = meta.withTime()
+
+	s := g.s
+	if s == nil {
+		return ErrNotOpen
+	}
+
+	batches := make(chan []line, 1)
+	stop := make(chan struct{})
+	errc := make(chan error, 1)
+	go func() {
+		defer close(batches)
+		errc <- g.readRecords(rr, meta, batches, stop)
+	}()
+
+	done := 0
+	for batch := range batches {
+		err = g.putLines(s, batch)
+		if err != nil {
+			close(stop)
+			break
+		}
+		if g.progress == nil {
+			continue
+		}
+		for _, l := range batch {
+			done += l.size
+			if total >= 0 && done > total {
+				done = total
+			}
+			g.progress(done, total)
+		}
+	}
+	if err != nil {
+		// wait for the reader to stop
+		for range batches {
+		}
+		return err
+	}
+
+	return <-errc
+}
+
+// readRecords reads the records from rr, tokenizes them and sends them to
+// batches until stop is closed.
+func (g *generator) readRecords(rr RecordReader, meta Meta, batches chan<- []line, stop <-chan struct{}) error {
+	batch := make([]line, 0, batchSize)
+	send := func() bool {
+		g.buildLines(batch)
+		select {
+		case batches <- batch:
+		case <-stop:
+			return false
+		}
+		batch = make([]line, 0, batchSize)
+		return true
+	}
+
+	for {
+		rec, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not read the input: %w", err)
+		}
+
+		size := len(rec.Text) + 1
+		if s, ok := rr.(sizer); ok {
+			size = s.size()
+		}
+		batch = append(batch, line{
+			textRecord: textRecord{text: rec.Text, meta: rec.Meta.withDefaults(meta)},
+			size:       size,
+		})
+		if len(batch) == batchSize && !send() {
+			return nil
+		}
+	}
+	if len(batch) > 0 {
+		send()
+	}
+
+	return nil
+}
+
+// buildLines builds the links of the lines on the worker goroutines.
+func (g *generator) buildLines(batch []line) {
+	n := g.workers
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+
+	idx := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range idx {
+				batch[j].wlmap = g.buildLinks(batch[j].text)
+			}
+		}()
+	}
+	for j := range batch {
+		idx <- j
+	}
+	close(idx)
+	wg.Wait()
+}
+
+// putLines writes the lines in a transaction, or adds them to the buffer.
+func (g *generator) putLines(s store, batch []line) error {
+	if g.buf != nil {
+		flush := false
+		for _, l := range batch {
+			if len(l.wlmap) == 0 {
+				continue
+			}
+			wlmap, err := g.dedupBuffered(l.text, l.wlmap)
+			if err != nil {
+				return err
+			}
+			if wlmap == nil {
+				continue
+			}
+			g.tag(wlmap, &l.textRecord)
+			if g.buf.add(g.ns, l.textRecord, wlmap) {
+				flush = true
+			}
+		}
+		g.debug("Buffered the texts.", "ns", g.ns, "texts", len(batch))
+		if flush {
+			return g.Flush()
+		}
+		return nil
+	}
+
+	_, span := g.span("uonum.update")
+	var w written
+	err := s.Update(func(tx tx) error {
+		w = written{}
+		c, err := namespace(tx, g.ns, true)
+		if err != nil {
+			return fmt.Errorf("[%s] could not create the namespace: %w", g.ns, err)
+		}
+
+		for _, l := range batch {
+			if len(l.wlmap) == 0 {
+				continue
+			}
+			wlmap, err := g.dedup(c, l.text, l.wlmap)
+			if err != nil {
+				return err
+			}
+			if wlmap == nil {
+				continue
+			}
+			g.tag(wlmap, &l.textRecord)
+			err = g.putText(c, wlmap, l.textRecord)
+			if err != nil {
+				return err
+			}
+			w.add(c, g.ns, wlmap)
+		}
+		return nil
+	})
+	if g.spans != nil {
+		span.SetAttributes(slog.String("ns", g.ns), slog.Int("texts", len(batch)))
+	}
+	endSpan(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to update the database: %w", err)
+	}
+	g.invalidate(&w)
+	g.debug("Wrote the texts.", "ns", g.ns, "texts", len(batch))
+
+	return nil
+}
+
+// inputSize returns the size of the rest of r in bytes, or -1 if it is
+// unknown.
+func inputSize(r io.Reader) int {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return r.Len()
+	case *os.File:
+		fi, err := r.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return -1
+		}
+		pos, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return int(fi.Size() - pos)
+	}
+
+	return -1
+}
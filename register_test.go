@@ -0,0 +1,107 @@
+package uonum
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchText returns n lines of sentences made of random words, the same for
+// the same n.
+func benchText(n int) string {
+	subjects := []string{"猫", "犬", "鳥", "子供", "先生", "友達", "彼女", "私"}
+	objects := []string{"魚", "本", "手紙", "花", "映画", "音楽", "ご飯", "写真"}
+	verbs := []string{"見る", "読む", "書く", "食べる", "買う", "送る", "作る", "探す"}
+	times := []string{"今日", "昨日", "明日", "毎朝", "夜"}
+
+	r := rand.New(rand.NewSource(int64(n)))
+	pick := func(words []string) string {
+		return words[r.Intn(len(words))]
+	}
+
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "%sは%s%sを%s。\n", pick(subjects), pick(times), pick(objects), pick(verbs))
+	}
+
+	return sb.String()
+}
+
+// openBench opens a generator of a new database for a benchmark.
+func openBench(b *testing.B, opts ...Option) *generator {
+	b.Helper()
+
+	g := New(opts...).(*generator)
+	if err := g.Open(filepath.Join(b.TempDir(), "bench.db")); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { g.Close() })
+
+	return g
+}
+
+func BenchmarkRegisterReader(b *testing.B) {
+	text := benchText(1000)
+	// load the dictionary before the first run
+	if err := openBench(b).Register("猫が鳴く。"); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				g := openBench(b, WithWorkers(workers))
+				b.StartTimer()
+
+				if err := g.RegisterReader(strings.NewReader(text)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestProgress(t *testing.T) {
+	const text = "猫が鳴く。\n犬が走る。\n鳥が飛ぶ。\n"
+
+	tests := []struct {
+		name      string
+		r         io.Reader
+		wantTotal int
+	}{
+		{name: "sized", r: strings.NewReader(text), wantTotal: len(text)},
+		{name: "unsized", r: io.MultiReader(strings.NewReader(text)), wantTotal: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dones []int
+			g := openModel(t, nil, WithProgress(func(done, total int) {
+				if total != tt.wantTotal {
+					t.Errorf("total = %d, want %d", total, tt.wantTotal)
+				}
+				dones = append(dones, done)
+			}))
+			if err := g.RegisterReader(tt.r); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(dones) != 3 {
+				t.Fatalf("progress called %d times, want 3", len(dones))
+			}
+			for i := 1; i < len(dones); i++ {
+				if dones[i] <= dones[i-1] {
+					t.Errorf("done = %v, want increasing", dones)
+				}
+			}
+			if last := dones[len(dones)-1]; last != len(text) {
+				t.Errorf("last done = %d, want %d", last, len(text))
+			}
+		})
+	}
+}
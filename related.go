@@ -0,0 +1,174 @@
+package uonum
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRelatedTexts is the number of the stored texts containing an unknown
+// trigger word which GenerateRelated looks into.
+const maxRelatedTexts = 1000
+
+// GenerateRelated is like Generate, but if trigger is not in the model, it
+// starts from a related word instead: a word of the same reading, a word
+// sharing the longest prefix with it, or the word occurring with it most in
+// the stored texts, in this order. used is the word which the sentence
+// starts from, which is trigger if it is known.
+func (g *generator) GenerateRelated(trigger string) (text, used string, err error) {
+	text, err = g.Generate(trigger)
+	if !errors.Is(err, ErrUnknownTrigger) {
+		return text, trigger, err
+	}
+
+	sub, rerr := g.relatedTrigger(g.normalize(trigger))
+	if rerr != nil {
+		return "", "", rerr
+	}
+	if sub == "" {
+		return "", "", err
+	}
+	g.debug("Used a related trigger word.", "trigger", trigger, "used", sub)
+
+	text, err = g.Generate(sub)
+	if err != nil {
+		return "", "", err
+	}
+
+	return text, sub, nil
+}
+
+// relatedTrigger returns a trigger word in the model related to trigger, or
+// "" if there is none.
+func (g *generator) relatedTrigger(trigger string) (string, error) {
+	var word string
+	err := g.viewNS(func(c container) error {
+		b := c.Bucket(bucketWords)
+		if b == nil {
+			return nil
+		}
+		known := func(w string) bool {
+			key, err := g.startKey(b, w)
+			return err == nil && key != nil && b.Get(key) != nil
+		}
+
+		word = g.sameReading(c, trigger, known)
+		if word == "" {
+			word = g.sharedPrefix(b, trigger, known)
+		}
+		if word == "" {
+			word = g.cooccurring(c, trigger, known)
+		}
+		return nil
+	})
+
+	return word, err
+}
+
+// sameReading returns a known word whose reading is that of trigger.
+func (g *generator) sameReading(c container, trigger string, known func(string) bool) string {
+	rb := c.Bucket(bucketReadings)
+	if rb == nil || g.mode != ModeWord {
+		return ""
+	}
+
+	var reading strings.Builder
+	for _, t := range g.tokenize(trigger) {
+		if t.Reading == "" || t.Reading == "*" {
+			// the unknown words are read as they are written in kana
+			reading.WriteString(t.Surface)
+			continue
+		}
+		reading.WriteString(t.Reading)
+	}
+
+	prefix := toHiragana(reading.String()) + readingSep
+	var words []string
+	cur := rb.Cursor()
+	for k, _ := cur.Seek([]byte(prefix)); k != nil && strings.HasPrefix(string(k), prefix); k, _ = cur.Next() {
+		w, _ := splitKey(string(k[len(prefix):]))
+		if w != trigger && known(w) {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+
+	return words[random.Intn(len(words))]
+}
+
+// sharedPrefix returns the known word of the default class sharing the
+// longest prefix with trigger, at least half of it, preferring the frequent
+// ones.
+func (g *generator) sharedPrefix(b bucket, trigger string, known func(string) bool) string {
+	min := (utf8.RuneCountInString(trigger) + 1) / 2
+	var best string
+	var bestLen int
+	var bestCount int64
+	eachClassKey(b, g.defaultClass(), func(key []byte, n int64) error {
+		w, _ := splitKey(string(key))
+		l := commonPrefix(w, trigger)
+		if l < min || l < bestLen || (l == bestLen && n <= bestCount) || !known(w) {
+			return nil
+		}
+		best, bestLen, bestCount = w, l, n
+		return nil
+	})
+
+	return best
+}
+
+// commonPrefix returns the number of the runes of the common prefix of a
+// and b.
+func commonPrefix(a, b string) int {
+	n := 0
+	for a != "" && b != "" {
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			break
+		}
+		n++
+		a, b = a[sa:], b[sb:]
+	}
+
+	return n
+}
+
+// cooccurring returns the known word of the default class occurring most in
+// the stored texts containing trigger.
+func (g *generator) cooccurring(c container, trigger string, known func(string) bool) string {
+	tb := c.Bucket(bucketTexts)
+	if tb == nil || trigger == "" {
+		return ""
+	}
+
+	class := g.defaultClass()
+	counts := make(map[string]int)
+	texts := 0
+	cur := tb.Cursor()
+	for k, v := cur.First(); k != nil && texts < maxRelatedTexts; k, v = cur.Next() {
+		text := string(v)
+		if !strings.Contains(g.normalize(text), trigger) {
+			continue
+		}
+		texts++
+		for _, t := range g.tokenize(g.normalize(g.filter(text))) {
+			if len(t.Features) > 0 && t.Features[0] == class && t.Surface != trigger {
+				counts[t.Surface]++
+			}
+		}
+	}
+
+	var best string
+	for w, n := range counts {
+		if n > counts[best] || (n == counts[best] && w < best) {
+			if known(w) {
+				best = w
+			}
+		}
+	}
+
+	return best
+}
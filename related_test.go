@@ -0,0 +1,59 @@
+package uonum
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateRelated(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "子猫が遊ぶ。"})
+
+	tests := []struct {
+		name     string
+		trigger  string
+		wantUsed string
+		wantErr  error
+	}{
+		{name: "known", trigger: "猫", wantUsed: "猫"},
+		{name: "reading", trigger: "ネコ", wantUsed: "猫"},
+		{name: "prefix", trigger: "子犬", wantUsed: "子猫"},
+		// 鳴 is only a part of 鳴く
+		{name: "cooccurring", trigger: "鳴", wantUsed: "猫"},
+		{name: "unknown", trigger: "鳥", wantErr: ErrUnknownTrigger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, used, err := g.GenerateRelated(tt.trigger)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateRelated() error = %v, want %v", err, tt.wantErr)
+			}
+			if used != tt.wantUsed {
+				t.Errorf("GenerateRelated() used %q, want %q", used, tt.wantUsed)
+			}
+			if err == nil && !strings.HasPrefix(text, used) {
+				t.Errorf("GenerateRelated() = %q, want from %q", text, used)
+			}
+		})
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "子猫", b: "子犬", want: 1},
+		{a: "猫", b: "猫舌", want: 1},
+		{a: "猫舌", b: "猫舌", want: 2},
+		{a: "猫", b: "犬"},
+		{a: "", b: "犬"},
+	}
+
+	for _, tt := range tests {
+		if got := commonPrefix(tt.a, tt.b); got != tt.want {
+			t.Errorf("commonPrefix(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
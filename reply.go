@@ -0,0 +1,109 @@
+package uonum
+
+// Reply generates a response to input. It is seeded from one of the nouns
+// (characters in ModeChar) in input known to the model, preferring rare
+// ones, or from a random noun weighted by its frequency if there is none.
+func (g *generator) Reply(input string) (string, error) {
+	tokens := g.tokenize(g.normalize(input))
+	class := g.defaultClass()
+
+	var text string
+	err := g.viewChain(func(b, tb bucket) error {
+		key, err := g.seedKey(b, tokens, nil)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			key = g.randomKey(b, class)
+		}
+		if key == nil {
+			return ErrEmptyModel
+		}
+
+		text, err = g.generate(b, tb, key)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return text, nil
+}
+
+// seedKey returns the key of one of the nouns (characters in ModeChar) in
+// tokens known to b, preferring rare ones, or nil if there is none.
+// The keys in exclude are not chosen.
+func (g *generator) seedKey(b bucket, tokens []token, exclude map[string]bool) ([]byte, error) {
+	class := g.defaultClass()
+
+	var keys []string
+	var weights []float64
+	seen := make(map[string]bool)
+	for _, t := range tokens {
+		if len(t.Features) == 0 || t.Features[0] != class {
+			continue
+		}
+		key := t.key()
+		if seen[key] || exclude[key] {
+			continue
+		}
+		seen[key] = true
+
+		wl, err := g.wordLink(b, []byte(key))
+		if err != nil {
+			return nil, err
+		}
+		if wl == nil {
+			continue
+		}
+		keys = append(keys, key)
+		// rare words carry more information
+		weights = append(weights, 1/float64(wl.total()+1))
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	return []byte(keys[weightedIndex(weights)]), nil
+}
+
+// weightedIndex returns a random index of weights with probability
+// proportional to its weight.
+func weightedIndex(weights []float64) int {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+
+	r := random.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r < 0 {
+			return i
+		}
+	}
+
+	return len(weights) - 1
+}
+
+// randomKey returns a key of class in b allowed by g, chosen at random with
+// probability proportional to the frequency of the word, or nil if there is
+// no such key.
+func (g *generator) randomKey(b bucket, class string) []byte {
+	var key []byte
+	var total float64
+	eachClassKey(b, class, func(k []byte, n int64) error {
+		if !g.allowed(string(k)) {
+			return nil
+		}
+		// the words which never followed another one have a chance as well
+		w := float64(n + 1)
+		total += w
+		if random.Float64()*total < w {
+			key = append(key[:0], k...)
+		}
+		return nil
+	})
+
+	return key
+}
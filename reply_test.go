@@ -0,0 +1,73 @@
+package uonum
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		input string
+		// wantPrefixes are the words the reply can start with
+		wantPrefixes []string
+		wantErr      error
+	}{
+		{
+			name:         "known noun",
+			texts:        []string{"猫が鳴く。", "犬が走る。"},
+			input:        "猫はかわいい",
+			wantPrefixes: []string{"猫が"},
+		},
+		{
+			name:         "no known noun",
+			texts:        []string{"猫が鳴く。", "犬が走る。"},
+			input:        "鳥がいる",
+			wantPrefixes: []string{"猫が", "犬が"},
+		},
+		{name: "empty", input: "猫", wantErr: ErrEmptyModel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, tt.texts)
+			for i := 0; i < 10; i++ {
+				got, err := g.Reply(tt.input)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Reply() error = %v, want %v", err, tt.wantErr)
+				}
+				if err != nil {
+					return
+				}
+				ok := false
+				for _, p := range tt.wantPrefixes {
+					ok = ok || strings.HasPrefix(got, p)
+				}
+				if !ok {
+					t.Errorf("Reply() = %q, want one starting with %q", got, tt.wantPrefixes)
+				}
+			}
+		})
+	}
+}
+
+func TestWeightedIndex(t *testing.T) {
+	tests := []struct {
+		weights []float64
+		want    int
+	}{
+		{weights: []float64{1}, want: 0},
+		{weights: []float64{0, 1, 0}, want: 1},
+		{weights: []float64{0, 0, 2}, want: 2},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 10; i++ {
+			if got := weightedIndex(tt.weights); got != tt.want {
+				t.Errorf("weightedIndex(%v) = %d, want %d", tt.weights, got, tt.want)
+			}
+		}
+	}
+}
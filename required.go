@@ -0,0 +1,137 @@
+package uonum
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxPathWords is the length limit of the path to the required word,
+// unless Limits.MaxWords is set.
+const maxPathWords = 30
+
+// WithRequiredWord makes the generated sentences contain word, which may be
+// made of several words of the model: the walk goes from the trigger word
+// to it by one of the shortest paths in the chain, then on as usual. If the
+// trigger word is empty, e.g. for Reply without any known noun, see
+// GenerateAround.
+func WithRequiredWord(word string) Option {
+	return func(g *generator) {
+		g.required = word
+	}
+}
+
+// walkThrough is like walk, but walks through the required word.
+func (g *generator) walkThrough(b bucket, key []byte, deadline time.Time) (string, bool, error) {
+	var keys []string
+	for _, t := range g.tokenize(g.normalize(g.required)) {
+		keys = append(keys, t.key())
+	}
+	if len(keys) == 0 {
+		return g.walk(b, key, deadline, nil)
+	}
+	for _, k := range keys {
+		if b.Get([]byte(k)) == nil {
+			return "", false, fmt.Errorf("[%s] the required word is not in the model: %w", g.required, ErrUnknownTrigger)
+		}
+	}
+
+	path, err := g.pathTo(b, string(key), keys[0])
+	if err != nil {
+		return "", false, err
+	}
+	if path == nil {
+		return "", false, fmt.Errorf("[%s] no path to the required word: %w", g.required, ErrGenerationFailed)
+	}
+	for i := 1; i < len(keys); i++ {
+		wl, err := g.wordLink(b, []byte(keys[i-1]))
+		if err != nil {
+			return "", false, err
+		}
+		if wl == nil || wl.Links[keys[i]] == 0 {
+			return "", false, fmt.Errorf("[%s] the required words are never linked: %w", g.required, ErrGenerationFailed)
+		}
+		path = append(path, keys[i-1])
+	}
+
+	tail, ok, err := g.walk(b, []byte(keys[len(keys)-1]), deadline, nil)
+	if err != nil || tail == "" {
+		return tail, ok, err
+	}
+	if g.tracer != nil {
+		g.tracer.keys = append(append([]string(nil), path...), g.tracer.keys...)
+	}
+
+	words, err := g.realize(b, path, keys[len(keys)-1])
+	if err != nil {
+		return "", false, err
+	}
+
+	return g.concat(append(words, tail)...), ok, nil
+}
+
+// pathTo returns the keys of a shortest path in b from from to to, without
+// to, which does not pass a term word, a banned word or the end of a
+// sentence. The ties are broken at random. It returns nil if there is no
+// such path.
+func (g *generator) pathTo(b bucket, from, to string) ([]string, error) {
+	if from == to {
+		return []string{}, nil
+	}
+
+	limit := maxPathWords
+	if g.limits.MaxWords > 0 {
+		limit = g.limits.MaxWords
+	}
+
+	parent := map[string]string{from: ""}
+	level := []string{from}
+	for depth := 0; depth < limit && len(level) > 0; depth++ {
+		var next []string
+		for _, k := range level {
+			if w, _ := splitKey(k); g.twMap[w] {
+				continue
+			}
+			wl, err := g.wordLink(b, []byte(k))
+			if err != nil {
+				return nil, err
+			}
+			if wl == nil {
+				continue
+			}
+
+			links := wl.sortedLinks()
+			random.Shuffle(len(links), func(i, j int) {
+				links[i], links[j] = links[j], links[i]
+			})
+			for _, n := range links {
+				if _, ok := parent[n]; ok || n == eosKey || !g.allowed(n) {
+					continue
+				}
+				parent[n] = k
+				if n == to {
+					return backtrack(parent, from, k), nil
+				}
+				next = append(next, n)
+			}
+		}
+		level = next
+	}
+
+	return nil, nil
+}
+
+// backtrack returns the path from from to last by parent.
+func backtrack(parent map[string]string, from, last string) []string {
+	var path []string
+	for k := last; ; k = parent[k] {
+		path = append(path, k)
+		if k == from {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
@@ -0,0 +1,61 @@
+package uonum
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRequiredWord(t *testing.T) {
+	texts := []string{"猫は魚を食べる。", "猫は家で寝る。", "犬は骨が好き。"}
+
+	tests := []struct {
+		name     string
+		required string
+		trigger  string
+		want     map[string]bool
+		wantErr  error
+	}{
+		{name: "other sentence", required: "骨", trigger: "猫", want: map[string]bool{"猫は骨が好き。": true}},
+		{name: "same sentence", required: "家", trigger: "猫", want: map[string]bool{"猫は家で寝る。": true}},
+		{name: "words", required: "家で", trigger: "猫", want: map[string]bool{"猫は家で寝る。": true}},
+		{name: "trigger", required: "猫", trigger: "猫", want: map[string]bool{"猫は魚を食べる。": true, "猫は家で寝る。": true, "猫は骨が好き。": true}},
+		{name: "unknown", required: "鳥", trigger: "猫", wantErr: ErrUnknownTrigger},
+		{name: "no path", required: "猫", trigger: "犬", wantErr: ErrGenerationFailed},
+		{name: "never linked", required: "魚で", trigger: "猫", wantErr: ErrGenerationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, texts, WithRequiredWord(tt.required))
+			for i := 0; i < 10; i++ {
+				got, err := g.Generate(tt.trigger)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Generate() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil && !tt.want[got] {
+					t.Errorf("Generate() = %q, want one of %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBacktrack(t *testing.T) {
+	parent := map[string]string{"a": "", "b": "a", "c": "b", "d": "a"}
+
+	tests := []struct {
+		last string
+		want []string
+	}{
+		{last: "a", want: []string{"a"}},
+		{last: "c", want: []string{"a", "b", "c"}},
+		{last: "d", want: []string{"a", "d"}},
+	}
+
+	for _, tt := range tests {
+		if got := backtrack(parent, "a", tt.last); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("backtrack(%q) = %q, want %q", tt.last, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package uonum
+
+// Result is a generated sentence with the details of its generation, to
+// build quality gates on.
+type Result struct {
+	Text string `json:"text"`
+	// Trigger is the word which the sentence starts from.
+	Trigger string `json:"trigger"`
+	// Tokens are the words of the sentence in the chain.
+	Tokens []Token `json:"tokens"`
+	// Score is the log-probability of the transitions of the sentence.
+	Score float64 `json:"score"`
+	// DeadEnd is true if the sentence stopped at a word which has no next
+	// word, instead of a term word or the end of a text.
+	DeadEnd bool `json:"dead_end"`
+	// Retries is the number of the walks rejected before the sentence.
+	Retries int `json:"retries"`
+}
+
+// Token is a word of a generated sentence.
+type Token struct {
+	Surface  string   `json:"surface"`
+	Class    string   `json:"class"`
+	Features []string `json:"features"`
+}
+
+// GenerateResult is like Generate but returns the sentence with the details
+// of its generation.
+func (g *generator) GenerateResult(trigger string) (*Result, error) {
+	c := *g
+	c.tracer = new(tracer)
+	text, err := c.Generate(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Result{Text: text, DeadEnd: c.tracer.deadEnd}
+	if c.tracer.walks > 0 {
+		r.Retries = c.tracer.walks - 1
+	}
+	keys := c.tracer.keys
+	if len(keys) == 0 {
+		return r, nil
+	}
+	r.Trigger, _ = splitKey(keys[0])
+
+	err = g.viewWords(func(b bucket) error {
+		var prev *wordLink
+		for i, k := range keys {
+			wl, err := g.wordLink(b, []byte(k))
+			if err != nil {
+				return err
+			}
+			w, cl := splitKey(k)
+			t := Token{Surface: w, Class: cl}
+			if wl != nil {
+				t.Features = wl.Features
+			}
+			r.Tokens = append(r.Tokens, t)
+			if i > 0 {
+				r.Score += prev.logProb(k)
+			}
+			prev = wl
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
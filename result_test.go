@@ -0,0 +1,133 @@
+package uonum
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestResultJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "result",
+			v: &Result{
+				Text:    "猫。",
+				Trigger: "猫",
+				Tokens:  []Token{{Surface: "猫", Class: "名詞", Features: []string{"名詞", "一般"}}},
+				Score:   -1.5,
+				DeadEnd: true,
+				Retries: 2,
+			},
+			want: `{"text":"猫。","trigger":"猫","tokens":[{"surface":"猫","class":"名詞","features":["名詞","一般"]}],"score":-1.5,"dead_end":true,"retries":2}`,
+		},
+		{
+			name: "token",
+			v:    Token{Surface: "。", Class: "記号"},
+			want: `{"surface":"。","class":"記号","features":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(d) != tt.want {
+				t.Errorf("json = %s, want %s", d, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateResult(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "猫が走る。", "犬は吠える。"})
+
+	tests := []struct {
+		trigger      string
+		wantSurfaces [][]string
+		wantScore    float64
+		wantErr      error
+	}{
+		{trigger: "犬", wantSurfaces: [][]string{{"犬", "は", "吠える", "。"}}},
+		{
+			trigger:      "猫",
+			wantSurfaces: [][]string{{"猫", "が", "鳴く", "。"}, {"猫", "が", "走る", "。"}},
+			wantScore:    math.Log(0.5),
+		},
+		{trigger: "鳥", wantErr: ErrUnknownTrigger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.trigger, func(t *testing.T) {
+			r, err := g.GenerateResult(tt.trigger)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateResult() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var surfaces []string
+			var text string
+			for _, tok := range r.Tokens {
+				surfaces = append(surfaces, tok.Surface)
+				text += tok.Surface
+				if len(tok.Features) == 0 || tok.Class == "" {
+					t.Errorf("token = %+v", tok)
+				}
+			}
+			found := false
+			for _, want := range tt.wantSurfaces {
+				found = found || reflect.DeepEqual(surfaces, want)
+			}
+			if !found {
+				t.Errorf("surfaces = %q, want one of %q", surfaces, tt.wantSurfaces)
+			}
+			if r.Text != text || r.Trigger != tt.trigger || r.DeadEnd || r.Retries != 0 {
+				t.Errorf("GenerateResult() = %+v", r)
+			}
+			if math.Abs(r.Score-tt.wantScore) > 1e-9 {
+				t.Errorf("score = %v, want %v", r.Score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestGenerateResultRetries(t *testing.T) {
+	t.Run("dead end", func(t *testing.T) {
+		g := openModel(t, []string{"猫が走る。"}, WithBannedWords([]string{"走る"}))
+		r, err := g.GenerateResult("猫")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the walk stops before the banned word
+		if r.Text != "猫が" || !r.DeadEnd || r.Retries != 0 {
+			t.Errorf("GenerateResult() = %+v", r)
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		g := openModel(t, []string{"猫が鳴く。", "猫が大きな声で鳴く。"}, WithLimits(Limits{MaxWords: 4, MaxRetries: 100}))
+		retried := false
+		for i := 0; i < 20; i++ {
+			r, err := g.GenerateResult("猫")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Text != "猫が鳴く。" || r.DeadEnd {
+				t.Errorf("GenerateResult() = %+v", r)
+			}
+			retried = retried || r.Retries > 0
+		}
+		if !retried {
+			t.Error("no retries counted")
+		}
+	})
+}
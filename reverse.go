@@ -0,0 +1,166 @@
+package uonum
+
+import (
+	"strings"
+	"time"
+)
+
+// GenerateAround generates a sentence which contains trigger, extending it
+// in both directions: the words before it follow the links to the
+// predecessors back to the beginning of a sentence.
+func (g *generator) GenerateAround(trigger string) (string, error) {
+	trigger = g.triggerWord(trigger)
+	if trigger == "" {
+		return "", nil
+	}
+
+	deadline := g.deadline()
+	var text string
+	err := g.viewChain(func(b, tb bucket) error {
+		key, err := g.startKey(b, trigger)
+		if err != nil {
+			return err
+		}
+		if key == nil || b.Get(key) == nil {
+			return missing(b, key)
+		}
+
+		tail, err := g.generateRaw(b, tb, key)
+		if err != nil || tail == "" {
+			return err
+		}
+		head, err := g.walkBack(b, key)
+		if err != nil {
+			return err
+		}
+
+		text = g.postProcess(g.concat(append(head, tail)...))
+		if !g.safe(text, deadline) {
+			return errRejected
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return text, nil
+}
+
+// walkBack walks the chain in b backward from key and returns the words
+// before it in the order of the sentence.
+func (g *generator) walkBack(b bucket, key []byte) ([]string, error) {
+	var deadline time.Time
+	if g.limits.Timeout > 0 {
+		deadline = time.Now().Add(g.limits.Timeout)
+	}
+
+	start := string(key)
+	var keys []string
+	for {
+		if g.limits.MaxWords > 0 && len(keys) >= g.limits.MaxWords {
+			break
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+
+		w, err := g.wordLink(b, key)
+		if err != nil {
+			return nil, err
+		}
+		if w == nil {
+			break
+		}
+
+		p := g.choose(&wordLink{Links: w.Prev})
+		if p == "" || p == bosKey {
+			break
+		}
+		// a term word ends the previous sentence
+		word, _ := splitKey(p)
+		if _, ok := g.twMap[word]; ok {
+			break
+		}
+
+		keys = append(keys, p)
+		key = []byte(p)
+	}
+
+	for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+
+	return g.realize(b, keys, start)
+}
+
+// concat joins words into a text of the mode of the model.
+func (g *generator) concat(words ...string) string {
+	var sb strings.Builder
+	prev := ""
+	for _, w := range words {
+		if g.mode == ModeWhitespace && needSpace(prev, w) {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(g.fill(w))
+		prev = w
+	}
+
+	return sb.String()
+}
+
+// migrateReverseLinks adds the links to the predecessors to the words
+// registered before they were stored.
+func migrateReverseLinks(tx tx) error {
+	for _, m := range models(tx) {
+		b := m.Bucket(bucketWords)
+		if b == nil {
+			continue
+		}
+		err := rebuildPrev(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildPrev recomputes the links to the predecessors of the words in b
+// from the links to their successors.
+func rebuildPrev(b bucket) error {
+	es := entries(b)
+	words := make(map[string]*wordLink, len(es))
+	compress := make(map[string]int, len(es))
+	for _, e := range es {
+		wl, err := decodeWord(b, e.k, e.v)
+		if err != nil {
+			return err
+		}
+		wl.Prev = nil
+		words[string(e.k)] = wl
+		compress[string(e.k)] = keepCompression(e.v)
+	}
+
+	for k, wl := range words {
+		for n, c := range wl.Links {
+			nwl := words[n]
+			if nwl == nil || c == 0 {
+				continue
+			}
+			if nwl.Prev == nil {
+				nwl.Prev = make(map[string]int64)
+			}
+			nwl.Prev[k] += c
+		}
+	}
+
+	for k, wl := range words {
+		_, err := putWordLink(b, []byte(k), wl, compress[k])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package uonum
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRebuildPrev(t *testing.T) {
+	a, b, c := encodeKey("a", "名詞"), encodeKey("b", "名詞"), encodeKey("c", "名詞")
+
+	tests := []struct {
+		name string
+		// links are the links of the words, and prev their stale links
+		// to the predecessors
+		links    map[string]map[string]int64
+		prev     map[string]map[string]int64
+		wantPrev map[string]map[string]int64
+	}{
+		{
+			name:     "chain",
+			links:    map[string]map[string]int64{a: {b: 2}, b: {c: 1}, c: {}},
+			wantPrev: map[string]map[string]int64{b: {a: 2}, c: {b: 1}},
+		},
+		{
+			name:     "merged",
+			links:    map[string]map[string]int64{a: {c: 2}, b: {c: 3}, c: {}},
+			wantPrev: map[string]map[string]int64{c: {a: 2, b: 3}},
+		},
+		{
+			name:     "stale",
+			links:    map[string]map[string]int64{a: {b: 1}, b: {}, c: {}},
+			prev:     map[string]map[string]int64{b: {a: 5}, c: {a: 1}},
+			wantPrev: map[string]map[string]int64{b: {a: 1}},
+		},
+		{
+			name:     "missing and zero",
+			links:    map[string]map[string]int64{a: {b: 0, eosKey: 1}, b: {}},
+			wantPrev: map[string]map[string]int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := openBoltStore(filepath.Join(t.TempDir(), "test.db"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			got := make(map[string]map[string]int64)
+			err = s.Update(func(tx tx) error {
+				bk, err := tx.CreateBucketIfNotExists(bucketWords)
+				if err != nil {
+					return err
+				}
+				for k, links := range tt.links {
+					w, _ := splitKey(k)
+					wl := newWordLinkWithFeatures(w, []string{"名詞"})
+					wl.Links = links
+					wl.Prev = tt.prev[k]
+					if _, err := putWordLink(bk, []byte(k), wl, 0); err != nil {
+						return err
+					}
+				}
+
+				if err := rebuildPrev(bk); err != nil {
+					return err
+				}
+				return eachWord(bk, func(wl *wordLink) error {
+					if len(wl.Prev) > 0 {
+						got[wl.key()] = wl.Prev
+					}
+					return nil
+				})
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.wantPrev) {
+				t.Errorf("prev = %v, want %v", got, tt.wantPrev)
+			}
+		})
+	}
+}
+
+func TestGenerateAround(t *testing.T) {
+	g := openModel(t, []string{"猫が魚を食べる。"})
+
+	tests := []struct {
+		trigger string
+		want    string
+	}{
+		{trigger: "猫", want: "猫が魚を食べる。"},
+		{trigger: "魚", want: "猫が魚を食べる。"},
+		{trigger: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		got, err := g.GenerateAround(tt.trigger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("GenerateAround(%q) = %q, want %q", tt.trigger, got, tt.want)
+		}
+	}
+
+	if _, err := g.GenerateAround("犬"); err == nil {
+		t.Error("GenerateAround() of an unknown word succeeded, want an error")
+	}
+}
@@ -0,0 +1,6 @@
+// Package rpc implements the gRPC service of uonum.proto, the server for
+// "uonum serve -grpc". The messages and the client are generated from
+// uonum.proto, as are the clients in other languages.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative uonum.proto
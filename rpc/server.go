@@ -0,0 +1,130 @@
+package rpc
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+
+	"github.com/kechako/uonum"
+	"google.golang.org/grpc"
+)
+
+// Server serves the Uonum service of uonum.proto on a grpc.Server (see
+// RegisterUonumServer).
+type Server struct {
+	UnimplementedUonumServer
+
+	g  uonum.Generator
+	mu sync.Locker
+}
+
+// NewServer returns a Server of g. The calls of g are serialized by mu, which
+// may be shared with other users of g, or by a mutex of its own if mu is nil.
+func NewServer(g uonum.Generator, mu sync.Locker) *Server {
+	if mu == nil {
+		mu = new(sync.Mutex)
+	}
+
+	return &Server{g: g, mu: mu}
+}
+
+func (s *Server) in(ns string) uonum.Generator {
+	if ns == "" {
+		return s.g
+	}
+
+	return s.g.In(ns)
+}
+
+func (s *Server) Register(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g := s.in(req.Namespace)
+	meta := uonum.Meta{Source: req.Source, Author: req.Author}
+	res := new(RegisterResponse)
+	for _, t := range req.Texts {
+		if err := g.RegisterWithMeta(t, meta); err != nil {
+			return nil, toStatus(err)
+		}
+		res.Count++
+	}
+
+	return res, nil
+}
+
+func (s *Server) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g := s.in(req.Namespace)
+	trigger := req.Trigger
+	if trigger == "" {
+		words, err := g.Triggers("")
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		if len(words) == 0 {
+			return nil, toStatus(uonum.ErrEmptyModel)
+		}
+		trigger = words[rand.Intn(len(words))]
+	}
+
+	text, err := g.Generate(trigger)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &GenerateResponse{Text: text}, nil
+}
+
+func (s *Server) Stats(ctx context.Context, req *StatsRequest) (*StatsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.in(req.Namespace).Stats()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &StatsResponse{
+		Words: int64(st.Words),
+		Links: int64(st.Links),
+		Count: st.Count,
+		Texts: int64(st.Texts),
+	}, nil
+}
+
+// Dump streams the words, which are read before they are sent not to block
+// the others with a slow client.
+func (s *Server) Dump(req *DumpRequest, stream grpc.ServerStreamingServer[Word]) error {
+	r := uonum.DumpRange{
+		Prefix:   req.Prefix,
+		Class:    req.Class,
+		MinCount: req.MinCount,
+		Limit:    int(req.Limit),
+	}
+
+	var words []*Word
+	s.mu.Lock()
+	_, err := s.in(req.Namespace).Words(r, func(info uonum.WordInfo) error {
+		word := &Word{Word: info.Word, Class: info.Class, Total: info.Total}
+		for _, t := range info.Links {
+			word.Links = append(word.Links, &Link{Word: t.Word, Class: t.Class, Count: t.Count})
+		}
+		words = append(words, word)
+		return nil
+	})
+	s.mu.Unlock()
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, word := range words {
+		if err := stream.Send(word); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
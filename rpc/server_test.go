@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/kechako/uonum"
+	"github.com/kechako/uonum/uonumtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T, g uonum.Generator) UonumClient {
+	t.Helper()
+
+	l := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	RegisterUonumServer(gs, NewServer(g, nil))
+	go gs.Serve(l)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return NewUonumClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	c := newTestClient(t, uonumtest.New(1))
+	ctx := context.Background()
+
+	if _, err := c.Generate(ctx, &GenerateRequest{}); !errors.Is(FromStatus(err), uonum.ErrEmptyModel) {
+		t.Errorf("Generate() of an empty model error = %v, want %v", err, uonum.ErrEmptyModel)
+	}
+
+	reg, err := c.Register(ctx, &RegisterRequest{Texts: []string{"猫 が 鳴く 。", "犬 が 走る 。"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg.Count != 2 {
+		t.Errorf("Register() count = %d, want 2", reg.Count)
+	}
+
+	tests := []struct {
+		trigger string
+		wantErr error
+	}{
+		{trigger: "猫"},
+		{trigger: ""},
+		{trigger: "鳥", wantErr: uonum.ErrUnknownTrigger},
+	}
+	for _, tt := range tests {
+		res, err := c.Generate(ctx, &GenerateRequest{Trigger: tt.trigger})
+		if !errors.Is(FromStatus(err), tt.wantErr) {
+			t.Errorf("Generate(%q) error = %v, want %v", tt.trigger, err, tt.wantErr)
+			continue
+		}
+		if err == nil && res.Text == "" {
+			t.Errorf("Generate(%q) = empty text", tt.trigger)
+		}
+	}
+
+	st, err := c.Stats(ctx, &StatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Texts != 2 {
+		t.Errorf("Stats() texts = %d, want 2", st.Texts)
+	}
+
+	stream, err := c.Dump(ctx, &DumpRequest{Prefix: "猫"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	for {
+		w, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		words = append(words, w.Word)
+	}
+	if len(words) != 1 || words[0] != "猫" {
+		t.Errorf("Dump() words = %q, want [猫]", words)
+	}
+}
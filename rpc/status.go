@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"errors"
+
+	"github.com/kechako/uonum"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codeOf returns the status code of err.
+func codeOf(err error) codes.Code {
+	switch {
+	case errors.Is(err, uonum.ErrUnknownTrigger):
+		return codes.NotFound
+	case errors.Is(err, uonum.ErrEmptyModel):
+		return codes.FailedPrecondition
+	case errors.Is(err, uonum.ErrGenerationFailed):
+		return codes.Aborted
+	case errors.Is(err, uonum.ErrNotOpen):
+		return codes.Unavailable
+	case errors.Is(err, uonum.ErrDecode):
+		return codes.DataLoss
+	}
+
+	return codes.Unknown
+}
+
+// toStatus returns the status error of err.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return status.Error(codeOf(err), err.Error())
+}
+
+// FromStatus returns the error of a call wrapping the error of uonum of
+// its status, so that the errors of the server can be compared with them by
+// errors.Is, or err itself if it has none.
+func FromStatus(err error) error {
+	var target error
+	switch status.Code(err) {
+	case codes.NotFound:
+		target = uonum.ErrUnknownTrigger
+	case codes.FailedPrecondition:
+		target = uonum.ErrEmptyModel
+	case codes.Aborted:
+		target = uonum.ErrGenerationFailed
+	default:
+		return err
+	}
+
+	return &statusError{err: err, target: target}
+}
+
+// statusError is the error of a call with the error of uonum of its status.
+type statusError struct {
+	err    error
+	target error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() []error {
+	return []error{e.err, e.target}
+}
@@ -0,0 +1,99 @@
+package uonum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SafetyFilter reports whether a generated sentence may be returned. ctx
+// ends at the Timeout of the Limits of the generation call.
+type SafetyFilter func(ctx context.Context, text string) bool
+
+// moderationTimeout is the time limit of a request of ModerationFilter given
+// a client without one.
+const moderationTimeout = 10 * time.Second
+
+// errRejected is returned when the generated sentence which is not retried
+// is rejected by the safety filter.
+var errRejected = fmt.Errorf("rejected by the safety filter: %w", ErrGenerationFailed)
+
+// WithSafetyFilter makes generation reject the sentences, after the
+// post-processing, for which f returns false, regenerating at most retries
+// times, apart from the MaxRetries of the Limits. The sentences of
+// GenerateAround, GenerateBeam and GenerateTemplate are not regenerated, and
+// the words given to GenerateFunc and GenerateStream are not checked.
+func WithSafetyFilter(f SafetyFilter, retries int) Option {
+	return func(g *generator) {
+		g.safety = f
+		g.safetyRetries = retries
+	}
+}
+
+// safe reports whether text passes the safety filter by deadline (none if
+// zero), counting it if not.
+func (g *generator) safe(text string, deadline time.Time) bool {
+	if g.safety == nil {
+		return true
+	}
+	ctx := context.Background()
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	if g.safety(ctx, text) {
+		return true
+	}
+	g.counters.rejected.Add(1)
+	g.debug("Rejected by the safety filter.", "text", text)
+
+	return false
+}
+
+// ModerationFilter returns a SafetyFilter asking the moderation endpoint at
+// url: it posts {"text": text} as JSON, and the sentence passes if the
+// response is 200 OK with {"flagged": false}. The sentences are rejected if
+// the endpoint fails. The requests are limited by the Timeout of client, or
+// by moderationTimeout if client is nil or has none, since they are made
+// while the database is read.
+func ModerationFilter(url string, client *http.Client) SafetyFilter {
+	if client == nil {
+		client = &http.Client{Timeout: moderationTimeout}
+	} else if client.Timeout <= 0 {
+		c := *client
+		c.Timeout = moderationTimeout
+		client = &c
+	}
+
+	return func(ctx context.Context, text string) bool {
+		body, err := json.Marshal(struct {
+			Text string `json:"text"`
+		}{text})
+		if err != nil {
+			return false
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return false
+		}
+		req.Header.Set("Content-Type", "application/json")
+		res, err := client.Do(req)
+		if err != nil {
+			return false
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return false
+		}
+
+		var r struct {
+			Flagged *bool `json:"flagged"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&r)
+		return err == nil && r.Flagged != nil && !*r.Flagged
+	}
+}
@@ -0,0 +1,151 @@
+package uonum
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSafetyFilterRetries(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。"})
+
+	tests := []struct {
+		name string
+		// rejects is the number of the sentences rejected before one passes
+		rejects int
+		opts    func(f SafetyFilter) []Option
+		wantErr error
+		// wantCalls is the number of the sentences checked
+		wantCalls int
+	}{
+		{
+			name:      "passed",
+			opts:      func(f SafetyFilter) []Option { return []Option{WithSafetyFilter(f, 3)} },
+			wantCalls: 1,
+		},
+		{
+			name:      "retried",
+			rejects:   3,
+			opts:      func(f SafetyFilter) []Option { return []Option{WithSafetyFilter(f, 3)} },
+			wantCalls: 4,
+		},
+		{
+			name:      "rejected",
+			rejects:   4,
+			opts:      func(f SafetyFilter) []Option { return []Option{WithSafetyFilter(f, 3)} },
+			wantErr:   ErrGenerationFailed,
+			wantCalls: 4,
+		},
+		{
+			name:    "limits after",
+			rejects: 4,
+			opts: func(f SafetyFilter) []Option {
+				return []Option{WithSafetyFilter(f, 3), WithLimits(Limits{MaxRetries: 20})}
+			},
+			wantErr:   ErrGenerationFailed,
+			wantCalls: 4,
+		},
+		{
+			name:    "limits before",
+			rejects: 10,
+			opts: func(f SafetyFilter) []Option {
+				return []Option{WithLimits(Limits{MaxRetries: 0}), WithSafetyFilter(f, 20)}
+			},
+			wantCalls: 11,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			f := func(ctx context.Context, text string) bool {
+				calls++
+				return calls > tt.rejects
+			}
+			c := *g
+			for _, opt := range tt.opts(f) {
+				opt(&c)
+			}
+
+			text, err := c.Generate("猫")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Generate() = %q, %v, want error %v", text, err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestModerationFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		delay   time.Duration
+		timeout time.Duration
+		want    bool
+	}{
+		{name: "safe", status: http.StatusOK, body: `{"flagged": false}`, want: true},
+		{name: "flagged", status: http.StatusOK, body: `{"flagged": true}`},
+		{name: "no verdict", status: http.StatusOK, body: `{}`},
+		{name: "error", status: http.StatusInternalServerError, body: `{"flagged": false}`},
+		{
+			name:    "timed out",
+			status:  http.StatusOK,
+			body:    `{"flagged": false}`,
+			delay:   500 * time.Millisecond,
+			timeout: 50 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(tt.delay):
+				case <-r.Context().Done():
+					return
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			if got := ModerationFilter(srv.URL, nil)(ctx, "猫が鳴く。"); got != tt.want {
+				t.Errorf("filter = %v, want %v", got, tt.want)
+			}
+			if tt.delay > 0 && time.Since(start) >= tt.delay {
+				t.Errorf("the filter took %v, want at most the timeout %v", time.Since(start), tt.timeout)
+			}
+		})
+	}
+}
+
+func TestSafetyFilterTimeout(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。"}, WithLimits(Limits{Timeout: 50 * time.Millisecond}))
+	var deadline time.Time
+	WithSafetyFilter(func(ctx context.Context, text string) bool {
+		deadline, _ = ctx.Deadline()
+		return true
+	}, 0)(g)
+
+	if _, err := g.Generate("猫"); err != nil {
+		t.Fatal(err)
+	}
+	if end := time.Now().Add(50 * time.Millisecond); deadline.IsZero() || deadline.After(end) {
+		t.Errorf("deadline of the filter = %v, want by %v", deadline, end)
+	}
+}
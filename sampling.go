@@ -0,0 +1,93 @@
+package uonum
+
+import (
+	"math"
+	"sort"
+)
+
+// Sampling reshapes the distribution of the links a next word is chosen
+// from. The zero value chooses one of the links uniformly at random.
+type Sampling struct {
+	// Temperature sharpens (< 1) or flattens (> 1) the distribution
+	// proportional to the counts of the links. 1 is used if it is <= 0.
+	Temperature float64
+	// TopP keeps only the most probable links whose cumulative probability
+	// reaches TopP (nucleus sampling). All the links are kept if it is <= 0
+	// or >= 1.
+	TopP float64
+}
+
+// WithSampling sets the sampling of the next words.
+func WithSampling(s Sampling) Option {
+	return func(g *generator) {
+		g.sampling = s
+	}
+}
+
+// choose returns the key of the next word of w allowed by g, or "" if there
+// is none.
+func (g *generator) choose(w *wordLink) string {
+	if g.sampling == (Sampling{}) {
+		return w.nextWhere(g.allowed)
+	}
+
+	t := g.sampling.Temperature
+	if t <= 0 {
+		t = 1
+	}
+
+	type link struct {
+		key string
+		w   float64
+	}
+	links := make([]link, 0, len(w.Links))
+	max := math.Inf(-1)
+	for k, c := range w.Links {
+		if c <= 0 || !g.allowed(k) {
+			continue
+		}
+		// weights in log space, so that a low temperature does not overflow
+		lw := math.Log(float64(c)) / t
+		if lw > max {
+			max = lw
+		}
+		links = append(links, link{k, lw})
+	}
+	if len(links) == 0 {
+		return ""
+	}
+
+	var total float64
+	for i := range links {
+		links[i].w = math.Exp(links[i].w - max)
+		total += links[i].w
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].w != links[j].w {
+			return links[i].w > links[j].w
+		}
+		return links[i].key < links[j].key
+	})
+
+	if p := g.sampling.TopP; p > 0 && p < 1 {
+		var sum float64
+		for i, l := range links {
+			sum += l.w
+			if sum >= p*total {
+				links = links[:i+1]
+				total = sum
+				break
+			}
+		}
+	}
+
+	r := random.Float64() * total
+	for _, l := range links {
+		r -= l.w
+		if r < 0 {
+			return l.key
+		}
+	}
+
+	return links[len(links)-1].key
+}
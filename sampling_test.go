@@ -0,0 +1,57 @@
+package uonum
+
+import (
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	a, b := encodeKey("鳴く", "動詞"), encodeKey("走る", "動詞")
+	w := &wordLink{Word: "が", Links: map[string]int64{a: 9, b: 1}}
+
+	tests := []struct {
+		name string
+		opts []Option
+		// minA and maxA are the range of the ratio of a chosen
+		minA, maxA float64
+	}{
+		{name: "uniform", minA: 0.4, maxA: 0.62},
+		{name: "temperature 1", opts: []Option{WithSampling(Sampling{Temperature: 1})}, minA: 0.8, maxA: 0.97},
+		{name: "sharp", opts: []Option{WithSampling(Sampling{Temperature: 0.01})}, minA: 1, maxA: 1},
+		{name: "flat", opts: []Option{WithSampling(Sampling{Temperature: 100})}, minA: 0.4, maxA: 0.62},
+		{name: "top p", opts: []Option{WithSampling(Sampling{TopP: 0.5})}, minA: 1, maxA: 1},
+		{name: "whole top p", opts: []Option{WithSampling(Sampling{TopP: 1})}, minA: 0.8, maxA: 0.97},
+		{
+			name: "banned",
+			opts: []Option{WithSampling(Sampling{TopP: 0.5}), WithBannedWords([]string{"鳴く"})},
+			minA: 0,
+			maxA: 0,
+		},
+	}
+
+	const n = 2000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(tt.opts...).(*generator)
+			count := 0
+			for range n {
+				switch k := g.choose(w); k {
+				case a:
+					count++
+				case b:
+				default:
+					t.Fatalf("choose() = %q", k)
+				}
+			}
+			if r := float64(count) / n; r < tt.minA || r > tt.maxA {
+				t.Errorf("ratio of %s = %v, want in [%v, %v]", displayKey(a), r, tt.minA, tt.maxA)
+			}
+		})
+	}
+
+	t.Run("no links", func(t *testing.T) {
+		g := New(WithSampling(Sampling{Temperature: 0.5}), WithBannedWords([]string{"鳴く", "走る"})).(*generator)
+		if k := g.choose(w); k != "" {
+			t.Errorf("choose() = %q, want none", k)
+		}
+	})
+}
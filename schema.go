@@ -0,0 +1,137 @@
+package uonum
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	bucketMeta       = []byte("meta")
+	keySchemaVersion = []byte("schema_version")
+)
+
+// migration upgrades a database from the previous schema version.
+type migration struct {
+	name string
+	fn   func(tx tx) error
+}
+
+// migrations are the upgrades of the schema. The database of version v has
+// gone through migrations[:v]. Append new ones at the end.
+var migrations = []migration{
+	{"composite word keys", migrateKeys},
+	{"reverse links", migrateReverseLinks},
+	{"class index", migrateClasses},
+	{"shingle index", migrateShingles},
+}
+
+// SchemaVersion is the schema version of the databases this package writes.
+var SchemaVersion = len(migrations)
+
+// WithoutMigration makes Open fail on a database of an older schema version
+// instead of upgrading it. Use Migrate to upgrade it.
+func WithoutMigration() Option {
+	return func(g *generator) {
+		g.noMigrate = true
+	}
+}
+
+// Migrate upgrades the database specified by name to SchemaVersion.
+// It returns the versions before and after the upgrade.
+func Migrate(name string) (from, to int, err error) {
+	s, err := openStore(name, 0)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not open database: %w", err)
+	}
+	defer s.Close()
+
+	err = s.Update(func(tx tx) error {
+		from, err = schemaVersion(tx)
+		if err != nil {
+			return err
+		}
+		return migrate(tx, from)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return from, SchemaVersion, nil
+}
+
+// schemaVersion returns the schema version of the database.
+func schemaVersion(tx tx) (int, error) {
+	b := tx.Bucket(bucketMeta)
+	if b == nil {
+		return 0, nil
+	}
+	d := b.Get(keySchemaVersion)
+	if d == nil {
+		return 0, nil
+	}
+	if len(d) != 8 {
+		return 0, errors.New("Invalid schema version.")
+	}
+
+	v := int(binary.BigEndian.Uint64(d))
+	if v > SchemaVersion {
+		return 0, fmt.Errorf("The schema version %d of the database is newer than %d.", v, SchemaVersion)
+	}
+
+	return v, nil
+}
+
+// migrate applies the migrations after version v and saves the new version.
+func migrate(tx tx, v int) error {
+	for i := v; i < len(migrations); i++ {
+		err := migrations[i].fn(tx)
+		if err != nil {
+			return fmt.Errorf("[%s] could not migrate the database to version %d: %w", migrations[i].name, i+1, err)
+		}
+	}
+
+	b, err := tx.CreateBucketIfNotExists(bucketMeta)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(keySchemaVersion, itob(uint64(SchemaVersion)))
+}
+
+// syncSchema upgrades the database on Open. A new database has nothing to
+// upgrade, and is stamped with SchemaVersion even with WithoutMigration. A
+// database opened with WithReadOnly is not upgraded.
+func (g *generator) syncSchema(tx tx) error {
+	v, err := schemaVersion(tx)
+	if err != nil {
+		return err
+	}
+	if v == SchemaVersion {
+		return nil
+	}
+	if (g.noMigrate || g.readOnly) && !isEmpty(tx) {
+		return fmt.Errorf("The schema version %d of the database is older than %d. Migrate the database.", v, SchemaVersion)
+	}
+	if g.readOnly {
+		return nil
+	}
+
+	return migrate(tx, v)
+}
+
+// isEmpty reports whether no word is registered in any model of the
+// database.
+func isEmpty(tx tx) bool {
+	for _, c := range models(tx) {
+		b := c.Bucket(bucketWords)
+		if b == nil {
+			continue
+		}
+		if k, _ := b.Cursor().First(); k != nil {
+			return false
+		}
+	}
+
+	return true
+}
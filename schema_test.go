@@ -0,0 +1,93 @@
+package uonum
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		// version is the schema version saved in the database, or -1 if
+		// none is
+		version   int
+		words     bool
+		noMigrate bool
+		wantErr   bool
+	}{
+		{name: "new", version: -1},
+		{name: "new without migration", version: -1, noMigrate: true},
+		{name: "current without migration", version: SchemaVersion, words: true, noMigrate: true},
+		{name: "old", version: 0, words: true},
+		{name: "old without migration", version: 0, words: true, noMigrate: true, wantErr: true},
+		{name: "unversioned without migration", version: -1, words: true, noMigrate: true, wantErr: true},
+		{name: "newer", version: SchemaVersion + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.db")
+			s, err := openBoltStore(name, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.Update(func(tx tx) error {
+				b, err := tx.CreateBucketIfNotExists(bucketWords)
+				if err != nil {
+					return err
+				}
+				if tt.words {
+					w := newWordLinkWithFeatures("猫", []string{"名詞"})
+					w.Links[eosKey] = 1
+					if _, err := putWordLink(b, []byte(w.key()), w, 0); err != nil {
+						return err
+					}
+				}
+				if tt.version < 0 {
+					return nil
+				}
+				mb, err := tx.CreateBucketIfNotExists(bucketMeta)
+				if err != nil {
+					return err
+				}
+				return mb.Put(keySchemaVersion, itob(uint64(tt.version)))
+			})
+			s.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var opts []Option
+			if tt.noMigrate {
+				opts = append(opts, WithoutMigration())
+			}
+			g := New(opts...).(*generator)
+			err = g.Open(name)
+			if tt.wantErr {
+				if err == nil {
+					g.Close()
+					t.Fatal("Open() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+
+			err = g.s.View(func(tx tx) error {
+				v, err := schemaVersion(tx)
+				if err != nil {
+					return err
+				}
+				if v != SchemaVersion {
+					t.Errorf("schema version = %d, want %d", v, SchemaVersion)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package uonum
+
+import (
+	"math"
+)
+
+// unseenLogProb is the log-probability given to a transition which is not
+// in the chain, so that the score of an unusual sentence stays finite.
+var unseenLogProb = math.Log(1e-6)
+
+func (w *wordLink) total() int64 {
+	var total int64
+	for _, c := range w.Links {
+		total += c
+	}
+
+	return total
+}
+
+// logProb returns the log-probability of the transition from w to key.
+func (w *wordLink) logProb(key string) float64 {
+	if w == nil {
+		return unseenLogProb
+	}
+
+	c := w.Links[key]
+	total := w.total()
+	if c <= 0 || total <= 0 {
+		return unseenLogProb
+	}
+
+	return math.Log(float64(c) / float64(total))
+}
+
+// Score returns the log-probability of text under the stored chain,
+// that is the sum of the log-probabilities of its transitions.
+func (g *generator) Score(text string) (float64, error) {
+	p, _, err := g.score(text)
+	return p, err
+}
+
+// Perplexity returns the perplexity of text per transition under the stored
+// chain. Lower means text is more likely to be generated from the model.
+func (g *generator) Perplexity(text string) (float64, error) {
+	p, n, err := g.score(text)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	return math.Exp(-p / float64(n)), nil
+}
+
+// score returns the log-probability of text and the number of its transitions.
+func (g *generator) score(text string) (float64, int, error) {
+	tokens := g.tokenize(g.normalize(text))
+	if len(tokens) < 2 {
+		return 0, 0, nil
+	}
+
+	p := unseenLogProb * float64(len(tokens)-1)
+	err := g.viewWords(func(b bucket) error {
+		var total int64
+		if g.backoff > 0 {
+			var err error
+			total, err = unigramTotal(b)
+			if err != nil {
+				return err
+			}
+		}
+
+		p = 0
+		wl, err := g.wordLink(b, []byte(tokens[0].key()))
+		if err != nil {
+			return err
+		}
+		for i := 1; i < len(tokens); i++ {
+			key := tokens[i].key()
+			next, err := g.wordLink(b, []byte(key))
+			if err != nil {
+				return err
+			}
+			if wl != nil && wl.Links[key] > 0 {
+				p += wl.logProb(key)
+			} else {
+				p += g.backoffLogProb(next, total)
+			}
+			wl = next
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return p, len(tokens) - 1, nil
+}
+
+// ScoreLogProb returns a Scorer which scores a text by its log-probability
+// under the chain of g.
+func ScoreLogProb(g Producer) Scorer {
+	return func(text string) float64 {
+		p, err := g.Score(text)
+		if err != nil {
+			return math.Inf(-1)
+		}
+		return p
+	}
+}
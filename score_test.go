@@ -0,0 +1,66 @@
+package uonum
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	texts := []string{"猫が鳴く。", "猫が走る。"}
+
+	tests := []struct {
+		name    string
+		text    string
+		backoff float64
+		want    float64
+		// wantPerplexity is the perplexity per transition
+		wantPerplexity float64
+	}{
+		{
+			name:           "seen",
+			text:           "猫が鳴く。",
+			want:           math.Log(0.5),
+			wantPerplexity: math.Pow(2, 1.0/3),
+		},
+		{
+			name:           "unseen",
+			text:           "犬が鳴く。",
+			want:           unseenLogProb + math.Log(0.5),
+			wantPerplexity: math.Exp(-(unseenLogProb + math.Log(0.5)) / 3),
+		},
+		{
+			name:    "backed off",
+			text:    "犬が鳴く。",
+			backoff: 0.4,
+			// が occurs twice in the 8 occurrences of the words
+			want:           math.Log(0.4) + math.Log(2.0/8) + math.Log(0.5),
+			wantPerplexity: math.Exp(-(math.Log(0.4) + math.Log(2.0/8) + math.Log(0.5)) / 3),
+		},
+		{name: "one word", text: "猫"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, texts, WithBackoff(tt.backoff))
+
+			got, err := g.Score(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+			if s := ScoreLogProb(g)(tt.text); s != got {
+				t.Errorf("ScoreLogProb() = %v, want %v", s, got)
+			}
+
+			p, err := g.Perplexity(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(p-tt.wantPerplexity) > 1e-9 {
+				t.Errorf("Perplexity() = %v, want %v", p, tt.wantPerplexity)
+			}
+		})
+	}
+}
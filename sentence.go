@@ -0,0 +1,46 @@
+package uonum
+
+import (
+	"strings"
+)
+
+// Markers of the beginning and the end of sentences, which are linked from
+// and to the words registered with WithSentenceSplit.
+const (
+	bosClass = "BOS"
+	eosClass = "EOS"
+)
+
+var (
+	bosKey = encodeKey("", bosClass)
+	eosKey = encodeKey("", eosClass)
+)
+
+// WithSentenceSplit makes Register split the text into sentences at the
+// term words and newlines, so that no links are made across sentences.
+// Each sentence is linked from BOS and to EOS.
+func WithSentenceSplit() Option {
+	return func(g *generator) {
+		g.split = true
+	}
+}
+
+// sentences splits text into the tokens of each sentence.
+func (g *generator) sentences(text string) [][]token {
+	var sentences [][]token
+	for _, line := range strings.Split(text, "\n") {
+		var s []token
+		for _, t := range g.tokenize(line) {
+			s = append(s, t)
+			if g.twMap[t.Surface] {
+				sentences = append(sentences, s)
+				s = nil
+			}
+		}
+		if len(s) > 0 {
+			sentences = append(sentences, s)
+		}
+	}
+
+	return sentences
+}
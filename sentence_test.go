@@ -0,0 +1,80 @@
+package uonum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts []Option
+		want [][]string
+	}{
+		{name: "one", text: "猫が鳴く。", want: [][]string{{"猫", "が", "鳴く", "。"}}},
+		{name: "term words", text: "猫が鳴く。犬が走る！", want: [][]string{{"猫", "が", "鳴く", "。"}, {"犬", "が", "走る", "！"}}},
+		{name: "newlines", text: "猫が鳴く\n犬が走る", want: [][]string{{"猫", "が", "鳴く"}, {"犬", "が", "走る"}}},
+		{name: "empty lines", text: "猫が鳴く。\n\n", want: [][]string{{"猫", "が", "鳴く", "。"}}},
+		{
+			name: "other term words",
+			text: "猫が鳴く。犬が走る。",
+			opts: []Option{WithTermWords([]string{"が"})},
+			want: [][]string{{"猫", "が"}, {"鳴く", "。", "犬", "が"}, {"走る", "。"}},
+		},
+		{name: "empty", text: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, nil, tt.opts...)
+			var got [][]string
+			for _, s := range g.sentences(tt.text) {
+				var words []string
+				for _, t := range s {
+					words = append(words, t.Surface)
+				}
+				got = append(got, words)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sentences(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSentenceSplit(t *testing.T) {
+	period, dog := encodeKey("。", "記号"), encodeKey("犬", "名詞")
+
+	tests := []struct {
+		name string
+		opts []Option
+		// wantEOS and wantDog are the counts of the links from the first
+		// 。 to EOS and to 犬
+		wantEOS int64
+		wantDog int64
+	}{
+		{name: "split", opts: []Option{WithSentenceSplit()}, wantEOS: 1},
+		{name: "not split", wantDog: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, []string{"猫が鳴く。犬が走る"}, tt.opts...)
+			wlmap, err := g.wordLinks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wl := wlmap[period]
+			if wl == nil {
+				t.Fatal("。 is not registered")
+			}
+			if got := wl.Links[eosKey]; got != tt.wantEOS {
+				t.Errorf("links from 。 to EOS = %d, want %d", got, tt.wantEOS)
+			}
+			if got := wl.Links[dog]; got != tt.wantDog {
+				t.Errorf("links from 。 to 犬 = %d, want %d", got, tt.wantDog)
+			}
+		})
+	}
+}
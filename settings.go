@@ -0,0 +1,97 @@
+package uonum
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+var (
+	bucketSettings = []byte("settings")
+	keyTermWords   = []byte("term_words")
+	keyMode        = []byte("mode")
+)
+
+// WithTermWords sets the term words, which end the generated sentences.
+// They are saved in the database on Open, so that the other commands
+// against the database use the same term words.
+func WithTermWords(tw []string) Option {
+	return func(g *generator) {
+		g.setTermWords(tw)
+		g.twSet = true
+	}
+}
+
+func (g *generator) setTermWords(tw []string) {
+	twMap := make(map[string]bool)
+	for _, w := range tw {
+		twMap[w] = true
+	}
+	g.twMap = twMap
+}
+
+// syncSettings saves the settings given to the generator in the database,
+// or loads the saved ones if not given.
+func (g *generator) syncSettings(tx tx) error {
+	var b bucket = readOnlyBucket{tx.Bucket(bucketSettings)}
+	if !g.readOnly {
+		var err error
+		b, err = tx.CreateBucketIfNotExists(bucketSettings)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := g.syncMode(tx, b)
+	if err != nil {
+		return err
+	}
+	err = g.syncLemma(tx, b)
+	if err != nil {
+		return err
+	}
+
+	if g.twSet {
+		tw := make([]string, 0, len(g.twMap))
+		for w := range g.twMap {
+			tw = append(tw, w)
+		}
+		d, err := json.Marshal(tw)
+		if err != nil {
+			return fmt.Errorf("[term words] JSON marshal error: %w", err)
+		}
+		return b.Put(keyTermWords, d)
+	}
+
+	if d := b.Get(keyTermWords); d != nil {
+		var tw []string
+		err := json.Unmarshal(d, &tw)
+		if err != nil {
+			return newDecodeError("term words", err)
+		}
+		g.setTermWords(tw)
+	}
+
+	return nil
+}
+
+// syncMode loads the mode of the database, or saves the mode given to the
+// generator if the database has none yet.
+func (g *generator) syncMode(tx tx, b bucket) error {
+	if d := b.Get(keyMode); d != nil {
+		m := Mode(d)
+		if g.modeSet && m != g.mode {
+			return fmt.Errorf("The database is in %s mode, not in %s mode.", m, g.mode)
+		}
+		g.mode = m
+		return nil
+	}
+
+	// An existing database without the mode is in ModeWord.
+	if g.mode != ModeWord {
+		if k, _ := tx.Bucket(bucketWords).Cursor().First(); k != nil {
+			return fmt.Errorf("The database is in %s mode, not in %s mode.", ModeWord, g.mode)
+		}
+	}
+
+	return b.Put(keyMode, []byte(g.mode))
+}
@@ -0,0 +1,55 @@
+package uonum
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTermWords(t *testing.T) {
+	tests := []struct {
+		name string
+		// opts are given on the first Open, and reopen on the second
+		opts   []Option
+		reopen []Option
+		want   string
+	}{
+		{name: "default", want: "猫が鳴く。"},
+		{name: "given", reopen: []Option{WithTermWords([]string{"が"})}, want: "猫が"},
+		{name: "saved", opts: []Option{WithTermWords([]string{"が"})}, want: "猫が"},
+		{
+			name:   "overridden",
+			opts:   []Option{WithTermWords([]string{"が"})},
+			reopen: []Option{WithTermWords([]string{"。"})},
+			want:   "猫が鳴く。",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.db")
+			g := New(tt.opts...).(*generator)
+			if err := g.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			err := g.Register("猫が鳴く。")
+			g.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g = New(tt.reopen...).(*generator)
+			if err := g.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+
+			got, err := g.Generate("猫")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Generate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package uonum
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// lockTimeout is the time the snapshots, Restore and Compact wait for the
+// lock of a database opened by another process.
+var lockTimeout = 5 * time.Second
+
+// Snapshot is a named copy of the model state of a Bolt database, kept in
+// the directory beside it to roll the database back to.
+type Snapshot struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// snapshotDir returns the directory of the snapshots of the database name.
+func snapshotDir(name string) string {
+	return name + ".snapshots"
+}
+
+// snapshotPath returns the file of the snapshot snap of the database name.
+func snapshotPath(name, snap string) (string, error) {
+	if snap == "" || snap == "." || snap == ".." || strings.ContainsAny(snap, `/\`) {
+		return "", fmt.Errorf("Invalid snapshot name [%s].", snap)
+	}
+
+	return filepath.Join(snapshotDir(name), snap+".db"), nil
+}
+
+// CreateSnapshot writes a consistent copy of the Bolt database name as the
+// snapshot snap. The database must not be opened, or it waits for the
+// lock for lockTimeout. A snapshot of the same name is not replaced.
+func CreateSnapshot(name, snap string) (*Snapshot, error) {
+	if isRedisDSN(name) {
+		return nil, errors.New("The database does not support snapshots.")
+	}
+
+	path, err := snapshotPath(name, snap)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("Snapshot [%s] already exists.", snap)
+	}
+
+	db, err := bolt.Open(name, 0600, &bolt.Options{ReadOnly: true, Timeout: lockTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+	defer db.Close()
+
+	err = os.MkdirAll(snapshotDir(name), 0700)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the directory of the snapshots: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(tmp, 0600)
+	})
+	if err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("could not write the snapshot: %w", err)
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("could not write the snapshot: %w", err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not write the snapshot: %w", err)
+	}
+
+	return &Snapshot{Name: snap, Time: fi.ModTime(), Size: fi.Size()}, nil
+}
+
+// Snapshots returns the snapshots of the Bolt database name, oldest first.
+func Snapshots(name string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(snapshotDir(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the snapshots: %w", err)
+	}
+
+	var snaps []Snapshot
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".db") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, Snapshot{
+			Name: strings.TrimSuffix(e.Name(), ".db"),
+			Time: fi.ModTime(),
+			Size: fi.Size(),
+		})
+	}
+	sort.SliceStable(snaps, func(i, j int) bool {
+		return snaps[i].Time.Before(snaps[j].Time)
+	})
+
+	return snaps, nil
+}
+
+// RollbackSnapshot replaces the Bolt database name with the snapshot snap.
+// The database must not be opened, or it fails after waiting for the lock for
+// lockTimeout (see Restore). The snapshot is kept, so that it can be rolled
+// back to again.
+func RollbackSnapshot(name, snap string) error {
+	if isRedisDSN(name) {
+		return errors.New("The database does not support snapshots.")
+	}
+
+	path, err := snapshotPath(name, snap)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("Unknown snapshot [%s].", snap)
+	}
+	if err != nil {
+		return fmt.Errorf("could not open the snapshot [%s]: %w", snap, err)
+	}
+	defer file.Close()
+
+	return Restore(name, file)
+}
+
+// RemoveSnapshot removes the snapshot snap of the Bolt database name.
+func RemoveSnapshot(name, snap string) error {
+	path, err := snapshotPath(name, snap)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("Unknown snapshot [%s].", snap)
+	}
+	if err != nil {
+		return fmt.Errorf("could not remove the snapshot [%s]: %w", snap, err)
+	}
+
+	return nil
+}
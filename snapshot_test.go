@@ -0,0 +1,124 @@
+package uonum
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotPath(t *testing.T) {
+	tests := []struct {
+		snap    string
+		want    string
+		wantErr bool
+	}{
+		{snap: "v1", want: filepath.Join("test.db.snapshots", "v1.db")},
+		{snap: "", wantErr: true},
+		{snap: ".", wantErr: true},
+		{snap: "..", wantErr: true},
+		{snap: "a/b", wantErr: true},
+		{snap: `a\b`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := snapshotPath("test.db", tt.snap)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("snapshotPath(%q) error = %v, want error %v", tt.snap, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("snapshotPath(%q) = %q, want %q", tt.snap, got, tt.want)
+		}
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.db")
+	// texts returns the number of the texts in the database
+	texts := func() int {
+		g := New().(*generator)
+		if err := g.Open(name); err != nil {
+			t.Fatal(err)
+		}
+		defer g.Close()
+		return textsIn(t, g)
+	}
+	register := func(text string) {
+		g := New()
+		if err := g.Open(name); err != nil {
+			t.Fatal(err)
+		}
+		defer g.Close()
+		if err := g.Register(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if snaps, err := Snapshots(name); err != nil || snaps != nil {
+		t.Errorf("Snapshots() = %v, %v before any snapshot, want nil", snaps, err)
+	}
+
+	register("猫が鳴く。")
+	snap, err := CreateSnapshot(name, "one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Name != "one" || snap.Size == 0 {
+		t.Errorf("CreateSnapshot() = %+v", snap)
+	}
+	if _, err := CreateSnapshot(name, "one"); err == nil {
+		t.Error("CreateSnapshot() of an existing name succeeded, want an error")
+	}
+
+	register("犬が鳴く。")
+	if _, err := CreateSnapshot(name, "two"); err != nil {
+		t.Fatal(err)
+	}
+	register("鳥が鳴く。")
+
+	snaps, err := Snapshots(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range snaps {
+		names = append(names, s.Name)
+	}
+	if want := []string{"one", "two"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Snapshots() = %q, want %q", names, want)
+	}
+
+	tests := []struct {
+		snap      string
+		wantTexts int
+		wantErr   bool
+	}{
+		{snap: "one", wantTexts: 1},
+		{snap: "two", wantTexts: 2},
+		// a snapshot is kept after a rollback
+		{snap: "one", wantTexts: 1},
+		{snap: "three", wantTexts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := RollbackSnapshot(name, tt.snap)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("RollbackSnapshot(%q) error = %v, want error %v", tt.snap, err, tt.wantErr)
+		}
+		if got := texts(); got != tt.wantTexts {
+			t.Errorf("texts after RollbackSnapshot(%q) = %d, want %d", tt.snap, got, tt.wantTexts)
+		}
+	}
+
+	if err := RemoveSnapshot(name, "one"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveSnapshot(name, "one"); err == nil {
+		t.Error("RemoveSnapshot() of a removed snapshot succeeded, want an error")
+	}
+	if _, err := os.Stat(filepath.Join(snapshotDir(name), "one.db")); !os.IsNotExist(err) {
+		t.Errorf("the snapshot is left: %v", err)
+	}
+	if snaps, err := Snapshots(name); err != nil || len(snaps) != 1 || snaps[0].Name != "two" {
+		t.Errorf("Snapshots() = %v, %v after the removal, want two", snaps, err)
+	}
+}
@@ -0,0 +1,44 @@
+package uonum
+
+// Stats are the sizes of a model.
+type Stats struct {
+	Words int   `json:"words"` // number of the words
+	Links int   `json:"links"` // number of the distinct links between the words
+	Count int64 `json:"count"` // total count of the links
+	Texts int   `json:"texts"` // number of the registered texts
+}
+
+// Stats returns the sizes of the model.
+func (g *generator) Stats() (*Stats, error) {
+	s := new(Stats)
+	err := g.viewModel(func(b, tb bucket) error {
+		err := eachWord(b, func(wl *wordLink) error {
+			s.Words++
+			s.Links += len(wl.Links)
+			s.Count += wl.total()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		c := tb.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if v != nil {
+				s.Texts++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Seed seeds the random numbers of the generation, so that the same
+// sentences are generated again from the same model.
+func Seed(seed int64) {
+	random.Seed(seed)
+}
@@ -0,0 +1,100 @@
+package uonum
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  Stats
+	}{
+		{name: "empty"},
+		{name: "one", texts: []string{"猫が鳴く。"}, want: Stats{Words: 4, Links: 3, Count: 3, Texts: 1}},
+		{
+			name:  "shared links",
+			texts: []string{"猫が鳴く。", "犬が鳴く。", "猫が鳴く。"},
+			want:  Stats{Words: 5, Links: 4, Count: 9, Texts: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, tt.texts)
+			got, err := g.Stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Stats() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportsJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "stats",
+			v:    &Stats{Words: 4, Links: 3, Count: 5, Texts: 2},
+			want: `{"words":4,"links":3,"count":5,"texts":2}`,
+		},
+		{
+			name: "trigger",
+			v:    Trigger{Word: "猫", Class: "名詞"},
+			want: `{"word":"猫","class":"名詞"}`,
+		},
+		{
+			name: "word",
+			v: WordInfo{
+				Word:     "猫",
+				Class:    "名詞",
+				Features: []string{"名詞", "一般"},
+				Reading:  "ネコ",
+				Total:    2,
+				Links:    []Transition{{Word: "が", Class: "助詞", Count: 2}},
+			},
+			want: `{"word":"猫","class":"名詞","features":["名詞","一般"],"reading":"ネコ","total":2,"links":[{"word":"が","class":"助詞","count":2}]}`,
+		},
+		{
+			name: "check",
+			v: &CheckReport{
+				Words:    2,
+				Links:    1,
+				Invalid:  []string{"犬_名詞"},
+				Dangling: []Link{{From: "猫_名詞", To: "が_助詞"}},
+				Repaired: true,
+			},
+			want: `{"words":2,"links":1,"invalid":["犬_名詞"],"dangling":[{"from":"猫_名詞","to":"が_助詞"}],"repaired":true}`,
+		},
+		{
+			name: "check ok",
+			v:    &CheckReport{Words: 2, Links: 1},
+			want: `{"words":2,"links":1,"repaired":false}`,
+		},
+		{
+			name: "metrics",
+			v:    Metrics{Walks: 1, DeadEnds: 2, Rejected: 3, CacheHits: 4, CacheMisses: 5, WordWrites: 6, WordBytes: 7},
+			want: `{"walks":1,"dead_ends":2,"rejected":3,"cache_hits":4,"cache_misses":5,"word_writes":6,"word_bytes":7}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(d) != tt.want {
+				t.Errorf("json = %s, want %s", d, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package uonum
+
+// WithStopWords leaves the words in words, and the words whose features are
+// matched by classes if it is not nil (e.g. Class("記号")), out of the
+// tokens of the texts, so that the words around them are linked to each
+// other. The term words are never left out. Rebuild builds the links of the
+// registered texts again under the stop words.
+func WithStopWords(words []string, classes FeatureMatcher) Option {
+	return func(g *generator) {
+		if g.stopWords == nil {
+			g.stopWords = make(map[string]bool)
+		}
+		for _, w := range words {
+			g.stopWords[w] = true
+		}
+		g.stopClasses = classes
+	}
+}
+
+// dropStopWords returns tokens without the stop words.
+func (g *generator) dropStopWords(tokens []token) []token {
+	if len(g.stopWords) == 0 && g.stopClasses == nil {
+		return tokens
+	}
+
+	kept := tokens[:0]
+	for _, t := range tokens {
+		stop := g.stopWords[t.Surface] || (g.stopClasses != nil && g.stopClasses(t.Features))
+		if stop && !g.twMap[t.Surface] {
+			continue
+		}
+		kept = append(kept, t)
+	}
+
+	return kept
+}
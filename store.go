@@ -0,0 +1,170 @@
+package uonum
+
+import (
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// store is a minimal transactional key/value storage used by the generator.
+// It mirrors the subset of the Bolt API that the generator needs, so that
+// other backends can be plugged in.
+type store interface {
+	View(fn func(tx) error) error
+	Update(fn func(tx) error) error
+	Close() error
+}
+
+// container is anything which holds buckets: a transaction or a bucket.
+type container interface {
+	Bucket(name []byte) bucket
+	CreateBucketIfNotExists(name []byte) (bucket, error)
+}
+
+type tx interface {
+	container
+}
+
+type bucket interface {
+	container
+
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	NextSequence() (uint64, error)
+	// Cursor iterates over the keys in the bucket in key order. The nested
+	// buckets are included with nil values.
+	Cursor() cursor
+}
+
+type cursor interface {
+	First() (key, value []byte)
+	Next() (key, value []byte)
+	Seek(seek []byte) (key, value []byte)
+}
+
+// WithLockTimeout makes Open fail if the lock of a Bolt database held by
+// another process is not released in d, instead of waiting for it forever.
+func WithLockTimeout(d time.Duration) Option {
+	return func(g *generator) {
+		g.lockWait = d
+	}
+}
+
+// openStore opens the store specified by name.
+// name is either a path of a Bolt database file or a Redis DSN
+// (redis://[user:password@]host[:port][/db][?prefix=name]). Opening a Bolt
+// database waits at most timeout for its lock, or forever if it is 0.
+func openStore(name string, timeout time.Duration) (store, error) {
+	if isRedisDSN(name) {
+		return openRedisStore(name)
+	}
+
+	return openBoltStore(name, timeout)
+}
+
+func isRedisDSN(name string) bool {
+	return strings.HasPrefix(name, "redis://") || strings.HasPrefix(name, "rediss://")
+}
+
+type boltStore struct {
+	db *bolt.DB
+}
+
+func openBoltStore(name string, timeout time.Duration) (store, error) {
+	db, err := bolt.Open(name, 0600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) View(fn func(tx) error) error {
+	return s.db.View(func(t *bolt.Tx) error {
+		return fn(&boltTx{t})
+	})
+}
+
+func (s *boltStore) Update(fn func(tx) error) error {
+	return s.db.Update(func(t *bolt.Tx) error {
+		return fn(&boltTx{t})
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t *boltTx) Bucket(name []byte) bucket {
+	b := t.tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+
+	return &boltBucket{b}
+}
+
+func (t *boltTx) CreateBucketIfNotExists(name []byte) (bucket, error) {
+	b, err := t.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltBucket{b}, nil
+}
+
+func (t *boltTx) version() uint64 {
+	return uint64(t.tx.ID())
+}
+
+type boltBucket struct {
+	b *bolt.Bucket
+}
+
+func (b *boltBucket) version() uint64 {
+	return uint64(b.b.Tx().ID())
+}
+
+func (b *boltBucket) Bucket(name []byte) bucket {
+	c := b.b.Bucket(name)
+	if c == nil {
+		return nil
+	}
+
+	return &boltBucket{c}
+}
+
+func (b *boltBucket) CreateBucketIfNotExists(name []byte) (bucket, error) {
+	c, err := b.b.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltBucket{c}, nil
+}
+
+func (b *boltBucket) Get(key []byte) []byte {
+	return b.b.Get(key)
+}
+
+func (b *boltBucket) Put(key, value []byte) error {
+	return b.b.Put(key, value)
+}
+
+func (b *boltBucket) Delete(key []byte) error {
+	return b.b.Delete(key)
+}
+
+func (b *boltBucket) NextSequence() (uint64, error) {
+	return b.b.NextSequence()
+}
+
+func (b *boltBucket) Cursor() cursor {
+	return b.b.Cursor()
+}
@@ -0,0 +1,58 @@
+package uonum
+
+import (
+	"context"
+	"time"
+)
+
+// GenerateFunc is like Generate but calls fn with each word as soon as it is
+// chosen. Generation stops when fn returns false. The sentence is never
+// regenerated, since the words are already delivered.
+func (g *generator) GenerateFunc(trigger string, fn func(word string) bool) error {
+	trigger = g.triggerWord(trigger)
+	if trigger == "" {
+		return nil
+	}
+
+	var deadline time.Time
+	if g.limits.Timeout > 0 {
+		deadline = time.Now().Add(g.limits.Timeout)
+	}
+
+	return g.viewChain(func(b, _ bucket) error {
+		key, err := g.startKey(b, trigger)
+		if err != nil {
+			return err
+		}
+		if key == nil || b.Get(key) == nil {
+			return missing(b, key)
+		}
+
+		_, _, err = g.walk(b, key, deadline, fn)
+		return err
+	})
+}
+
+// GenerateStream is like Generate but sends each word to the returned
+// channel as soon as it is chosen. The channel is closed at the end of the
+// sentence, or when ctx is canceled.
+func (g *generator) GenerateStream(ctx context.Context, trigger string) (<-chan string, error) {
+	if g.s == nil {
+		return nil, ErrNotOpen
+	}
+
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		g.GenerateFunc(trigger, func(word string) bool {
+			select {
+			case ch <- word:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return ch, nil
+}
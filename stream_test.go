@@ -0,0 +1,80 @@
+package uonum
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateFunc(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger string
+		// stop is the number of the words after which fn returns false
+		stop    int
+		want    []string
+		wantErr error
+	}{
+		{name: "all", trigger: "猫", want: []string{"猫", "が", "鳴く", "。"}},
+		{name: "stopped", trigger: "猫", stop: 2, want: []string{"猫", "が"}},
+		{name: "unknown", trigger: "犬", wantErr: ErrUnknownTrigger},
+		{name: "empty", trigger: ""},
+	}
+
+	g := openModel(t, []string{"猫が鳴く。"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := g.GenerateFunc(tt.trigger, func(word string) bool {
+				got = append(got, word)
+				return tt.stop == 0 || len(got) < tt.stop
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateFunc() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("words = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateStream(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。"})
+
+	t.Run("all", func(t *testing.T) {
+		ch, err := g.GenerateStream(context.Background(), "猫")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for w := range ch {
+			got = append(got, w)
+		}
+		if want := []string{"猫", "が", "鳴く", "。"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("words = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ch, err := g.GenerateStream(ctx, "猫")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := <-ch; w != "猫" {
+			t.Errorf("first word = %q, want %q", w, "猫")
+		}
+		cancel()
+		// the channel is closed without the rest of the words
+		for range ch {
+		}
+	})
+
+	t.Run("not open", func(t *testing.T) {
+		if _, err := New().GenerateStream(context.Background(), "猫"); !errors.Is(err, ErrNotOpen) {
+			t.Errorf("GenerateStream() error = %v, want %v", err, ErrNotOpen)
+		}
+	})
+}
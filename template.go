@@ -0,0 +1,186 @@
+package uonum
+
+import (
+	"fmt"
+	"strings"
+)
+
+// slot is a part of a template: a fixed text, or a word of the classes
+// matched by match to be filled.
+type slot struct {
+	text  string
+	match FeatureMatcher
+	// keys are the keys of the words of text
+	keys []string
+}
+
+// GenerateTemplate generates a sentence from tmpl, whose slots in braces
+// are filled with the words of the classes in them in the form of
+// ParseClasses (e.g. "今日は{名詞}が{動詞}"). The words are chosen so that
+// they follow the words before them and are followed by the words after
+// them in the model, if there are such words. The text outside the slots is
+// kept as it is.
+func (g *generator) GenerateTemplate(tmpl string) (string, error) {
+	deadline := g.deadline()
+	slots, err := g.parseTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	err = g.viewChain(func(b, _ bucket) error {
+		prev := bosKey
+		for i, s := range slots {
+			if s.match == nil {
+				sb.WriteString(s.text)
+				if len(s.keys) > 0 {
+					prev = s.keys[len(s.keys)-1]
+				}
+				continue
+			}
+
+			next := ""
+			if i+1 < len(slots) && len(slots[i+1].keys) > 0 {
+				next = slots[i+1].keys[0]
+			}
+			key, err := g.fillSlot(b, prev, next, s.match)
+			if err != nil {
+				return err
+			}
+			if key == "" {
+				return fmt.Errorf("[%s] no word for the slot: %w", s.text, ErrGenerationFailed)
+			}
+			w, err := g.realize(b, []string{key}, next)
+			if err != nil {
+				return err
+			}
+			sb.WriteString(g.fill(w[0]))
+			prev = key
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	text := g.postProcess(sb.String())
+	if !g.safe(text, deadline) {
+		return "", errRejected
+	}
+
+	return text, nil
+}
+
+// parseTemplate splits tmpl into the fixed texts and the slots.
+func (g *generator) parseTemplate(tmpl string) ([]slot, error) {
+	var slots []slot
+	for rest := tmpl; rest != ""; {
+		i := strings.IndexByte(rest, '{')
+		if i < 0 {
+			i = len(rest)
+		}
+		if i > 0 {
+			s := slot{text: rest[:i]}
+			for _, t := range g.tokenize(g.normalize(s.text)) {
+				s.keys = append(s.keys, t.key())
+			}
+			slots = append(slots, s)
+			rest = rest[i:]
+			continue
+		}
+
+		j := strings.IndexByte(rest, '}')
+		if j < 0 {
+			return nil, fmt.Errorf("Unclosed slot in the template [%s].", tmpl)
+		}
+		classes := strings.TrimSpace(rest[1:j])
+		if classes == "" {
+			return nil, fmt.Errorf("Empty slot in the template [%s].", tmpl)
+		}
+		slots = append(slots, slot{text: rest[:j+1], match: ParseClasses(classes)})
+		rest = rest[j+1:]
+	}
+
+	return slots, nil
+}
+
+// fillSlot returns the key of a word matched by match to follow prev and to
+// be followed by next, which is empty if it is not a fixed word. The words
+// which only follow prev, then those which only precede next, then any word
+// of the class are chosen if there are none. It returns "" if there is no
+// word matched by match.
+func (g *generator) fillSlot(b bucket, prev, next string, match FeatureMatcher) (string, error) {
+	var after, before map[string]int64
+	if pwl, err := g.wordLink(b, []byte(prev)); err != nil {
+		return "", err
+	} else if pwl != nil {
+		after = pwl.Links
+	}
+	if next != "" {
+		nwl, err := g.wordLink(b, []byte(next))
+		if err != nil {
+			return "", err
+		}
+		if nwl != nil {
+			before = nwl.Prev
+		}
+	}
+
+	matched := func(links map[string]int64, also map[string]int64) (map[string]int64, error) {
+		m := make(map[string]int64)
+		for k, c := range links {
+			if k == eosKey || !g.allowed(k) {
+				continue
+			}
+			if also != nil && also[k] == 0 {
+				continue
+			}
+			wl, err := g.wordLink(b, []byte(k))
+			if err != nil {
+				return nil, err
+			}
+			if wl == nil || !match(wl.Features) {
+				continue
+			}
+			m[k] = c
+		}
+		return m, nil
+	}
+
+	for _, c := range [][2]map[string]int64{{after, before}, {after, nil}, {before, nil}} {
+		if c[0] == nil {
+			continue
+		}
+		m, err := matched(c[0], c[1])
+		if err != nil {
+			return "", err
+		}
+		if len(m) > 0 {
+			return g.choose(&wordLink{Links: m}), nil
+		}
+	}
+
+	return g.randomMatch(b, match)
+}
+
+// randomMatch returns the key of a word in b matched by match chosen at
+// random with probability proportional to its frequency, or "" if there is
+// none.
+func (g *generator) randomMatch(b bucket, match FeatureMatcher) (string, error) {
+	var key string
+	var total float64
+	err := eachWord(b, func(wl *wordLink) error {
+		k := wl.key()
+		if !match(wl.Features) || !g.allowed(k) {
+			return nil
+		}
+		w := float64(wl.occurrences() + 1)
+		total += w
+		if random.Float64()*total < w {
+			key = k
+		}
+		return nil
+	})
+
+	return key, err
+}
@@ -0,0 +1,51 @@
+package uonum
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGenerateTemplate(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "犬が走る。", "鳥は飛ぶ。"})
+
+	tests := []struct {
+		tmpl    string
+		want    map[string]bool
+		wantErr error
+		// wantAnyErr is true if tmpl is invalid
+		wantAnyErr bool
+	}{
+		// followed by は
+		{tmpl: "{名詞}は飛ぶ。", want: map[string]bool{"鳥は飛ぶ。": true}},
+		// following が
+		{tmpl: "猫が{動詞}。", want: map[string]bool{"猫が鳴く。": true, "猫が走る。": true}},
+		{tmpl: "鳥は{動詞}。", want: map[string]bool{"鳥は飛ぶ。": true}},
+		{tmpl: "{名詞}が鳴く。", want: map[string]bool{"猫が鳴く。": true, "犬が鳴く。": true}},
+		// any word of the class without the links
+		{tmpl: "今日は{ 名詞 }", want: map[string]bool{"今日は猫": true, "今日は犬": true, "今日は鳥": true}},
+		{tmpl: "今日は晴れ", want: map[string]bool{"今日は晴れ": true}},
+		{tmpl: "今日は{形容詞}", wantErr: ErrGenerationFailed},
+		{tmpl: "今日は{名詞", wantAnyErr: true},
+		{tmpl: "今日は{}", wantAnyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				got, err := g.GenerateTemplate(tt.tmpl)
+				if tt.wantAnyErr {
+					if err == nil {
+						t.Fatalf("GenerateTemplate() = %q, want an error", got)
+					}
+					return
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GenerateTemplate() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil && !tt.want[got] {
+					t.Errorf("GenerateTemplate() = %q, want one of %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package uonum
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ikawaha/kagome/v2/tokenizer"
+)
+
+// token is a unit of the chain.
+type token struct {
+	Surface  string
+	Features []string
+	Reading  string
+	// Base is the base form of the word in the lemma mode (see WithLemma),
+	// or empty.
+	Base string
+}
+
+// word returns the word of t in the chain: the base form in the lemma mode,
+// or the surface.
+func (t token) word() string {
+	if t.Base != "" {
+		return t.Base
+	}
+
+	return t.Surface
+}
+
+func (t token) key() string {
+	return newWordLinkWithFeatures(t.word(), t.Features).key()
+}
+
+// Mode is the kind of tokens which a model is made of.
+type Mode string
+
+const (
+	// ModeWord makes the chain of words tokenized by kagome.
+	ModeWord Mode = "word"
+	// ModeChar makes the chain of characters, for short texts such as
+	// user names or product names. The class of every character is
+	// charClass.
+	ModeChar Mode = "char"
+	// ModeWhitespace makes the chain of words split at white spaces and
+	// punctuations, for English and other languages written with spaces.
+	// It does not need the dictionary of kagome.
+	ModeWhitespace Mode = "whitespace"
+)
+
+const charClass = "文字"
+
+// WithMode sets the mode of the model. It is saved in the database when
+// the database is created, and can not be changed later.
+func WithMode(m Mode) Option {
+	return func(g *generator) {
+		g.mode = m
+		g.modeSet = true
+	}
+}
+
+// defaultClass returns the class of the trigger words used by default.
+func (g *generator) defaultClass() string {
+	switch g.mode {
+	case ModeChar:
+		return charClass
+	case ModeWhitespace:
+		return "NOUN"
+	}
+
+	return "名詞"
+}
+
+// tokenize splits text into the tokens of the mode of the model.
+func (g *generator) tokenize(text string) []token {
+	_, span := g.span("uonum.tokenize")
+	defer span.End()
+
+	tokenize := g.modeTokens
+	if g.placeholders {
+		tokenize = func(text string) []token {
+			return g.placeholderTokens(text, g.modeTokens)
+		}
+	}
+	var tokens []token
+	if g.emoji != nil {
+		tokens = g.emojiTokens(text, tokenize)
+	} else {
+		tokens = tokenize(text)
+	}
+	tokens = g.dropStopWords(tokens)
+
+	if g.debugging() {
+		surfaces := make([]string, len(tokens))
+		for i, t := range tokens {
+			surfaces[i] = t.Surface
+		}
+		g.debug("Tokenized.", "text", text, "tokens", strings.Join(surfaces, "|"))
+	}
+	if g.spans != nil {
+		span.SetAttributes(slog.Int("tokens", len(tokens)))
+	}
+
+	return tokens
+}
+
+// preTokenize tokenizes text with tokenize, except the matches of re which
+// match makes tokens of. m is the submatch indexes of a match, and match
+// returns nil to leave it to tokenize.
+func preTokenize(text string, re *regexp.Regexp, tokenize func(string) []token, match func(m []int) *token) []token {
+	var tokens []token
+	rest := 0
+	for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+		t := match(m)
+		if t == nil {
+			continue
+		}
+		tokens = append(tokens, tokenize(text[rest:m[0]])...)
+		tokens = append(tokens, *t)
+		rest = m[1]
+	}
+
+	return append(tokens, tokenize(text[rest:])...)
+}
+
+// modeTokens tokenizes text in the mode of the model.
+func (g *generator) modeTokens(text string) []token {
+	switch g.mode {
+	case ModeChar:
+		return charTokens(text)
+	case ModeWhitespace:
+		return whitespaceTokens(text)
+	}
+
+	return cleanTokens(g.t.get().Tokenize(text), g.lemma)
+}
+
+// cleanTokens returns the tokens of kagome without the dummies and the
+// spaces, with their base forms if lemma is true.
+func cleanTokens(tokens []tokenizer.Token, lemma bool) []token {
+	c := make([]token, 0, len(tokens))
+
+	for _, t := range tokens {
+		if t.Class == tokenizer.DUMMY {
+			continue
+		}
+		if strings.TrimSpace(t.Surface) == "" {
+			continue
+		}
+
+		r, _ := t.Reading()
+		tk := token{
+			Surface:  t.Surface,
+			Features: t.Features(),
+			Reading:  r,
+		}
+		if lemma {
+			tk.Base = t.Surface
+			if b, ok := t.BaseForm(); ok && b != "" && b != "*" {
+				tk.Base = b
+			}
+		}
+		c = append(c, tk)
+	}
+
+	return c
+}
+
+func charTokens(text string) []token {
+	c := make([]token, 0, len(text))
+
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		c = append(c, token{
+			Surface:  string(r),
+			Features: []string{charClass, script(r)},
+		})
+	}
+
+	return c
+}
+
+func script(r rune) string {
+	switch {
+	case unicode.Is(unicode.Hiragana, r):
+		return "ひらがな"
+	case unicode.Is(unicode.Katakana, r):
+		return "カタカナ"
+	case unicode.Is(unicode.Han, r):
+		return "漢字"
+	case unicode.IsLetter(r):
+		return "英字"
+	case unicode.IsDigit(r):
+		return "数字"
+	}
+
+	return "記号"
+}
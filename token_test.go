@@ -0,0 +1,95 @@
+package uonum
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCharTokens(t *testing.T) {
+	got := charTokens("aネ こ猫1。")
+	want := []token{
+		{Surface: "a", Features: []string{charClass, "英字"}},
+		{Surface: "ネ", Features: []string{charClass, "カタカナ"}},
+		{Surface: "こ", Features: []string{charClass, "ひらがな"}},
+		{Surface: "猫", Features: []string{charClass, "漢字"}},
+		{Surface: "1", Features: []string{charClass, "数字"}},
+		{Surface: "。", Features: []string{charClass, "記号"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("charTokens() = %v, want %v", got, want)
+	}
+}
+
+func TestCharMode(t *testing.T) {
+	g := openModel(t, []string{"ねこ"}, WithMode(ModeChar), WithTermWords(nil))
+
+	tests := []struct {
+		trigger string
+		want    string
+	}{
+		{trigger: "ね", want: "ねこ"},
+		{trigger: "こ", want: "こ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.trigger, func(t *testing.T) {
+			got, err := g.Generate(tt.trigger)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Generate(%q) = %q, want %q", tt.trigger, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncMode(t *testing.T) {
+	tests := []struct {
+		name string
+		// create and reopen are the modes of the first Open and the second,
+		// which are not given if empty
+		create, reopen Mode
+		want           Mode
+		wantErr        bool
+	}{
+		{name: "default", want: ModeWord},
+		{name: "saved", create: ModeChar, want: ModeChar},
+		{name: "same", create: ModeChar, reopen: ModeChar, want: ModeChar},
+		{name: "other", create: ModeChar, reopen: ModeWord, wantErr: true},
+		{name: "word model", create: ModeWord, reopen: ModeChar, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.db")
+			var opts []Option
+			if tt.create != "" {
+				opts = append(opts, WithMode(tt.create))
+			}
+			g := New(opts...)
+			if err := g.Open(name); err != nil {
+				t.Fatal(err)
+			}
+			g.Close()
+
+			opts = nil
+			if tt.reopen != "" {
+				opts = append(opts, WithMode(tt.reopen))
+			}
+			r := New(opts...).(*generator)
+			err := r.Open(name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Open() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer r.Close()
+			if r.mode != tt.want {
+				t.Errorf("mode = %s, want %s", r.mode, tt.want)
+			}
+		})
+	}
+}
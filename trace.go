@@ -0,0 +1,78 @@
+package uonum
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Tracer starts the spans of the steps of the registration and the
+// generation. It is a small subset of a Tracer of OpenTelemetry, to be
+// adapted to it or to other tracing systems.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	RecordError(err error)
+	End()
+}
+
+// WithTracer makes the Generator start the spans by t:
+//
+//	uonum.Register         Register and RegisterWithMeta
+//	uonum.RegisterRecords  RegisterReader and RegisterRecords
+//	  uonum.tokenize       tokenizing a text
+//	  uonum.update         writing a batch of the texts to the database
+//	uonum.Generate         generating a sentence from a trigger
+//	  uonum.walk           a walk of the chain, which is retried if it fails
+//	    uonum.lookup       reading a word
+//	    uonum.sample       choosing the next word
+//
+// The spans are the children of the span in the context of WithContext.
+func WithTracer(t Tracer) Option {
+	return func(g *generator) {
+		g.spans = t
+	}
+}
+
+// WithContext returns a Generator whose spans are the children of the span
+// in ctx.
+func (g *generator) WithContext(ctx context.Context) Generator {
+	c := *g
+	c.ctx = ctx
+	return &c
+}
+
+// span starts the span name as a child of the span of g, and returns the
+// generator of the span. It returns g and a span doing nothing without
+// WithTracer.
+func (g *generator) span(name string) (*generator, Span) {
+	if g.spans == nil {
+		return g, noopSpan{}
+	}
+
+	ctx := g.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, s := g.spans.Start(ctx, name)
+	c := *g
+	c.ctx = ctx
+	return &c, s
+}
+
+// endSpan records err in s if it is not nil, and ends s.
+func endSpan(s Span, err error) {
+	if err != nil {
+		s.RecordError(err)
+	}
+	s.End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...slog.Attr) {}
+func (noopSpan) RecordError(err error)            {}
+func (noopSpan) End()                             {}
@@ -0,0 +1,152 @@
+package uonum
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type spanKey struct{}
+
+// testSpan is a span recorded by testTracer.
+type testSpan struct {
+	name   string
+	parent string
+	attrs  map[string]string
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...slog.Attr) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value.String()
+	}
+}
+
+func (s *testSpan) RecordError(err error) { s.err = err }
+func (s *testSpan) End()                  { s.ended = true }
+
+// testTracer records the spans in the order of their start.
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (tr *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &testSpan{name: name, attrs: make(map[string]string)}
+	if parent, ok := ctx.Value(spanKey{}).(*testSpan); ok {
+		s.parent = parent.name
+	}
+
+	tr.mu.Lock()
+	tr.spans = append(tr.spans, s)
+	tr.mu.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// tree returns the names of the spans and their parents, without the
+// repeated ones.
+func (tr *testTracer) tree() [][2]string {
+	seen := make(map[[2]string]bool)
+	var tree [][2]string
+	for _, s := range tr.spans {
+		k := [2]string{s.name, s.parent}
+		if !seen[k] {
+			seen[k] = true
+			tree = append(tree, k)
+		}
+	}
+
+	return tree
+}
+
+func TestWithTracer(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      func(g Generator) error
+		want     [][2]string
+		wantErr  error
+		wantAttr map[string]string
+	}{
+		{
+			name: "register",
+			run:  func(g Generator) error { return g.Register("犬が吠える。") },
+			want: [][2]string{
+				{"uonum.Register", ""},
+				{"uonum.tokenize", "uonum.Register"},
+				{"uonum.update", "uonum.Register"},
+			},
+		},
+		{
+			name: "generate",
+			run: func(g Generator) error {
+				_, err := g.Generate("猫")
+				return err
+			},
+			want: [][2]string{
+				{"uonum.Generate", ""},
+				{"uonum.walk", "uonum.Generate"},
+				{"uonum.lookup", "uonum.walk"},
+				{"uonum.sample", "uonum.walk"},
+			},
+			wantAttr: map[string]string{"trigger": "猫_名詞"},
+		},
+		{
+			name: "unknown trigger",
+			run: func(g Generator) error {
+				_, err := g.Generate("鳥")
+				return err
+			},
+			want: [][2]string{
+				{"uonum.Generate", ""},
+			},
+			wantErr: ErrUnknownTrigger,
+		},
+		{
+			name: "context",
+			run: func(g Generator) error {
+				ctx := context.WithValue(context.Background(), spanKey{}, &testSpan{name: "request"})
+				return g.WithContext(ctx).Register("犬が吠える。")
+			},
+			want: [][2]string{
+				{"uonum.Register", "request"},
+				{"uonum.tokenize", "uonum.Register"},
+				{"uonum.update", "uonum.Register"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := new(testTracer)
+			g := openModel(t, []string{"猫が鳴く。"}, WithTracer(tr))
+			tr.spans = nil
+
+			if err := tt.run(g); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got := tr.tree(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("spans = %q, want %q", got, tt.want)
+			}
+			for _, s := range tr.spans {
+				if !s.ended {
+					t.Errorf("span %s is not ended", s.name)
+				}
+			}
+
+			top := tr.spans[0]
+			if !errors.Is(top.err, tt.wantErr) {
+				t.Errorf("span %s error = %v, want %v", top.name, top.err, tt.wantErr)
+			}
+			for k, v := range tt.wantAttr {
+				if top.attrs[k] != v {
+					t.Errorf("span %s %s = %q, want %q", top.name, k, top.attrs[k], v)
+				}
+			}
+		})
+	}
+}
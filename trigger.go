@@ -0,0 +1,154 @@
+package uonum
+
+import (
+	"sort"
+	"strings"
+)
+
+// Trigger is a word which can start a sentence.
+type Trigger struct {
+	Word  string `json:"word"`
+	Class string `json:"class"`
+}
+
+// Triggers returns the words of class in the model. If class is empty, the
+// class of the trigger words used by default is used.
+func (g *generator) Triggers(class string) ([]string, error) {
+	if class == "" {
+		class = g.defaultClass()
+	}
+
+	var words []string
+	err := g.viewWords(func(b bucket) error {
+		return eachClassKey(b, class, func(key []byte, _ int64) error {
+			w, _ := splitKey(string(key))
+			words = append(words, w)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return words, nil
+}
+
+// RandomTrigger returns a word of class in the model chosen at random with
+// probability proportional to its frequency, so that the common words start
+// the sentences more often than the rare ones. If class is empty, the class
+// of the trigger words used by default is used.
+func (g *generator) RandomTrigger(class string) (string, error) {
+	if class == "" {
+		class = g.defaultClass()
+	}
+
+	var word string
+	err := g.viewChain(func(b, _ bucket) error {
+		key := g.randomKey(b, class)
+		if key == nil {
+			return ErrEmptyModel
+		}
+		word, _ = splitKey(string(key))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return word, nil
+}
+
+// FindTrigger returns the triggers whose word starts with prefix.
+func (g *generator) FindTrigger(prefix string) ([]Trigger, error) {
+	var triggers []Trigger
+	prefix = g.normalize(prefix)
+	err := g.viewWords(func(b bucket) error {
+		// the keys are ordered by the length of the words first, so all
+		// of them are scanned
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if v == nil {
+				continue
+			}
+			w, cl := splitKey(string(k))
+			if !strings.HasPrefix(w, prefix) {
+				continue
+			}
+			triggers = append(triggers, Trigger{Word: w, Class: cl})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return triggers, nil
+}
+
+// MatchTriggers returns the triggers whose word is within maxDist edits
+// (Levenshtein distance in characters) of query, nearest first.
+func (g *generator) MatchTriggers(query string, maxDist int) ([]Trigger, error) {
+	type match struct {
+		t Trigger
+		d int
+	}
+	var matches []match
+	q := []rune(g.normalize(query))
+	err := g.viewWords(func(b bucket) error {
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if v == nil {
+				continue
+			}
+			w, cl := splitKey(string(k))
+			d := levenshtein(q, []rune(w))
+			if d > maxDist {
+				continue
+			}
+			matches = append(matches, match{Trigger{Word: w, Class: cl}, d})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].d < matches[j].d
+	})
+	triggers := make([]Trigger, len(matches))
+	for i, m := range matches {
+		triggers[i] = m.t
+	}
+
+	return triggers, nil
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,176 @@
+package uonum
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTriggers(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "犬が走る。", "猫舌の子猫。"})
+
+	tests := []struct {
+		class string
+		want  []string
+	}{
+		{class: "", want: []string{"犬", "猫", "子猫", "猫舌"}},
+		{class: "名詞", want: []string{"犬", "猫", "子猫", "猫舌"}},
+		{class: "動詞", want: []string{"走る", "鳴く"}},
+		{class: "形容詞"},
+	}
+
+	for _, tt := range tests {
+		got, err := g.Triggers(tt.class)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Triggers(%q) = %q, want %q", tt.class, got, tt.want)
+		}
+	}
+}
+
+func TestFindTrigger(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "犬が走る。", "猫舌の子猫。"})
+
+	tests := []struct {
+		prefix string
+		want   []Trigger
+	}{
+		{prefix: "猫", want: []Trigger{{Word: "猫", Class: "名詞"}, {Word: "猫舌", Class: "名詞"}}},
+		{prefix: "走", want: []Trigger{{Word: "走る", Class: "動詞"}}},
+		{prefix: "鳥"},
+	}
+
+	for _, tt := range tests {
+		got, err := g.FindTrigger(tt.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindTrigger(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTriggers(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "犬が走る。"})
+
+	tests := []struct {
+		query   string
+		maxDist int
+		want    []Trigger
+	}{
+		{query: "猫", maxDist: 0, want: []Trigger{{Word: "猫", Class: "名詞"}}},
+		{query: "鳴き", maxDist: 1, want: []Trigger{{Word: "鳴く", Class: "動詞"}}},
+		// nearest first, and in key order at the same distance
+		{query: "走る", maxDist: 2, want: []Trigger{
+			{Word: "走る", Class: "動詞"},
+			{Word: "。", Class: "記号"},
+			{Word: "が", Class: "助詞"},
+			{Word: "犬", Class: "名詞"},
+			{Word: "猫", Class: "名詞"},
+			{Word: "鳴く", Class: "動詞"},
+		}},
+		{query: "鳥", maxDist: 0, want: []Trigger{}},
+	}
+
+	for _, tt := range tests {
+		got, err := g.MatchTriggers(tt.query, tt.maxDist)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MatchTriggers(%q, %d) = %v, want %v", tt.query, tt.maxDist, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "猫", b: "", want: 1},
+		{a: "", b: "子猫", want: 2},
+		{a: "猫", b: "子猫", want: 1},
+		{a: "鳴く", b: "鳴き", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRandomTrigger(t *testing.T) {
+	tests := []struct {
+		name    string
+		texts   []string
+		class   string
+		want    map[string]bool
+		wantErr error
+	}{
+		{name: "default class", texts: []string{"猫が鳴く。", "犬が走る。"}, want: map[string]bool{"猫": true, "犬": true}},
+		{name: "class", texts: []string{"猫が鳴く。"}, class: "動詞", want: map[string]bool{"鳴く": true}},
+		{name: "no word of class", texts: []string{"猫が鳴く。"}, class: "形容詞", wantErr: ErrEmptyModel},
+		{name: "empty", wantErr: ErrEmptyModel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, tt.texts)
+			for i := 0; i < 10; i++ {
+				got, err := g.RandomTrigger(tt.class)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("RandomTrigger() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil && !tt.want[got] {
+					t.Errorf("RandomTrigger() = %q, want one of %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomTriggerWeighted(t *testing.T) {
+	const n = 2000
+
+	tests := []struct {
+		name  string
+		texts []string
+		// want is the share of 猫 in the triggers, with the weight of
+		// frequency + 1
+		want float64
+	}{
+		{name: "even", texts: []string{"猫が鳴く。", "犬が走る。"}, want: 0.5},
+		{
+			name:  "frequent",
+			texts: []string{"猫が鳴く。", "猫が鳴く。", "猫が鳴く。", "猫が鳴く。", "猫が鳴く。", "犬が走る。"},
+			want:  0.75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := openModel(t, tt.texts)
+			cats := 0
+			for i := 0; i < n; i++ {
+				got, err := g.RandomTrigger("")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got == "猫" {
+					cats++
+				}
+			}
+			if got := float64(cats) / n; math.Abs(got-tt.want) > 0.05 {
+				t.Errorf("share of 猫 = %.3f, want %.3f", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,105 +2,403 @@ package uonum
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
-	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"math/rand"
-	"strings"
+	"regexp"
+	"sync"
 	"time"
 
-	"github.com/boltdb/bolt"
-	"github.com/ikawaha/kagome/tokenizer"
-	"github.com/pkg/errors"
+	"github.com/ikawaha/kagome-dict/dict"
 )
 
 var (
-	bucketTexts = []byte("texts")
-	bucketWords = []byte("words")
-	random      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	bucketTexts    = []byte("texts")
+	bucketWords    = []byte("words")
+	bucketNS       = []byte("namespaces")
+	bucketReadings = []byte("readings")
+	random         = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)})
 )
 
+// lockedSource is a rand.Source safe for concurrent use, since random is
+// shared by all the generators, which may be used by several goroutines at
+// once (e.g. the tenants of a server).
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
 var DefaultTermWords = []string{
 	"。",
 	".",
 }
 
-type Generator interface {
-	Open(name string) error
-	Close() error
-
+// Learner is the part of a Generator which changes its model: it learns the
+// texts, and forgets them by Decay. Preview shows what it would learn.
+type Learner interface {
 	Register(text string) error
+	RegisterWithMeta(text string, meta Meta) error
+	RegisterReader(r io.Reader) error
+	RegisterReaderWithMeta(r io.Reader, meta Meta) error
+	RegisterRecords(rr RecordReader, meta Meta) error
+	RegisterFile(name string, meta Meta) (*FileResult, error)
+	Flush() error
+	Preview(text string) (*Preview, error)
+	Decay(halfLife time.Duration) error
+	Rebuild() (int, error)
+}
+
+// Producer is the read-only part of a Generator which produces and scores
+// the sentences.
+type Producer interface {
 	Generate(trigger string) (string, error)
 	GenerateWithClass(trigger, class string) (string, error)
+	GenerateWithClasses(trigger string, classes ...string) (string, error)
+	GenerateMatch(trigger string, match FeatureMatcher) (string, error)
+	GenerateN(trigger string, n int) ([]string, error)
+	GenerateSince(trigger string, since time.Time) (string, error)
+	GenerateTraced(trigger string) (*Trace, error)
+	GenerateResult(trigger string) (*Result, error)
+	GenerateBest(trigger string, n int, score Scorer) (string, error)
+	GenerateAround(trigger string) (string, error)
+	GenerateTemplate(tmpl string) (string, error)
+	GenerateRelated(trigger string) (text, used string, err error)
+	GenerateParagraph(trigger string, sentences int) (string, error)
+	GenerateBeam(trigger string, width int) (string, error)
+	GenerateFunc(trigger string, fn func(word string) bool) error
+	GenerateStream(ctx context.Context, trigger string) (<-chan string, error)
+	Reply(input string) (string, error)
+	Triggers(class string) ([]string, error)
+	RandomTrigger(class string) (string, error)
+	FindTrigger(prefix string) ([]Trigger, error)
+	FindByReading(reading string) ([]Trigger, error)
+	GenerateByReading(reading string) (string, error)
+	MatchTriggers(query string, maxDist int) ([]Trigger, error)
+	Lookup(word string) ([]WordInfo, error)
+	Score(text string) (float64, error)
+	Perplexity(text string) (float64, error)
+}
+
+// Store is the part of a Generator which manages its database.
+type Store interface {
+	Open(name string) error
+	Close() error
+
+	Stats() (*Stats, error)
+	Metrics() Metrics
 	Dump(w io.Writer) error
+	DumpFormat(w io.Writer, f Format) error
+	DumpRange(w io.Writer, f Format, r DumpRange) (int, error)
+	Words(r DumpRange, fn func(WordInfo) error) (int, error)
+	Check(repair bool) (*CheckReport, error)
+	Backup(w io.Writer) (int64, error)
+	Merge(other Generator) error
+	Graph(w io.Writer, word string, hops int) error
 }
 
-type generator struct {
-	t     tokenizer.Tokenizer
-	db    *bolt.DB
-	twMap map[string]bool
+// Generator learns the texts and generates the sentences. The consumers
+// which only read the model can take it as a Producer.
+type Generator interface {
+	Learner
+	Producer
+	Store
+
+	// WithContext returns a Generator whose spans of WithTracer are the
+	// children of the span in ctx.
+	WithContext(ctx context.Context) Generator
+
+	// In returns a Generator which works on the named model ns in the same
+	// database. The empty name is the default model.
+	In(ns string) Generator
+
+	// The *In variants work on the named model ns in the database.
+	RegisterIn(ns, text string) error
+	GenerateIn(ns, trigger string) (string, error)
+	GenerateWithClassIn(ns, trigger, class string) (string, error)
+	DumpIn(ns string, w io.Writer) error
 }
 
-func New() Generator {
-	return NewWithTermWords(DefaultTermWords)
+type generator struct {
+	t         *lazyTokenizer
+	dict      *dict.Dict
+	udictPath string
+	s         store
+	ns        string
+	twMap     map[string]bool
+	twSet     bool
+	split     bool
+	trigger   FeatureMatcher
+	ending    FeatureMatcher
+	mode      Mode
+	modeSet   bool
+	lemma     bool
+	lemmaSet  bool
+
+	normalizers  []Normalizer
+	filters      []Filter
+	banned       map[string]bool
+	stopWords    map[string]bool
+	stopClasses  FeatureMatcher
+	placeholders bool
+	emoji        *regexp.Regexp
+	required     string
+	post         []PostProcessor
+	closeBias    float64
+	safety       SafetyFilter
+	// safetyRetries is the number of the regenerations of the sentences
+	// rejected by the safety filter
+	safetyRetries int
+
+	maxOverlap float64
+	// overlapRetries is the number of the regenerations of the sentences
+	// overlapping too much with the texts
+	overlapRetries int
+
+	backoff    float64
+	sampling   Sampling
+	encoding   string
+	limits     Limits
+	progress   func(done, total int)
+	workers    int
+	buf        *writeBuffer
+	cache      *lru
+	noMigrate  bool
+	readOnly   bool
+	lockWait   time.Duration
+	compress   int
+	partSize   int
+	sources    []string
+	since      time.Time
+	halfLife   time.Duration
+	dedupMode  Dedup
+	provenance bool
+	tracer     *tracer
+	counters   *counters
+	log        *slog.Logger
+	spans      Tracer
+	ctx        context.Context
 }
 
-func NewWithTermWords(tw []string) Generator {
-	twMap := make(map[string]bool)
-	for _, w := range tw {
-		twMap[w] = true
+// Option configures a Generator.
+type Option func(g *generator)
+
+// WithTriggerMatch makes Generate start from the words matched by m,
+// instead of nouns.
+func WithTriggerMatch(m FeatureMatcher) Option {
+	return func(g *generator) {
+		g.trigger = m
 	}
+}
 
-	return &generator{
-		t:     tokenizer.New(),
-		twMap: twMap,
+func New(opts ...Option) Generator {
+	g := &generator{
+		mode:     ModeWord,
+		counters: new(counters),
 	}
+	g.setTermWords(DefaultTermWords)
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
 }
 
+func NewWithTermWords(tw []string, opts ...Option) Generator {
+	return New(append([]Option{WithTermWords(tw)}, opts...)...)
+}
+
+// Open opens the database specified by name.
+// name is a path of a Bolt database file, or a Redis DSN like
+// "redis://localhost:6379/0?prefix=uonum" to share the model between hosts.
 func (g *generator) Open(name string) error {
-	db, err := bolt.Open(name, 0600, nil)
+	open, timeout := openStore, g.lockWait
+	if g.readOnly {
+		open = openReadOnlyStore
+		if timeout == 0 {
+			timeout = readOnlyTimeout
+		}
+	}
+	s, err := open(name, timeout)
 	if err != nil {
-		return errors.Wrap(err, "Could not open database.")
+		return fmt.Errorf("could not open database: %w", err)
 	}
-	g.db = db
+	g.s = s
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(bucketWords)
+	// a read-only database is read as it is
+	sync := s.Update
+	if g.readOnly {
+		sync = s.View
+	}
+	err = sync(func(tx tx) error {
+		if !g.readOnly {
+			err := createModelBuckets(tx)
+			if err != nil {
+				return err
+			}
+		}
+		err := g.syncSchema(tx)
 		if err != nil {
 			return err
 		}
-		_, err = tx.CreateBucketIfNotExists(bucketTexts)
-		if err != nil {
+		err = g.syncSettings(tx)
+		if err != nil || g.halfLife <= 0 || g.readOnly {
 			return err
 		}
-		return nil
+		return decay(tx, g.halfLife, time.Now())
 	})
 	if err != nil {
-		return errors.Wrap(err, "Failed to create the bucket.")
+		return fmt.Errorf("failed to create the bucket: %w", err)
+
+	}
 
+	// the dictionary is loaded only if the mode of the database needs it,
+	// and at the first tokenization
+	if g.mode == ModeWord && g.t == nil {
+		g.t, err = g.sharedTokenizer()
+		if err != nil {
+			return fmt.Errorf("could not initialize the tokenizer: %w", err)
+		}
 	}
+	g.startFlusher()
 
 	return nil
 }
 
+// namespace returns the container which holds the buckets of the model ns.
+// Models other than the default one are nested buckets in bucketNS.
+// If create is false and the model does not exist, it returns nil.
+func namespace(tx tx, ns string, create bool) (container, error) {
+	if ns == "" {
+		return tx, nil
+	}
+
+	if !create {
+		nb := tx.Bucket(bucketNS)
+		if nb == nil {
+			return nil, nil
+		}
+		b := nb.Bucket([]byte(ns))
+		if b == nil {
+			return nil, nil
+		}
+		return b, nil
+	}
+
+	nb, err := tx.CreateBucketIfNotExists(bucketNS)
+	if err != nil {
+		return nil, err
+	}
+	b, err := nb.CreateBucketIfNotExists([]byte(ns))
+	if err != nil {
+		return nil, err
+	}
+	err = createModelBuckets(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// createModelBuckets creates the buckets of a model in c.
+func createModelBuckets(c container) error {
+	for _, name := range [][]byte{bucketWords, bucketTexts, bucketReadings, bucketTextMeta, bucketHashes} {
+		_, err := c.CreateBucketIfNotExists(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// models returns the containers of all the models in the database, the
+// default model first and then the namespaces in name order.
+func models(tx tx) []container {
+	models := []container{tx}
+	if nb := tx.Bucket(bucketNS); nb != nil {
+		c := nb.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if v != nil {
+				continue
+			}
+			if b := nb.Bucket(k); b != nil {
+				models = append(models, b)
+			}
+		}
+	}
+
+	return models
+}
+
+func (g *generator) In(ns string) Generator {
+	return g.in(ns)
+}
+
+func (g *generator) in(ns string) *generator {
+	c := *g
+	c.ns = ns
+	return &c
+}
+
 func (g *generator) Close() error {
-	if g.db == nil {
-		return errors.New("Database is not opened.")
+	if g.s == nil {
+		return ErrNotOpen
 	}
 
-	err := g.db.Close()
+	g.stopFlusher()
+	ferr := g.Flush()
+
+	err := g.s.Close()
 	if err != nil {
-		err = errors.Wrap(err, "Failed to close the database.")
+		return fmt.Errorf("failed to close the database: %w", err)
 	}
 
-	return err
+	return ferr
 }
 
 type wordLink struct {
 	Word     string           `json:"word"`
 	Features []string         `json:"features"`
+	Reading  string           `json:"reading,omitempty"`
 	Links    map[string]int64 `json:"links"`
+	// Prev are the links from the predecessors.
+	Prev map[string]int64 `json:"prev,omitempty"`
+	// Sources are the links contributed by each source.
+	Sources map[string]map[string]int64 `json:"sources,omitempty"`
+	// History are the links made on each day.
+	History map[string]map[string]int64 `json:"history,omitempty"`
+	// Surfaces are the links made by each surface of the word in the lemma
+	// mode (see WithLemma).
+	Surfaces map[string]map[string]int64 `json:"surfaces,omitempty"`
+	// Parts is the number of the parts the links are split into, 0 if they
+	// are not, and PartLinks are the numbers of the links in each of them.
+	Parts     int   `json:"parts,omitempty"`
+	PartLinks []int `json:"part_links,omitempty"`
+
+	// partial is true if only the first part of the links is read.
+	partial bool
 }
 
 func newWordLink(word string) *wordLink {
@@ -115,12 +413,18 @@ func newWordLinkWithFeatures(word string, f []string) *wordLink {
 	}
 }
 
+// getWordLink returns the word stored as key in b, or nil if it does not exist.
+func getWordLink(b bucket, key []byte) (*wordLink, error) {
+	v := b.Get(key)
+	if v == nil {
+		return nil, nil
+	}
+
+	return decodeWord(b, key, v)
+}
+
 func (w *wordLink) key() string {
-	return strings.Join(
-		[]string{
-			w.Word,
-			w.Features[0],
-		}, "_")
+	return encodeKey(w.Word, w.Features[0])
 }
 
 func (w *wordLink) merge(other *wordLink) {
@@ -131,117 +435,238 @@ func (w *wordLink) merge(other *wordLink) {
 	for k, v := range other.Links {
 		w.Links[k] += v
 	}
+	for k, v := range other.Prev {
+		if w.Prev == nil {
+			w.Prev = make(map[string]int64)
+		}
+		w.Prev[k] += v
+	}
+	w.Sources = mergeLinkMaps(w.Sources, other.Sources)
+	w.History = mergeLinkMaps(w.History, other.History)
+	w.Surfaces = mergeLinkMaps(w.Surfaces, other.Surfaces)
 }
 
-func (w *wordLink) next() string {
-	var total int64 = 0
-	keys := make([]string, 0, len(w.Links))
-	for k, c := range w.Links {
-		if c == 0 {
-			continue
+// mergeLinkMaps adds the counts of the links in other to m and returns m.
+func mergeLinkMaps(m, other map[string]map[string]int64) map[string]map[string]int64 {
+	for s, links := range other {
+		if m == nil {
+			m = make(map[string]map[string]int64)
+		}
+		if m[s] == nil {
+			m[s] = make(map[string]int64)
+		}
+		for k, v := range links {
+			m[s][k] += v
 		}
-		keys = append(keys, k)
-		total += c
-	}
-	if total == 0 {
-		return ""
 	}
 
-	return keys[random.Intn(len(keys))]
+	return m
 }
 
-func (g *generator) Register(text string) error {
-	db := g.db
-	if db == nil {
-		return errors.New("Database is not opened.")
-	}
+// clone returns a copy of w whose maps can be changed without changing w.
+func (w *wordLink) clone() *wordLink {
+	c := *w
+	c.Links = copyLinks(w.Links)
+	c.Prev = copyLinks(w.Prev)
+	c.Sources = mergeLinkMaps(nil, w.Sources)
+	c.History = mergeLinkMaps(nil, w.History)
+	c.Surfaces = mergeLinkMaps(nil, w.Surfaces)
+	c.PartLinks = append([]int(nil), w.PartLinks...)
 
-	tokens := g.t.Tokenize(text)
-	tokens = cleanTokens(tokens)
-	if len(tokens) < 2 {
+	return &c
+}
+
+func copyLinks(links map[string]int64) map[string]int64 {
+	if links == nil {
 		return nil
 	}
 
-	wlmap := make(map[string]*wordLink)
-	var prevwl *wordLink
-	for _, t := range tokens {
-		wl := newWordLinkWithFeatures(t.Surface, t.Features())
-		if old, ok := wlmap[wl.key()]; ok {
-			wl = old
-		} else {
-			wlmap[wl.key()] = wl
-		}
+	c := make(map[string]int64, len(links))
+	for k, v := range links {
+		c[k] = v
+	}
 
-		if prevwl != nil {
-			prevwl.Links[wl.key()]++
+	return c
+}
+
+func (w *wordLink) next() string {
+	return w.nextWhere(nil)
+}
+
+// nextWhere is like next but chooses only the links for which ok returns
+// true. ok is ignored if nil.
+func (w *wordLink) nextWhere(ok func(key string) bool) string {
+	eligible := func(k string, c int64) bool {
+		return c != 0 && (ok == nil || ok(k))
+	}
+
+	// count the links first and walk to the chosen one, rather than
+	// collecting the keys in a slice for each step
+	n := 0
+	for k, c := range w.Links {
+		if eligible(k, c) {
+			n++
 		}
+	}
+	if n == 0 {
+		return ""
+	}
 
-		prevwl = wl
+	i := random.Intn(n)
+	for k, c := range w.Links {
+		if !eligible(k, c) {
+			continue
+		}
+		if i == 0 {
+			return k
+		}
+		i--
 	}
 
-	err := db.Update(func(tx *bolt.Tx) error {
-		var err error
+	return ""
+}
 
-		// put original text
-		tb := tx.Bucket(bucketTexts)
+func (g *generator) RegisterIn(ns, text string) error {
+	return g.in(ns).Register(text)
+}
+
+func (g *generator) Register(text string) error {
+	return g.RegisterWithMeta(text, Meta{})
+}
+
+// putText puts texts and merges their words wlmap into the model c.
+func (g *generator) putText(c container, wlmap map[string]*wordLink, texts ...textRecord) error {
+	// put original texts
+	tb := c.Bucket(bucketTexts)
+	for _, t := range texts {
 		id, err := tb.NextSequence()
 		if err != nil {
-			return errors.Wrap(err, "Could not get next sequence.")
+			return fmt.Errorf("could not get next sequence: %w", err)
 		}
-		err = tb.Put(itob(id), []byte(text))
+		err = tb.Put(itob(id), []byte(t.text))
 		if err != nil {
-			return errors.Wrap(err, "Could not put text.")
+			return fmt.Errorf("could not put text: %w", err)
 		}
+		err = putMeta(c, itob(id), t.meta)
+		if err != nil {
+			return err
+		}
+		err = putProvenance(c, itob(id), t.links)
+		if err != nil {
+			return err
+		}
+		err = putShingles(c.Bucket(bucketWords), itob(id), t.text)
+		if err != nil {
+			return err
+		}
+	}
 
-		b := tx.Bucket(bucketWords)
-
-		for _, w := range wlmap {
-			key := []byte(w.key())
+	return g.putWords(c, wlmap)
+}
 
-			old := new(wordLink)
-			d := b.Get(key)
-			if d != nil {
-				err = json.Unmarshal(d, old)
-				if err != nil {
-					return errors.Wrapf(err, "[%s] JSON unmarshal error.", w.Word)
-				}
-			}
-			w.merge(old)
-			d, err = json.Marshal(w)
-			if err != nil {
-				return errors.Wrapf(err, "[%s] JSON marshal error.", w.Word)
-			}
+// putWords merges the words in wlmap into the words of c.
+func (g *generator) putWords(c container, wlmap map[string]*wordLink) error {
+	b := c.Bucket(bucketWords)
 
-			err = b.Put(key, d)
-			if err != nil {
-				return err
-			}
+	counts := make(map[string]int64, len(wlmap))
+	for k, w := range wlmap {
+		// before the merge, the links are those of texts alone
+		counts[k] = w.occurrences()
+		n, err := mergeWordLink(b, []byte(w.key()), w, g.partSize, g.compress)
+		if err != nil {
+			return err
 		}
+		g.counters.wordWrites.Add(1)
+		g.counters.wordBytes.Add(int64(n))
+	}
 
-		return nil
-	})
+	err := putClasses(b, counts, false)
 	if err != nil {
-		return errors.Wrap(err, "Failed to update the database.")
+		return err
 	}
 
-	return nil
+	return putReadings(c.Bucket(bucketReadings), wlmap)
 }
 
-func cleanTokens(tokens []tokenizer.Token) []tokenizer.Token {
-	c := make([]tokenizer.Token, 0, len(tokens))
+// buildLinks tokenizes text and returns the words in it with the counts of
+// the links between them.
+func (g *generator) buildLinks(text string) map[string]*wordLink {
+	sentences, markers := g.textTokens(g.normalize(g.filter(text)))
+	if sentences == nil {
+		return nil
+	}
 
-	for _, t := range tokens {
-		if t.ID == tokenizer.BosEosID {
-			continue
+	wlmap := make(map[string]*wordLink)
+	for _, sentence := range sentences {
+		addLinks(wlmap, sentence, markers)
+	}
+
+	return wlmap
+}
+
+// textTokens tokenizes the filtered and normalized text into the sentences
+// whose links are registered. markers is true if the sentences are split at
+// the term words, and linked from BOS and to EOS. It returns nil if a text
+// not split has less than two tokens.
+func (g *generator) textTokens(text string) (sentences [][]token, markers bool) {
+	// texts in ModeChar are always split, since the chains of characters
+	// rarely reach a term word
+	if !g.split && g.mode != ModeChar {
+		tokens := g.tokenize(text)
+		if len(tokens) < 2 {
+			return nil, false
 		}
-		if t.Surface == " " {
-			continue
+		return [][]token{tokens}, false
+	}
+
+	return g.sentences(text), true
+}
+
+// addLinks adds the links between tokens to wlmap. If markers is true, the
+// links from BOS to the first token and from the last token to EOS are added.
+// The surfaces of the tokens with base forms are counted by the words they
+// are linked to.
+func addLinks(wlmap map[string]*wordLink, tokens []token, markers bool) {
+	var prevwl *wordLink
+	if markers {
+		prevwl = wlmap[bosKey]
+		if prevwl == nil {
+			prevwl = newWordLinkWithFeatures("", []string{bosClass})
+			wlmap[bosKey] = prevwl
+		}
+	}
+
+	prevKey := bosKey
+	prevBase := ""
+	for i, t := range tokens {
+		key := t.key()
+		wl, ok := wlmap[key]
+		if !ok {
+			wl = newWordLinkWithFeatures(t.word(), t.Features)
+			wl.Reading = t.Reading
+			wlmap[key] = wl
 		}
 
-		c = append(c, t)
+		if prevwl != nil {
+			prevwl.Links[key]++
+			if wl.Prev == nil {
+				wl.Prev = make(map[string]int64)
+			}
+			wl.Prev[prevKey]++
+		}
+		if i > 0 && prevBase != "" {
+			prevwl.addSurface(tokens[i-1].Surface, key)
+		}
+
+		prevwl, prevKey, prevBase = wl, key, t.Base
 	}
 
-	return c
+	if markers && prevwl != nil {
+		prevwl.Links[eosKey]++
+		if prevBase != "" {
+			prevwl.addSurface(tokens[len(tokens)-1].Surface, eosKey)
+		}
+	}
 }
 
 // itob returns an 8-byte big endian representation of v.
@@ -252,89 +677,370 @@ func itob(v uint64) []byte {
 }
 
 func (g *generator) Dump(w io.Writer) error {
-	db := g.db
-	if db == nil {
-		return errors.New("Database is not opened.")
+	return g.DumpFormat(w, FormatText)
+}
+
+func (g *generator) DumpIn(ns string, w io.Writer) error {
+	return g.in(ns).Dump(w)
+}
+
+// viewWords calls fn with the words bucket of the model in a read-only
+// transaction. fn is not called if the model does not exist.
+func (g *generator) viewWords(fn func(b bucket) error) error {
+	return g.viewModel(func(words, _ bucket) error {
+		return fn(words)
+	})
+}
+
+// viewModel is like viewWords but calls fn with the texts bucket as well.
+func (g *generator) viewModel(fn func(words, texts bucket) error) error {
+	return g.viewNS(func(c container) error {
+		return fn(c.Bucket(bucketWords), c.Bucket(bucketTexts))
+	})
+}
+
+// viewChain is viewModel for the generation, which fails with ErrEmptyModel
+// if the model does not exist.
+func (g *generator) viewChain(fn func(words, texts bucket) error) error {
+	found := false
+	err := g.viewModel(func(b, tb bucket) error {
+		found = true
+		return fn(b, tb)
+	})
+	if err == nil && !found {
+		return ErrEmptyModel
 	}
 
-	err := g.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketWords)
+	return err
+}
 
-		c := b.Cursor()
+// missing returns the error of the start key which is not in b:
+// ErrEmptyModel if b is empty, or ErrUnknownTrigger.
+func missing(b bucket, key []byte) error {
+	if k, _ := b.Cursor().First(); k == nil {
+		return ErrEmptyModel
+	}
+	if key == nil {
+		return ErrUnknownTrigger
+	}
 
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			wl := new(wordLink)
-			err := json.Unmarshal(v, wl)
-			if err != nil {
-				return errors.Wrapf(err, "[%s] JSON unmarshal error.", k)
-			}
-			fmt.Fprintln(w, wl.key())
-			for link, count := range wl.Links {
-				fmt.Fprintf(w, "  %s : %d\n", link, count)
-			}
-			fmt.Fprintln(w)
+	return fmt.Errorf("[%s] %w", displayKey(string(key)), ErrUnknownTrigger)
+}
+
+// viewNS calls fn with the container of the buckets of the model in a
+// read-only transaction. fn is not called if the model does not exist.
+func (g *generator) viewNS(fn func(c container) error) error {
+	s := g.s
+	if s == nil {
+		return ErrNotOpen
+	}
+
+	err := s.View(func(tx tx) error {
+		c, err := namespace(tx, g.ns, false)
+		if err != nil || c == nil {
+			return err
 		}
 
-		return nil
+		return fn(c)
+	})
+	if err != nil {
+		return fmt.Errorf("could not read the database: %w", err)
+	}
+
+	return nil
+}
+
+// updateNS calls fn with the model in a writable transaction, creating the
+// model if it does not exist.
+func (g *generator) updateNS(fn func(c container) error) error {
+	s := g.s
+	if s == nil {
+		return ErrNotOpen
+	}
+
+	err := s.Update(func(tx tx) error {
+		c, err := namespace(tx, g.ns, true)
+		if err != nil {
+			return fmt.Errorf("[%s] could not create the namespace: %w", g.ns, err)
+		}
+
+		return fn(c)
 	})
 	if err != nil {
-		return errors.Wrap(err, "Could not read the database.")
+		return fmt.Errorf("failed to update the database: %w", err)
+	}
+
+	return nil
+}
+
+// eachWordLink calls fn for each word of the model in key order.
+func (g *generator) eachWordLink(fn func(wl *wordLink) error) error {
+	return g.viewWords(func(b bucket) error {
+		return eachWord(b, fn)
+	})
+}
+
+// eachWord calls fn with each word in b.
+func eachWord(b bucket, fn func(wl *wordLink) error) error {
+	c := b.Cursor()
+
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			continue
+		}
+		wl, err := decodeWord(b, k, v)
+		if err != nil {
+			return err
+		}
+		err = fn(wl)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func (g *generator) Generate(trigger string) (string, error) {
-	return g.GenerateWithClass(trigger, "名詞")
+	if g.trigger != nil {
+		return g.GenerateMatch(trigger, g.trigger)
+	}
+
+	return g.GenerateWithClass(trigger, g.defaultClass())
+}
+
+func (g *generator) GenerateIn(ns, trigger string) (string, error) {
+	return g.in(ns).Generate(trigger)
+}
+
+func (g *generator) GenerateWithClassIn(ns, trigger, class string) (string, error) {
+	return g.in(ns).GenerateWithClass(trigger, class)
 }
 
 func (g *generator) GenerateWithClass(trigger, class string) (string, error) {
+	trigger = g.triggerWord(trigger)
 	if trigger == "" {
 		return "", nil
 	}
 
-	db := g.db
-	if db == nil {
-		return "", errors.New("Database is not opened.")
+	var text string
+	err := g.viewChain(func(b, tb bucket) error {
+		var err error
+		text, err = g.generate(b, tb, []byte(encodeKey(trigger, class)))
+		return err
+	})
+	if err != nil {
+		return "", err
 	}
 
-	buf := bytes.NewBuffer(make([]byte, 0, 4096))
+	return text, nil
+}
 
-	err := g.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketWords)
+// generate walks the chain in b starting from key and returns the text
+// post-processed. See generateText.
+func (g *generator) generate(b, tb bucket, key []byte) (string, error) {
+	return g.generateText(b, tb, key, true)
+}
 
-		key := []byte(fmt.Sprintf("%s_%s", trigger, class))
-		for {
-			v := b.Get(key)
-			if v == nil {
-				break
-			}
+// generateRaw is like generate but returns the text not post-processed nor
+// checked by the safety filter, to be a part of a sentence.
+func (g *generator) generateRaw(b, tb bucket, key []byte) (string, error) {
+	return g.generateText(b, tb, key, false)
+}
 
-			w := new(wordLink)
-			err := json.Unmarshal(v, w)
-			if err != nil {
-				return errors.Wrapf(err, "[%s] JSON unmarshal error.", key)
-			}
+// generateText walks the chain in b starting from key and returns the text,
+// post-processed and checked by the safety filter if post is true.
+// The sentences which exceed the limits, overlap too much with the texts in
+// tb or are rejected are regenerated. It returns ErrGenerationFailed if it
+// never succeeds.
+func (g *generator) generateText(b, tb bucket, key []byte, post bool) (_ string, err error) {
+	if g.spans != nil {
+		var span Span
+		g, span = g.span("uonum.Generate")
+		span.SetAttributes(slog.String("ns", g.ns), slog.String("trigger", displayKey(string(key))))
+		defer func() { endSpan(span, err) }()
+	}
 
-			buf.WriteString(w.Word)
+	if b.Get(key) == nil {
+		return "", missing(b, key)
+	}
+
+	deadline := g.deadline()
+	// retry counts a regeneration of the ones limited to max, and reports
+	// whether it is allowed
+	retry := func(n *int, max int) bool {
+		if *n >= max {
+			return false
+		}
+		*n++
+		return true
+	}
+
+	var retries, overlaps, rejected int
+	for {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out: %w", ErrGenerationFailed)
+		}
 
-			if _, ok := g.twMap[w.Word]; ok {
-				break
+		var text string
+		var ok bool
+		if g.required != "" {
+			text, ok, err = g.walkThrough(b, key, deadline)
+		} else {
+			text, ok, err = g.walk(b, key, deadline, nil)
+		}
+		if err != nil || text == "" {
+			return text, err
+		}
+		if !ok {
+			if !retry(&retries, g.limits.MaxRetries) {
+				return "", ErrGenerationFailed
 			}
+			continue
+		}
+		if g.maxOverlap > 0 && tooSimilar(b, tb, text, g.maxOverlap) {
+			if !retry(&overlaps, g.overlapRetries) {
+				return "", ErrGenerationFailed
+			}
+			continue
+		}
+		if post {
+			text = g.postProcess(text)
+			if !g.safe(text, deadline) {
+				if !retry(&rejected, g.safetyRetries) {
+					return "", errRejected
+				}
+				continue
+			}
+		}
 
-			n := w.next()
-			if n == "" {
-				break
+		return text, nil
+	}
+}
+
+// walk walks the chain in b starting from key once and returns the text.
+// If emit is not nil, it is called with each word, and the walk is aborted
+// when it returns false. ok is false if the walk was aborted or the sentence
+// did not end naturally (see WithEnding).
+func (g *generator) walk(b bucket, key []byte, deadline time.Time, emit func(word string) bool) (text string, ok bool, err error) {
+	buf := bytes.NewBuffer(make([]byte, 0, 4096))
+	prev := ""
+	// put writes a surface of a word, and reports whether the walk goes on
+	put := func(surface string) bool {
+		word := g.fill(surface)
+		if g.mode == ModeWhitespace && needSpace(prev, word) {
+			word = " " + word
+		}
+		prev = surface
+
+		buf.WriteString(word)
+		return emit == nil || emit(word)
+	}
+	var last *wordLink
+	var open []rune
+	if g.tracer != nil {
+		g.tracer.keys = g.tracer.keys[:0]
+		g.tracer.walks++
+		g.tracer.deadEnd = false
+	}
+	g.counters.walks.Add(1)
+	if g.spans != nil {
+		var span Span
+		g, span = g.span("uonum.walk")
+		defer func() {
+			span.SetAttributes(slog.Bool("ok", ok), slog.Int("bytes", len(text)))
+			endSpan(span, err)
+		}()
+	}
+
+	for words := 0; ; words++ {
+		if g.limits.MaxWords > 0 && words >= g.limits.MaxWords {
+			return buf.String(), false, nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return buf.String(), false, nil
+		}
+
+		_, lookup := g.span("uonum.lookup")
+		w, err := g.wordHead(b, key)
+		endSpan(lookup, err)
+		if err != nil {
+			return "", false, err
+		}
+		if w == nil || g.banned[w.Word] {
+			return buf.String(), g.deadEnd(last), nil
+		}
+		last = w
+		if g.tracer != nil {
+			g.tracer.keys = append(g.tracer.keys, string(key))
+		}
+
+		if g.closeBias > 0 {
+			open = trackBrackets(open, w.Word)
+		}
+		// in the lemma mode, the surface depends on the next word
+		if !g.lemma && !put(w.Word) {
+			return buf.String(), false, nil
+		}
+
+		if _, ok := g.twMap[w.Word]; ok {
+			if g.lemma && !put(w.surface(eosKey)) {
+				return buf.String(), false, nil
 			}
+			break
+		}
 
-			key = []byte(n)
+		_, sample := g.span("uonum.sample")
+		n, w, err := g.chooseNext(b, key, w, open)
+		if err != nil {
+			endSpan(sample, err)
+			return "", false, err
+		}
+		backoff := false
+		if n == "" {
+			n, err = g.backOff(b, w)
+			if err != nil {
+				endSpan(sample, err)
+				return "", false, err
+			}
+			backoff = n != ""
+		}
+		if g.spans != nil {
+			sample.SetAttributes(slog.String("next", displayKey(n)), slog.Int("candidates", w.size()), slog.Bool("backoff", backoff))
+		}
+		sample.End()
+		if g.debugging() {
+			g.debug("Chose the next word.", "word", w.Word, "next", displayKey(n), "candidates", w.size(), "backoff", backoff)
+		}
+		if g.lemma && !put(w.surface(n)) {
+			return buf.String(), false, nil
+		}
+		if n == eosKey {
+			break
+		}
+		if n == "" {
+			return buf.String(), g.deadEnd(w), nil
 		}
 
-		return nil
-	})
-	if err != nil {
-		return "", errors.Wrap(err, "Could not read the database.")
+		key = []byte(n)
+	}
+
+	return buf.String(), true, nil
+}
+
+// deadEnd reports whether the walk which has no next word of w ends
+// naturally, and counts it as a dead end if not.
+func (g *generator) deadEnd(w *wordLink) bool {
+	if g.tracer != nil {
+		g.tracer.deadEnd = true
+	}
+	if g.endsNaturally(w) {
+		return true
+	}
+	g.counters.deadEnds.Add(1)
+	if w != nil {
+		g.debug("Dead end.", "word", w.Word)
 	}
 
-	return buf.String(), nil
+	return false
 }
@@ -0,0 +1,143 @@
+package uonum
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// benchDB returns a database of n lines of benchText registered with opts.
+func benchDB(b *testing.B, n int, opts ...Option) string {
+	b.Helper()
+
+	name := filepath.Join(b.TempDir(), "bench.db")
+	g := New(opts...)
+	if err := g.Open(name); err != nil {
+		b.Fatal(err)
+	}
+	defer g.Close()
+	if err := g.RegisterReader(strings.NewReader(benchText(n))); err != nil {
+		b.Fatal(err)
+	}
+
+	return name
+}
+
+func BenchmarkOpen(b *testing.B) {
+	name := benchDB(b, 1000)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		g := New()
+		if err := g.Open(name); err != nil {
+			b.Fatal(err)
+		}
+		if err := g.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRegister(b *testing.B) {
+	texts := strings.Split(strings.TrimSpace(benchText(1000)), "\n")
+	g := openBench(b)
+	// load the dictionary before the timer
+	if err := g.Register(texts[0]); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := g.Register(texts[i%len(texts)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for _, compress := range []int{0, 1} {
+		b.Run(fmt.Sprintf("compress=%d", compress), func(b *testing.B) {
+			name := benchDB(b, 1000, WithCompression(compress))
+			g := New()
+			if err := g.Open(name); err != nil {
+				b.Fatal(err)
+			}
+			defer g.Close()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := g.Generate("猫"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateConcurrent(t *testing.T) {
+	// the generators of two tenants share the random numbers
+	tenants := []*generator{
+		openModel(t, []string{"猫が鳴く。", "猫が魚を食べる。"}),
+		openModel(t, []string{"猫が眠る。", "猫が走る。"}),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(g *generator) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := g.Generate("猫"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(tenants[i%len(tenants)])
+	}
+	wg.Wait()
+}
+
+func TestInterfaces(t *testing.T) {
+	g := openModel(t, nil)
+
+	tests := []struct {
+		name string
+		g    Generator
+	}{
+		{name: "default", g: g},
+		{name: "namespace", g: g.In("cats")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// each part works on the same model
+			var (
+				l Learner  = tt.g
+				p Producer = tt.g
+				s Store    = tt.g
+			)
+			if err := l.Register("猫が鳴く。"); err != nil {
+				t.Fatal(err)
+			}
+			if err := l.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			text, err := p.Generate("猫")
+			if err != nil || text != "猫が鳴く。" {
+				t.Errorf("Generate() = %q, %v, want %q", text, err, "猫が鳴く。")
+			}
+			st, err := s.Stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.Texts != 1 {
+				t.Errorf("Stats() texts = %d, want 1", st.Texts)
+			}
+		})
+	}
+}
@@ -0,0 +1,1044 @@
+// Package uonumtest provides a fake uonum.Generator for the tests of the
+// applications embedding uonum.
+//
+// The fake keeps its models in memory, needs no dictionary nor database, and
+// chooses the words by a seeded random source, so that the same calls
+// generate the same sentences.
+package uonumtest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kechako/uonum"
+)
+
+// maxWords is the length limit of a generated sentence.
+const maxWords = 100
+
+// unseenLogProb is the log-probability of a transition not in the model.
+var unseenLogProb = math.Log(1e-6)
+
+var _ uonum.Generator = (*Fake)(nil)
+
+// Fake is an in-memory uonum.Generator. The texts are split into words at
+// the spaces (e.g. "今日 は 雨 です 。"), the generated sentences are their
+// words joined with spaces, and a sentence ends after a word ending with one
+// of uonum.DefaultTermWords, "!" or "?". The word classes are ignored.
+//
+// Open and Close do nothing. It is safe for concurrent use.
+type Fake struct {
+	*state
+	ns string
+}
+
+// state is shared by a Fake and the Fakes of its namespaces.
+type state struct {
+	mu      sync.Mutex
+	rand    *rand.Rand
+	models  map[string]*model
+	metrics uonum.Metrics
+}
+
+// model is the chain of a namespace.
+type model struct {
+	// links are the counts of the next words of each word; the empty word is
+	// the end of a text
+	links map[string]map[string]int64
+	texts []uonum.Record
+}
+
+// New returns a Fake whose choices of the words are determined by seed.
+func New(seed int64) *Fake {
+	return &Fake{state: &state{
+		rand:   rand.New(rand.NewSource(seed)),
+		models: make(map[string]*model),
+	}}
+}
+
+// model returns the model of the namespace of f. f.mu must be held.
+func (f *Fake) model() *model {
+	m, ok := f.models[f.ns]
+	if !ok {
+		m = &model{links: make(map[string]map[string]int64)}
+		f.models[f.ns] = m
+	}
+
+	return m
+}
+
+func isTerm(word string) bool {
+	for _, t := range append(uonum.DefaultTermWords, "!", "?", "！", "？") {
+		if strings.HasSuffix(word, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Open does nothing.
+func (f *Fake) Open(name string) error {
+	return nil
+}
+
+// Close does nothing.
+func (f *Fake) Close() error {
+	return nil
+}
+
+func (f *Fake) Register(text string) error {
+	return f.RegisterWithMeta(text, uonum.Meta{})
+}
+
+func (f *Fake) RegisterWithMeta(text string, meta uonum.Meta) error {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.model()
+	m.addLinks(words)
+	if meta.Time.IsZero() {
+		meta.Time = time.Now()
+	}
+	m.texts = append(m.texts, uonum.Record{Text: text, Meta: meta})
+
+	return nil
+}
+
+// addLinks adds the links between words to m.
+func (m *model) addLinks(words []string) {
+	for i, w := range words {
+		var next string
+		if i+1 < len(words) {
+			next = words[i+1]
+		}
+		if m.links[w] == nil {
+			m.links[w] = make(map[string]int64)
+		}
+		m.links[w][next]++
+	}
+}
+
+func (f *Fake) RegisterReader(r io.Reader) error {
+	return f.RegisterReaderWithMeta(r, uonum.Meta{})
+}
+
+func (f *Fake) RegisterReaderWithMeta(r io.Reader, meta uonum.Meta) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if err := f.RegisterWithMeta(s.Text(), meta); err != nil {
+			return err
+		}
+	}
+
+	return s.Err()
+}
+
+func (f *Fake) RegisterRecords(rr uonum.RecordReader, meta uonum.Meta) error {
+	for {
+		rec, err := rr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rec.Meta.Source == "" {
+			rec.Meta.Source = meta.Source
+		}
+		if rec.Meta.Author == "" {
+			rec.Meta.Author = meta.Author
+		}
+		if err := f.RegisterWithMeta(rec.Text, rec.Meta); err != nil {
+			return err
+		}
+	}
+}
+
+func (f *Fake) RegisterFile(name string, meta uonum.Meta) (*uonum.FileResult, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &uonum.FileResult{Name: name, Bytes: int64(len(data))}
+	s := bufio.NewScanner(strings.NewReader(string(data)))
+	for s.Scan() {
+		if err := f.RegisterWithMeta(s.Text(), meta); err != nil {
+			return nil, err
+		}
+		res.Lines++
+	}
+
+	return res, s.Err()
+}
+
+// Flush does nothing, since nothing is buffered.
+func (f *Fake) Flush() error {
+	return nil
+}
+
+// Preview returns the words of text as a sentence, and the links Register
+// would add to the model. The words have no class, and the end of the text
+// is the empty word.
+func (f *Fake) Preview(text string) (*uonum.Preview, error) {
+	p := &uonum.Preview{Text: text}
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return p, nil
+	}
+
+	tokens := make([]uonum.PreviewToken, len(words))
+	for i, w := range words {
+		tokens[i] = uonum.PreviewToken{Surface: w, Key: w}
+	}
+	p.Sentences = [][]uonum.PreviewToken{tokens}
+
+	links := &model{links: make(map[string]map[string]int64)}
+	links.addLinks(words)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.model()
+	for _, w := range sortedKeys(links.links) {
+		for _, n := range sortedKeys(links.links[w]) {
+			p.Links = append(p.Links, uonum.PreviewLink{
+				From:  w,
+				To:    n,
+				Count: links.links[w][n],
+				New:   m.links[w][n] == 0,
+			})
+		}
+	}
+
+	return p, nil
+}
+
+// Decay halves the counts of the links, as if halfLife had passed, and
+// removes the links whose counts become 0.
+func (f *Fake) Decay(halfLife time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.model()
+	for w, next := range m.links {
+		for n, c := range next {
+			if c /= 2; c == 0 {
+				delete(next, n)
+			} else {
+				next[n] = c
+			}
+		}
+		if len(next) == 0 {
+			delete(m.links, w)
+		}
+	}
+
+	return nil
+}
+
+// Rebuild builds the links again from the registered texts, which undoes
+// Decay.
+func (f *Fake) Rebuild() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.model()
+	m.links = make(map[string]map[string]int64)
+	for _, rec := range m.texts {
+		m.addLinks(strings.Fields(rec.Text))
+	}
+
+	return len(m.texts), nil
+}
+
+// walk returns the words of a sentence from trigger, calling fn with each of
+// them until it returns false. If greedy is true, the most frequent next
+// word is chosen instead of a random one. f.mu must be held.
+func (f *Fake) walk(trigger string, greedy bool, fn func(word string) bool) ([]string, error) {
+	m := f.model()
+	if len(m.links) == 0 {
+		return nil, uonum.ErrEmptyModel
+	}
+	if m.links[trigger] == nil {
+		return nil, fmt.Errorf("[%s] %w", trigger, uonum.ErrUnknownTrigger)
+	}
+
+	f.metrics.Walks++
+	words := []string{trigger}
+	for w := trigger; fn(w) && !isTerm(w); {
+		if len(words) == maxWords {
+			f.metrics.DeadEnds++
+			break
+		}
+		next := f.choose(m.links[w], greedy)
+		if next == "" {
+			f.metrics.DeadEnds++
+			break
+		}
+		words = append(words, next)
+		w = next
+	}
+
+	return words, nil
+}
+
+// choose returns a next word in links, by their counts.
+func (f *Fake) choose(links map[string]int64, greedy bool) string {
+	keys := sortedKeys(links)
+	if greedy {
+		var best string
+		for _, k := range keys {
+			if links[k] > links[best] {
+				best = k
+			}
+		}
+		return best
+	}
+
+	var total int64
+	for _, k := range keys {
+		total += links[k]
+	}
+	if total == 0 {
+		return ""
+	}
+	r := f.rand.Int63n(total)
+	for _, k := range keys {
+		if r -= links[k]; r < 0 {
+			return k
+		}
+	}
+
+	return ""
+}
+
+func all(string) bool {
+	return true
+}
+
+func (f *Fake) Generate(trigger string) (string, error) {
+	if trigger == "" {
+		return "", nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	words, err := f.walk(trigger, false, all)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+func (f *Fake) GenerateWithClass(trigger, class string) (string, error) {
+	return f.Generate(trigger)
+}
+
+func (f *Fake) GenerateWithClasses(trigger string, classes ...string) (string, error) {
+	return f.Generate(trigger)
+}
+
+func (f *Fake) GenerateMatch(trigger string, match uonum.FeatureMatcher) (string, error) {
+	return f.Generate(trigger)
+}
+
+func (f *Fake) GenerateN(trigger string, n int) ([]string, error) {
+	var texts []string
+	for i := 0; i < n; i++ {
+		text, err := f.Generate(trigger)
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, text)
+	}
+
+	return texts, nil
+}
+
+func (f *Fake) GenerateSince(trigger string, since time.Time) (string, error) {
+	return f.Generate(trigger)
+}
+
+// GenerateResult is like Generate but returns the details of the sentence.
+// The tokens have no class, and the walks of the Fake are never retried.
+func (f *Fake) GenerateResult(trigger string) (*uonum.Result, error) {
+	text, err := f.Generate(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &uonum.Result{Text: text, Trigger: trigger}
+	words := strings.Fields(text)
+	for _, w := range words {
+		r.Tokens = append(r.Tokens, uonum.Token{Surface: w})
+	}
+	r.Score, _ = f.score(text)
+	if len(words) > 0 && len(words) < maxWords {
+		last := words[len(words)-1]
+		f.mu.Lock()
+		r.DeadEnd = !isTerm(last) && f.model().links[last][""] == 0
+		f.mu.Unlock()
+	}
+
+	return r, nil
+}
+
+func (f *Fake) GenerateTraced(trigger string) (*uonum.Trace, error) {
+	text, err := f.Generate(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &uonum.Trace{Text: text}
+	words := strings.Fields(text)
+	for i := 0; i+1 < len(words); i++ {
+		t.Steps = append(t.Steps, uonum.Step{Link: uonum.Link{From: words[i], To: words[i+1]}})
+	}
+
+	return t, nil
+}
+
+func (f *Fake) GenerateBest(trigger string, n int, score uonum.Scorer) (string, error) {
+	texts, err := f.GenerateN(trigger, n)
+	if err != nil {
+		return "", err
+	}
+
+	return uonum.Best(texts, score), nil
+}
+
+func (f *Fake) GenerateAround(trigger string) (string, error) {
+	return f.Generate(trigger)
+}
+
+// GenerateRelated is like Generate, but if trigger is not in the model, it
+// starts from the word sharing the longest prefix with it, or else from the
+// word occurring with it most in the registered texts.
+func (f *Fake) GenerateRelated(trigger string) (text, used string, err error) {
+	text, err = f.Generate(trigger)
+	if !errors.Is(err, uonum.ErrUnknownTrigger) {
+		return text, trigger, err
+	}
+
+	f.mu.Lock()
+	m := f.model()
+	var best string
+	bestLen := 0
+	for _, w := range sortedKeys(m.links) {
+		n := 0
+		for n < len(w) && n < len(trigger) && w[n] == trigger[n] {
+			n++
+		}
+		if n > bestLen {
+			best, bestLen = w, n
+		}
+	}
+	if best == "" {
+		counts := make(map[string]int64)
+		for _, rec := range m.texts {
+			words := strings.Fields(rec.Text)
+			if !contains(words, trigger) {
+				continue
+			}
+			for _, w := range words {
+				if w != trigger {
+					counts[w]++
+				}
+			}
+		}
+		for _, w := range sortedKeys(counts) {
+			if counts[w] > counts[best] {
+				best = w
+			}
+		}
+	}
+	f.mu.Unlock()
+	if best == "" {
+		return "", "", err
+	}
+
+	text, err = f.Generate(best)
+	if err != nil {
+		return "", "", err
+	}
+
+	return text, best, nil
+}
+
+func contains(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GenerateTemplate fills each slot of tmpl, in braces, with a word following
+// the word before it, or a random word if there is none. The classes in the
+// slots are ignored.
+func (f *Fake) GenerateTemplate(tmpl string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.model()
+	if len(m.links) == 0 {
+		return "", uonum.ErrEmptyModel
+	}
+
+	var sb strings.Builder
+	prev := ""
+	for rest := tmpl; rest != ""; {
+		i := strings.IndexByte(rest, '{')
+		if i < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		sb.WriteString(rest[:i])
+		if words := strings.Fields(rest[:i]); len(words) > 0 {
+			prev = words[len(words)-1]
+		}
+		j := strings.IndexByte(rest[i:], '}')
+		if j < 0 {
+			return "", fmt.Errorf("Unclosed slot in the template [%s].", tmpl)
+		}
+
+		word := f.choose(m.links[prev], false)
+		if word == "" {
+			words := sortedKeys(m.links)
+			word = words[f.rand.Intn(len(words))]
+		}
+		sb.WriteString(word)
+		prev = word
+		rest = rest[i+j+1:]
+	}
+
+	return sb.String(), nil
+}
+
+// GenerateParagraph generates the first sentence from trigger, and the others
+// from random trigger words.
+func (f *Fake) GenerateParagraph(trigger string, sentences int) (string, error) {
+	var texts []string
+	for i := 0; i < sentences; i++ {
+		text, err := f.Generate(trigger)
+		if err != nil {
+			return "", err
+		}
+		texts = append(texts, text)
+
+		if trigger, err = f.RandomTrigger(""); err != nil {
+			return "", err
+		}
+	}
+
+	return strings.Join(texts, " "), nil
+}
+
+// GenerateBeam generates the sentence of the most frequent next words.
+func (f *Fake) GenerateBeam(trigger string, width int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	words, err := f.walk(trigger, true, all)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+func (f *Fake) GenerateFunc(trigger string, fn func(word string) bool) error {
+	if trigger == "" {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, err := f.walk(trigger, false, fn)
+	return err
+}
+
+func (f *Fake) GenerateStream(ctx context.Context, trigger string) (<-chan string, error) {
+	f.mu.Lock()
+	words, err := f.walk(trigger, false, all)
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for _, w := range words {
+			select {
+			case ch <- w:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Reply generates a sentence from the first word of input in the model, or
+// from a random trigger word.
+func (f *Fake) Reply(input string) (string, error) {
+	f.mu.Lock()
+	m := f.model()
+	var trigger string
+	for _, w := range strings.Fields(input) {
+		if m.links[w] != nil && !isTerm(w) {
+			trigger = w
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if trigger == "" {
+		var err error
+		if trigger, err = f.RandomTrigger(""); err != nil {
+			return "", err
+		}
+	}
+
+	return f.Generate(trigger)
+}
+
+// RandomTrigger returns a word of the model not ending sentences, chosen by
+// the number of times it followed another word plus one.
+func (f *Fake) RandomTrigger(class string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	counts := make(map[string]int64)
+	m := f.model()
+	for w := range m.links {
+		if !isTerm(w) {
+			counts[w]++
+		}
+	}
+	for _, links := range m.links {
+		for w, c := range links {
+			if _, ok := counts[w]; ok {
+				counts[w] += c
+			}
+		}
+	}
+	if len(counts) == 0 {
+		return "", uonum.ErrEmptyModel
+	}
+
+	return f.choose(counts, false), nil
+}
+
+// Triggers returns the words of the model not ending sentences, in order.
+func (f *Fake) Triggers(class string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var words []string
+	for _, w := range sortedKeys(f.model().links) {
+		if !isTerm(w) {
+			words = append(words, w)
+		}
+	}
+
+	return words, nil
+}
+
+func (f *Fake) FindTrigger(prefix string) ([]uonum.Trigger, error) {
+	return f.findTriggers(func(w string) bool {
+		return strings.HasPrefix(w, prefix)
+	})
+}
+
+// FindByReading finds nothing, since the words have no readings.
+func (f *Fake) FindByReading(reading string) ([]uonum.Trigger, error) {
+	return nil, nil
+}
+
+// GenerateByReading generates a sentence from the word reading.
+func (f *Fake) GenerateByReading(reading string) (string, error) {
+	return f.Generate(reading)
+}
+
+func (f *Fake) MatchTriggers(query string, maxDist int) ([]uonum.Trigger, error) {
+	return f.findTriggers(func(w string) bool {
+		return distance(query, w) <= maxDist
+	})
+}
+
+func (f *Fake) findTriggers(match func(word string) bool) ([]uonum.Trigger, error) {
+	words, err := f.Triggers("")
+	if err != nil {
+		return nil, err
+	}
+
+	var ts []uonum.Trigger
+	for _, w := range words {
+		if match(w) {
+			ts = append(ts, uonum.Trigger{Word: w})
+		}
+	}
+
+	return ts, nil
+}
+
+// distance returns the Levenshtein distance of the runes of a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(rb)]
+}
+
+func (f *Fake) Lookup(word string) ([]uonum.WordInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	links := f.model().links[word]
+	if links == nil {
+		return nil, nil
+	}
+
+	return []uonum.WordInfo{wordInfo(word, links)}, nil
+}
+
+// wordInfo returns the word and its next words, most frequent first.
+func wordInfo(word string, links map[string]int64) uonum.WordInfo {
+	info := uonum.WordInfo{Word: word}
+	for _, n := range sortedKeys(links) {
+		info.Total += links[n]
+		if n != "" {
+			info.Links = append(info.Links, uonum.Transition{Word: n, Count: links[n]})
+		}
+	}
+	sort.SliceStable(info.Links, func(i, j int) bool {
+		return info.Links[i].Count > info.Links[j].Count
+	})
+
+	return info
+}
+
+func (f *Fake) Score(text string) (float64, error) {
+	p, _ := f.score(text)
+	return p, nil
+}
+
+func (f *Fake) Perplexity(text string) (float64, error) {
+	p, n := f.score(text)
+	if n == 0 {
+		return 0, nil
+	}
+
+	return math.Exp(-p / float64(n)), nil
+}
+
+// score returns the log-probability of text and the number of its
+// transitions.
+func (f *Fake) score(text string) (float64, int) {
+	words := strings.Fields(text)
+	if len(words) < 2 {
+		return 0, 0
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.model()
+	var p float64
+	for i := 0; i+1 < len(words); i++ {
+		links := m.links[words[i]]
+		var total int64
+		for _, c := range links {
+			total += c
+		}
+		if c := links[words[i+1]]; c > 0 {
+			p += math.Log(float64(c) / float64(total))
+		} else {
+			p += unseenLogProb
+		}
+	}
+
+	return p, len(words) - 1
+}
+
+func (f *Fake) Stats() (*uonum.Stats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.model()
+	words := make(map[string]bool)
+	st := &uonum.Stats{Texts: len(m.texts)}
+	for w, links := range m.links {
+		words[w] = true
+		for n, c := range links {
+			if n != "" {
+				words[n] = true
+				st.Links++
+			}
+			st.Count += c
+		}
+	}
+	st.Words = len(words)
+
+	return st, nil
+}
+
+// Metrics returns the walks and the dead ends of the Fake and all its
+// namespaces. The cache is never used.
+func (f *Fake) Metrics() uonum.Metrics {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.metrics
+}
+
+func (f *Fake) Dump(w io.Writer) error {
+	return f.DumpFormat(w, uonum.FormatText)
+}
+
+// DumpFormat writes the words in the text or the JSON format.
+func (f *Fake) DumpFormat(w io.Writer, format uonum.Format) error {
+	_, err := f.DumpRange(w, format, uonum.DumpRange{})
+	return err
+}
+
+func (f *Fake) DumpRange(w io.Writer, format uonum.Format, r uonum.DumpRange) (int, error) {
+	if format != uonum.FormatText && format != uonum.FormatJSON && format != "" {
+		return 0, fmt.Errorf("Unknown format [%s].", format)
+	}
+
+	var infos []uonum.WordInfo
+	n, err := f.Words(r, func(info uonum.WordInfo) error {
+		infos = append(infos, info)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if format == uonum.FormatJSON {
+		if infos == nil {
+			infos = []uonum.WordInfo{}
+		}
+		return n, json.NewEncoder(w).Encode(infos)
+	}
+	for _, info := range infos {
+		fmt.Fprintln(w, info.Word)
+		for _, t := range info.Links {
+			fmt.Fprintf(w, "  %s : %d\n", t.Word, t.Count)
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+// Words calls fn with the words in r. The classes are ignored.
+func (f *Fake) Words(r uonum.DumpRange, fn func(uonum.WordInfo) error) (int, error) {
+	f.mu.Lock()
+	m := f.model()
+	var infos []uonum.WordInfo
+	for _, w := range sortedKeys(m.links) {
+		info := wordInfo(w, m.links[w])
+		if strings.HasPrefix(w, r.Prefix) && info.Total >= r.MinCount {
+			infos = append(infos, info)
+		}
+	}
+	f.mu.Unlock()
+
+	if r.Sort == uonum.SortCount {
+		sort.SliceStable(infos, func(i, j int) bool {
+			return infos[i].Total > infos[j].Total
+		})
+	}
+	if r.Offset >= len(infos) {
+		return 0, nil
+	}
+	infos = infos[r.Offset:]
+	if r.Limit > 0 && r.Limit < len(infos) {
+		infos = infos[:r.Limit]
+	}
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(infos), nil
+}
+
+// Check finds no problem, since the model is never broken.
+func (f *Fake) Check(repair bool) (*uonum.CheckReport, error) {
+	st, err := f.Stats()
+	if err != nil {
+		return nil, err
+	}
+
+	return &uonum.CheckReport{Words: st.Words, Links: st.Links}, nil
+}
+
+// Backup writes the texts of the model as JSON.
+func (f *Fake) Backup(w io.Writer) (int64, error) {
+	f.mu.Lock()
+	data, err := json.Marshal(f.model().texts)
+	f.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Merge adds the model of other, which must be a Fake.
+func (f *Fake) Merge(other uonum.Generator) error {
+	o, ok := other.(*Fake)
+	if !ok {
+		return errors.New("Could not merge a Generator of another implementation.")
+	}
+
+	o.mu.Lock()
+	src := o.model()
+	links := make(map[string]map[string]int64, len(src.links))
+	for w, next := range src.links {
+		links[w] = make(map[string]int64, len(next))
+		for n, c := range next {
+			links[w][n] = c
+		}
+	}
+	texts := append([]uonum.Record(nil), src.texts...)
+	o.mu.Unlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.model()
+	for w, next := range links {
+		if m.links[w] == nil {
+			m.links[w] = make(map[string]int64)
+		}
+		for n, c := range next {
+			m.links[w][n] += c
+		}
+	}
+	m.texts = append(m.texts, texts...)
+
+	return nil
+}
+
+// Graph writes the links as a Graphviz DOT graph, limited to the words
+// reachable from word within hops links if word is not empty.
+func (f *Fake) Graph(w io.Writer, word string, hops int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m := f.model()
+	words := sortedKeys(m.links)
+	if word != "" {
+		reached := map[string]bool{word: true}
+		frontier := []string{word}
+		for i := 0; len(frontier) > 0 && (hops <= 0 || i < hops); i++ {
+			var next []string
+			for _, from := range frontier {
+				for _, to := range sortedKeys(m.links[from]) {
+					if to != "" && !reached[to] {
+						reached[to] = true
+						next = append(next, to)
+					}
+				}
+			}
+			frontier = next
+		}
+		words = sortedKeys(reached)
+	}
+
+	fmt.Fprintln(w, "digraph uonum {")
+	for _, from := range words {
+		for _, to := range sortedKeys(m.links[from]) {
+			if to != "" {
+				fmt.Fprintf(w, "  %q -> %q [label=%d];\n", from, to, m.links[from][to])
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+
+	return err
+}
+
+func (f *Fake) WithContext(ctx context.Context) uonum.Generator {
+	return f
+}
+
+// In returns the Fake of the namespace ns, sharing the random source.
+func (f *Fake) In(ns string) uonum.Generator {
+	return &Fake{state: f.state, ns: ns}
+}
+
+func (f *Fake) RegisterIn(ns, text string) error {
+	return f.In(ns).Register(text)
+}
+
+func (f *Fake) GenerateIn(ns, trigger string) (string, error) {
+	return f.In(ns).Generate(trigger)
+}
+
+func (f *Fake) GenerateWithClassIn(ns, trigger, class string) (string, error) {
+	return f.In(ns).GenerateWithClass(trigger, class)
+}
+
+func (f *Fake) DumpIn(ns string, w io.Writer) error {
+	return f.In(ns).Dump(w)
+}
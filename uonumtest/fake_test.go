@@ -0,0 +1,457 @@
+package uonumtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kechako/uonum"
+)
+
+// newFake returns a Fake of seed 1 with texts registered.
+func newFake(t *testing.T, texts ...string) *Fake {
+	t.Helper()
+
+	f := New(1)
+	for _, text := range texts {
+		if err := f.Register(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return f
+}
+
+func TestGenerate(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。", "猫 が 走る 。", "犬 が 吠える 。")
+
+	tests := []struct {
+		trigger string
+		want    []string
+		wantErr error
+	}{
+		{trigger: "猫", want: []string{"猫 は 鳴く 。", "猫 が 走る 。", "猫 が 吠える 。"}},
+		{trigger: "犬", want: []string{"犬 が 走る 。", "犬 が 吠える 。"}},
+		{trigger: "鳴く", want: []string{"鳴く 。"}},
+		{trigger: "。", want: []string{"。"}},
+		{trigger: "", want: []string{""}},
+		{trigger: "鳥", wantErr: uonum.ErrUnknownTrigger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.trigger, func(t *testing.T) {
+			for range 10 {
+				got, err := f.Generate(tt.trigger)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Generate() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil && !contains(tt.want, got) {
+					t.Errorf("Generate() = %q, want one of %q", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := New(1).Generate("猫"); !errors.Is(err, uonum.ErrEmptyModel) {
+		t.Errorf("Generate() of an empty model error = %v, want %v", err, uonum.ErrEmptyModel)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	generate := func(seed int64) []string {
+		f := New(seed)
+		for _, text := range []string{"猫 は 鳴く 。", "猫 が 走る 。", "犬 が 吠える 。"} {
+			if err := f.Register(text); err != nil {
+				t.Fatal(err)
+			}
+		}
+		texts, err := f.GenerateN("猫", 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return texts
+	}
+
+	a, b := generate(1), generate(1)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("generated %q and %q by the same seed", a, b)
+	}
+	if c := generate(2); reflect.DeepEqual(a, c) {
+		t.Errorf("generated %q by the other seed", c)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。")
+	if err := f.RegisterIn("dogs", "犬 が 吠える 。"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		g       uonum.Generator
+		trigger string
+		want    string
+		wantErr error
+	}{
+		{name: "default", g: f, trigger: "猫", want: "猫 は 鳴く 。"},
+		{name: "default without dogs", g: f, trigger: "犬", wantErr: uonum.ErrUnknownTrigger},
+		{name: "dogs", g: f.In("dogs"), trigger: "犬", want: "犬 が 吠える 。"},
+		{name: "dogs without cats", g: f.In("dogs"), trigger: "猫", wantErr: uonum.ErrUnknownTrigger},
+		{name: "empty", g: f.In("birds"), trigger: "鳥", wantErr: uonum.ErrEmptyModel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.g.Generate(tt.trigger)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Generate() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	if got, err := f.GenerateIn("dogs", "犬"); err != nil || got != "犬 が 吠える 。" {
+		t.Errorf("GenerateIn() = %q, %v", got, err)
+	}
+	// the metrics are of all the namespaces
+	if got := f.In("dogs").Metrics().Walks; got != f.Metrics().Walks || got == 0 {
+		t.Errorf("Metrics() walks = %d, want %d", got, f.Metrics().Walks)
+	}
+}
+
+func TestDecay(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。", "猫 は 鳴く 。", "猫 が 走る 。")
+
+	if err := f.Decay(0); err != nil {
+		t.Fatal(err)
+	}
+	// the links counted once are removed
+	for range 10 {
+		if got, err := f.Generate("猫"); err != nil || got != "猫 は 鳴く 。" {
+			t.Fatalf("Generate() after Decay = %q, %v", got, err)
+		}
+	}
+	if _, err := f.Generate("走る"); !errors.Is(err, uonum.ErrUnknownTrigger) {
+		t.Errorf("Generate() of a decayed word error = %v, want %v", err, uonum.ErrUnknownTrigger)
+	}
+
+	n, err := f.Rebuild()
+	if err != nil || n != 3 {
+		t.Fatalf("Rebuild() = %d, %v, want 3", n, err)
+	}
+	if got, err := f.Generate("走る"); err != nil || got != "走る 。" {
+		t.Errorf("Generate() after Rebuild = %q, %v", got, err)
+	}
+}
+
+func TestReply(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。")
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "鳥 と 猫", want: "猫 は 鳴く 。"},
+		{input: "。 鳴く", want: "鳴く 。"},
+		{input: "鳥", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := f.Reply(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a random trigger word without a word of input
+			if tt.want == "" {
+				tt.want = got
+				if !strings.HasSuffix(got, " 。") {
+					t.Errorf("Reply() = %q, want a sentence", got)
+				}
+			}
+			if got != tt.want {
+				t.Errorf("Reply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(1).Reply("猫"); !errors.Is(err, uonum.ErrEmptyModel) {
+		t.Errorf("Reply() of an empty model error = %v, want %v", err, uonum.ErrEmptyModel)
+	}
+}
+
+func TestTriggers(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。", "猫舌 の 人 !")
+
+	words, err := f.Triggers("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"の", "は", "人", "猫", "猫舌", "鳴く"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("Triggers() = %q, want %q", words, want)
+	}
+
+	tests := []struct {
+		name string
+		find func() ([]uonum.Trigger, error)
+		want []string
+	}{
+		{name: "prefix", find: func() ([]uonum.Trigger, error) { return f.FindTrigger("猫") }, want: []string{"猫", "猫舌"}},
+		{name: "match", find: func() ([]uonum.Trigger, error) { return f.MatchTriggers("犬舌", 1) }, want: []string{"猫舌"}},
+		{name: "reading", find: func() ([]uonum.Trigger, error) { return f.FindByReading("ネコ") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, err := tt.find()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, tr := range ts {
+				got = append(got, tr.Word)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("found %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for range 10 {
+		w, err := f.RandomTrigger("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !contains(words, w) {
+			t.Errorf("RandomTrigger() = %q, want one of %q", w, words)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。", "猫 が 走る 。")
+
+	tests := []struct {
+		text           string
+		wantScore      float64
+		wantPerplexity float64
+	}{
+		{text: "猫 は 鳴く 。", wantScore: math.Log(0.5), wantPerplexity: math.Exp(-math.Log(0.5) / 3)},
+		{text: "は 鳴く", wantScore: 0, wantPerplexity: 1},
+		{text: "猫 鳴く", wantScore: unseenLogProb, wantPerplexity: math.Exp(-unseenLogProb)},
+		{text: "猫"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			score, err := f.Score(tt.text)
+			if err != nil || math.Abs(score-tt.wantScore) > 1e-9 {
+				t.Errorf("Score() = %v, %v, want %v", score, err, tt.wantScore)
+			}
+			p, err := f.Perplexity(tt.text)
+			if err != nil || math.Abs(p-tt.wantPerplexity) > 1e-6 {
+				t.Errorf("Perplexity() = %v, %v, want %v", p, err, tt.wantPerplexity)
+			}
+		})
+	}
+}
+
+func TestGenerateRelated(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。", "猫 は 鳴く 。", "子猫 が 走る 。", "子猫 が 走る 。", "dog 猫")
+	// the links of dog are removed
+	if err := f.Decay(0); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		trigger  string
+		wantUsed string
+		wantErr  error
+	}{
+		{trigger: "猫", wantUsed: "猫"},
+		// the longest common prefix
+		{trigger: "子犬", wantUsed: "子猫"},
+		// the word occurring with it
+		{trigger: "dog", wantUsed: "猫"},
+		{trigger: "bird", wantErr: uonum.ErrUnknownTrigger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.trigger, func(t *testing.T) {
+			text, used, err := f.GenerateRelated(tt.trigger)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateRelated() error = %v, want %v", err, tt.wantErr)
+			}
+			if used != tt.wantUsed || (err == nil && !strings.HasPrefix(text, used+" ")) {
+				t.Errorf("GenerateRelated() = %q, %q, want from %q", text, used, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestGenerateTemplate(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。")
+
+	tests := []struct {
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{tmpl: "猫 {助詞} {動詞}", want: "猫 は 鳴く"},
+		{tmpl: "今日 は 晴れ", want: "今日 は 晴れ"},
+		{tmpl: "猫 {助詞", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			got, err := f.GenerateTemplate(tt.tmpl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateTemplate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GenerateTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(1).GenerateTemplate("{名詞}"); !errors.Is(err, uonum.ErrEmptyModel) {
+		t.Errorf("GenerateTemplate() of an empty model error = %v, want %v", err, uonum.ErrEmptyModel)
+	}
+}
+
+func TestGenerateStream(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。")
+
+	ch, err := f.GenerateStream(context.Background(), "猫")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	for w := range ch {
+		words = append(words, w)
+	}
+	if want := []string{"猫", "は", "鳴く", "。"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("GenerateStream() = %q, want %q", words, want)
+	}
+
+	words = nil
+	err = f.GenerateFunc("猫", func(word string) bool {
+		words = append(words, word)
+		return word != "は"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"猫", "は"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("GenerateFunc() = %q, want %q", words, want)
+	}
+}
+
+func TestResult(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。", "猫 が 走る")
+
+	r, err := f.GenerateResult("鳴く")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Text != "鳴く 。" || r.Trigger != "鳴く" || len(r.Tokens) != 2 || r.DeadEnd {
+		t.Errorf("GenerateResult() = %+v", r)
+	}
+
+	r, err = f.GenerateResult("走る")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 走る ends the text without a term word
+	if r.Text != "走る" || r.DeadEnd {
+		t.Errorf("GenerateResult() = %+v", r)
+	}
+
+	tr, err := f.GenerateTraced("鳴く")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uonum.Step{{Link: uonum.Link{From: "鳴く", To: "。"}}}; !reflect.DeepEqual(tr.Steps, want) {
+		t.Errorf("GenerateTraced() steps = %+v, want %+v", tr.Steps, want)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。")
+
+	p, err := f.Preview("猫 が 鳴く 。")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Sentences) != 1 || len(p.Sentences[0]) != 4 {
+		t.Errorf("Preview() sentences = %+v", p.Sentences)
+	}
+	want := []uonum.PreviewLink{
+		{From: "。", To: "", Count: 1},
+		{From: "が", To: "鳴く", Count: 1, New: true},
+		{From: "猫", To: "が", Count: 1, New: true},
+		{From: "鳴く", To: "。", Count: 1},
+	}
+	if !reflect.DeepEqual(p.Links, want) {
+		t.Errorf("Preview() links = %+v, want %+v", p.Links, want)
+	}
+
+	// nothing is registered
+	if st, _ := f.Stats(); st.Texts != 1 {
+		t.Errorf("Stats() texts = %d, want 1", st.Texts)
+	}
+}
+
+func TestStore(t *testing.T) {
+	f := newFake(t, "猫 は 鳴く 。", "猫 が 鳴く 。")
+
+	st, err := f.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (uonum.Stats{Texts: 2, Words: 5, Links: 5, Count: 8}); *st != want {
+		t.Errorf("Stats() = %+v, want %+v", *st, want)
+	}
+
+	infos, err := f.Lookup("猫")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Total != 2 || len(infos[0].Links) != 2 {
+		t.Errorf("Lookup() = %+v", infos)
+	}
+
+	var buf bytes.Buffer
+	n, err := f.DumpRange(&buf, uonum.FormatText, uonum.DumpRange{Prefix: "猫", Sort: uonum.SortCount, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "猫\n  が : 1\n  は : 1\n\n"; n != 1 || buf.String() != want {
+		t.Errorf("DumpRange() = %d, %q, want 1, %q", n, buf.String(), want)
+	}
+	if _, err := f.DumpRange(&buf, uonum.FormatCSV, uonum.DumpRange{}); err == nil {
+		t.Error("DumpRange() of CSV = nil error")
+	}
+
+	buf.Reset()
+	if err := f.Graph(&buf, "は", 1); err != nil {
+		t.Fatal(err)
+	}
+	if want := "digraph uonum {\n  \"は\" -> \"鳴く\" [label=1];\n  \"鳴く\" -> \"。\" [label=2];\n}\n"; buf.String() != want {
+		t.Errorf("Graph() = %q, want %q", buf.String(), want)
+	}
+
+	other := newFake(t, "犬 が 吠える 。")
+	if err := f.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := f.Generate("犬"); err != nil || !strings.HasPrefix(got, "犬 が ") {
+		t.Errorf("Generate() of a merged word = %q, %v", got, err)
+	}
+	if err := f.Merge(nil); err == nil {
+		t.Error("Merge() of another implementation = nil error")
+	}
+}
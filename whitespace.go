@@ -0,0 +1,96 @@
+package uonum
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// WithWhitespaceTokenizer is the same as WithMode(ModeWhitespace).
+func WithWhitespaceTokenizer() Option {
+	return WithMode(ModeWhitespace)
+}
+
+var reWord = regexp.MustCompile(`[\p{L}\p{N}]+(?:['’\-][\p{L}\p{N}]+)*|[^\s\p{L}\p{N}]`)
+
+// closedClasses are the classes of the frequent function words in English.
+var closedClasses = map[string]string{}
+
+func init() {
+	for class, words := range map[string]string{
+		"DET":  "a an the this that these those some any no every each",
+		"PRON": "i you he she it we they me him her us them my your his its our their mine yours who what which",
+		"ADP":  "of in on at by for with from to into about over under after before between through",
+		"CONJ": "and or but nor so yet because if while than",
+		"AUX":  "be am is are was were been being have has had do does did will would can could shall should may might must",
+		"ADV":  "not very too also just only never always often here there now then",
+		"INTJ": "oh ah wow hey hi hello yes no ok okay",
+	} {
+		for _, w := range strings.Fields(words) {
+			if _, ok := closedClasses[w]; !ok {
+				closedClasses[w] = class
+			}
+		}
+	}
+}
+
+func whitespaceTokens(text string) []token {
+	words := reWord.FindAllString(text, -1)
+	c := make([]token, 0, len(words))
+
+	for i, w := range words {
+		c = append(c, token{
+			Surface:  w,
+			Features: []string{guessClass(w, i == 0)},
+		})
+	}
+
+	return c
+}
+
+// guessClass guesses the part of speech of w roughly from its spelling.
+// first tells whether w begins the text.
+func guessClass(w string, first bool) string {
+	r := []rune(w)
+	switch {
+	case !unicode.IsLetter(r[0]) && !unicode.IsNumber(r[0]):
+		return "PUNCT"
+	case unicode.IsNumber(r[0]):
+		return "NUM"
+	}
+
+	lw := strings.ToLower(w)
+	if c, ok := closedClasses[lw]; ok {
+		return c
+	}
+	if !first && unicode.IsUpper(r[0]) {
+		return "PROPN"
+	}
+
+	switch {
+	case strings.HasSuffix(lw, "ly"):
+		return "ADV"
+	case strings.HasSuffix(lw, "ing"), strings.HasSuffix(lw, "ed"):
+		return "VERB"
+	case strings.HasSuffix(lw, "ous"), strings.HasSuffix(lw, "ful"), strings.HasSuffix(lw, "ive"), strings.HasSuffix(lw, "able"):
+		return "ADJ"
+	}
+
+	return "NOUN"
+}
+
+// needSpace tells whether a space is needed between prev and word when the
+// words are joined.
+func needSpace(prev, word string) bool {
+	if prev == "" {
+		return false
+	}
+	if strings.ContainsAny(prev, "([{“‘") && len([]rune(prev)) == 1 {
+		return false
+	}
+	if strings.ContainsAny(word, ".,!?;:)]}”’%") && len([]rune(word)) == 1 {
+		return false
+	}
+
+	return true
+}
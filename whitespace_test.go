@@ -0,0 +1,85 @@
+package uonum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhitespaceTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want []token
+	}{
+		{
+			text: "The cat's running quickly.",
+			want: []token{
+				{Surface: "The", Features: []string{"DET"}},
+				{Surface: "cat's", Features: []string{"NOUN"}},
+				{Surface: "running", Features: []string{"VERB"}},
+				{Surface: "quickly", Features: []string{"ADV"}},
+				{Surface: ".", Features: []string{"PUNCT"}},
+			},
+		},
+		{
+			text: "Cats meet Alice, 2 well-known friends!",
+			want: []token{
+				{Surface: "Cats", Features: []string{"NOUN"}},
+				{Surface: "meet", Features: []string{"NOUN"}},
+				{Surface: "Alice", Features: []string{"PROPN"}},
+				{Surface: ",", Features: []string{"PUNCT"}},
+				{Surface: "2", Features: []string{"NUM"}},
+				{Surface: "well-known", Features: []string{"NOUN"}},
+				{Surface: "friends", Features: []string{"NOUN"}},
+				{Surface: "!", Features: []string{"PUNCT"}},
+			},
+		},
+		{text: "  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := whitespaceTokens(tt.text)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("whitespaceTokens(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedSpace(t *testing.T) {
+	tests := []struct {
+		prev, word string
+		want       bool
+	}{
+		{prev: "", word: "cat", want: false},
+		{prev: "the", word: "cat", want: true},
+		{prev: "cat", word: ".", want: false},
+		{prev: "cat", word: ",", want: false},
+		{prev: "(", word: "cat", want: false},
+		{prev: "cat", word: "(", want: true},
+		{prev: ")", word: "cat", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prev+" "+tt.word, func(t *testing.T) {
+			if got := needSpace(tt.prev, tt.word); got != tt.want {
+				t.Errorf("needSpace(%q, %q) = %v, want %v", tt.prev, tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWhitespaceMode(t *testing.T) {
+	g := openModel(t, []string{"The cat (a kitten) sleeps."}, WithWhitespaceTokenizer(), WithTermWords([]string{"."}))
+
+	got, err := g.Generate("cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "cat (a kitten) sleeps."; got != want {
+		t.Errorf("Generate() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package uonum
+
+import (
+	"bytes"
+	"sort"
+)
+
+// WordInfo describes a word of a class in the model.
+type WordInfo struct {
+	Word     string       `json:"word"`
+	Class    string       `json:"class"`
+	Features []string     `json:"features"`
+	Reading  string       `json:"reading,omitempty"`
+	Total    int64        `json:"total"` // total count of the outgoing links
+	Links    []Transition `json:"links"` // outgoing links, most frequent first
+}
+
+// Transition is an outgoing link of a word.
+type Transition struct {
+	Word  string `json:"word"`
+	Class string `json:"class"`
+	Count int64  `json:"count"`
+}
+
+// Lookup returns the words whose surface is word, one for each class.
+func (g *generator) Lookup(word string) ([]WordInfo, error) {
+	word = g.normalize(word)
+
+	var infos []WordInfo
+	err := g.viewWords(func(b bucket) error {
+		prefix := wordPrefix(word)
+		c := b.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			wl, err := getWordLink(b, k)
+			if err != nil {
+				return err
+			}
+			if wl == nil {
+				continue
+			}
+			infos = append(infos, wl.info())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+func (w *wordLink) info() WordInfo {
+	_, class := splitKey(w.key())
+	info := WordInfo{
+		Word:     w.Word,
+		Class:    class,
+		Features: w.Features,
+		Reading:  w.Reading,
+		Total:    w.total(),
+		Links:    make([]Transition, 0, len(w.Links)),
+	}
+	for _, k := range w.sortedLinks() {
+		word, class := splitKey(k)
+		info.Links = append(info.Links, Transition{Word: word, Class: class, Count: w.Links[k]})
+	}
+	sort.SliceStable(info.Links, func(i, j int) bool {
+		return info.Links[i].Count > info.Links[j].Count
+	})
+
+	return info
+}
@@ -0,0 +1,68 @@
+package uonum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	g := openModel(t, []string{"猫が鳴く。", "猫が走る。", "猫が鳴く。", "猫の走るのが好き。", "猫舌の子猫。"})
+
+	// summary is the class, the total and the transitions of a word
+	type summary struct {
+		Class string
+		Total int64
+		Links []Transition
+	}
+
+	tests := []struct {
+		word string
+		want []summary
+	}{
+		{
+			word: "が",
+			want: []summary{{Class: "助詞", Total: 4, Links: []Transition{
+				{Word: "鳴く", Class: "動詞", Count: 2},
+				{Word: "好き", Class: "名詞", Count: 1},
+				{Word: "走る", Class: "動詞", Count: 1},
+			}}},
+		},
+		{
+			word: "の",
+			want: []summary{
+				{Class: "助詞", Total: 2, Links: []Transition{
+					{Word: "子猫", Class: "名詞", Count: 1},
+					{Word: "走る", Class: "動詞", Count: 1},
+				}},
+				{Class: "名詞", Total: 1, Links: []Transition{{Word: "が", Class: "助詞", Count: 1}}},
+			},
+		},
+		{
+			word: "猫",
+			want: []summary{{Class: "名詞", Total: 4, Links: []Transition{
+				{Word: "が", Class: "助詞", Count: 3},
+				{Word: "の", Class: "助詞", Count: 1},
+			}}},
+		},
+		{word: "犬"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			infos, err := g.Lookup(tt.word)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []summary
+			for _, info := range infos {
+				if info.Word != tt.word {
+					t.Errorf("word = %q, want %q", info.Word, tt.word)
+				}
+				got = append(got, summary{Class: info.Class, Total: info.Total, Links: info.Links})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup(%q) = %+v, want %+v", tt.word, got, tt.want)
+			}
+		})
+	}
+}